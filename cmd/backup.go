@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"io"
+	"os"
+
+	"github.com/alist-org/alist/v3/internal/backup"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+var (
+	backupOutput     string
+	backupPassphrase string
+	backupMode       string
+)
+
+// BackupCmd represents the backup command
+var BackupCmd = &cobra.Command{
+	Use:   "backup",
+	Short: "Export and import the configuration",
+}
+
+var exportBackupCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export storages, users, roles, metas, settings, shares, labels, ssh keys and the keys of crypt storages",
+	Run: func(cmd *cobra.Command, args []string) {
+		Init()
+		defer Release()
+		b, err := backup.Export(backupPassphrase)
+		if err != nil {
+			utils.Log.Errorf("failed to export backup: %+v", err)
+			return
+		}
+		data, err := utils.Json.MarshalIndent(b, "", "  ")
+		if err != nil {
+			utils.Log.Errorf("failed to marshal backup: %+v", err)
+			return
+		}
+		if backupOutput == "" || backupOutput == "-" {
+			if _, err := os.Stdout.Write(append(data, '\n')); err != nil {
+				utils.Log.Errorf("failed to write backup: %+v", err)
+				return
+			}
+		} else {
+			if err := os.WriteFile(backupOutput, data, 0600); err != nil {
+				utils.Log.Errorf("failed to write backup: %+v", err)
+				return
+			}
+			utils.Log.Infof("Backup has been written to %s", backupOutput)
+		}
+		if err := backup.Saved(b); err != nil {
+			utils.Log.Errorf("failed to mark the keys of the backup as backed up: %+v", err)
+		}
+	},
+}
+
+var importBackupCmd = &cobra.Command{
+	Use:   "import [file]",
+	Short: "Import a backup, read from stdin if file is - or missing",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		var data []byte
+		var err error
+		if len(args) == 0 || args[0] == "-" {
+			data, err = io.ReadAll(os.Stdin)
+		} else {
+			data, err = os.ReadFile(args[0])
+		}
+		if err != nil {
+			utils.Log.Errorf("failed to read backup: %+v", err)
+			return
+		}
+		var b backup.Backup
+		if err := utils.Json.Unmarshal(data, &b); err != nil {
+			utils.Log.Errorf("failed to parse backup: %+v", err)
+			return
+		}
+		Init()
+		defer Release()
+		report, err := backup.Import(&b, backupMode, backupPassphrase)
+		if err != nil {
+			utils.Log.Errorf("failed to import backup: %+v", err)
+			return
+		}
+		for section, count := range report {
+			utils.Log.Infof("%s: %d created, %d updated", section, count.Created, count.Updated)
+		}
+		utils.Log.Infof("Backup imported, restart the server if it's running to load the changes")
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(BackupCmd)
+	BackupCmd.AddCommand(exportBackupCmd)
+	BackupCmd.AddCommand(importBackupCmd)
+	BackupCmd.PersistentFlags().StringVar(&backupPassphrase, "passphrase", "", "Passphrase encrypting the confidential fields")
+	exportBackupCmd.Flags().StringVarP(&backupOutput, "output", "o", "", "Output file, stdout if empty or -")
+	importBackupCmd.Flags().StringVar(&backupMode, "mode", backup.ModeMerge, "Import mode, merge or replace")
+}
@@ -8,7 +8,9 @@ import (
 
 	"github.com/alist-org/alist/v3/internal/bootstrap"
 	"github.com/alist-org/alist/v3/internal/bootstrap/data"
+	"github.com/alist-org/alist/v3/internal/cluster"
 	"github.com/alist-org/alist/v3/internal/db"
+	shareauth "github.com/alist-org/alist/v3/internal/share"
 	"github.com/alist-org/alist/v3/pkg/utils"
 	log "github.com/sirupsen/logrus"
 )
@@ -30,6 +32,8 @@ func Init() {
 }
 
 func Release() {
+	shareauth.FlushAccessLog()
+	cluster.Close()
 	db.Close()
 }
 
@@ -13,6 +13,7 @@ var MCPCmd = &cobra.Command{
 	Long:  `Start an MCP (Model Context Protocol) server that communicates via STDIO, suitable for integration with AI assistants like Claude Desktop.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		Init()
+		bootstrap.InitCluster()
 		bootstrap.LoadStorages()
 		bootstrap.InitTaskManager()
 		username, _ := cmd.Flags().GetString("user")
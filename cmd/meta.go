@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"strconv"
+
+	dbmodel "github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var newMeta dbmodel.Meta
+
+// MetaCmd represents the meta command
+var MetaCmd = &cobra.Command{
+	Use:   "meta",
+	Short: "Manage metas",
+}
+
+var listMetaCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List all metas",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		Init()
+		defer Release()
+		metas, _, err := op.GetMetas(1, -1)
+		if err != nil {
+			return errors.WithMessage(err, "failed to query metas")
+		}
+		rows := make([][]string, 0, len(metas))
+		for _, m := range metas {
+			rows = append(rows, []string{strconv.Itoa(int(m.ID)), m.Path,
+				strconv.FormatBool(m.Password != ""), strconv.FormatBool(m.Write), strconv.FormatBool(m.Hide != "")})
+		}
+		return printTable(metas, []string{"ID", "PATH", "PASSWORD", "WRITE", "HIDE"}, rows)
+	},
+}
+
+var createMetaCmd = &cobra.Command{
+	Use:   "create <path>",
+	Short: "Create a meta",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		Init()
+		defer Release()
+		meta := newMeta
+		meta.Path = args[0]
+		if err := op.CreateMeta(&meta); err != nil {
+			return errors.WithMessage(err, "failed to create meta")
+		}
+		return printResult(meta, "Meta of [%s] has been created", meta.Path)
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(MetaCmd)
+	MetaCmd.AddCommand(listMetaCmd, createMetaCmd)
+	addJSONFlag(MetaCmd)
+	flags := createMetaCmd.Flags()
+	flags.StringVar(&newMeta.Password, "password", "", "Password of the path")
+	flags.BoolVar(&newMeta.PSub, "password-sub", false, "Apply the password to sub folders")
+	flags.BoolVar(&newMeta.Write, "write", false, "Allow anyone to upload to the path")
+	flags.BoolVar(&newMeta.WSub, "write-sub", false, "Apply write to sub folders")
+	flags.StringVar(&newMeta.Hide, "hide", "", "Regular expressions of the hidden files, one per line")
+	flags.BoolVar(&newMeta.HSub, "hide-sub", false, "Apply hide to sub folders")
+	flags.StringVar(&newMeta.Readme, "readme", "", "Readme of the path")
+	flags.BoolVar(&newMeta.RSub, "readme-sub", false, "Apply the readme to sub folders")
+	flags.StringVar(&newMeta.Header, "header", "", "Header of the path")
+	flags.BoolVar(&newMeta.HeaderSub, "header-sub", false, "Apply the header to sub folders")
+}
@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+// jsonOutput makes the management commands print json to stdout, so they can be used in scripts
+var jsonOutput bool
+
+func addJSONFlag(cmd *cobra.Command) {
+	cmd.PersistentFlags().BoolVar(&jsonOutput, "json", false, "Print the result as json")
+	// the errors are printed by Execute and returned to the shell, the usage would only hide them
+	cmd.PersistentPreRun = func(cmd *cobra.Command, args []string) {
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
+	}
+}
+
+func printJSON(v any) error {
+	data, err := utils.Json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(os.Stdout, string(data))
+	return err
+}
+
+// printTable prints rows aligned by columns, or v as json if --json is set
+func printTable(v any, header []string, rows [][]string) error {
+	if jsonOutput {
+		return printJSON(v)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, strings.Join(header, "\t"))
+	for _, row := range rows {
+		_, _ = fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	return w.Flush()
+}
+
+// printResult prints v as json if --json is set, otherwise logs msg
+func printResult(v any, msg string, args ...any) error {
+	if jsonOutput {
+		return printJSON(v)
+	}
+	utils.Log.Infof(msg, args...)
+	return nil
+}
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/alist-org/alist/v3/internal/bootstrap"
+	"github.com/alist-org/alist/v3/internal/provision"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var provisionCheck bool
+
+// ProvisionCmd represents the provision command
+var ProvisionCmd = &cobra.Command{
+	Use:   "provision [file]",
+	Short: "Apply a provision file, or show its changes with --check",
+	Long: `Reconcile the database against a provision file declaring storages, metas, roles, users and settings.
+The file is the argument, the --provision flag or provision_file of the config. It's also applied when the server starts,
+a running server only loads the changed storages after a restart.
+Strings may refer to secrets as ${env:NAME} or ${file:/path/to/secret}.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		Init()
+		defer Release()
+		path := bootstrap.ProvisionFile()
+		if len(args) > 0 {
+			path = args[0]
+		}
+		if path == "" {
+			return errors.New("no provision file is given")
+		}
+		spec, err := provision.Load(path)
+		if err != nil {
+			return err
+		}
+		var changes []provision.Change
+		if provisionCheck {
+			changes, err = provision.Plan(spec)
+		} else {
+			changes, err = provision.Apply(spec)
+		}
+		if err != nil {
+			return err
+		}
+		if !jsonOutput && len(changes) == 0 {
+			utils.Log.Infof("The database matches the provision file")
+			return nil
+		}
+		rows := make([][]string, 0, len(changes))
+		for _, c := range changes {
+			rows = append(rows, []string{c.Action, c.Kind, c.Name, strings.Join(c.Fields, ",")})
+		}
+		return printTable(changes, []string{"ACTION", "KIND", "NAME", "FIELDS"}, rows)
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(ProvisionCmd)
+	addJSONFlag(ProvisionCmd)
+	ProvisionCmd.Flags().BoolVar(&provisionCheck, "check", false, "Only show the changes without applying them")
+}
@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"strconv"
+	"strings"
+
+	dbmodel "github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var (
+	roleDescription string
+	roleDefault     bool
+	roleScopes      []string
+	roleReplace     bool
+)
+
+// RoleCmd represents the role command
+var RoleCmd = &cobra.Command{
+	Use:   "role",
+	Short: "Manage roles",
+}
+
+var listRoleCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List all roles",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		Init()
+		defer Release()
+		roles, _, err := op.GetRoles(1, -1)
+		if err != nil {
+			return errors.WithMessage(err, "failed to query roles")
+		}
+		rows := make([][]string, 0, len(roles))
+		for _, r := range roles {
+			scopes := make([]string, 0, len(r.PermissionScopes))
+			for _, s := range r.PermissionScopes {
+				scopes = append(scopes, s.Path+"="+strconv.Itoa(int(s.Permission)))
+			}
+			rows = append(rows, []string{strconv.Itoa(int(r.ID)), r.Name, strconv.FormatBool(r.Default), strings.Join(scopes, ",")})
+		}
+		return printTable(roles, []string{"ID", "NAME", "DEFAULT", "SCOPES"}, rows)
+	},
+}
+
+var createRoleCmd = &cobra.Command{
+	Use:   "create <name>",
+	Short: "Create a role",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		role := dbmodel.Role{
+			Name:        args[0],
+			Description: roleDescription,
+			Default:     roleDefault,
+		}
+		for _, scope := range roleScopes {
+			path, perm, ok := strings.Cut(scope, "=")
+			if !ok {
+				return errors.Errorf("invalid scope [%s], expect path=permission", scope)
+			}
+			permission, err := strconv.ParseInt(perm, 10, 32)
+			if err != nil {
+				return errors.Errorf("invalid permission of scope [%s]", scope)
+			}
+			role.PermissionScopes = append(role.PermissionScopes, dbmodel.PermissionEntry{Path: path, Permission: int32(permission)})
+		}
+		Init()
+		defer Release()
+		if err := op.CreateRole(&role); err != nil {
+			return errors.WithMessage(err, "failed to create role")
+		}
+		return printResult(role, "Role [%s] has been created", role.Name)
+	},
+}
+
+var assignRoleCmd = &cobra.Command{
+	Use:   "assign <username> <role>...",
+	Short: "Add roles to a user, or set them with --replace",
+	Args:  cobra.MinimumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		Init()
+		defer Release()
+		user, err := op.GetUserByName(args[0])
+		if err != nil {
+			return errors.WithMessage(err, "failed to get user")
+		}
+		roles, err := roleIDs(args[1:])
+		if err != nil {
+			return err
+		}
+		if !roleReplace {
+			merged := append(dbmodel.Roles{}, user.Role...)
+			for _, id := range roles {
+				if !merged.Contains(id) {
+					merged = append(merged, id)
+				}
+			}
+			roles = merged
+		}
+		u := *user
+		if err := checkRolesChange(&u, roles); err != nil {
+			return err
+		}
+		u.Role = roles
+		if err := op.UpdateUser(&u); err != nil {
+			return errors.WithMessage(err, "failed to update user")
+		}
+		DelUserCacheOnline(u.Username)
+		return printResult(u, "Roles of user [%s] have been set to %s", u.Username, roleNames(u.Role))
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(RoleCmd)
+	RoleCmd.AddCommand(listRoleCmd, createRoleCmd, assignRoleCmd)
+	addJSONFlag(RoleCmd)
+	createRoleCmd.Flags().StringVar(&roleDescription, "description", "", "Description of the role")
+	createRoleCmd.Flags().BoolVar(&roleDefault, "default", false, "Make it the default role of new users")
+	createRoleCmd.Flags().StringArrayVar(&roleScopes, "scope", nil, "Permission scope as path=permission, can be repeated")
+	assignRoleCmd.Flags().BoolVar(&roleReplace, "replace", false, "Replace the roles of the user instead of adding to them")
+}
@@ -33,4 +33,5 @@ func init() {
 	RootCmd.PersistentFlags().BoolVar(&flags.Dev, "dev", false, "start with dev mode")
 	RootCmd.PersistentFlags().BoolVar(&flags.ForceBinDir, "force-bin-dir", false, "Force to use the directory where the binary file is located as data directory")
 	RootCmd.PersistentFlags().BoolVar(&flags.LogStd, "log-std", false, "Force to log to std")
+	RootCmd.PersistentFlags().StringVar(&flags.Provision, "provision", "", "provision file declaring storages, metas, roles, users and settings, overrides provision_file of the config")
 }
@@ -14,15 +14,16 @@ import (
 	"time"
 
 	ftpserver "github.com/KirCute/ftpserverlib-pasvportmap"
-	"github.com/KirCute/sftpd-alist"
 	"github.com/alist-org/alist/v3/cmd/flags"
 	"github.com/alist-org/alist/v3/internal/bootstrap"
 	"github.com/alist-org/alist/v3/internal/conf"
 	"github.com/alist-org/alist/v3/internal/frp"
 	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/metrics"
 	"github.com/alist-org/alist/v3/pkg/utils"
 	"github.com/alist-org/alist/v3/server"
 	mcpserver "github.com/alist-org/alist/v3/server/mcp"
+	"github.com/alist-org/alist/v3/server/sftp"
 	"github.com/gin-gonic/gin"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
@@ -43,6 +44,7 @@ the address is defined in config file`,
 			time.Sleep(time.Duration(conf.Conf.DelayedStart) * time.Second)
 		}
 		bootstrap.InitOfflineDownloadTools()
+		bootstrap.InitCluster()
 		bootstrap.LoadStorages()
 		bootstrap.InitTaskManager()
 		bootstrap.InitFRP()
@@ -143,7 +145,7 @@ the address is defined in config file`,
 			}
 		}
 		var sftpDriver *server.SftpDriver
-		var sftpServer *sftpd.SftpServer
+		var sftpServer *sftp.Server
 		if conf.Conf.SFTP.Listen != "" && conf.Conf.SFTP.Enable {
 			var err error
 			sftpDriver, err = server.NewSftpDriver()
@@ -152,7 +154,7 @@ the address is defined in config file`,
 			} else {
 				utils.Log.Infof("start sftp server on %s", conf.Conf.SFTP.Listen)
 				go func() {
-					sftpServer = sftpd.NewSftpServer(sftpDriver)
+					sftpServer = sftp.NewServer(sftpDriver)
 					err = sftpServer.RunServer()
 					if err != nil {
 						utils.Log.Fatalf("problem sftp server listening: %s", err.Error())
@@ -162,7 +164,7 @@ the address is defined in config file`,
 		}
 		var mcpHttpSrv *http.Server
 		if conf.Conf.MCP.Port != -1 && conf.Conf.MCP.Enable {
-			mcpHandler := mcpserver.NewHTTPHandler()
+			mcpHandler := metrics.InstrumentHandler(metrics.ProtocolMCP, mcpserver.NewHTTPHandler())
 			mcpBase := fmt.Sprintf("%s:%d", conf.Conf.Scheme.Address, conf.Conf.MCP.Port)
 			utils.Log.Infof("start MCP server @ %s", mcpBase)
 			mcpHttpSrv = &http.Server{Addr: mcpBase, Handler: mcpHandler}
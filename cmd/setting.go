@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	dbmodel "github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// SettingCmd represents the setting command
+var SettingCmd = &cobra.Command{
+	Use:   "setting",
+	Short: "Get and set settings",
+	Long: `Get and set settings in the database.
+A running server caches the settings, restart it to apply the changes.`,
+}
+
+var getSettingCmd = &cobra.Command{
+	Use:   "get <key>...",
+	Short: "Print the values of settings, or all settings if no key is given",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		Init()
+		defer Release()
+		var items []dbmodel.SettingItem
+		var err error
+		if len(args) == 0 {
+			items, err = op.GetSettingItems()
+		} else {
+			items, err = op.GetSettingItemInKeys(args)
+		}
+		if err != nil {
+			return errors.WithMessage(err, "failed to get settings")
+		}
+		// a single value is printed as it is, so it can be used in shell substitutions
+		if len(args) == 1 && !jsonOutput {
+			fmt.Println(items[0].Value)
+			return nil
+		}
+		rows := make([][]string, 0, len(items))
+		for _, item := range items {
+			rows = append(rows, []string{item.Key, item.Value})
+		}
+		return printTable(items, []string{"KEY", "VALUE"}, rows)
+	},
+}
+
+var setSettingCmd = &cobra.Command{
+	Use:   "set <key> <value>",
+	Short: "Set the value of a setting",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		Init()
+		defer Release()
+		old, err := op.GetSettingItemByKey(args[0])
+		if err != nil {
+			return errors.WithMessage(err, "failed to get setting")
+		}
+		if old.IsDeprecated() {
+			return errors.Errorf("setting [%s] is deprecated", old.Key)
+		}
+		item := *old
+		item.Value = args[1]
+		if item.Key == conf.DefaultRole {
+			role, err := op.GetRoleByName(item.Value)
+			if err != nil {
+				return err
+			}
+			if role.Name == "admin" || role.Name == "guest" {
+				return errors.New("cannot set admin or guest as default role")
+			}
+			item.Value = strconv.Itoa(int(role.ID))
+		}
+		if err := op.SaveSettingItem(&item); err != nil {
+			return errors.WithMessage(err, "failed to save setting")
+		}
+		return printResult(item, "Setting [%s] has been set", item.Key)
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(SettingCmd)
+	SettingCmd.AddCommand(getSettingCmd, setSettingCmd)
+	addJSONFlag(SettingCmd)
+}
@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/db"
+	dbmodel "github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var (
+	shareCreator string
+	shareDelete  bool
+)
+
+// ShareCmd represents the share command
+var ShareCmd = &cobra.Command{
+	Use:   "share",
+	Short: "Manage shares",
+}
+
+var listShareCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List all shares, or the shares of a user with --creator",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		Init()
+		defer Release()
+		var shares []dbmodel.Share
+		var err error
+		if shareCreator != "" {
+			var user *dbmodel.User
+			user, err = op.GetUserByName(shareCreator)
+			if err != nil {
+				return errors.WithMessage(err, "failed to get user")
+			}
+			shares, _, err = db.GetSharesByCreator(user.ID, 1, -1)
+		} else {
+			shares, _, err = db.GetShares(1, -1)
+		}
+		if err != nil {
+			return errors.WithMessage(err, "failed to query shares")
+		}
+		rows := make([][]string, 0, len(shares))
+		for _, s := range shares {
+			expires := ""
+			if s.ExpiresAt != nil {
+				expires = s.ExpiresAt.Format(time.DateTime)
+			}
+			rows = append(rows, []string{s.ShareID, strconv.Itoa(int(s.CreatorID)), s.RootPath,
+				strconv.FormatBool(s.Enabled), strconv.FormatInt(s.AccessCount, 10), expires})
+		}
+		return printTable(shares, []string{"SHARE ID", "CREATOR", "PATH", "ENABLED", "ACCESSES", "EXPIRES"}, rows)
+	},
+}
+
+var revokeShareCmd = &cobra.Command{
+	Use:   "revoke <share_id>...",
+	Short: "Disable shares, or delete them with --delete",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		Init()
+		defer Release()
+		for _, shareID := range args {
+			share, err := db.GetShareByShareID(shareID)
+			if err != nil {
+				return errors.WithMessagef(err, "failed to get share [%s]", shareID)
+			}
+			if shareDelete {
+				err = db.DeleteShareByShareID(share.CreatorID, shareID)
+			} else {
+				err = db.DisableShare(shareID)
+			}
+			if err != nil {
+				return errors.WithMessagef(err, "failed to revoke share [%s]", shareID)
+			}
+		}
+		return printResult(args, "%d shares have been revoked", len(args))
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(ShareCmd)
+	ShareCmd.AddCommand(listShareCmd, revokeShareCmd)
+	addJSONFlag(ShareCmd)
+	listShareCmd.Flags().StringVar(&shareCreator, "creator", "", "Only list the shares created by this user")
+	revokeShareCmd.Flags().BoolVar(&shareDelete, "delete", false, "Delete the shares instead of disabling them")
+}
@@ -0,0 +1,185 @@
+package cmd
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/alist-org/alist/v3/internal/db"
+	dbmodel "github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/task"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/xhofe/tache"
+)
+
+var taskType string
+
+// TaskCmd represents the task command
+var TaskCmd = &cobra.Command{
+	Use:   "task",
+	Short: "Manage the persisted tasks",
+	Long: `Manage the tasks persisted in the database, only the task types with task_persistant enabled are persisted.
+A running server keeps the tasks in memory and overwrites the persisted ones, stop it before canceling tasks.
+In a cluster each instance persists its own tasks, the tasks of a dead instance are adopted by another one.`,
+}
+
+// persistedTask is the part of a task shared by all task types
+type persistedTask struct {
+	Type string `json:"type"`
+	// Node is the instance of a cluster the task belongs to
+	Node      string      `json:"node,omitempty"`
+	ID        string      `json:"id"`
+	State     tache.State `json:"state"`
+	StateName string      `json:"state_name"`
+	Creator   string      `json:"creator"`
+	// Data is the task as it's persisted
+	Data json.RawMessage `json:"data"`
+}
+
+// parsePersistedTasks parses the tasks persisted in the row key, which is the type
+// of the tasks, or type@node if they are persisted by an instance of a cluster
+func parsePersistedTasks(key, data string) ([]persistedTask, error) {
+	typ, node, _ := strings.Cut(key, "@")
+	if data == "" {
+		return nil, nil
+	}
+	var raws []json.RawMessage
+	if err := json.Unmarshal([]byte(data), &raws); err != nil {
+		return nil, errors.WithMessagef(err, "failed to parse %s tasks", typ)
+	}
+	tasks := make([]persistedTask, 0, len(raws))
+	for _, raw := range raws {
+		var t struct {
+			ID      string      `json:"id"`
+			State   tache.State `json:"state"`
+			Creator *struct {
+				Username string `json:"username"`
+			} `json:"creator"`
+		}
+		if err := json.Unmarshal(raw, &t); err != nil {
+			return nil, errors.WithMessagef(err, "failed to parse %s task", typ)
+		}
+		pt := persistedTask{Type: typ, Node: node, ID: t.ID, State: t.State, StateName: task.StateName(t.State), Data: raw}
+		if t.Creator != nil {
+			pt.Creator = t.Creator.Username
+		}
+		tasks = append(tasks, pt)
+	}
+	return tasks, nil
+}
+
+var listTaskCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the persisted tasks",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		Init()
+		defer Release()
+		items, err := db.GetTaskItems()
+		if err != nil {
+			return err
+		}
+		tasks := make([]persistedTask, 0)
+		for _, item := range items {
+			if typ, _, _ := strings.Cut(item.Key, "@"); taskType != "" && typ != taskType {
+				continue
+			}
+			t, err := parsePersistedTasks(item.Key, item.PersistData)
+			if err != nil {
+				return err
+			}
+			tasks = append(tasks, t...)
+		}
+		rows := make([][]string, 0, len(tasks))
+		for _, t := range tasks {
+			rows = append(rows, []string{t.Type, t.ID, t.StateName, t.Creator, t.Node})
+		}
+		return printTable(tasks, []string{"TYPE", "ID", "STATE", "CREATOR", "NODE"}, rows)
+	},
+}
+
+var cancelTaskCmd = &cobra.Command{
+	Use:   "cancel <type> <id>...",
+	Short: "Cancel persisted tasks, they are marked as canceled when the server starts",
+	Args:  cobra.MinimumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		typ, ids := args[0], args[1:]
+		Init()
+		defer Release()
+		items, err := db.GetTaskItems()
+		if err != nil {
+			return err
+		}
+		// the tasks of a type are persisted in a row per instance in a cluster
+		type row struct {
+			item    dbmodel.TaskItem
+			raws    []map[string]json.RawMessage
+			changed bool
+		}
+		rows := make([]*row, 0)
+		for _, item := range items {
+			if t, _, _ := strings.Cut(item.Key, "@"); t != typ {
+				continue
+			}
+			r := &row{item: item}
+			if item.PersistData != "" {
+				if err := json.Unmarshal([]byte(item.PersistData), &r.raws); err != nil {
+					return errors.WithMessagef(err, "failed to parse %s tasks", item.Key)
+				}
+			}
+			rows = append(rows, r)
+		}
+		canceled := make([]string, 0, len(ids))
+		for _, id := range ids {
+			found := false
+			for _, r := range rows {
+				for _, raw := range r.raws {
+					var tid string
+					var state tache.State
+					_ = json.Unmarshal(raw["id"], &tid)
+					_ = json.Unmarshal(raw["state"], &state)
+					if tid != id {
+						continue
+					}
+					found = true
+					if utils.SliceContains([]tache.State{tache.StateSucceeded, tache.StateCanceled, tache.StateFailed}, state) {
+						utils.Log.Warnf("Task [%s] is already %s", id, task.StateName(state))
+						break
+					}
+					// the manager turns canceling tasks into canceled ones with a canceled error when recovering them
+					raw["state"], _ = json.Marshal(tache.State(tache.StateCanceling))
+					r.changed = true
+					canceled = append(canceled, id)
+					break
+				}
+				if found {
+					break
+				}
+			}
+			if !found {
+				return errors.Errorf("task [%s] of type [%s] not found", id, typ)
+			}
+		}
+		for _, r := range rows {
+			if !r.changed {
+				continue
+			}
+			data, err := json.Marshal(r.raws)
+			if err != nil {
+				return err
+			}
+			r.item.PersistData = string(data)
+			if err := db.UpdateTaskData(&r.item); err != nil {
+				return err
+			}
+		}
+		return printResult(canceled, "%d %s tasks have been canceled", len(canceled), typ)
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(TaskCmd)
+	TaskCmd.AddCommand(listTaskCmd, cancelTaskCmd)
+	addJSONFlag(TaskCmd)
+	listTaskCmd.Flags().StringVar(&taskType, "type", "", "Only list the tasks of this type, e.g. copy, download, transfer, decompress")
+}
@@ -3,13 +3,20 @@ package cmd
 import (
 	"crypto/tls"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/db"
+	dbmodel "github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/op"
 	"github.com/alist-org/alist/v3/internal/setting"
 	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/pkg/utils/random"
 	"github.com/go-resty/resty/v2"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
 )
 
 func DelAdminCacheOnline() {
@@ -50,3 +57,213 @@ func DelUserCacheOnline(username string) {
 	}
 	utils.Log.Debugf("[del_user_cache_online] del user [%s] cache success", username)
 }
+
+var (
+	userPassword   string
+	userBasePath   string
+	userRoles      []string
+	userPermission int32
+	userDisabled   bool
+)
+
+// UserCmd represents the user command
+var UserCmd = &cobra.Command{
+	Use:   "user",
+	Short: "Manage users",
+}
+
+var listUserCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List all users",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		Init()
+		defer Release()
+		users, err := db.GetAllUsers()
+		if err != nil {
+			return errors.WithMessage(err, "failed to query users")
+		}
+		rows := make([][]string, 0, len(users))
+		for _, u := range users {
+			rows = append(rows, []string{strconv.Itoa(int(u.ID)), u.Username, roleNames(u.Role),
+				u.BasePath, strconv.FormatBool(!u.Disabled)})
+		}
+		return printTable(users, []string{"ID", "USERNAME", "ROLES", "BASE PATH", "ENABLED"}, rows)
+	},
+}
+
+var createUserCmd = &cobra.Command{
+	Use:   "create <username>",
+	Short: "Create a user, the default role is used if --role is not set",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		Init()
+		defer Release()
+		user := dbmodel.User{
+			Username:   args[0],
+			BasePath:   userBasePath,
+			Permission: userPermission,
+			Disabled:   userDisabled,
+			Authn:      "[]",
+		}
+		if len(userRoles) > 0 {
+			roles, err := roleIDs(userRoles)
+			if err != nil {
+				return err
+			}
+			user.Role = roles
+		} else {
+			user.Role = dbmodel.Roles{op.GetDefaultRoleID()}
+		}
+		if user.IsAdmin() || user.IsGuest() {
+			return errors.New("admin or guest user can not be created, set another role with --role")
+		}
+		if userPassword == "" {
+			userPassword = random.String(8)
+			utils.Log.Infof("The password of user [%s] is: %s", user.Username, userPassword)
+		}
+		user.SetPassword(userPassword)
+		if err := op.CreateUser(&user); err != nil {
+			return errors.WithMessage(err, "failed to create user")
+		}
+		return printResult(user, "User [%s] has been created", user.Username)
+	},
+}
+
+var updateUserCmd = &cobra.Command{
+	Use:   "update <username>",
+	Short: "Update the password, base path, roles or permission of a user",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		Init()
+		defer Release()
+		user, err := op.GetUserByName(args[0])
+		if err != nil {
+			return errors.WithMessage(err, "failed to get user")
+		}
+		// the cached user may be shared, don't modify it in place
+		u := *user
+		flags := cmd.Flags()
+		if flags.Changed("password") {
+			u.SetPassword(userPassword)
+		}
+		if flags.Changed("base-path") {
+			u.BasePath = userBasePath
+		}
+		if flags.Changed("permission") {
+			u.Permission = userPermission
+		}
+		if flags.Changed("role") {
+			roles, err := roleIDs(userRoles)
+			if err != nil {
+				return err
+			}
+			if err := checkRolesChange(&u, roles); err != nil {
+				return err
+			}
+			u.Role = roles
+		}
+		if err := op.UpdateUser(&u); err != nil {
+			return errors.WithMessage(err, "failed to update user")
+		}
+		DelUserCacheOnline(u.Username)
+		return printResult(u, "User [%s] has been updated", u.Username)
+	},
+}
+
+var disableUserCmd = &cobra.Command{
+	Use:   "disable <username>",
+	Short: "Disable a user",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return setUserDisabled(args[0], true)
+	},
+}
+
+var enableUserCmd = &cobra.Command{
+	Use:   "enable <username>",
+	Short: "Enable a user",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return setUserDisabled(args[0], false)
+	},
+}
+
+func setUserDisabled(username string, disabled bool) error {
+	Init()
+	defer Release()
+	user, err := op.GetUserByName(username)
+	if err != nil {
+		return errors.WithMessage(err, "failed to get user")
+	}
+	u := *user
+	if disabled && u.IsAdmin() {
+		count, err := op.CountEnabledAdminsExcluding(u.ID)
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			return errors.New("at least one enabled admin must be kept")
+		}
+	}
+	u.Disabled = disabled
+	if err := op.UpdateUser(&u); err != nil {
+		return errors.WithMessage(err, "failed to update user")
+	}
+	DelUserCacheOnline(u.Username)
+	if disabled {
+		return printResult(u, "User [%s] has been disabled", u.Username)
+	}
+	return printResult(u, "User [%s] has been enabled", u.Username)
+}
+
+// checkRolesChange keeps the admin and guest roles bound to their builtin users, as the web UI does
+func checkRolesChange(u *dbmodel.User, roles dbmodel.Roles) error {
+	if utils.SliceEqual(u.Role, roles) {
+		return nil
+	}
+	if u.Username == "admin" {
+		return errors.New("cannot change role of admin user")
+	}
+	if roles.Contains(dbmodel.ADMIN) || roles.Contains(dbmodel.GUEST) {
+		return errors.New("cannot assign admin or guest role to user")
+	}
+	return nil
+}
+
+func roleIDs(names []string) (dbmodel.Roles, error) {
+	roles := make(dbmodel.Roles, 0, len(names))
+	for _, name := range names {
+		role, err := op.GetRoleByName(name)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "failed to get role [%s]", name)
+		}
+		roles = append(roles, int(role.ID))
+	}
+	return roles, nil
+}
+
+func roleNames(ids dbmodel.Roles) string {
+	names := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if role, err := op.GetRole(uint(id)); err == nil {
+			names = append(names, role.Name)
+		} else {
+			names = append(names, strconv.Itoa(id))
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+func init() {
+	RootCmd.AddCommand(UserCmd)
+	UserCmd.AddCommand(listUserCmd, createUserCmd, updateUserCmd, disableUserCmd, enableUserCmd)
+	addJSONFlag(UserCmd)
+	for _, c := range []*cobra.Command{createUserCmd, updateUserCmd} {
+		c.Flags().StringVar(&userBasePath, "base-path", "/", "Base path of the user")
+		c.Flags().StringSliceVar(&userRoles, "role", nil, "Role names of the user")
+		c.Flags().Int32Var(&userPermission, "permission", 0, "Permission bits of the user")
+	}
+	createUserCmd.Flags().StringVar(&userPassword, "password", "", "Password of the user, a random one is generated and logged if empty")
+	updateUserCmd.Flags().StringVar(&userPassword, "password", "", "New password of the user")
+	createUserCmd.Flags().BoolVar(&userDisabled, "disabled", false, "Create the user disabled")
+}
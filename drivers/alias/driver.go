@@ -318,4 +318,26 @@ func (d *Alias) ArchiveDecompress(ctx context.Context, srcObj, dstDir model.Obj,
 	return err
 }
 
+// MirrorPaths returns the paths of the alias showing the file at fullPath, or its root
+// folders under fullPath
+func (d *Alias) MirrorPaths(fullPath string) []string {
+	var paths []string
+	for k, dsts := range d.pathMap {
+		root := "/"
+		if !d.autoFlatten {
+			root = stdpath.Join("/", k)
+		}
+		for _, dst := range dsts {
+			dst = utils.FixAndCleanPath(dst)
+			if utils.IsSubPath(dst, fullPath) {
+				paths = append(paths, stdpath.Join(root, strings.TrimPrefix(fullPath, dst)))
+			} else if utils.IsSubPath(fullPath, dst) {
+				paths = append(paths, root)
+			}
+		}
+	}
+	return paths
+}
+
 var _ driver.Driver = (*Alias)(nil)
+var _ driver.Mirror = (*Alias)(nil)
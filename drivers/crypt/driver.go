@@ -7,7 +7,9 @@ import (
 	stdpath "path"
 	"regexp"
 	"strings"
+	"sync"
 
+	"github.com/alist-org/alist/v3/internal/cryptkey"
 	"github.com/alist-org/alist/v3/internal/driver"
 	"github.com/alist-org/alist/v3/internal/errs"
 	"github.com/alist-org/alist/v3/internal/fs"
@@ -29,6 +31,12 @@ type Crypt struct {
 	Addition
 	cipher        *rcCrypt.Cipher
 	remoteStorage driver.Driver
+	// cipherKey is the mount key d.cipher is made from, with per user keys
+	cipherMu  sync.Mutex
+	cipherKey string
+	// rotateMu is held by the changes and by the rotation of the key, which
+	// waits for the changes in progress and refuses the others
+	rotateMu sync.RWMutex
 }
 
 const obfuscatedPrefix = "___Obfuscated___"
@@ -42,14 +50,32 @@ func (d *Crypt) GetAddition() driver.Additional {
 }
 
 func (d *Crypt) Init(ctx context.Context) error {
-	//obfuscate credentials if it's updated or just created
-	err := d.updateObfusParm(&d.Password)
-	if err != nil {
-		return fmt.Errorf("failed to obfuscate password: %w", err)
+	if d.KeyMode == "" {
+		d.KeyMode = keyModeStorage
 	}
-	err = d.updateObfusParm(&d.Salt)
-	if err != nil {
-		return fmt.Errorf("failed to obfuscate salt: %w", err)
+	if !d.PerUserKeys() && d.Password == "" {
+		return fmt.Errorf("password is required")
+	}
+	if d.PerUserKeys() {
+		if err := cryptkey.Available(); err != nil {
+			return err
+		}
+	}
+	// a backup saved by another process may hold the key since the last load
+	if d.PerUserKeys() && d.Password != "" && cryptkey.KeyBackedUp(d.ID) {
+		d.Password, d.Salt = "", ""
+	}
+	//obfuscate credentials if it's updated or just created
+	//with per user keys they are empty once the key is sealed to a user
+	if !d.PerUserKeys() || d.Password != "" {
+		err := d.updateObfusParm(&d.Password)
+		if err != nil {
+			return fmt.Errorf("failed to obfuscate password: %w", err)
+		}
+		err = d.updateObfusParm(&d.Salt)
+		if err != nil {
+			return fmt.Errorf("failed to obfuscate salt: %w", err)
+		}
 	}
 
 	isCryptExt := regexp.MustCompile(`^[.][A-Za-z0-9-_]{2,}$`).MatchString
@@ -68,11 +94,27 @@ func (d *Crypt) Init(ctx context.Context) error {
 	}
 	d.remoteStorage = storage
 
+	// with per user keys the cipher is made from the key of the requesting user
+	d.cipher, d.cipherKey = nil, ""
+	if d.PerUserKeys() {
+		return nil
+	}
 	p, _ := strings.CutPrefix(d.Password, obfuscatedPrefix)
 	p2, _ := strings.CutPrefix(d.Salt, obfuscatedPrefix)
+	c, err := d.newCipher(p, p2)
+	if err != nil {
+		return err
+	}
+	d.cipher = c
+
+	return nil
+}
+
+// newCipher makes the rclone cipher from the obscured password and salt
+func (d *Crypt) newCipher(password, password2 string) (*rcCrypt.Cipher, error) {
 	config := configmap.Simple{
-		"password":                  p,
-		"password2":                 p2,
+		"password":                  password,
+		"password2":                 password2,
 		"filename_encryption":       d.FileNameEnc,
 		"directory_name_encryption": d.DirNameEnc,
 		"filename_encoding":         d.FileNameEncoding,
@@ -81,11 +123,9 @@ func (d *Crypt) Init(ctx context.Context) error {
 	}
 	c, err := rcCrypt.NewCipher(config)
 	if err != nil {
-		return fmt.Errorf("failed to create Cipher: %w", err)
+		return nil, fmt.Errorf("failed to create Cipher: %w", err)
 	}
-	d.cipher = c
-
-	return nil
+	return c, nil
 }
 
 func (d *Crypt) updateObfusParm(str *string) error {
@@ -109,8 +149,12 @@ func (d *Crypt) List(ctx context.Context, dir model.Obj, args model.ListArgs) ([
 	path := dir.GetPath()
 	//return d.list(ctx, d.RemotePath, path)
 	//remoteFull
+	cipher, err := d.getCipher(ctx)
+	if err != nil {
+		return nil, err
+	}
 
-	objs, err := fs.List(ctx, d.getPathForRemote(path, true), &fs.ListArgs{NoLog: true})
+	objs, err := fs.List(ctx, d.getPathForRemote(cipher, path, true), &fs.ListArgs{NoLog: true})
 	// the obj must implement the model.SetPath interface
 	// return objs, err
 	if err != nil {
@@ -120,7 +164,7 @@ func (d *Crypt) List(ctx context.Context, dir model.Obj, args model.ListArgs) ([
 	var result []model.Obj
 	for _, obj := range objs {
 		if obj.IsDir() {
-			name, err := d.cipher.DecryptDirName(obj.GetName())
+			name, err := cipher.DecryptDirName(obj.GetName())
 			if err != nil {
 				//filter illegal files
 				continue
@@ -139,12 +183,12 @@ func (d *Crypt) List(ctx context.Context, dir model.Obj, args model.ListArgs) ([
 			result = append(result, &objRes)
 		} else {
 			thumb, ok := model.GetThumb(obj)
-			size, err := d.cipher.DecryptedSize(obj.GetSize())
+			size, err := cipher.DecryptedSize(obj.GetSize())
 			if err != nil {
 				//filter illegal files
 				continue
 			}
-			name, err := d.cipher.DecryptFileName(obj.GetName())
+			name, err := cipher.DecryptFileName(obj.GetName())
 			if err != nil {
 				//filter illegal files
 				continue
@@ -192,16 +236,20 @@ func (d *Crypt) Get(ctx context.Context, path string) (model.Obj, error) {
 			Path:     "/",
 		}, nil
 	}
+	cipher, err := d.getCipher(ctx)
+	if err != nil {
+		return nil, err
+	}
 	remoteFullPath := ""
 	var remoteObj model.Obj
-	var err, err2 error
+	var err2 error
 	firstTryIsFolder, secondTry := guessPath(path)
-	remoteFullPath = d.getPathForRemote(path, firstTryIsFolder)
+	remoteFullPath = d.getPathForRemote(cipher, path, firstTryIsFolder)
 	remoteObj, err = fs.Get(ctx, remoteFullPath, &fs.GetArgs{NoLog: true})
 	if err != nil {
 		if errs.IsObjectNotFound(err) && secondTry {
 			//try the opposite
-			remoteFullPath = d.getPathForRemote(path, !firstTryIsFolder)
+			remoteFullPath = d.getPathForRemote(cipher, path, !firstTryIsFolder)
 			remoteObj, err2 = fs.Get(ctx, remoteFullPath, &fs.GetArgs{NoLog: true})
 			if err2 != nil {
 				return nil, err2
@@ -213,18 +261,18 @@ func (d *Crypt) Get(ctx context.Context, path string) (model.Obj, error) {
 	var size int64 = 0
 	name := ""
 	if !remoteObj.IsDir() {
-		size, err = d.cipher.DecryptedSize(remoteObj.GetSize())
+		size, err = cipher.DecryptedSize(remoteObj.GetSize())
 		if err != nil {
 			log.Warnf("DecryptedSize failed for %s ,will use original size, err:%s", path, err)
 			size = remoteObj.GetSize()
 		}
-		name, err = d.cipher.DecryptFileName(remoteObj.GetName())
+		name, err = cipher.DecryptFileName(remoteObj.GetName())
 		if err != nil {
 			log.Warnf("DecryptFileName failed for %s ,will use original name, err:%s", path, err)
 			name = remoteObj.GetName()
 		}
 	} else {
-		name, err = d.cipher.DecryptDirName(remoteObj.GetName())
+		name, err = cipher.DecryptDirName(remoteObj.GetName())
 		if err != nil {
 			log.Warnf("DecryptDirName failed for %s ,will use original name, err:%s", path, err)
 			name = remoteObj.GetName()
@@ -242,7 +290,11 @@ func (d *Crypt) Get(ctx context.Context, path string) (model.Obj, error) {
 }
 
 func (d *Crypt) Link(ctx context.Context, file model.Obj, args model.LinkArgs) (*model.Link, error) {
-	dstDirActualPath, err := d.getActualPathForRemote(file.GetPath(), false)
+	cipher, err := d.getCipher(ctx)
+	if err != nil {
+		return nil, err
+	}
+	dstDirActualPath, err := d.getActualPathForRemote(cipher, file.GetPath(), false)
 	if err != nil {
 		return nil, fmt.Errorf("failed to convert path to remote path: %w", err)
 	}
@@ -291,7 +343,7 @@ func (d *Crypt) Link(ctx context.Context, file model.Obj, args model.LinkArgs) (
 
 	}
 	resultRangeReader := func(ctx context.Context, httpRange http_range.Range) (io.ReadCloser, error) {
-		readSeeker, err := d.cipher.DecryptDataSeek(ctx, rangeReaderFunc, httpRange.Start, httpRange.Length)
+		readSeeker, err := cipher.DecryptDataSeek(ctx, rangeReaderFunc, httpRange.Start, httpRange.Length)
 		if err != nil {
 			return nil, err
 		}
@@ -309,20 +361,30 @@ func (d *Crypt) Link(ctx context.Context, file model.Obj, args model.LinkArgs) (
 }
 
 func (d *Crypt) MakeDir(ctx context.Context, parentDir model.Obj, dirName string) error {
-	dstDirActualPath, err := d.getActualPathForRemote(parentDir.GetPath(), true)
+	cipher, done, err := d.writeCipher(ctx)
+	if err != nil {
+		return err
+	}
+	defer done()
+	dstDirActualPath, err := d.getActualPathForRemote(cipher, parentDir.GetPath(), true)
 	if err != nil {
 		return fmt.Errorf("failed to convert path to remote path: %w", err)
 	}
-	dir := d.cipher.EncryptDirName(dirName)
+	dir := cipher.EncryptDirName(dirName)
 	return op.MakeDir(ctx, d.remoteStorage, stdpath.Join(dstDirActualPath, dir))
 }
 
 func (d *Crypt) Move(ctx context.Context, srcObj, dstDir model.Obj) error {
-	srcRemoteActualPath, err := d.getActualPathForRemote(srcObj.GetPath(), srcObj.IsDir())
+	cipher, done, err := d.writeCipher(ctx)
+	if err != nil {
+		return err
+	}
+	defer done()
+	srcRemoteActualPath, err := d.getActualPathForRemote(cipher, srcObj.GetPath(), srcObj.IsDir())
 	if err != nil {
 		return fmt.Errorf("failed to convert path to remote path: %w", err)
 	}
-	dstRemoteActualPath, err := d.getActualPathForRemote(dstDir.GetPath(), dstDir.IsDir())
+	dstRemoteActualPath, err := d.getActualPathForRemote(cipher, dstDir.GetPath(), dstDir.IsDir())
 	if err != nil {
 		return fmt.Errorf("failed to convert path to remote path: %w", err)
 	}
@@ -330,25 +392,35 @@ func (d *Crypt) Move(ctx context.Context, srcObj, dstDir model.Obj) error {
 }
 
 func (d *Crypt) Rename(ctx context.Context, srcObj model.Obj, newName string) error {
-	remoteActualPath, err := d.getActualPathForRemote(srcObj.GetPath(), srcObj.IsDir())
+	cipher, done, err := d.writeCipher(ctx)
+	if err != nil {
+		return err
+	}
+	defer done()
+	remoteActualPath, err := d.getActualPathForRemote(cipher, srcObj.GetPath(), srcObj.IsDir())
 	if err != nil {
 		return fmt.Errorf("failed to convert path to remote path: %w", err)
 	}
 	var newEncryptedName string
 	if srcObj.IsDir() {
-		newEncryptedName = d.cipher.EncryptDirName(newName)
+		newEncryptedName = cipher.EncryptDirName(newName)
 	} else {
-		newEncryptedName = d.cipher.EncryptFileName(newName)
+		newEncryptedName = cipher.EncryptFileName(newName)
 	}
 	return op.Rename(ctx, d.remoteStorage, remoteActualPath, newEncryptedName)
 }
 
 func (d *Crypt) Copy(ctx context.Context, srcObj, dstDir model.Obj) error {
-	srcRemoteActualPath, err := d.getActualPathForRemote(srcObj.GetPath(), srcObj.IsDir())
+	cipher, done, err := d.writeCipher(ctx)
+	if err != nil {
+		return err
+	}
+	defer done()
+	srcRemoteActualPath, err := d.getActualPathForRemote(cipher, srcObj.GetPath(), srcObj.IsDir())
 	if err != nil {
 		return fmt.Errorf("failed to convert path to remote path: %w", err)
 	}
-	dstRemoteActualPath, err := d.getActualPathForRemote(dstDir.GetPath(), dstDir.IsDir())
+	dstRemoteActualPath, err := d.getActualPathForRemote(cipher, dstDir.GetPath(), dstDir.IsDir())
 	if err != nil {
 		return fmt.Errorf("failed to convert path to remote path: %w", err)
 	}
@@ -357,7 +429,12 @@ func (d *Crypt) Copy(ctx context.Context, srcObj, dstDir model.Obj) error {
 }
 
 func (d *Crypt) Remove(ctx context.Context, obj model.Obj) error {
-	remoteActualPath, err := d.getActualPathForRemote(obj.GetPath(), obj.IsDir())
+	cipher, done, err := d.writeCipher(ctx)
+	if err != nil {
+		return err
+	}
+	defer done()
+	remoteActualPath, err := d.getActualPathForRemote(cipher, obj.GetPath(), obj.IsDir())
 	if err != nil {
 		return fmt.Errorf("failed to convert path to remote path: %w", err)
 	}
@@ -365,13 +442,18 @@ func (d *Crypt) Remove(ctx context.Context, obj model.Obj) error {
 }
 
 func (d *Crypt) Put(ctx context.Context, dstDir model.Obj, streamer model.FileStreamer, up driver.UpdateProgress) error {
-	dstDirActualPath, err := d.getActualPathForRemote(dstDir.GetPath(), true)
+	cipher, done, err := d.writeCipher(ctx)
+	if err != nil {
+		return err
+	}
+	defer done()
+	dstDirActualPath, err := d.getActualPathForRemote(cipher, dstDir.GetPath(), true)
 	if err != nil {
 		return fmt.Errorf("failed to convert path to remote path: %w", err)
 	}
 
 	// Encrypt the data into wrappedIn
-	wrappedIn, err := d.cipher.EncryptData(streamer)
+	wrappedIn, err := cipher.EncryptData(streamer)
 	if err != nil {
 		return fmt.Errorf("failed to EncryptData: %w", err)
 	}
@@ -381,8 +463,8 @@ func (d *Crypt) Put(ctx context.Context, dstDir model.Obj, streamer model.FileSt
 		Obj: &model.Object{
 			ID:       streamer.GetID(),
 			Path:     streamer.GetPath(),
-			Name:     d.cipher.EncryptFileName(streamer.GetName()),
-			Size:     d.cipher.EncryptedSize(streamer.GetSize()),
+			Name:     cipher.EncryptFileName(streamer.GetName()),
+			Size:     cipher.EncryptedSize(streamer.GetSize()),
 			Modified: streamer.ModTime(),
 			IsFolder: streamer.IsDir(),
 		},
@@ -403,4 +485,14 @@ func (d *Crypt) Put(ctx context.Context, dstDir model.Obj, streamer model.FileSt
 //	return nil, errs.NotSupport
 //}
 
+// MirrorPaths returns the root for a file under the remote path, as the names shown
+// can't be told from the encrypted ones
+func (d *Crypt) MirrorPaths(fullPath string) []string {
+	if utils.IsSubPath(d.RemotePath, fullPath) || utils.IsSubPath(fullPath, d.RemotePath) {
+		return []string{"/"}
+	}
+	return nil
+}
+
 var _ driver.Driver = (*Crypt)(nil)
+var _ driver.Mirror = (*Crypt)(nil)
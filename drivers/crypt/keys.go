@@ -0,0 +1,273 @@
+package crypt
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	stdpath "path"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/cryptkey"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/stream"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/pkg/utils/random"
+	"github.com/pkg/errors"
+	rcCrypt "github.com/rclone/rclone/backend/crypt"
+	"github.com/rclone/rclone/fs/config/obscure"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	keyModeStorage = "storage"
+	keyModePerUser = "per_user"
+)
+
+// mountKey is the key of the storage sealed to its users, the obscured
+// password and salt as written in a rclone config, so the files stay in the
+// rclone layout. There is a single key for the whole storage, not one per
+// file: revoking a user rotates it, the files are encrypted again with a new
+// key in a folder next to the remote path which then replaces it. The copies
+// of the files the revoked user made before stay readable with the old key.
+type mountKey struct {
+	Password  string `json:"password"`
+	Password2 string `json:"password2"`
+}
+
+func (d *Crypt) PerUserKeys() bool {
+	return d.KeyMode == keyModePerUser
+}
+
+// InitialKey is the password and salt of the storage when it's switched to
+// per user keys, so the existing files stay readable, or new random ones
+func (d *Crypt) InitialKey() ([]byte, error) {
+	if d.Password == "" {
+		return d.NewKey()
+	}
+	var key mountKey
+	key.Password, _ = strings.CutPrefix(d.Password, obfuscatedPrefix)
+	key.Password2, _ = strings.CutPrefix(d.Salt, obfuscatedPrefix)
+	return utils.Json.Marshal(key)
+}
+
+func (d *Crypt) NewKey() ([]byte, error) {
+	var key mountKey
+	var err error
+	if key.Password, err = obscure.Obscure(random.String(32)); err != nil {
+		return nil, err
+	}
+	if key.Password2, err = obscure.Obscure(random.String(32)); err != nil {
+		return nil, err
+	}
+	return utils.Json.Marshal(key)
+}
+
+func (d *Crypt) ForgetStorageKey() {
+	if d.Password == "" && d.Salt == "" {
+		return
+	}
+	d.Password, d.Salt = "", ""
+	op.MustSaveDriverStorage(d)
+}
+
+// getCipher returns the cipher of the storage, with per user keys it's made
+// from the key held by the user of the request. Downloads by /d and /p only
+// have a user when their sign is bound to one, see sign.SignUser.
+func (d *Crypt) getCipher(ctx context.Context) (*rcCrypt.Cipher, error) {
+	if !d.PerUserKeys() {
+		return d.cipher, nil
+	}
+	user, _ := ctx.Value("user").(*model.User)
+	if user == nil {
+		return nil, errors.WithStack(errs.KeysLocked)
+	}
+	key, err := cryptkey.MountKey(user.ID, d.ID)
+	if err != nil {
+		return nil, err
+	}
+	d.cipherMu.Lock()
+	defer d.cipherMu.Unlock()
+	if d.cipher != nil && d.cipherKey == string(key) {
+		return d.cipher, nil
+	}
+	c, err := d.keyCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	d.cipher, d.cipherKey = c, string(key)
+	return c, nil
+}
+
+// writeCipher is getCipher for the changes, done must be called once the
+// change is made. They are refused while the key is rotated as the files are
+// copied to the new folder.
+func (d *Crypt) writeCipher(ctx context.Context) (cipher *rcCrypt.Cipher, done func(), err error) {
+	if !d.rotateMu.TryRLock() {
+		return nil, nil, errors.WithStack(errs.KeyRotating)
+	}
+	if cipher, err = d.getCipher(ctx); err != nil {
+		d.rotateMu.RUnlock()
+		return nil, nil, err
+	}
+	return cipher, d.rotateMu.RUnlock, nil
+}
+
+func (d *Crypt) keyCipher(key []byte) (*rcCrypt.Cipher, error) {
+	var mk mountKey
+	if err := utils.Json.Unmarshal(key, &mk); err != nil {
+		return nil, errors.Wrap(err, "invalid mount key")
+	}
+	return d.newCipher(mk.Password, mk.Password2)
+}
+
+// Reencrypt writes the files encrypted with newKey to a new folder next to
+// the remote path, then saves it as the remote path with the new keys and
+// removes the old folder. The old folder is kept if some of its files are
+// not files of the storage.
+func (d *Crypt) Reencrypt(ctx context.Context, key, newKey []byte, save func(storage *model.Storage) error) error {
+	from, err := d.keyCipher(key)
+	if err != nil {
+		return err
+	}
+	to, err := d.keyCipher(newKey)
+	if err != nil {
+		return err
+	}
+	oldPath := utils.FixAndCleanPath(d.RemotePath)
+	if oldPath == utils.FixAndCleanPath(d.remoteStorage.GetStorage().MountPath) {
+		return errors.WithStack(errs.KeyNotRotatable)
+	}
+	d.rotateMu.Lock()
+	defer d.rotateMu.Unlock()
+	newPath := rotatedPath(oldPath, time.Now())
+	if err := fs.MakeDir(ctx, newPath); err != nil {
+		return err
+	}
+	kept, err := d.reencryptDir(ctx, from, to, oldPath, newPath)
+	if err != nil {
+		if err := fs.Remove(ctx, newPath); err != nil {
+			log.Errorf("failed remove %s after a failed rotation: %+v", newPath, err)
+		}
+		return err
+	}
+	addition := d.Addition
+	addition.RemotePath = newPath
+	storage := d.Storage
+	if storage.Addition, err = utils.Json.MarshalToString(addition); err != nil {
+		return err
+	}
+	if err := save(&storage); err != nil {
+		if err := fs.Remove(ctx, newPath); err != nil {
+			log.Errorf("failed remove %s after a failed rotation: %+v", newPath, err)
+		}
+		return err
+	}
+	d.Storage.Addition, d.RemotePath = storage.Addition, newPath
+	if remote, err := fs.GetStorage(newPath, &fs.GetStoragesArgs{}); err == nil {
+		d.remoteStorage = remote
+	}
+	if kept > 0 {
+		log.Warnf("kept %s, %d of its files are not files of the storage", oldPath, kept)
+	} else if err := fs.Remove(ctx, oldPath); err != nil {
+		log.Errorf("failed remove %s after the rotation: %+v", oldPath, err)
+	}
+	return nil
+}
+
+// rotatedPath is the folder next to the remote path the files are written to
+// when the key is rotated
+func rotatedPath(remotePath string, now time.Time) string {
+	base := rotatedSuffix.ReplaceAllString(remotePath, "")
+	return fmt.Sprintf("%s.rotated-%d", base, now.UnixNano())
+}
+
+var rotatedSuffix = regexp.MustCompile(`\.rotated-\d+$`)
+
+// reencryptDir copies the files of src to dst, decrypted with from and
+// encrypted with to. It returns the number of entries that are not files of
+// the storage, they are left in src.
+func (d *Crypt) reencryptDir(ctx context.Context, from, to *rcCrypt.Cipher, src, dst string) (int, error) {
+	objs, err := fs.List(ctx, src, &fs.ListArgs{NoLog: true, Refresh: true})
+	if err != nil {
+		return 0, err
+	}
+	kept := 0
+	for _, obj := range objs {
+		if obj.IsDir() {
+			name, err := from.DecryptDirName(obj.GetName())
+			if err != nil {
+				kept++
+				continue
+			}
+			dir := stdpath.Join(dst, to.EncryptDirName(name))
+			if err := fs.MakeDir(ctx, dir); err != nil {
+				return kept, err
+			}
+			n, err := d.reencryptDir(ctx, from, to, stdpath.Join(src, obj.GetName()), dir)
+			kept += n
+			if err != nil {
+				return kept, err
+			}
+			continue
+		}
+		name, err := from.DecryptFileName(obj.GetName())
+		if err != nil {
+			kept++
+			continue
+		}
+		if err := d.reencryptFile(ctx, from, to, stdpath.Join(src, obj.GetName()), dst, name); err != nil {
+			return kept, errors.WithMessagef(err, "failed re-encrypt %s", name)
+		}
+	}
+	return kept, nil
+}
+
+func (d *Crypt) reencryptFile(ctx context.Context, from, to *rcCrypt.Cipher, src, dstDir, name string) error {
+	srcStorage, srcActualPath, err := op.GetStorageAndActualPath(src)
+	if err != nil {
+		return err
+	}
+	link, obj, err := op.Link(ctx, srcStorage, srcActualPath, model.LinkArgs{Header: http.Header{}})
+	if err != nil {
+		return err
+	}
+	ss, err := stream.NewSeekableStream(stream.FileStream{Obj: obj, Ctx: ctx}, link)
+	if err != nil {
+		return err
+	}
+	defer ss.Close()
+	size, err := from.DecryptedSize(obj.GetSize())
+	if err != nil {
+		return err
+	}
+	plain, err := from.DecryptData(ss)
+	if err != nil {
+		return err
+	}
+	defer plain.Close()
+	encrypted, err := to.EncryptData(plain)
+	if err != nil {
+		return err
+	}
+	dstStorage, dstActualPath, err := op.GetStorageAndActualPath(dstDir)
+	if err != nil {
+		return err
+	}
+	return op.Put(ctx, dstStorage, dstActualPath, &stream.FileStream{
+		Obj: &model.Object{
+			Name:     to.EncryptFileName(name),
+			Size:     to.EncryptedSize(size),
+			Modified: obj.ModTime(),
+		},
+		Reader:            encrypted,
+		Mimetype:          "application/octet-stream",
+		ForceStreamUpload: true,
+	}, nil, false)
+}
+
+var _ cryptkey.Mount = (*Crypt)(nil)
@@ -0,0 +1,152 @@
+package crypt_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	_ "github.com/alist-org/alist/v3/drivers/crypt"
+	_ "github.com/alist-org/alist/v3/drivers/local"
+	"github.com/alist-org/alist/v3/internal/backup"
+	"github.com/alist-org/alist/v3/internal/cryptkey"
+	"github.com/alist-org/alist/v3/internal/db/dbtest"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/stream"
+	"github.com/alist-org/alist/v3/pkg/http_range"
+	"github.com/pkg/errors"
+)
+
+func init() {
+	dbtest.Init()
+}
+
+func asUser(id uint) context.Context {
+	return context.WithValue(context.Background(), "user", &model.User{ID: id})
+}
+
+func TestPerUserKeys(t *testing.T) {
+	const alice, bob = 101, 102
+	dir := t.TempDir()
+	vault := filepath.Join(dir, "vault")
+	if err := os.Mkdir(vault, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	if _, err := op.CreateStorage(ctx, model.Storage{Driver: "Local", MountPath: "/crypt_remote",
+		Addition: fmt.Sprintf(`{"root_folder_path":%q}`, dir)}); err != nil {
+		t.Fatalf("create remote: %+v", err)
+	}
+	if _, err := op.CreateStorage(ctx, model.Storage{Driver: "Crypt", MountPath: "/crypt",
+		Addition: `{"filename_encryption":"standard","directory_name_encryption":"true","remote_path":"/crypt_remote/vault","key_mode":"per_user","password":"secret","encrypted_suffix":".bin","filename_encoding":"base64"}`}); err != nil {
+		t.Fatalf("create crypt: %+v", err)
+	}
+	storage, err := op.GetStorageByMountPath("/crypt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, ok := storage.(cryptkey.Mount)
+	if !ok || !m.PerUserKeys() {
+		t.Fatal("expected a storage with per user keys")
+	}
+	for _, id := range []uint{alice, bob} {
+		if err := cryptkey.Setup(id, fmt.Sprintf("pass-%d", id)); err != nil {
+			t.Fatalf("setup %d: %+v", id, err)
+		}
+	}
+	if err := cryptkey.InitMount(alice, storage.GetStorage().ID, m); err != nil {
+		t.Fatalf("init mount: %+v", err)
+	}
+	// the password is kept until a saved backup holds the key
+	if !strings.Contains(storage.GetStorage().Addition, "Obfuscated") {
+		t.Fatalf("expected the password to be kept, got %s", storage.GetStorage().Addition)
+	}
+	b, err := backup.Export("")
+	if err != nil {
+		t.Fatalf("export: %+v", err)
+	}
+	if len(b.MountKeys) != 1 || b.MountKeys[0].SealedKey == "" || len(b.UserKeys) != 2 {
+		t.Fatalf("expected the keys in the backup, got %+v %+v", b.MountKeys, b.UserKeys)
+	}
+	if err := backup.Saved(b); err != nil {
+		t.Fatalf("saved: %+v", err)
+	}
+	if strings.Contains(storage.GetStorage().Addition, "Obfuscated") {
+		t.Fatalf("expected the password to be forgotten once backed up, got %s", storage.GetStorage().Addition)
+	}
+
+	content := "per user secret"
+	file := &stream.FileStream{
+		Obj:    &model.Object{Name: "a.txt", Size: int64(len(content)), Modified: time.Now()},
+		Reader: strings.NewReader(content),
+	}
+	if err := op.Put(asUser(alice), storage, "/", file, nil); err != nil {
+		t.Fatalf("put: %+v", err)
+	}
+	entries, err := os.ReadDir(vault)
+	if err != nil || len(entries) != 1 || strings.Contains(entries[0].Name(), "a.txt") {
+		t.Fatalf("expected a single file with an encrypted name, got %v %v", entries, err)
+	}
+
+	// the requests without a user, e.g. unsigned downloads, can't open the files
+	if _, err := op.List(ctx, storage, "/", model.ListArgs{}); !errors.Is(errors.Cause(err), errs.KeysLocked) {
+		t.Errorf("expected locked keys without a user, got %v", err)
+	}
+	if _, err := op.List(asUser(bob), storage, "/", model.ListArgs{}); !errors.Is(errors.Cause(err), errs.NoMountKey) {
+		t.Errorf("expected bob to not hold the key, got %v", err)
+	}
+	objs, err := op.List(asUser(alice), storage, "/", model.ListArgs{})
+	if err != nil || len(objs) != 1 || objs[0].GetName() != "a.txt" {
+		t.Fatalf("expected alice to list a.txt, got %v %+v", objs, err)
+	}
+	read := func() {
+		t.Helper()
+		link, _, err := op.Link(asUser(alice), storage, "/a.txt", model.LinkArgs{})
+		if err != nil {
+			t.Fatalf("link: %+v", err)
+		}
+		rc, err := link.RangeReadCloser.RangeRead(asUser(alice), http_range.Range{Length: -1})
+		if err != nil {
+			t.Fatalf("read: %+v", err)
+		}
+		got, err := io.ReadAll(rc)
+		_ = rc.Close()
+		if err != nil || string(got) != content {
+			t.Errorf("expected %q, got %q %v", content, got, err)
+		}
+	}
+	read()
+
+	if err := cryptkey.Grant(alice, storage.GetStorage().ID, bob); err != nil {
+		t.Fatalf("grant: %+v", err)
+	}
+	if objs, err := op.List(asUser(bob), storage, "/", model.ListArgs{}); err != nil || len(objs) != 1 {
+		t.Errorf("expected bob to list the file once granted, got %v %+v", objs, err)
+	}
+
+	oldKey, _ := cryptkey.MountKey(alice, storage.GetStorage().ID)
+	if err := cryptkey.Revoke(ctx, alice, storage.GetStorage().ID, bob, m); err != nil {
+		t.Fatalf("revoke: %+v", err)
+	}
+	if _, err := op.List(asUser(bob), storage, "/", model.ListArgs{}); !errors.Is(errors.Cause(err), errs.NoMountKey) {
+		t.Errorf("expected bob to lose the key, got %v", err)
+	}
+	if key, err := cryptkey.MountKey(alice, storage.GetStorage().ID); err != nil || string(key) == string(oldKey) {
+		t.Fatalf("expected the key to be rotated, got %v", err)
+	}
+	// the files are encrypted again in a folder replacing the remote path
+	entries, err = os.ReadDir(dir)
+	if err != nil || len(entries) != 1 || !strings.HasPrefix(entries[0].Name(), "vault.rotated-") {
+		t.Fatalf("expected the rotated folder only, got %v %v", entries, err)
+	}
+	if !strings.Contains(storage.GetStorage().Addition, "/crypt_remote/"+entries[0].Name()) {
+		t.Errorf("expected the remote path to be saved, got %s", storage.GetStorage().Addition)
+	}
+	read()
+}
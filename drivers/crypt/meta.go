@@ -15,7 +15,9 @@ type Addition struct {
 	DirNameEnc  string `json:"directory_name_encryption" type:"select" required:"true" options:"false,true" default:"false"`
 	RemotePath  string `json:"remote_path" required:"true" help:"AList mounted folder path used to store encrypted data, e.g. /my-storage/secret"`
 
-	Password         string `json:"password" required:"true" confidential:"true" help:"the main password"`
+	KeyMode string `json:"key_mode" type:"select" options:"storage,per_user" default:"storage" help:"per_user seals the key of the storage to each allowed user, the password and salt are only used to set it up and are removed once a saved backup holds the key. Not available in a cluster"`
+
+	Password         string `json:"password" confidential:"true" help:"the main password"`
 	Salt             string `json:"salt" confidential:"true"  help:"If you don't know what is salt, treat it as a second password. Optional but recommended"`
 	EncryptedSuffix  string `json:"encrypted_suffix" required:"true" default:".bin" help:"for advanced user only! encrypted files will have this suffix"`
 	FileNameEncoding string `json:"filename_encoding" type:"select" required:"true" options:"base64,base32,base32768" default:"base64" help:"for advanced user only!"`
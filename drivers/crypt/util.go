@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/alist-org/alist/v3/internal/op"
+	rcCrypt "github.com/rclone/rclone/backend/crypt"
 )
 
 // will give the best guessing based on the path
@@ -22,23 +23,23 @@ func guessPath(path string) (isFolder, secondTry bool) {
 	return false, true
 }
 
-func (d *Crypt) getPathForRemote(path string, isFolder bool) (remoteFullPath string) {
+func (d *Crypt) getPathForRemote(cipher *rcCrypt.Cipher, path string, isFolder bool) (remoteFullPath string) {
 	if isFolder && !strings.HasSuffix(path, "/") {
 		path = path + "/"
 	}
 	dir, fileName := filepath.Split(path)
 
-	remoteDir := d.cipher.EncryptDirName(dir)
+	remoteDir := cipher.EncryptDirName(dir)
 	remoteFileName := ""
 	if len(strings.TrimSpace(fileName)) > 0 {
-		remoteFileName = d.cipher.EncryptFileName(fileName)
+		remoteFileName = cipher.EncryptFileName(fileName)
 	}
 	return stdpath.Join(d.RemotePath, remoteDir, remoteFileName)
 
 }
 
 // actual path is used for internal only. any link for user should come from remoteFullPath
-func (d *Crypt) getActualPathForRemote(path string, isFolder bool) (string, error) {
-	_, remoteActualPath, err := op.GetStorageAndActualPath(d.getPathForRemote(path, isFolder))
+func (d *Crypt) getActualPathForRemote(cipher *rcCrypt.Cipher, path string, isFolder bool) (string, error) {
+	_, remoteActualPath, err := op.GetStorageAndActualPath(d.getPathForRemote(cipher, path, isFolder))
 	return remoteActualPath, err
 }
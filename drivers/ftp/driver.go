@@ -3,6 +3,7 @@ package ftp
 import (
 	"context"
 	stdpath "path"
+	"time"
 
 	"github.com/alist-org/alist/v3/internal/driver"
 	"github.com/alist-org/alist/v3/internal/errs"
@@ -114,15 +115,32 @@ func (d *FTP) Remove(ctx context.Context, obj model.Obj) error {
 	}
 }
 
+func (d *FTP) SetModTime(ctx context.Context, obj model.Obj, modTime time.Time) error {
+	if err := d.login(); err != nil {
+		return err
+	}
+	if !d.conn.IsSetTimeSupported() {
+		return errs.NotSupport
+	}
+	return d.conn.SetTime(encode(obj.GetPath(), d.Encoding), modTime)
+}
+
 func (d *FTP) Put(ctx context.Context, dstDir model.Obj, s model.FileStreamer, up driver.UpdateProgress) error {
 	if err := d.login(); err != nil {
 		return err
 	}
 	path := stdpath.Join(dstDir.GetPath(), s.GetName())
-	return d.conn.Stor(encode(path, d.Encoding), driver.NewLimitedUploadStream(ctx, &driver.ReaderUpdatingProgress{
+	err := d.conn.Stor(encode(path, d.Encoding), driver.NewLimitedUploadStream(ctx, &driver.ReaderUpdatingProgress{
 		Reader:         s,
 		UpdateProgress: up,
 	}))
+	if err != nil {
+		return err
+	}
+	if modTime := s.ModTime(); !modTime.IsZero() && d.conn.IsSetTimeSupported() {
+		_ = d.conn.SetTime(encode(path, d.Encoding), modTime)
+	}
+	return nil
 }
 
 var _ driver.Driver = (*FTP)(nil)
@@ -24,6 +24,7 @@ import (
 	"github.com/alist-org/alist/v3/server/common"
 	"github.com/alist-org/times"
 	cp "github.com/otiai10/copy"
+	"github.com/shirou/gopsutil/v3/disk"
 	log "github.com/sirupsen/logrus"
 	_ "golang.org/x/image/webp"
 )
@@ -364,6 +365,10 @@ func (d *Local) Remove(ctx context.Context, obj model.Obj) error {
 	return nil
 }
 
+func (d *Local) SetModTime(ctx context.Context, obj model.Obj, modTime time.Time) error {
+	return os.Chtimes(obj.GetPath(), modTime, modTime)
+}
+
 func (d *Local) Put(ctx context.Context, dstDir model.Obj, stream model.FileStreamer, up driver.UpdateProgress) error {
 	fullPath := filepath.Join(dstDir.GetPath(), stream.GetName())
 	out, err := os.Create(fullPath)
@@ -387,4 +392,17 @@ func (d *Local) Put(ctx context.Context, dstDir model.Obj, stream model.FileStre
 	return nil
 }
 
+func (d *Local) GetDetails(ctx context.Context) (*model.StorageDetails, error) {
+	du, err := disk.UsageWithContext(ctx, d.GetRootPath())
+	if err != nil {
+		return nil, err
+	}
+	return &model.StorageDetails{
+		DiskUsage: model.DiskUsage{
+			TotalSpace: du.Total,
+			FreeSpace:  du.Free,
+		},
+	}, nil
+}
+
 var _ driver.Driver = (*Local)(nil)
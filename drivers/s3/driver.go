@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/errs"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/stream"
 	"github.com/alist-org/alist/v3/pkg/cron"
@@ -104,10 +105,20 @@ func (d *S3) Drop(ctx context.Context) error {
 }
 
 func (d *S3) List(ctx context.Context, dir model.Obj, args model.ListArgs) ([]model.Obj, error) {
+	var files []model.Obj
+	var err error
 	if d.ListObjectVersion == "v2" {
-		return d.listV2(dir.GetPath(), args)
+		files, err = d.listV2(dir.GetPath(), args)
+	} else {
+		files, err = d.listV1(dir.GetPath(), args)
+	}
+	if err != nil || !d.ReadModTime {
+		return files, err
+	}
+	if err = d.readModTimes(ctx, dir.GetPath(), files); err != nil {
+		return nil, err
 	}
-	return d.listV1(dir.GetPath(), args)
+	return files, nil
 }
 
 func (d *S3) Link(ctx context.Context, file model.Obj, args model.LinkArgs) (*model.Link, error) {
@@ -197,6 +208,13 @@ func (d *S3) Remove(ctx context.Context, obj model.Obj) error {
 	return d.removeFile(obj.GetPath())
 }
 
+func (d *S3) SetModTime(ctx context.Context, obj model.Obj, modTime time.Time) error {
+	if obj.IsDir() {
+		return errs.NotSupport
+	}
+	return d.setModTime(ctx, getKey(obj.GetPath(), false), modTime)
+}
+
 func (d *S3) Put(ctx context.Context, dstDir model.Obj, s model.FileStreamer, up driver.UpdateProgress) error {
 	uploader := s3manager.NewUploader(d.Session)
 	if s.GetSize() > s3manager.MaxUploadParts*s3manager.DefaultUploadPartSize {
@@ -214,6 +232,9 @@ func (d *S3) Put(ctx context.Context, dstDir model.Obj, s model.FileStreamer, up
 		}),
 		ContentType: &contentType,
 	}
+	if modTime := s.ModTime(); !modTime.IsZero() {
+		input.Metadata = map[string]*string{metaModTime: aws.String(formatModTime(modTime))}
+	}
 	if storageClass := d.resolveStorageClass(); storageClass != nil {
 		input.StorageClass = storageClass
 	}
@@ -3,10 +3,13 @@ package s3
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/url"
 	"path"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/alist-org/alist/v3/internal/errs"
 	"github.com/alist-org/alist/v3/internal/model"
@@ -18,6 +21,7 @@ import (
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/s3"
 	log "github.com/sirupsen/logrus"
+	"golang.org/x/sync/errgroup"
 )
 
 // do others that not defined in Driver interface
@@ -191,6 +195,180 @@ func (d *S3) listV2(prefix string, args model.ListArgs) ([]model.Obj, error) {
 	return files, nil
 }
 
+// metaModTime is the user metadata holding the modification time of an object,
+// the format is the same as rclone's so that the value survives round trips
+const metaModTime = "Mtime"
+
+const (
+	// maxCopySize is the largest object CopyObject accepts, larger ones are copied by parts
+	maxCopySize  = 5 * 1024 * 1024 * 1024
+	copyPartSize = 1024 * 1024 * 1024
+)
+
+// formatModTime writes the seconds with a decimal fraction, a float64 can't hold nanoseconds
+func formatModTime(t time.Time) string {
+	s := fmt.Sprintf("%d.%09d", t.Unix(), t.Nanosecond())
+	return strings.TrimSuffix(strings.TrimRight(s, "0"), ".")
+}
+
+// parseModTime reads the time written by formatModTime or rclone
+func parseModTime(metadata map[string]*string) (time.Time, bool) {
+	var value string
+	for k, v := range metadata {
+		if strings.EqualFold(k, metaModTime) && v != nil {
+			value = *v
+			break
+		}
+	}
+	if value == "" {
+		return time.Time{}, false
+	}
+	secStr, fracStr, _ := strings.Cut(value, ".")
+	sec, err := strconv.ParseInt(secStr, 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	var nsec int64
+	if fracStr != "" {
+		if len(fracStr) > 9 {
+			fracStr = fracStr[:9]
+		}
+		if nsec, err = strconv.ParseInt(fracStr+strings.Repeat("0", 9-len(fracStr)), 10, 64); err != nil {
+			return time.Time{}, false
+		}
+	}
+	return time.Unix(sec, nsec), true
+}
+
+// readModTimes replaces the upload times of the files of dir by the times kept in their metadata
+func (d *S3) readModTimes(ctx context.Context, dir string, files []model.Obj) error {
+	g, ctx := errgroup.WithContext(ctx)
+	g.SetLimit(8)
+	for _, f := range files {
+		obj, ok := model.UnwrapObj(f).(*model.Object)
+		if !ok || obj.IsFolder {
+			continue
+		}
+		g.Go(func() error {
+			key := getKey(path.Join(dir, obj.Name), false)
+			head, err := d.client.HeadObjectWithContext(ctx, &s3.HeadObjectInput{
+				Bucket: &d.Bucket,
+				Key:    &key,
+			})
+			if err != nil {
+				return err
+			}
+			if modTime, ok := parseModTime(head.Metadata); ok {
+				obj.Modified = modTime
+			}
+			return nil
+		})
+	}
+	return g.Wait()
+}
+
+// setModTime replaces the metadata of the object by copying it onto itself
+func (d *S3) setModTime(ctx context.Context, key string, modTime time.Time) error {
+	return d.copyObject(ctx, key, key, nil, modTime)
+}
+
+// copyObject copies src to dst with its metadata, the modification time is replaced unless
+// modTime is zero and the storage class of src is kept if storageClass is nil. The objects
+// over maxCopySize are copied by parts.
+func (d *S3) copyObject(ctx context.Context, src, dst string, storageClass *string, modTime time.Time) error {
+	head, err := d.client.HeadObjectWithContext(ctx, &s3.HeadObjectInput{
+		Bucket: &d.Bucket,
+		Key:    &src,
+	})
+	if err != nil {
+		return err
+	}
+	metadata := head.Metadata
+	if metadata == nil {
+		metadata = make(map[string]*string)
+	}
+	if !modTime.IsZero() {
+		for k := range metadata {
+			if strings.EqualFold(k, metaModTime) {
+				delete(metadata, k)
+			}
+		}
+		metadata[metaModTime] = aws.String(formatModTime(modTime))
+	}
+	if storageClass == nil {
+		storageClass = head.StorageClass
+	}
+	copySource := aws.String(url.PathEscape(d.Bucket + "/" + src))
+	size := aws.Int64Value(head.ContentLength)
+	if size <= maxCopySize {
+		_, err = d.client.CopyObjectWithContext(ctx, &s3.CopyObjectInput{
+			Bucket:             &d.Bucket,
+			CopySource:         copySource,
+			Key:                &dst,
+			Metadata:           metadata,
+			MetadataDirective:  aws.String(s3.MetadataDirectiveReplace),
+			ContentType:        head.ContentType,
+			ContentDisposition: head.ContentDisposition,
+			ContentEncoding:    head.ContentEncoding,
+			CacheControl:       head.CacheControl,
+			StorageClass:       storageClass,
+		})
+		return err
+	}
+	upload, err := d.client.CreateMultipartUploadWithContext(ctx, &s3.CreateMultipartUploadInput{
+		Bucket:             &d.Bucket,
+		Key:                &dst,
+		Metadata:           metadata,
+		ContentType:        head.ContentType,
+		ContentDisposition: head.ContentDisposition,
+		ContentEncoding:    head.ContentEncoding,
+		CacheControl:       head.CacheControl,
+		StorageClass:       storageClass,
+	})
+	if err != nil {
+		return err
+	}
+	parts, err := d.copyParts(ctx, copySource, dst, upload.UploadId, size)
+	if err == nil {
+		_, err = d.client.CompleteMultipartUploadWithContext(ctx, &s3.CompleteMultipartUploadInput{
+			Bucket:          &d.Bucket,
+			Key:             &dst,
+			UploadId:        upload.UploadId,
+			MultipartUpload: &s3.CompletedMultipartUpload{Parts: parts},
+		})
+	}
+	if err != nil {
+		if _, e := d.client.AbortMultipartUploadWithContext(context.Background(), &s3.AbortMultipartUploadInput{
+			Bucket:   &d.Bucket,
+			Key:      &dst,
+			UploadId: upload.UploadId,
+		}); e != nil {
+			log.Warnf("s3: failed abort multipart copy to %s: %+v", dst, e)
+		}
+	}
+	return err
+}
+
+func (d *S3) copyParts(ctx context.Context, copySource *string, dst string, uploadID *string, size int64) ([]*s3.CompletedPart, error) {
+	parts := make([]*s3.CompletedPart, 0, (size+copyPartSize-1)/copyPartSize)
+	for start, n := int64(0), int64(1); start < size; start, n = start+copyPartSize, n+1 {
+		end := min(start+copyPartSize, size) - 1
+		res, err := d.client.UploadPartCopyWithContext(ctx, &s3.UploadPartCopyInput{
+			Bucket:          &d.Bucket,
+			CopySource:      copySource,
+			CopySourceRange: aws.String(fmt.Sprintf("bytes=%d-%d", start, end)),
+			Key:             &dst,
+			PartNumber:      aws.Int64(n),
+			UploadId:        uploadID,
+		})
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, &s3.CompletedPart{ETag: res.CopyPartResult.ETag, PartNumber: aws.Int64(n)})
+	}
+	return parts, nil
+}
+
 func (d *S3) copy(ctx context.Context, src string, dst string, isDir bool) error {
 	if isDir {
 		return d.copyDir(ctx, src, dst)
@@ -199,18 +377,7 @@ func (d *S3) copy(ctx context.Context, src string, dst string, isDir bool) error
 }
 
 func (d *S3) copyFile(ctx context.Context, src string, dst string) error {
-	srcKey := getKey(src, false)
-	dstKey := getKey(dst, false)
-	input := &s3.CopyObjectInput{
-		Bucket:     &d.Bucket,
-		CopySource: aws.String(url.PathEscape(d.Bucket + "/" + srcKey)),
-		Key:        &dstKey,
-	}
-	if storageClass := d.resolveStorageClass(); storageClass != nil {
-		input.StorageClass = storageClass
-	}
-	_, err := d.client.CopyObject(input)
-	return err
+	return d.copyObject(ctx, getKey(src, false), getKey(dst, false), d.resolveStorageClass(), time.Time{})
 }
 
 func (d *S3) copyDir(ctx context.Context, src string, dst string) error {
@@ -0,0 +1,150 @@
+package s3
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/model"
+)
+
+// fakeS3 answers the requests setting the modification times and records them
+type fakeS3 struct {
+	mu       sync.Mutex
+	size     int64
+	metadata map[string]string
+	requests []*http.Request
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	f.requests = append(f.requests, r)
+	f.mu.Unlock()
+	q := r.URL.Query()
+	switch {
+	case r.Method == http.MethodHead:
+		for k, v := range f.metadata {
+			w.Header().Set("X-Amz-Meta-"+k, v)
+		}
+		w.Header().Set("X-Amz-Storage-Class", "STANDARD_IA")
+		w.Header().Set("Content-Length", strconv.FormatInt(f.size, 10))
+	case r.Method == http.MethodPost && q.Has("uploads"):
+		fmt.Fprint(w, `<InitiateMultipartUploadResult><UploadId>upload</UploadId></InitiateMultipartUploadResult>`)
+	case r.Method == http.MethodPut && q.Has("partNumber"):
+		fmt.Fprintf(w, `<CopyPartResult><ETag>"part%s"</ETag></CopyPartResult>`, q.Get("partNumber"))
+	case r.Method == http.MethodPost && q.Has("uploadId"):
+		fmt.Fprint(w, `<CompleteMultipartUploadResult><ETag>"done"</ETag></CompleteMultipartUploadResult>`)
+	case r.Method == http.MethodPut:
+		fmt.Fprint(w, `<CopyObjectResult><ETag>"copy"</ETag></CopyObjectResult>`)
+	default:
+		w.WriteHeader(http.StatusNotImplemented)
+	}
+}
+
+func newFakeS3(t *testing.T, f *fakeS3) *S3 {
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	d := &S3{Addition: Addition{
+		Bucket:          "bucket",
+		Endpoint:        srv.URL,
+		Region:          "us-east-1",
+		AccessKeyID:     "id",
+		SecretAccessKey: "secret",
+		ForcePathStyle:  true,
+	}}
+	if err := d.initSession(); err != nil {
+		t.Fatal(err)
+	}
+	d.client = d.getClient(false)
+	return d
+}
+
+func TestModTimeMetadata(t *testing.T) {
+	want := time.Unix(1700000000, 123456789)
+	got, ok := parseModTime(map[string]*string{"mtime": &[]string{"1700000000.123456789"}[0]})
+	if !ok || !got.Equal(want) {
+		t.Fatalf("expect %v, got %v", want, got)
+	}
+	ms := time.UnixMilli(1700000000123)
+	value := formatModTime(ms)
+	if got, ok := parseModTime(map[string]*string{"Mtime": &value}); !ok || !got.Equal(ms) {
+		t.Fatalf("expect %v after a round trip of %s, got %v", ms, value, got)
+	}
+	if _, ok := parseModTime(map[string]*string{"Mtime": &[]string{"invalid"}[0]}); ok {
+		t.Fatal("invalid value must be ignored")
+	}
+}
+
+func TestSetModTime(t *testing.T) {
+	modTime := time.Unix(1700000000, 0)
+	f := &fakeS3{size: 10, metadata: map[string]string{"Foo": "bar", "Mtime": "1"}}
+	d := newFakeS3(t, f)
+	if err := d.setModTime(context.Background(), "dir/file", modTime); err != nil {
+		t.Fatal(err)
+	}
+	if len(f.requests) != 2 {
+		t.Fatalf("expect a head and a copy, got %d requests", len(f.requests))
+	}
+	copyReq := f.requests[1]
+	if src, _ := url.PathUnescape(copyReq.Header.Get("X-Amz-Copy-Source")); src != "bucket/dir/file" || copyReq.URL.Path != "/bucket/dir/file" {
+		t.Fatalf("object must be copied onto itself: %s from %s", copyReq.URL.Path, src)
+	}
+	if copyReq.Header.Get("X-Amz-Metadata-Directive") != "REPLACE" {
+		t.Error("metadata must be replaced")
+	}
+	if got := copyReq.Header.Get("X-Amz-Meta-Mtime"); got != formatModTime(modTime) {
+		t.Errorf("unexpected mtime %s", got)
+	}
+	if copyReq.Header.Get("X-Amz-Meta-Foo") != "bar" {
+		t.Error("other metadata must be kept")
+	}
+	if copyReq.Header.Get("X-Amz-Storage-Class") != "STANDARD_IA" {
+		t.Error("storage class must be kept")
+	}
+}
+
+func TestSetModTimeLargeObject(t *testing.T) {
+	modTime := time.Unix(1700000000, 0)
+	f := &fakeS3{size: maxCopySize + copyPartSize/2, metadata: map[string]string{}}
+	d := newFakeS3(t, f)
+	if err := d.setModTime(context.Background(), "big", modTime); err != nil {
+		t.Fatal(err)
+	}
+	// head, create, 6 parts, complete
+	if len(f.requests) != 9 {
+		t.Fatalf("unexpected %d requests", len(f.requests))
+	}
+	if got := f.requests[1].Header.Get("X-Amz-Meta-Mtime"); got != formatModTime(modTime) {
+		t.Errorf("multipart upload must carry the mtime, got %q", got)
+	}
+	last := f.requests[7]
+	if got, want := last.Header.Get("X-Amz-Copy-Source-Range"), fmt.Sprintf("bytes=%d-%d", maxCopySize, f.size-1); got != want {
+		t.Errorf("expect last range %s, got %s", want, got)
+	}
+	if q := f.requests[8].URL.Query(); f.requests[8].Method != http.MethodPost || q.Get("uploadId") != "upload" {
+		t.Error("multipart copy must be completed")
+	}
+}
+
+func TestReadModTimes(t *testing.T) {
+	modTime := time.Unix(1700000000, 5)
+	f := &fakeS3{metadata: map[string]string{"Mtime": formatModTime(modTime)}}
+	d := newFakeS3(t, f)
+	file := &model.Object{Name: "file", Modified: time.Now()}
+	dir := &model.Object{Name: "dir", IsFolder: true}
+	if err := d.readModTimes(context.Background(), "/", []model.Obj{model.WrapObjStorageClass(file, "STANDARD"), dir}); err != nil {
+		t.Fatal(err)
+	}
+	if !file.Modified.Equal(modTime) {
+		t.Errorf("expect %v, got %v", modTime, file.Modified)
+	}
+	if len(f.requests) != 1 {
+		t.Errorf("only files must be read, got %d requests", len(f.requests))
+	}
+}
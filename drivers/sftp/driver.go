@@ -4,6 +4,7 @@ import (
 	"context"
 	"os"
 	"path"
+	"time"
 
 	"github.com/alist-org/alist/v3/internal/driver"
 	"github.com/alist-org/alist/v3/internal/errs"
@@ -100,6 +101,13 @@ func (d *SFTP) Remove(ctx context.Context, obj model.Obj) error {
 	return d.remove(obj.GetPath())
 }
 
+func (d *SFTP) SetModTime(ctx context.Context, obj model.Obj, modTime time.Time) error {
+	if err := d.clientReconnectOnConnectionError(); err != nil {
+		return err
+	}
+	return d.client.Chtimes(obj.GetPath(), modTime, modTime)
+}
+
 func (d *SFTP) Put(ctx context.Context, dstDir model.Obj, stream model.FileStreamer, up driver.UpdateProgress) error {
 	if err := d.clientReconnectOnConnectionError(); err != nil {
 		return err
@@ -112,7 +120,31 @@ func (d *SFTP) Put(ctx context.Context, dstDir model.Obj, stream model.FileStrea
 		_ = dstFile.Close()
 	}()
 	err = utils.CopyWithCtx(ctx, dstFile, driver.NewLimitedUploadStream(ctx, stream), stream.GetSize(), up)
-	return err
+	if err != nil {
+		return err
+	}
+	if modTime := stream.ModTime(); !modTime.IsZero() {
+		if err := d.client.Chtimes(dstFile.Name(), modTime, modTime); err != nil {
+			log.Errorf("[sftp] failed to change time of %s: %s", dstFile.Name(), err)
+		}
+	}
+	return nil
+}
+
+func (d *SFTP) GetDetails(ctx context.Context) (*model.StorageDetails, error) {
+	if err := d.clientReconnectOnConnectionError(); err != nil {
+		return nil, err
+	}
+	stat, err := d.client.StatVFS(d.GetRootPath())
+	if err != nil {
+		return nil, err
+	}
+	return &model.StorageDetails{
+		DiskUsage: model.DiskUsage{
+			TotalSpace: stat.TotalSpace(),
+			FreeSpace:  stat.FreeSpace(),
+		},
+	}, nil
 }
 
 var _ driver.Driver = (*SFTP)(nil)
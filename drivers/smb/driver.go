@@ -5,6 +5,7 @@ import (
 	"errors"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/alist-org/alist/v3/internal/driver"
 	"github.com/alist-org/alist/v3/internal/model"
@@ -169,6 +170,19 @@ func (d *SMB) Remove(ctx context.Context, obj model.Obj) error {
 	return nil
 }
 
+func (d *SMB) SetModTime(ctx context.Context, obj model.Obj, modTime time.Time) error {
+	if err := d.checkConn(); err != nil {
+		return err
+	}
+	err := d.fs.Chtimes(obj.GetPath(), modTime, modTime)
+	if err != nil {
+		d.cleanLastConnTime()
+		return err
+	}
+	d.updateLastConnTime()
+	return nil
+}
+
 func (d *SMB) Put(ctx context.Context, dstDir model.Obj, stream model.FileStreamer, up driver.UpdateProgress) error {
 	if err := d.checkConn(); err != nil {
 		return err
@@ -190,9 +204,30 @@ func (d *SMB) Put(ctx context.Context, dstDir model.Obj, stream model.FileStream
 	if err != nil {
 		return err
 	}
+	if modTime := stream.ModTime(); !modTime.IsZero() {
+		_ = d.fs.Chtimes(fullPath, modTime, modTime)
+	}
 	return nil
 }
 
+func (d *SMB) GetDetails(ctx context.Context) (*model.StorageDetails, error) {
+	if err := d.checkConn(); err != nil {
+		return nil, err
+	}
+	stat, err := d.fs.Statfs(d.GetRootPath())
+	if err != nil {
+		d.cleanLastConnTime()
+		return nil, err
+	}
+	d.updateLastConnTime()
+	return &model.StorageDetails{
+		DiskUsage: model.DiskUsage{
+			TotalSpace: stat.BlockSize() * stat.TotalBlockCount(),
+			FreeSpace:  stat.BlockSize() * stat.AvailableBlockCount(),
+		},
+	}, nil
+}
+
 //func (d *SMB) Other(ctx context.Context, args model.OtherArgs) (interface{}, error) {
 //	return nil, errs.NotSupport
 //}
@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"os"
 	"path"
+	"strconv"
 	"time"
 
 	"github.com/alist-org/alist/v3/internal/driver"
@@ -93,10 +94,18 @@ func (d *WebDav) Remove(ctx context.Context, obj model.Obj) error {
 	return d.client.RemoveAll(getPath(obj))
 }
 
+func (d *WebDav) SetModTime(ctx context.Context, obj model.Obj, modTime time.Time) error {
+	return d.client.SetModTime(getPath(obj), modTime)
+}
+
 func (d *WebDav) Put(ctx context.Context, dstDir model.Obj, s model.FileStreamer, up driver.UpdateProgress) error {
 	callback := func(r *http.Request) {
 		r.Header.Set("Content-Type", s.GetMimetype())
 		r.ContentLength = s.GetSize()
+		// ownCloud and Nextcloud keep the modification time sent with the upload
+		if modTime := s.ModTime(); !modTime.IsZero() {
+			r.Header.Set("X-OC-Mtime", strconv.FormatInt(modTime.Unix(), 10))
+		}
 	}
 	reader := driver.NewLimitedUploadStream(ctx, &driver.ReaderUpdatingProgress{
 		Reader:         s,
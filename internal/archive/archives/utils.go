@@ -85,5 +85,11 @@ func decompress(fsys fs2.FS, filePath, dstPath string, up model.UpdateProgress)
 		},
 		UpdateProgress: up,
 	})
-	return err
+	if err != nil {
+		return err
+	}
+	if modTime := stat.ModTime(); !modTime.IsZero() {
+		_ = os.Chtimes(dstPath, modTime, modTime)
+	}
+	return nil
 }
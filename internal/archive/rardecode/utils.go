@@ -210,6 +210,9 @@ func _decompress(reader *rardecode.Reader, header *rardecode.FileHeader, dstPath
 	if err != nil {
 		return err
 	}
+	if modTime := header.ModificationTime; !modTime.IsZero() {
+		_ = os.Chtimes(dstPath, modTime, modTime)
+	}
 	return nil
 }
 
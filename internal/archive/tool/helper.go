@@ -261,5 +261,8 @@ func _decompress(file SubFile, dstPath, password string, up model.UpdateProgress
 	if err != nil {
 		return err
 	}
+	if modTime := file.FileInfo().ModTime(); !modTime.IsZero() {
+		_ = os.Chtimes(dstPath, modTime, modTime)
+	}
 	return nil
 }
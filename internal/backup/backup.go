@@ -0,0 +1,246 @@
+package backup
+
+import (
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/cryptkey"
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+)
+
+// Version is the version of the backup format, backups of newer versions can't be imported
+const Version = 1
+
+type Backup struct {
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	// Encryption is set if the confidential fields are encrypted with a passphrase
+	Encryption *Encryption `json:"encryption,omitempty"`
+
+	Storages          []model.Storage          `json:"storages"`
+	Users             []User                   `json:"users"`
+	Roles             []model.Role             `json:"roles"`
+	Groups            []model.Group            `json:"groups"`
+	GroupMembers      []model.GroupMember      `json:"group_members"`
+	Metas             []model.Meta             `json:"metas"`
+	Settings          []model.SettingItem      `json:"settings"`
+	Shares            []Share                  `json:"shares"`
+	Labels            []model.Label            `json:"labels"`
+	LabelFileBindings []model.LabelFileBinding `json:"label_file_bindings"`
+	SSHKeys           []SSHKey                 `json:"ssh_keys"`
+	OAuthClients      []OAuthClient            `json:"oauth_clients"`
+	InternalShares    []model.InternalShare    `json:"internal_shares"`
+	DownloadLinks     []DownloadLink           `json:"download_links"`
+	UserKeys          []UserKey                `json:"user_keys"`
+	MountKeys         []MountKey               `json:"mount_keys"`
+}
+
+// User carries the fields hidden from the json of model.User
+type User struct {
+	model.User
+	PwdHash   string `json:"pwd_hash"`
+	PwdTS     int64  `json:"pwd_ts"`
+	Salt      string `json:"salt"`
+	OtpSecret string `json:"otp_secret"`
+	Authn     string `json:"authn"`
+}
+
+func (u User) toModel() model.User {
+	user := u.User
+	user.PwdHash = u.PwdHash
+	user.PwdTS = u.PwdTS
+	user.Salt = u.Salt
+	user.OtpSecret = u.OtpSecret
+	user.Authn = u.Authn
+	return user
+}
+
+// Share carries the fields hidden from the json of model.Share
+type Share struct {
+	model.Share
+	PasswordHash string `json:"password_hash"`
+	PasswordSalt string `json:"password_salt"`
+}
+
+func (s Share) toModel() model.Share {
+	share := s.Share
+	share.PasswordHash = s.PasswordHash
+	share.PasswordSalt = s.PasswordSalt
+	return share
+}
+
+// SSHKey carries the fields hidden from the json of model.SSHPublicKey
+type SSHKey struct {
+	model.SSHPublicKey
+	UserId uint   `json:"user_id"`
+	KeyStr string `json:"key_str"`
+}
+
+func (k SSHKey) toModel() model.SSHPublicKey {
+	key := k.SSHPublicKey
+	key.UserId = k.UserId
+	key.KeyStr = k.KeyStr
+	return key
+}
+
+// OAuthClient carries the fields hidden from the json of model.OAuthClient
+type OAuthClient struct {
+	model.OAuthClient
+	SecretHash string `json:"secret_hash"`
+}
+
+func (c OAuthClient) toModel() model.OAuthClient {
+	client := c.OAuthClient
+	client.SecretHash = c.SecretHash
+	return client
+}
+
+// DownloadLink carries the fields hidden from the json of model.DownloadLink
+type DownloadLink struct {
+	model.DownloadLink
+	PasswordHash string `json:"password_hash"`
+}
+
+func (l DownloadLink) toModel() model.DownloadLink {
+	link := l.DownloadLink
+	link.PasswordHash = l.PasswordHash
+	return link
+}
+
+// UserKey carries the fields hidden from the json of model.UserKey, the
+// private key stays wrapped with the passphrase of the user
+type UserKey struct {
+	model.UserKey
+	Salt              string `json:"salt"`
+	WrappedPrivateKey string `json:"wrapped_private_key"`
+}
+
+func (k UserKey) toModel() model.UserKey {
+	key := k.UserKey
+	key.Salt = k.Salt
+	key.WrappedPrivateKey = k.WrappedPrivateKey
+	return key
+}
+
+// MountKey carries the fields hidden from the json of model.MountKey
+type MountKey struct {
+	model.MountKey
+	SealedKey string `json:"sealed_key"`
+}
+
+func (k MountKey) toModel() model.MountKey {
+	key := k.MountKey
+	key.SealedKey = k.SealedKey
+	return key
+}
+
+// Export reads the configuration from the database, the confidential fields
+// are encrypted if passphrase is not empty. The offline download tools and the
+// signing key of the OpenID Connect provider are settings, so they are included
+// in Settings. Expired download links are left out. The keys of the crypt
+// storages with per user keys are exported as stored, sealed to their users,
+// call Saved once the backup is written.
+//
+// The state of the running instance is not exported:
+//   - pipelines, they follow tasks that are not in the backup and can't resume without them
+//   - authorization codes of the OpenID Connect provider, they expire within minutes
+//   - sessions, the visits of shares, dav locks and dead properties, hashes and the search index
+func Export(passphrase string) (*Backup, error) {
+	tx := db.GetDb()
+	b := &Backup{Version: Version, CreatedAt: time.Now()}
+	var users []model.User
+	var shares []model.Share
+	var keys []model.SSHPublicKey
+	var clients []model.OAuthClient
+	var userKeys []model.UserKey
+	var mountKeys []model.MountKey
+	for _, dst := range []any{&b.Storages, &users, &b.Roles, &b.Groups, &b.GroupMembers, &b.Metas, &b.Settings, &shares, &b.Labels,
+		&b.LabelFileBindings, &keys, &clients, &b.InternalShares, &userKeys, &mountKeys} {
+		if err := tx.Find(dst).Error; err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+	var links []model.DownloadLink
+	if err := tx.Where("expires_at > ?", b.CreatedAt).Find(&links).Error; err != nil {
+		return nil, errors.WithStack(err)
+	}
+	for _, u := range users {
+		b.Users = append(b.Users, User{User: u, PwdHash: u.PwdHash, PwdTS: u.PwdTS, Salt: u.Salt, OtpSecret: u.OtpSecret, Authn: u.Authn})
+	}
+	for _, s := range shares {
+		b.Shares = append(b.Shares, Share{Share: s, PasswordHash: s.PasswordHash, PasswordSalt: s.PasswordSalt})
+	}
+	for _, k := range keys {
+		b.SSHKeys = append(b.SSHKeys, SSHKey{SSHPublicKey: k, UserId: k.UserId, KeyStr: k.KeyStr})
+	}
+	for _, c := range clients {
+		b.OAuthClients = append(b.OAuthClients, OAuthClient{OAuthClient: c, SecretHash: c.SecretHash})
+	}
+	for _, l := range links {
+		b.DownloadLinks = append(b.DownloadLinks, DownloadLink{DownloadLink: l, PasswordHash: l.PasswordHash})
+	}
+	for _, k := range userKeys {
+		b.UserKeys = append(b.UserKeys, UserKey{UserKey: k, Salt: k.Salt, WrappedPrivateKey: k.WrappedPrivateKey})
+	}
+	for _, k := range mountKeys {
+		b.MountKeys = append(b.MountKeys, MountKey{MountKey: k, SealedKey: k.SealedKey})
+	}
+	if passphrase != "" {
+		if err := b.encrypt(passphrase); err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+// Saved marks the keys of the crypt storages in the backup as backed up once
+// it's written, the storages with per user keys then forget their password
+// and salt
+func Saved(b *Backup) error {
+	ids := make([]uint, 0, len(b.MountKeys))
+	for _, k := range b.MountKeys {
+		ids = append(ids, k.ID)
+	}
+	if err := db.MarkMountKeysBackedUp(ids); err != nil {
+		return err
+	}
+	cryptkey.ForgetBackedUp()
+	return nil
+}
+
+// confidential returns the fields holding secrets, they are encrypted in place
+func (b *Backup) confidential() []*string {
+	var fields []*string
+	for i := range b.Storages {
+		fields = append(fields, &b.Storages[i].Addition)
+	}
+	for i := range b.Users {
+		u := &b.Users[i]
+		fields = append(fields, &u.Password, &u.PwdHash, &u.Salt, &u.OtpSecret, &u.Authn)
+	}
+	for i := range b.Metas {
+		fields = append(fields, &b.Metas[i].Password)
+	}
+	for i := range b.Settings {
+		if b.Settings[i].Flag == model.PRIVATE {
+			fields = append(fields, &b.Settings[i].Value)
+		}
+	}
+	for i := range b.Shares {
+		fields = append(fields, &b.Shares[i].PasswordHash, &b.Shares[i].PasswordSalt)
+	}
+	for i := range b.OAuthClients {
+		fields = append(fields, &b.OAuthClients[i].SecretHash)
+	}
+	for i := range b.DownloadLinks {
+		fields = append(fields, &b.DownloadLinks[i].PasswordHash)
+	}
+	for i := range b.UserKeys {
+		fields = append(fields, &b.UserKeys[i].Salt, &b.UserKeys[i].WrappedPrivateKey)
+	}
+	for i := range b.MountKeys {
+		fields = append(fields, &b.MountKeys[i].SealedKey)
+	}
+	return fields
+}
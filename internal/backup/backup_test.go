@@ -0,0 +1,172 @@
+package backup
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/db/dbtest"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+)
+
+func init() {
+	dbtest.Init()
+}
+
+func TestEncryptedRoundTrip(t *testing.T) {
+	b := &Backup{
+		Version:  Version,
+		Storages: []model.Storage{{MountPath: "/local", Driver: "Local", Addition: `{"root_folder_path":"/secret"}`}},
+		Users:    []User{{User: model.User{Username: "alice"}, PwdHash: "hash", Salt: "salt"}},
+	}
+	if err := b.encrypt("passphrase"); err != nil {
+		t.Fatalf("failed encrypt: %+v", err)
+	}
+	if b.Storages[0].Addition == `{"root_folder_path":"/secret"}` || b.Users[0].PwdHash == "hash" {
+		t.Fatal("the confidential fields must be encrypted")
+	}
+	wrong := *b
+	wrong.Storages = append([]model.Storage(nil), b.Storages...)
+	wrong.Users = append([]User(nil), b.Users...)
+	if err := wrong.decrypt("wrong"); !errors.Is(err, ErrWrongPassphrase) {
+		t.Fatalf("expect ErrWrongPassphrase, got %v", err)
+	}
+	if err := b.decrypt("passphrase"); err != nil {
+		t.Fatalf("failed decrypt: %+v", err)
+	}
+	if b.Storages[0].Addition != `{"root_folder_path":"/secret"}` || b.Users[0].PwdHash != "hash" || b.Users[0].Salt != "salt" {
+		t.Fatalf("unexpected decrypted backup: %+v", b)
+	}
+}
+
+func TestImportRemapsIDs(t *testing.T) {
+	b := &Backup{
+		Version: Version,
+		Roles:   []model.Role{{ID: 50, Name: "editor"}},
+		// the role 99 is not in the backup
+		Users:  []User{{User: model.User{ID: 70, Username: "bob", Role: model.Roles{50, 99}}}},
+		Shares: []Share{{Share: model.Share{ShareID: "abc", CreatorID: 70, Name: "abc", RootPath: "/"}}},
+	}
+	report, err := Import(b, ModeMerge, "")
+	if err != nil {
+		t.Fatalf("failed import: %+v", err)
+	}
+	if report["users"].Created != 1 || report["shares"].Created != 1 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	var role model.Role
+	var user model.User
+	var share model.Share
+	db.GetDb().Where("name = ?", "editor").First(&role)
+	db.GetDb().Where("username = ?", "bob").First(&user)
+	db.GetDb().Where("share_id = ?", "abc").First(&share)
+	if len(user.Role) != 1 || uint(user.Role[0]) != role.ID {
+		t.Errorf("the role of the user is not remapped or the missing one is kept: %v, role id %d", user.Role, role.ID)
+	}
+	if share.CreatorID != user.ID {
+		t.Errorf("the creator of the share is not remapped: %d, user id %d", share.CreatorID, user.ID)
+	}
+	// importing again updates the same entries
+	report, err = Import(b, ModeMerge, "")
+	if err != nil {
+		t.Fatalf("failed import again: %+v", err)
+	}
+	if report["users"].Updated != 1 || report["shares"].Updated != 1 {
+		t.Fatalf("unexpected report of the second import: %+v", report)
+	}
+}
+
+func TestExportOAuthClientsInternalSharesAndLinks(t *testing.T) {
+	owner := &model.User{Username: "backup_owner"}
+	target := &model.User{Username: "backup_target"}
+	for _, u := range []*model.User{owner, target} {
+		if err := db.CreateUser(u); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := db.CreateOAuthClient(&model.OAuthClient{ClientID: "backup_client", Name: "app", SecretHash: "secret"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.GetDb().Create(&model.InternalShare{OwnerID: owner.ID, Path: "/docs", Name: "docs",
+		TargetType: model.InternalShareUser, TargetID: target.ID}).Error; err != nil {
+		t.Fatal(err)
+	}
+	for token, expires := range map[string]time.Time{"backuplive": time.Now().Add(time.Hour), "backupgone": time.Now().Add(-time.Hour)} {
+		link := &model.DownloadLink{Token: token, CreatorID: owner.ID, Path: "/docs/a", ExpiresAt: expires}
+		link.SetPassword("pass")
+		if err := db.CreateDownloadLink(link); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	b, err := Export("passphrase")
+	if err != nil {
+		t.Fatalf("failed export: %+v", err)
+	}
+	if len(b.OAuthClients) != 1 || b.OAuthClients[0].SecretHash == "secret" {
+		t.Fatalf("expect the client with its secret encrypted, got %+v", b.OAuthClients)
+	}
+	if len(b.DownloadLinks) != 1 || b.DownloadLinks[0].Token != "backuplive" {
+		t.Fatalf("expect the live link only, got %+v", b.DownloadLinks)
+	}
+	if len(b.InternalShares) != 1 {
+		t.Fatalf("expect the internal share, got %+v", b.InternalShares)
+	}
+
+	// replacing gives new ids to the users
+	report, err := Import(b, ModeReplace, "passphrase")
+	if err != nil {
+		t.Fatalf("failed import: %+v", err)
+	}
+	if report["oauth_clients"].Created != 1 || report["internal_shares"].Created != 1 || report["download_links"].Created != 1 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	var client model.OAuthClient
+	var share model.InternalShare
+	var link model.DownloadLink
+	db.GetDb().Where("client_id = ?", "backup_client").First(&client)
+	db.GetDb().First(&share)
+	db.GetDb().Where("token = ?", "backuplive").First(&link)
+	if client.SecretHash != "secret" {
+		t.Errorf("the secret of the client is not restored: %q", client.SecretHash)
+	}
+	var newOwner, newTarget model.User
+	db.GetDb().Where("username = ?", "backup_owner").First(&newOwner)
+	db.GetDb().Where("username = ?", "backup_target").First(&newTarget)
+	if share.OwnerID != newOwner.ID || share.TargetID != newTarget.ID {
+		t.Errorf("the internal share is not remapped: %+v", share)
+	}
+	if link.CreatorID != newOwner.ID || !link.MatchesPassword("pass") {
+		t.Errorf("the download link is not restored: %+v", link)
+	}
+}
+
+func TestImportCryptKeys(t *testing.T) {
+	b := &Backup{
+		Version:   Version,
+		Storages:  []model.Storage{{ID: 40, MountPath: "/keys_crypt", Driver: "Crypt"}},
+		Users:     []User{{User: model.User{ID: 41, Username: "keys_holder"}}},
+		UserKeys:  []UserKey{{UserKey: model.UserKey{UserID: 41, PublicKey: "pub"}, Salt: "salt", WrappedPrivateKey: "wrapped"}},
+		MountKeys: []MountKey{{MountKey: model.MountKey{StorageID: 40, UserID: 41, GrantedBy: 41}, SealedKey: "sealed"}},
+	}
+	report, err := Import(b, ModeMerge, "")
+	if err != nil {
+		t.Fatalf("failed import: %+v", err)
+	}
+	if report["user_keys"].Created != 1 || report["mount_keys"].Created != 1 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	var user model.User
+	var storage model.Storage
+	db.GetDb().Where("username = ?", "keys_holder").First(&user)
+	db.GetDb().Where("mount_path = ?", "/keys_crypt").First(&storage)
+	userKey, err := db.GetUserKey(user.ID)
+	if err != nil || userKey.WrappedPrivateKey != "wrapped" || userKey.Salt != "salt" {
+		t.Fatalf("the user key is not restored: %+v %v", userKey, err)
+	}
+	mountKey, err := db.GetMountKey(storage.ID, user.ID)
+	if err != nil || mountKey.SealedKey != "sealed" || mountKey.GrantedBy != user.ID || !mountKey.BackedUp {
+		t.Fatalf("the mount key is not restored: %+v %v", mountKey, err)
+	}
+}
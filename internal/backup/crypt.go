@@ -0,0 +1,123 @@
+package backup
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	algorithm = "scrypt-aes-256-gcm"
+	// checkText is encrypted with the key, so a wrong passphrase is told before importing anything
+	checkText = "alist-backup"
+)
+
+var ErrWrongPassphrase = errors.New("wrong passphrase of the backup")
+
+type Encryption struct {
+	Algorithm string `json:"algorithm"`
+	Salt      string `json:"salt"`
+	Check     string `json:"check"`
+}
+
+func deriveKey(passphrase string, salt []byte) (cipher.AEAD, error) {
+	key, err := scrypt.Key([]byte(passphrase), salt, 1<<15, 8, 1, 32)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return cipher.NewGCM(block)
+}
+
+func seal(aead cipher.AEAD, plain string) (string, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", errors.WithStack(err)
+	}
+	return base64.StdEncoding.EncodeToString(aead.Seal(nonce, nonce, []byte(plain), nil)), nil
+}
+
+func open(aead cipher.AEAD, sealed string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	if len(data) < aead.NonceSize() {
+		return "", errors.New("invalid encrypted field")
+	}
+	plain, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], nil)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	return string(plain), nil
+}
+
+func (b *Backup) encrypt(passphrase string) error {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return errors.WithStack(err)
+	}
+	aead, err := deriveKey(passphrase, salt)
+	if err != nil {
+		return err
+	}
+	check, err := seal(aead, checkText)
+	if err != nil {
+		return err
+	}
+	// empty fields stay empty, they tell nothing
+	for _, field := range b.confidential() {
+		if *field == "" {
+			continue
+		}
+		if *field, err = seal(aead, *field); err != nil {
+			return err
+		}
+	}
+	b.Encryption = &Encryption{
+		Algorithm: algorithm,
+		Salt:      base64.StdEncoding.EncodeToString(salt),
+		Check:     check,
+	}
+	return nil
+}
+
+func (b *Backup) decrypt(passphrase string) error {
+	if b.Encryption == nil {
+		return nil
+	}
+	if passphrase == "" {
+		return errors.New("the backup is encrypted, a passphrase is required")
+	}
+	if b.Encryption.Algorithm != algorithm {
+		return errors.Errorf("unsupported encryption algorithm: %s", b.Encryption.Algorithm)
+	}
+	salt, err := base64.StdEncoding.DecodeString(b.Encryption.Salt)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	aead, err := deriveKey(passphrase, salt)
+	if err != nil {
+		return err
+	}
+	if check, err := open(aead, b.Encryption.Check); err != nil || check != checkText {
+		return ErrWrongPassphrase
+	}
+	for _, field := range b.confidential() {
+		if *field == "" {
+			continue
+		}
+		if *field, err = open(aead, *field); err != nil {
+			return errors.WithMessage(err, "failed decrypt field")
+		}
+	}
+	b.Encryption = nil
+	return nil
+}
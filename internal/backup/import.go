@@ -0,0 +1,428 @@
+package backup
+
+import (
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+const (
+	// ModeMerge updates the entries matching the backup and creates the others
+	ModeMerge = "merge"
+	// ModeReplace deletes the entries missing from the backup, settings are only updated
+	// because the server can't run without them
+	ModeReplace = "replace"
+)
+
+// Count is the number of entries created and updated of a section
+type Count struct {
+	Created int `json:"created"`
+	Updated int `json:"updated"`
+}
+
+type Report map[string]*Count
+
+func (r Report) add(section string, updated bool) {
+	c, ok := r[section]
+	if !ok {
+		c = &Count{}
+		r[section] = c
+	}
+	if updated {
+		c.Updated++
+	} else {
+		c.Created++
+	}
+}
+
+// idMap maps the ids in the backup to the ids in the database, the entries are matched
+// by their natural keys, so the ids of the source instance never collide with local ones
+type idMap map[uint]uint
+
+func (m idMap) get(id uint) (uint, bool) {
+	newID, ok := m[id]
+	return newID, ok
+}
+
+// Import writes the backup to the database in a transaction, nothing is changed on error.
+// The caller has to reload the storages and clear the caches if the server is running.
+func Import(b *Backup, mode, passphrase string) (Report, error) {
+	if b.Version > Version {
+		return nil, errors.Errorf("backup version %d is newer than the supported %d", b.Version, Version)
+	}
+	if mode == "" {
+		mode = ModeMerge
+	}
+	if mode != ModeMerge && mode != ModeReplace {
+		return nil, errors.Errorf("unknown import mode: %s", mode)
+	}
+	if err := b.decrypt(passphrase); err != nil {
+		return nil, err
+	}
+	report := make(Report)
+	err := db.GetDb().Transaction(func(tx *gorm.DB) error {
+		if mode == ModeReplace {
+			for _, table := range []any{&model.LabelFileBinding{}, &model.SSHPublicKey{}, &model.ShareAccess{}, &model.Share{}, &model.Label{},
+				&model.DownloadLink{}, &model.InternalShare{}, &model.OAuthCode{}, &model.OAuthClient{}, &model.MountKey{}, &model.UserKey{},
+				&model.GroupMember{}, &model.Group{}, &model.Meta{}, &model.Storage{}, &model.User{}, &model.Role{}} {
+				if err := tx.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(table).Error; err != nil {
+					return errors.WithStack(err)
+				}
+			}
+		}
+		im := importer{tx: tx, report: report}
+		return im.run(b)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+type importer struct {
+	tx       *gorm.DB
+	report   Report
+	roles    idMap
+	users    idMap
+	groups   idMap
+	labels   idMap
+	storages idMap
+}
+
+func (im *importer) run(b *Backup) error {
+	var err error
+	if im.roles, err = im.importRoles(b.Roles); err != nil {
+		return errors.WithMessage(err, "failed import roles")
+	}
+	if im.users, err = im.importUsers(b.Users); err != nil {
+		return errors.WithMessage(err, "failed import users")
+	}
+	if im.groups, err = im.importGroups(b.Groups); err != nil {
+		return errors.WithMessage(err, "failed import groups")
+	}
+	if im.labels, err = im.importLabels(b.Labels); err != nil {
+		return errors.WithMessage(err, "failed import labels")
+	}
+	for _, step := range []struct {
+		name string
+		fn   func(b *Backup) error
+	}{
+		{"group members", im.importGroupMembers},
+		{"storages", im.importStorages},
+		{"metas", im.importMetas},
+		{"settings", im.importSettings},
+		{"shares", im.importShares},
+		{"label file bindings", im.importLabelFileBindings},
+		{"ssh keys", im.importSSHKeys},
+		{"oauth clients", im.importOAuthClients},
+		{"internal shares", im.importInternalShares},
+		{"download links", im.importDownloadLinks},
+		{"user keys", im.importUserKeys},
+		{"mount keys", im.importMountKeys},
+	} {
+		if err := step.fn(b); err != nil {
+			return errors.WithMessagef(err, "failed import %s", step.name)
+		}
+	}
+	return nil
+}
+
+// save creates dst with newID if there is no entry matching where, otherwise overwrites the existing one,
+// id points to the id field of dst and is set to the id in the database, 0 lets the database choose it
+func save[T any](im *importer, section string, dst *T, id *uint, newID uint, where string, args ...any) error {
+	var existing []uint
+	if err := im.tx.Model(new(T)).Where(where, args...).Limit(1).Pluck("id", &existing).Error; err != nil {
+		return errors.WithStack(err)
+	}
+	*id = newID
+	if len(existing) > 0 {
+		*id = existing[0]
+	}
+	if err := im.tx.Save(dst).Error; err != nil {
+		return errors.WithStack(err)
+	}
+	im.report.add(section, len(existing) > 0)
+	return nil
+}
+
+// builtinRoleID returns the fixed id of the guest and admin roles, the users are checked against them
+func builtinRoleID(name string) uint {
+	switch name {
+	case "guest":
+		return model.GUEST
+	case "admin":
+		return model.ADMIN
+	}
+	return 0
+}
+
+func (im *importer) importRoles(roles []model.Role) (idMap, error) {
+	ids := make(idMap)
+	for _, role := range roles {
+		oldID := role.ID
+		if err := save(im, "roles", &role, &role.ID, builtinRoleID(role.Name), "name = ?", role.Name); err != nil {
+			return nil, err
+		}
+		ids[oldID] = role.ID
+	}
+	return ids, nil
+}
+
+func (im *importer) importUsers(users []User) (idMap, error) {
+	ids := make(idMap)
+	for _, u := range users {
+		user := u.toModel()
+		oldID := user.ID
+		roles := make(model.Roles, 0, len(user.Role))
+		for _, id := range user.Role {
+			if newID, ok := im.roles.get(uint(id)); ok {
+				roles = append(roles, int(newID))
+			} else if id == model.GUEST || id == model.ADMIN {
+				// the builtin roles have the same ids everywhere
+				roles = append(roles, id)
+			}
+			// the other roles missing from the backup would point to unrelated roles, so they are dropped
+		}
+		user.Role = roles
+		if err := save(im, "users", &user, &user.ID, 0, "username = ?", user.Username); err != nil {
+			return nil, err
+		}
+		ids[oldID] = user.ID
+	}
+	return ids, nil
+}
+
+func (im *importer) importGroups(groups []model.Group) (idMap, error) {
+	ids := make(idMap)
+	for _, group := range groups {
+		oldID := group.ID
+		if err := save(im, "groups", &group, &group.ID, 0, "name = ?", group.Name); err != nil {
+			return nil, err
+		}
+		ids[oldID] = group.ID
+	}
+	return ids, nil
+}
+
+func (im *importer) importGroupMembers(b *Backup) error {
+	for _, member := range b.GroupMembers {
+		groupID, ok := im.groups.get(member.GroupID)
+		if !ok {
+			continue
+		}
+		userID, ok := im.users.get(member.UserID)
+		if !ok {
+			continue
+		}
+		member.GroupID, member.UserID = groupID, userID
+		var count int64
+		if err := im.tx.Model(&model.GroupMember{}).Where("group_id = ? AND user_id = ?", groupID, userID).Count(&count).Error; err != nil {
+			return errors.WithStack(err)
+		}
+		if err := im.tx.Save(&member).Error; err != nil {
+			return errors.WithStack(err)
+		}
+		im.report.add("group_members", count > 0)
+	}
+	return nil
+}
+
+func (im *importer) importLabels(labels []model.Label) (idMap, error) {
+	ids := make(idMap)
+	for _, label := range labels {
+		oldID := label.ID
+		if err := save(im, "labels", &label, &label.ID, 0, "name = ?", label.Name); err != nil {
+			return nil, err
+		}
+		ids[oldID] = label.ID
+	}
+	return ids, nil
+}
+
+func (im *importer) importStorages(b *Backup) error {
+	im.storages = make(idMap)
+	for _, storage := range b.Storages {
+		oldID := storage.ID
+		storage.MountPath = utils.FixAndCleanPath(storage.MountPath)
+		// the status is set when the storage is loaded
+		storage.Status = ""
+		if err := save(im, "storages", &storage, &storage.ID, 0, "mount_path = ?", storage.MountPath); err != nil {
+			return err
+		}
+		im.storages[oldID] = storage.ID
+	}
+	return nil
+}
+
+func (im *importer) importMetas(b *Backup) error {
+	for _, meta := range b.Metas {
+		if err := save(im, "metas", &meta, &meta.ID, 0, "path = ?", meta.Path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (im *importer) importSettings(b *Backup) error {
+	for _, item := range b.Settings {
+		// settings are keyed by their key, the struct condition quotes the column
+		var count int64
+		if err := im.tx.Model(&model.SettingItem{}).Where(&model.SettingItem{Key: item.Key}).Count(&count).Error; err != nil {
+			return errors.WithStack(err)
+		}
+		if err := im.tx.Save(&item).Error; err != nil {
+			return errors.WithStack(err)
+		}
+		im.report.add("settings", count > 0)
+	}
+	return nil
+}
+
+func (im *importer) importShares(b *Backup) error {
+	for _, s := range b.Shares {
+		share := s.toModel()
+		creatorID, ok := im.users.get(share.CreatorID)
+		if !ok {
+			return errors.Errorf("creator %d of share %s is not in the backup", share.CreatorID, share.ShareID)
+		}
+		share.CreatorID = creatorID
+		// the share falls back to its creator if its group is not in the backup
+		share.GroupID, _ = im.groups.get(share.GroupID)
+		if err := save(im, "shares", &share, &share.ID, 0, "share_id = ?", share.ShareID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (im *importer) importLabelFileBindings(b *Backup) error {
+	for _, binding := range b.LabelFileBindings {
+		userID, ok := im.users.get(binding.UserId)
+		if !ok {
+			continue
+		}
+		labelID, ok := im.labels.get(binding.LabelId)
+		if !ok {
+			continue
+		}
+		binding.UserId, binding.LabelId = userID, labelID
+		binding.GroupId, _ = im.groups.get(binding.GroupId)
+		if err := save(im, "label_file_bindings", &binding, &binding.ID, 0,
+			"user_id = ? AND label_id = ? AND file_name = ?", userID, labelID, binding.FileName); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (im *importer) importSSHKeys(b *Backup) error {
+	for _, k := range b.SSHKeys {
+		key := k.toModel()
+		userID, ok := im.users.get(key.UserId)
+		if !ok {
+			continue
+		}
+		key.UserId = userID
+		if err := save(im, "ssh_keys", &key, &key.ID, 0,
+			"user_id = ? AND fingerprint = ?", userID, key.Fingerprint); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (im *importer) importOAuthClients(b *Backup) error {
+	for _, c := range b.OAuthClients {
+		client := c.toModel()
+		if err := save(im, "oauth_clients", &client, &client.ID, 0, "client_id = ?", client.ClientID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (im *importer) importInternalShares(b *Backup) error {
+	for _, share := range b.InternalShares {
+		ownerID, ok := im.users.get(share.OwnerID)
+		if !ok {
+			continue
+		}
+		targets := im.users
+		switch share.TargetType {
+		case model.InternalShareRole:
+			targets = im.roles
+		case model.InternalShareGroup:
+			targets = im.groups
+		}
+		targetID, ok := targets.get(share.TargetID)
+		if !ok {
+			continue
+		}
+		share.OwnerID, share.TargetID = ownerID, targetID
+		if err := save(im, "internal_shares", &share, &share.ID, 0,
+			"owner_id = ? AND path = ? AND target_type = ? AND target_id = ?", ownerID, share.Path, share.TargetType, targetID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (im *importer) importDownloadLinks(b *Backup) error {
+	for _, l := range b.DownloadLinks {
+		link := l.toModel()
+		creatorID, ok := im.users.get(link.CreatorID)
+		if !ok {
+			continue
+		}
+		link.CreatorID = creatorID
+		if err := save(im, "download_links", &link, &link.ID, 0, "token = ?", link.Token); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (im *importer) importUserKeys(b *Backup) error {
+	for _, k := range b.UserKeys {
+		key := k.toModel()
+		userID, ok := im.users.get(key.UserID)
+		if !ok {
+			continue
+		}
+		key.UserID = userID
+		// user keys are keyed by their user
+		var count int64
+		if err := im.tx.Model(&model.UserKey{}).Where("user_id = ?", userID).Count(&count).Error; err != nil {
+			return errors.WithStack(err)
+		}
+		if err := im.tx.Save(&key).Error; err != nil {
+			return errors.WithStack(err)
+		}
+		im.report.add("user_keys", count > 0)
+	}
+	return nil
+}
+
+func (im *importer) importMountKeys(b *Backup) error {
+	for _, k := range b.MountKeys {
+		key := k.toModel()
+		storageID, ok := im.storages.get(key.StorageID)
+		if !ok {
+			continue
+		}
+		userID, ok := im.users.get(key.UserID)
+		if !ok {
+			continue
+		}
+		key.StorageID, key.UserID = storageID, userID
+		key.GrantedBy, _ = im.users.get(key.GrantedBy)
+		// the key comes from a backup
+		key.BackedUp = true
+		if err := save(im, "mount_keys", &key, &key.ID, 0, "storage_id = ? AND user_id = ?", storageID, userID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
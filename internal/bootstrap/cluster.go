@@ -0,0 +1,13 @@
+package bootstrap
+
+import (
+	"github.com/alist-org/alist/v3/internal/cluster"
+	"github.com/alist-org/alist/v3/pkg/utils"
+)
+
+// InitCluster joins the cluster if it's enabled, it must be called before the storages and the tasks are loaded
+func InitCluster() {
+	if err := cluster.Init(); err != nil {
+		utils.Log.Fatalf("failed join cluster: %+v", err)
+	}
+}
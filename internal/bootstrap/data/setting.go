@@ -158,6 +158,7 @@ func InitialSettings() []model.SettingItem {
 		{Key: conf.CustomizeHead, PreDefault: `<script src="https://cdnjs.cloudflare.com/polyfill/v3/polyfill.min.js?features=String.prototype.replaceAll"></script>`, Type: conf.TypeText, Group: model.GLOBAL, Flag: model.PRIVATE},
 		{Key: conf.CustomizeBody, Type: conf.TypeText, Group: model.GLOBAL, Flag: model.PRIVATE},
 		{Key: conf.LinkExpiration, Value: "0", Type: conf.TypeNumber, Group: model.GLOBAL, Flag: model.PRIVATE},
+		{Key: conf.DownloadLinkMaxTTL, Value: "720", Type: conf.TypeNumber, Group: model.GLOBAL, Flag: model.PRIVATE, Help: "Longest life of a download link in hours"},
 		{Key: conf.SignAll, Value: "true", Type: conf.TypeBool, Group: model.GLOBAL, Flag: model.PRIVATE},
 		{Key: conf.PrivacyRegs, Value: `(?:(?:\d|[1-9]\d|1\d\d|2[0-4]\d|25[0-5])\.){3}(?:\d|[1-9]\d|1\d\d|2[0-4]\d|25[0-5])
 ([[:xdigit:]]{1,4}(?::[[:xdigit:]]{1,4}){7}|::|:(?::[[:xdigit:]]{1,4}){1,6}|[[:xdigit:]]{1,4}:(?::[[:xdigit:]]{1,4}){1,5}|(?:[[:xdigit:]]{1,4}:){2}(?::[[:xdigit:]]{1,4}){1,4}|(?:[[:xdigit:]]{1,4}:){3}(?::[[:xdigit:]]{1,4}){1,3}|(?:[[:xdigit:]]{1,4}:){4}(?::[[:xdigit:]]{1,4}){1,2}|(?:[[:xdigit:]]{1,4}:){5}:[[:xdigit:]]{1,4}|(?:[[:xdigit:]]{1,4}:){1,6}:)
@@ -172,6 +173,9 @@ func InitialSettings() []model.SettingItem {
 		{Key: conf.DeviceEvictPolicy, Value: "deny", Type: conf.TypeSelect, Options: "deny,evict_oldest", Group: model.GLOBAL},
 		{Key: conf.DeviceSessionTTL, Value: "86400", Type: conf.TypeNumber, Group: model.GLOBAL},
 		{Key: conf.MetaNotFoundCacheExpire, Value: "60", Type: conf.TypeNumber, Group: model.GLOBAL, Flag: model.PRIVATE, Help: "Negative cache expiration for missing meta records, in seconds. Set 0 to disable."},
+		{Key: conf.ShareAccessLogDays, Value: "90", Type: conf.TypeNumber, Group: model.GLOBAL, Flag: model.PRIVATE, Help: "Days the access log of shares is kept. Set 0 to keep it forever."},
+		{Key: conf.ShareAccessLogMax, Value: "10000", Type: conf.TypeNumber, Group: model.GLOBAL, Flag: model.PRIVATE, Help: "Max access log entries kept per share. Set 0 for no limit."},
+		{Key: conf.PipelineWebhookHosts, Value: "", Type: conf.TypeText, Group: model.GLOBAL, Flag: model.PRIVATE, Help: "Hosts the webhooks of pipelines created by users other than admins may call, comma separated. Empty allows admins only."},
 
 		// single settings
 		{Key: conf.Token, Value: token, Type: conf.TypeString, Group: model.SINGLE, Flag: model.PRIVATE},
@@ -196,6 +200,11 @@ func InitialSettings() []model.SettingItem {
 		{Key: conf.SSODefaultDir, Value: "/", Type: conf.TypeString, Group: model.SSO, Flag: model.PRIVATE},
 		{Key: conf.SSODefaultPermission, Value: "0", Type: conf.TypeNumber, Group: model.SSO, Flag: model.PRIVATE},
 		{Key: conf.SSOCompatibilityMode, Value: "false", Type: conf.TypeBool, Group: model.SSO, Flag: model.PUBLIC},
+		{Key: conf.SSOOIDCGroupsKey, Value: "groups", Type: conf.TypeString, Group: model.SSO, Flag: model.PRIVATE, Help: "Claim of the OIDC id token holding the groups of the user"},
+		{Key: conf.SSOGroupRoleMapping, Value: "", Type: conf.TypeText, Group: model.SSO, Flag: model.PRIVATE, Help: `JSON object of group to roles, e.g. {"devs": ["editor"]}, the roles are synced on every OIDC login`},
+		{Key: conf.OIDCProviderEnabled, Value: "false", Type: conf.TypeBool, Group: model.SSO, Flag: model.PRIVATE, Help: "Let other applications log in with the users of this site, their clients are managed in admin"},
+		{Key: conf.OIDCProviderTokenExpiresIn, Value: "3600", Type: conf.TypeNumber, Group: model.SSO, Flag: model.PRIVATE, Help: "Seconds the access and id tokens issued to the clients are valid"},
+		{Key: conf.OIDCProviderSigningKey, Value: "", Type: conf.TypeText, Group: model.SSO, Flag: model.PRIVATE, Help: "PEM RSA private key signing the issued tokens, generated when it's empty"},
 
 		// ldap settings
 		{Key: conf.LdapLoginEnabled, Value: "false", Type: conf.TypeBool, Group: model.LDAP, Flag: model.PUBLIC},
@@ -207,6 +216,10 @@ func InitialSettings() []model.SettingItem {
 		{Key: conf.LdapDefaultDir, Value: "/", Type: conf.TypeString, Group: model.LDAP, Flag: model.PRIVATE},
 		{Key: conf.LdapDefaultPermission, Value: "0", Type: conf.TypeNumber, Group: model.LDAP, Flag: model.PRIVATE},
 		{Key: conf.LdapLoginTips, Value: "login with ldap", Type: conf.TypeString, Group: model.LDAP, Flag: model.PUBLIC},
+		{Key: conf.LdapGroupAttribute, Value: "memberOf", Type: conf.TypeString, Group: model.LDAP, Flag: model.PRIVATE, Help: "Attribute of the user entry listing its groups"},
+		{Key: conf.LdapGroupSearchBase, Value: "", Type: conf.TypeString, Group: model.LDAP, Flag: model.PRIVATE, Help: "Base DN the groups are searched in, the groups are not searched if it's empty"},
+		{Key: conf.LdapGroupSearchFilter, Value: "(member=%s)", Type: conf.TypeString, Group: model.LDAP, Flag: model.PRIVATE, Help: "%s is the DN of the user"},
+		{Key: conf.LdapGroupRoleMapping, Value: "", Type: conf.TypeText, Group: model.LDAP, Flag: model.PRIVATE, Help: `JSON object of group DN to roles, e.g. {"cn=devs,ou=groups,dc=example,dc=org": ["editor"]}, the roles are synced on every LDAP login`},
 
 		// s3 settings
 		{Key: conf.S3AccessKeyId, Value: "", Type: conf.TypeString, Group: model.S3, Flag: model.PRIVATE},
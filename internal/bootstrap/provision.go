@@ -0,0 +1,39 @@
+package bootstrap
+
+import (
+	"strings"
+
+	"github.com/alist-org/alist/v3/cmd/flags"
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/provision"
+	"github.com/alist-org/alist/v3/pkg/utils"
+)
+
+// ProvisionFile returns the provision file set by the flag or the config, empty if there is none
+func ProvisionFile() string {
+	if flags.Provision != "" {
+		return flags.Provision
+	}
+	return conf.Conf.ProvisionFile
+}
+
+// Provision reconciles the database against the provision file, the storages are
+// written before they are loaded, so they start with the declared configuration
+func Provision() {
+	path := ProvisionFile()
+	if path == "" {
+		return
+	}
+	spec, err := provision.Load(path)
+	if err != nil {
+		utils.Log.Fatalf("failed load provision file %s: %+v", path, err)
+	}
+	changes, err := provision.Apply(spec)
+	for _, c := range changes {
+		utils.Log.Infof("[provision] %s %s [%s] %s", c.Action, c.Kind, c.Name, strings.Join(c.Fields, ","))
+	}
+	if err != nil {
+		utils.Log.Fatalf("failed apply provision file %s: %+v", path, err)
+	}
+	utils.Log.Infof("provision file %s applied, %d changes", path, len(changes))
+}
@@ -11,6 +11,7 @@ import (
 )
 
 func LoadStorages() {
+	Provision()
 	storages, err := db.GetEnabledStorages()
 	if err != nil {
 		utils.Log.Fatalf("failed get enabled storages: %+v", err)
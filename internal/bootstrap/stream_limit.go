@@ -1,7 +1,6 @@
 package bootstrap
 
 import (
-	"context"
 	"github.com/alist-org/alist/v3/internal/conf"
 	"github.com/alist-org/alist/v3/internal/op"
 	"github.com/alist-org/alist/v3/internal/setting"
@@ -9,25 +8,6 @@ import (
 	"golang.org/x/time/rate"
 )
 
-type blockBurstLimiter struct {
-	*rate.Limiter
-}
-
-func (l blockBurstLimiter) WaitN(ctx context.Context, total int) error {
-	for total > 0 {
-		n := l.Burst()
-		if l.Limiter.Limit() == rate.Inf || n > total {
-			n = total
-		}
-		err := l.Limiter.WaitN(ctx, n)
-		if err != nil {
-			return err
-		}
-		total -= n
-	}
-	return nil
-}
-
 func streamFilterNegative(limit int) (rate.Limit, int) {
 	if limit < 0 {
 		return rate.Inf, 0
@@ -37,7 +17,7 @@ func streamFilterNegative(limit int) (rate.Limit, int) {
 
 func initLimiter(limiter *stream.Limiter, s string) {
 	clientDownLimit, burst := streamFilterNegative(setting.GetInt(s, -1))
-	*limiter = blockBurstLimiter{Limiter: rate.NewLimiter(clientDownLimit, burst)}
+	*limiter = stream.NewLimiter(clientDownLimit, burst)
 	op.RegisterSettingChangingCallback(func() {
 		newLimit, newBurst := streamFilterNegative(setting.GetInt(s, -1))
 		(*limiter).SetLimit(newLimit)
@@ -1,6 +1,7 @@
 package bootstrap
 
 import (
+	"github.com/alist-org/alist/v3/internal/cluster"
 	"github.com/alist-org/alist/v3/internal/conf"
 	"github.com/alist-org/alist/v3/internal/db"
 	"github.com/alist-org/alist/v3/internal/fs"
@@ -22,15 +23,15 @@ func InitTaskManager() {
 	op.RegisterSettingChangingCallback(func() {
 		fs.UploadTaskManager.SetWorkersNumActive(taskFilterNegative(setting.GetInt(conf.TaskUploadThreadsNum, conf.Conf.Tasks.Upload.Workers)))
 	})
-	fs.CopyTaskManager = tache.NewManager[*fs.CopyTask](tache.WithWorks(setting.GetInt(conf.TaskCopyThreadsNum, conf.Conf.Tasks.Copy.Workers)), tache.WithPersistFunction(db.GetTaskDataFunc("copy", conf.Conf.Tasks.Copy.TaskPersistant), db.UpdateTaskDataFunc("copy", conf.Conf.Tasks.Copy.TaskPersistant)), tache.WithMaxRetry(conf.Conf.Tasks.Copy.MaxRetry))
+	fs.CopyTaskManager = tache.NewManager[*fs.CopyTask](tache.WithWorks(setting.GetInt(conf.TaskCopyThreadsNum, conf.Conf.Tasks.Copy.Workers)), tache.WithPersistFunction(db.GetTaskDataFunc(taskPersistKey("copy"), conf.Conf.Tasks.Copy.TaskPersistant), db.UpdateTaskDataFunc(taskPersistKey("copy"), conf.Conf.Tasks.Copy.TaskPersistant)), tache.WithMaxRetry(conf.Conf.Tasks.Copy.MaxRetry))
 	op.RegisterSettingChangingCallback(func() {
 		fs.CopyTaskManager.SetWorkersNumActive(taskFilterNegative(setting.GetInt(conf.TaskCopyThreadsNum, conf.Conf.Tasks.Copy.Workers)))
 	})
-	tool.DownloadTaskManager = tache.NewManager[*tool.DownloadTask](tache.WithWorks(setting.GetInt(conf.TaskOfflineDownloadThreadsNum, conf.Conf.Tasks.Download.Workers)), tache.WithPersistFunction(db.GetTaskDataFunc("download", conf.Conf.Tasks.Download.TaskPersistant), db.UpdateTaskDataFunc("download", conf.Conf.Tasks.Download.TaskPersistant)), tache.WithMaxRetry(conf.Conf.Tasks.Download.MaxRetry))
+	tool.DownloadTaskManager = tache.NewManager[*tool.DownloadTask](tache.WithWorks(setting.GetInt(conf.TaskOfflineDownloadThreadsNum, conf.Conf.Tasks.Download.Workers)), tache.WithPersistFunction(db.GetTaskDataFunc(taskPersistKey("download"), conf.Conf.Tasks.Download.TaskPersistant), db.UpdateTaskDataFunc(taskPersistKey("download"), conf.Conf.Tasks.Download.TaskPersistant)), tache.WithMaxRetry(conf.Conf.Tasks.Download.MaxRetry))
 	op.RegisterSettingChangingCallback(func() {
 		tool.DownloadTaskManager.SetWorkersNumActive(taskFilterNegative(setting.GetInt(conf.TaskOfflineDownloadThreadsNum, conf.Conf.Tasks.Download.Workers)))
 	})
-	tool.TransferTaskManager = tache.NewManager[*tool.TransferTask](tache.WithWorks(setting.GetInt(conf.TaskOfflineDownloadTransferThreadsNum, conf.Conf.Tasks.Transfer.Workers)), tache.WithPersistFunction(db.GetTaskDataFunc("transfer", conf.Conf.Tasks.Transfer.TaskPersistant), db.UpdateTaskDataFunc("transfer", conf.Conf.Tasks.Transfer.TaskPersistant)), tache.WithMaxRetry(conf.Conf.Tasks.Transfer.MaxRetry))
+	tool.TransferTaskManager = tache.NewManager[*tool.TransferTask](tache.WithWorks(setting.GetInt(conf.TaskOfflineDownloadTransferThreadsNum, conf.Conf.Tasks.Transfer.Workers)), tache.WithPersistFunction(db.GetTaskDataFunc(taskPersistKey("transfer"), conf.Conf.Tasks.Transfer.TaskPersistant), db.UpdateTaskDataFunc(taskPersistKey("transfer"), conf.Conf.Tasks.Transfer.TaskPersistant)), tache.WithMaxRetry(conf.Conf.Tasks.Transfer.MaxRetry))
 	op.RegisterSettingChangingCallback(func() {
 		tool.TransferTaskManager.SetWorkersNumActive(taskFilterNegative(setting.GetInt(conf.TaskOfflineDownloadTransferThreadsNum, conf.Conf.Tasks.Transfer.Workers)))
 	})
@@ -44,12 +45,14 @@ func InitTaskManager() {
 	fs.S3TransitionTaskManager = tache.NewManager[*fs.S3TransitionTask](
 		tache.WithWorks(workers),
 		tache.WithPersistFunction(
-			db.GetTaskDataFunc("s3_transition", conf.Conf.Tasks.S3Transition.TaskPersistant),
-			db.UpdateTaskDataFunc("s3_transition", conf.Conf.Tasks.S3Transition.TaskPersistant),
+			db.GetTaskDataFunc(taskPersistKey("s3_transition"), conf.Conf.Tasks.S3Transition.TaskPersistant),
+			db.UpdateTaskDataFunc(taskPersistKey("s3_transition"), conf.Conf.Tasks.S3Transition.TaskPersistant),
 		),
 		tache.WithMaxRetry(conf.Conf.Tasks.S3Transition.MaxRetry),
 	)
-	fs.ArchiveDownloadTaskManager = tache.NewManager[*fs.ArchiveDownloadTask](tache.WithWorks(setting.GetInt(conf.TaskDecompressDownloadThreadsNum, conf.Conf.Tasks.Decompress.Workers)), tache.WithPersistFunction(db.GetTaskDataFunc("decompress", conf.Conf.Tasks.Decompress.TaskPersistant), db.UpdateTaskDataFunc("decompress", conf.Conf.Tasks.Decompress.TaskPersistant)), tache.WithMaxRetry(conf.Conf.Tasks.Decompress.MaxRetry))
+	fs.HashTaskManager = tache.NewManager[*fs.HashTask](tache.WithWorks(conf.Conf.Tasks.Hash.Workers), tache.WithPersistFunction(db.GetTaskDataFunc(taskPersistKey("hash"), conf.Conf.Tasks.Hash.TaskPersistant), db.UpdateTaskDataFunc(taskPersistKey("hash"), conf.Conf.Tasks.Hash.TaskPersistant)), tache.WithMaxRetry(conf.Conf.Tasks.Hash.MaxRetry))
+	fs.DedupeTaskManager = tache.NewManager[*fs.DedupeTask](tache.WithWorks(conf.Conf.Tasks.Dedupe.Workers), tache.WithPersistFunction(db.GetTaskDataFunc(taskPersistKey("dedupe"), conf.Conf.Tasks.Dedupe.TaskPersistant), db.UpdateTaskDataFunc(taskPersistKey("dedupe"), conf.Conf.Tasks.Dedupe.TaskPersistant)), tache.WithMaxRetry(conf.Conf.Tasks.Dedupe.MaxRetry))
+	fs.ArchiveDownloadTaskManager = tache.NewManager[*fs.ArchiveDownloadTask](tache.WithWorks(setting.GetInt(conf.TaskDecompressDownloadThreadsNum, conf.Conf.Tasks.Decompress.Workers)), tache.WithPersistFunction(db.GetTaskDataFunc(taskPersistKey("decompress"), conf.Conf.Tasks.Decompress.TaskPersistant), db.UpdateTaskDataFunc(taskPersistKey("decompress"), conf.Conf.Tasks.Decompress.TaskPersistant)), tache.WithMaxRetry(conf.Conf.Tasks.Decompress.MaxRetry))
 	op.RegisterSettingChangingCallback(func() {
 		fs.ArchiveDownloadTaskManager.SetWorkersNumActive(taskFilterNegative(setting.GetInt(conf.TaskDecompressDownloadThreadsNum, conf.Conf.Tasks.Decompress.Workers)))
 	})
@@ -57,4 +60,14 @@ func InitTaskManager() {
 	op.RegisterSettingChangingCallback(func() {
 		fs.ArchiveContentUploadTaskManager.SetWorkersNumActive(taskFilterNegative(setting.GetInt(conf.TaskDecompressUploadThreadsNum, conf.Conf.Tasks.DecompressUpload.Workers)))
 	})
+	registerTaskAdopter("copy", conf.Conf.Tasks.Copy.TaskPersistant, fs.CopyTaskManager)
+	registerTaskAdopter("download", conf.Conf.Tasks.Download.TaskPersistant, tool.DownloadTaskManager)
+	registerTaskAdopter("transfer", conf.Conf.Tasks.Transfer.TaskPersistant, tool.TransferTaskManager)
+	registerTaskAdopter("s3_transition", conf.Conf.Tasks.S3Transition.TaskPersistant, fs.S3TransitionTaskManager)
+	registerTaskAdopter("hash", conf.Conf.Tasks.Hash.TaskPersistant, fs.HashTaskManager)
+	registerTaskAdopter("dedupe", conf.Conf.Tasks.Dedupe.TaskPersistant, fs.DedupeTaskManager)
+	registerTaskAdopter("decompress", conf.Conf.Tasks.Decompress.TaskPersistant, fs.ArchiveDownloadTaskManager)
+	if cluster.Enabled() {
+		go adoptTasksLoop()
+	}
 }
@@ -0,0 +1,109 @@
+package bootstrap
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/cluster"
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/xhofe/tache"
+)
+
+// taskAdopters add the persisted tasks of a dead instance to the managers of this one by task type
+var taskAdopters = make(map[string]func(data []byte) error)
+
+// taskPersistKey is the key of the row the tasks of typ are persisted in. In a cluster each
+// instance persists its own tasks in typ@node, the row is created if it doesn't exist.
+func taskPersistKey(typ string) string {
+	if !cluster.Enabled() {
+		return typ
+	}
+	key := typ + "@" + cluster.NodeID()
+	if item, _ := db.GetTaskDataByType(key); item == nil {
+		if err := db.CreateTaskData(&model.TaskItem{Key: key, PersistData: "[]"}); err != nil {
+			utils.Log.Errorf("failed create task data %s: %+v", key, err)
+		}
+	}
+	return key
+}
+
+func registerTaskAdopter[T tache.Task](typ string, persistent bool, m *tache.Manager[T]) {
+	if !persistent {
+		return
+	}
+	taskAdopters[typ] = func(data []byte) error {
+		var tasks []T
+		if err := json.Unmarshal(data, &tasks); err != nil {
+			return err
+		}
+		for _, t := range tasks {
+			// same as the manager does when recovering its own tasks
+			if r, ok := tache.Task(t).(tache.Recoverable); ok && !r.Recoverable() {
+				t.SetState(tache.StateFailed)
+				t.SetErr(fmt.Errorf("the task is interrupted and cannot be recovered"))
+			}
+			m.Add(t)
+		}
+		return nil
+	}
+}
+
+// adoptTasksLoop looks for the tasks persisted by instances whose node lease has expired,
+// the instance that takes over the lease of a dead one runs its tasks
+func adoptTasksLoop() {
+	for {
+		time.Sleep(cluster.LeaseTTL())
+		items, err := db.GetTaskItems()
+		if err != nil {
+			utils.Log.Errorf("failed get task data: %+v", err)
+			continue
+		}
+		byNode := make(map[string][]model.TaskItem)
+		for _, item := range items {
+			// the tasks persisted before the cluster was enabled have no node, a single instance adopts them as well
+			_, node, _ := strings.Cut(item.Key, "@")
+			if node == cluster.NodeID() || (node == "" && (item.PersistData == "" || item.PersistData == "[]")) {
+				continue
+			}
+			byNode[node] = append(byNode[node], item)
+		}
+		for node, items := range byNode {
+			lease := cluster.NodeLease(node)
+			if !cluster.TryAcquire(lease) {
+				continue
+			}
+			for _, item := range items {
+				adoptTasks(item)
+			}
+			cluster.Release(lease)
+		}
+	}
+}
+
+func adoptTasks(item model.TaskItem) {
+	typ, node, _ := strings.Cut(item.Key, "@")
+	adopt, ok := taskAdopters[typ]
+	if !ok {
+		return
+	}
+	if item.PersistData != "" && item.PersistData != "[]" {
+		if err := adopt([]byte(item.PersistData)); err != nil {
+			utils.Log.Errorf("failed adopt %s tasks of %s: %+v", typ, node, err)
+			return
+		}
+		utils.Log.Infof("adopted %s tasks of dead instance %s", typ, node)
+	}
+	var err error
+	if node == "" {
+		err = db.UpdateTaskData(&model.TaskItem{Key: item.Key, PersistData: "[]"})
+	} else {
+		err = db.DeleteTaskData(item.Key)
+	}
+	if err != nil {
+		utils.Log.Errorf("failed clear task data %s: %+v", item.Key, err)
+	}
+}
@@ -0,0 +1,178 @@
+package cluster
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/pkg/utils/random"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// Event is an invalidation published by the instance Node, see op.ApplyInvalidation
+type Event struct {
+	Node string `json:"node"`
+	Kind string `json:"kind"`
+	Key  string `json:"key"`
+}
+
+// Backend delivers the events to the other instances and holds the leases of the elections
+type Backend interface {
+	Publish(e Event) error
+	// Subscribe calls handle with the events published after it's called until the backend is closed
+	Subscribe(handle func(Event)) error
+	// Acquire takes or renews the lease name for owner, it fails if another owner holds it
+	Acquire(name, owner string, ttl time.Duration) (bool, error)
+	Release(name, owner string) error
+	Close() error
+}
+
+var (
+	backend Backend
+	nodeID  string
+	ttl     time.Duration
+	events  chan Event
+	node    *Election
+)
+
+// Enabled tells whether the instance runs in a cluster
+func Enabled() bool {
+	return backend != nil
+}
+
+// NodeID is the unique id of this instance in the cluster
+func NodeID() string {
+	return nodeID
+}
+
+// LeaseTTL is the time a lease lasts without being renewed
+func LeaseTTL() time.Duration {
+	return ttl
+}
+
+// NodeLease is the lease held by the running instance id, it expires when the instance is down
+func NodeLease(id string) string {
+	return "node:" + id
+}
+
+func newBackend(c conf.Cluster) (Backend, error) {
+	interval := time.Duration(c.PollInterval) * time.Second
+	if interval <= 0 {
+		interval = time.Second
+	}
+	switch c.Backend {
+	case "", "db":
+		return newDBBackend(interval)
+	case "redis":
+		return newRedisBackend(c.RedisURL)
+	case "local":
+		return newLocalBackend(), nil
+	default:
+		return nil, errors.Errorf("unknown cluster backend: %s", c.Backend)
+	}
+}
+
+// Init connects to the backend, publishes the invalidations of this instance and applies
+// the ones of the others. It returns after this instance holds its node lease.
+func Init() error {
+	c := conf.Conf.Cluster
+	if !c.Enable {
+		return nil
+	}
+	b, err := newBackend(c)
+	if err != nil {
+		return err
+	}
+	nodeID = c.NodeID
+	if nodeID == "" {
+		hostname, _ := os.Hostname()
+		nodeID = hostname + "-" + random.String(8)
+	}
+	ttl = time.Duration(c.LeaseTTL) * time.Second
+	if ttl <= 0 {
+		ttl = 30 * time.Second
+	}
+	if err := b.Subscribe(apply); err != nil {
+		_ = b.Close()
+		return err
+	}
+	backend = b
+	// a restarted instance with a fixed node id waits for the one adopting its tasks
+	for {
+		ok, err := backend.Acquire(NodeLease(nodeID), nodeID, ttl)
+		if err != nil {
+			return errors.WithMessage(err, "failed acquire node lease")
+		}
+		if ok {
+			break
+		}
+		log.Warnf("the node lease of %s is held by another instance, waiting", nodeID)
+		time.Sleep(time.Second)
+	}
+	node = Elect(NodeLease(nodeID))
+	events = make(chan Event, 1024)
+	go publish()
+	op.RegisterInvalidationHook(func(kind, key string) {
+		select {
+		case events <- Event{Node: nodeID, Kind: kind, Key: key}:
+		default:
+			log.Warnf("cluster event queue is full, dropped %s invalidation of %s", kind, key)
+		}
+	})
+	log.Infof("joined cluster as %s with %s backend", nodeID, c.Backend)
+	return nil
+}
+
+func publish() {
+	for e := range events {
+		if err := backend.Publish(e); err != nil {
+			log.Errorf("failed publish %s invalidation of %s: %+v", e.Kind, e.Key, err)
+		}
+	}
+}
+
+func apply(e Event) {
+	if e.Node == nodeID {
+		return
+	}
+	log.Debugf("apply %s invalidation of %s from %s", e.Kind, e.Key, e.Node)
+	if err := op.ApplyInvalidation(context.Background(), e.Kind, e.Key); err != nil {
+		log.Errorf("failed apply %s invalidation of %s from %s: %+v", e.Kind, e.Key, e.Node, err)
+	}
+}
+
+// TryAcquire takes the lease name for this instance once, it's always true out of a cluster
+func TryAcquire(name string) bool {
+	if !Enabled() {
+		return true
+	}
+	ok, err := backend.Acquire(name, nodeID, ttl)
+	if err != nil {
+		log.Errorf("failed acquire lease %s: %+v", name, err)
+	}
+	return ok
+}
+
+func Release(name string) {
+	if !Enabled() {
+		return
+	}
+	if err := backend.Release(name, nodeID); err != nil {
+		log.Errorf("failed release lease %s: %+v", name, err)
+	}
+}
+
+// Close leaves the cluster, the node lease is released so the tasks of this instance are adopted at once
+func Close() {
+	if !Enabled() {
+		return
+	}
+	node.Resign()
+	if err := backend.Close(); err != nil {
+		log.Errorf("failed close cluster backend: %+v", err)
+	}
+	backend = nil
+}
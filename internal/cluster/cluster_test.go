@@ -0,0 +1,108 @@
+package cluster
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/db/dbtest"
+	"github.com/alist-org/alist/v3/internal/model"
+)
+
+func init() {
+	dbtest.Init()
+}
+
+func TestDBLease(t *testing.T) {
+	b, _ := newDBBackend(10 * time.Millisecond)
+	defer b.Close()
+	acquire := func(owner string, ttl time.Duration) bool {
+		ok, err := b.Acquire("test", owner, ttl)
+		if err != nil {
+			t.Fatalf("failed acquire: %+v", err)
+		}
+		return ok
+	}
+	if !acquire("a", time.Minute) {
+		t.Fatal("free lease must be acquired")
+	}
+	if acquire("b", time.Minute) {
+		t.Fatal("lease held by another owner must not be acquired")
+	}
+	if !acquire("a", time.Millisecond) {
+		t.Fatal("owner must renew its lease")
+	}
+	time.Sleep(5 * time.Millisecond)
+	if !acquire("b", time.Minute) {
+		t.Fatal("expired lease must be taken over")
+	}
+	if err := b.Release("test", "a"); err != nil {
+		t.Fatal(err)
+	}
+	if acquire("a", time.Minute) {
+		t.Fatal("release of another owner must not free the lease")
+	}
+	if err := b.Release("test", "b"); err != nil {
+		t.Fatal(err)
+	}
+	if !acquire("a", time.Minute) {
+		t.Fatal("released lease must be acquired")
+	}
+}
+
+func TestDBEvents(t *testing.T) {
+	b, _ := newDBBackend(10 * time.Millisecond)
+	defer b.Close()
+	if err := b.Publish(Event{Node: "a", Kind: "list", Key: "/old"}); err != nil {
+		t.Fatal(err)
+	}
+	received := make(chan Event, 10)
+	if err := b.Subscribe(func(e Event) { received <- e }); err != nil {
+		t.Fatal(err)
+	}
+	want := Event{Node: "a", Kind: "list", Key: "/local/dir"}
+	if err := b.Publish(want); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case e := <-received:
+		if e != want {
+			t.Fatalf("unexpected event: %+v", e)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("event is not received")
+	}
+}
+
+func TestDBEventsCommittedLate(t *testing.T) {
+	b, _ := newDBBackend(10 * time.Millisecond)
+	defer b.Close()
+	received := make(chan Event, 10)
+	if err := b.Subscribe(func(e Event) { received <- e }); err != nil {
+		t.Fatal(err)
+	}
+	receive := func(key string) {
+		select {
+		case e := <-received:
+			if e.Key != key {
+				t.Fatalf("expect %s, got %+v", key, e)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("event %s is not received", key)
+		}
+	}
+	// an event whose transaction commits after one with a greater id
+	if err := db.CreateClusterEvent(&model.ClusterEvent{ID: 100000, Node: "a", Kind: "list", Key: "/first"}); err != nil {
+		t.Fatal(err)
+	}
+	receive("/first")
+	if err := db.CreateClusterEvent(&model.ClusterEvent{ID: 99999, Node: "a", Kind: "list", Key: "/late"}); err != nil {
+		t.Fatal(err)
+	}
+	receive("/late")
+	select {
+	case e := <-received:
+		t.Fatalf("event handled twice: %+v", e)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
@@ -0,0 +1,101 @@
+package cluster
+
+import (
+	"sync"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/model"
+	log "github.com/sirupsen/logrus"
+)
+
+// eventRetention is how long the events are kept in the database for the instances polling them
+const eventRetention = 10 * time.Minute
+
+// eventWindow is how far back each poll looks. The ids are taken when the events are inserted
+// but they become visible when their transactions commit, so a poll after the greatest id seen
+// would skip the events committed late; the ones already handled in the window are skipped instead.
+// It must also cover the clock drift between the instances.
+const eventWindow = time.Minute
+
+// dbBackend shares the events and the leases through the database all instances use
+type dbBackend struct {
+	interval time.Duration
+	stop     chan struct{}
+	once     sync.Once
+}
+
+func newDBBackend(interval time.Duration) (*dbBackend, error) {
+	return &dbBackend{interval: interval, stop: make(chan struct{})}, nil
+}
+
+func (b *dbBackend) Publish(e Event) error {
+	return db.CreateClusterEvent(&model.ClusterEvent{Node: e.Node, Kind: e.Kind, Key: e.Key})
+}
+
+func (b *dbBackend) Subscribe(handle func(Event)) error {
+	// the events published before subscribing are not handled
+	seen := make(map[uint]time.Time)
+	if err := b.poll(seen, nil); err != nil {
+		return err
+	}
+	go func() {
+		ticker := time.NewTicker(b.interval)
+		defer ticker.Stop()
+		pruned := time.Now()
+		for {
+			select {
+			case <-b.stop:
+				return
+			case <-ticker.C:
+			}
+			if err := b.poll(seen, handle); err != nil {
+				log.Errorf("failed poll cluster events: %+v", err)
+			}
+			if time.Since(pruned) > eventRetention {
+				pruned = time.Now()
+				if err := db.DeleteClusterEventsBefore(pruned.Add(-eventRetention)); err != nil {
+					log.Errorf("failed prune cluster events: %+v", err)
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+// poll handles the events of the window not seen yet and forgets the ones that left it
+func (b *dbBackend) poll(seen map[uint]time.Time, handle func(Event)) error {
+	since := time.Now().Add(-eventWindow)
+	events, err := db.GetClusterEventsSince(since)
+	if err != nil {
+		return err
+	}
+	for _, e := range events {
+		if _, ok := seen[e.ID]; ok {
+			continue
+		}
+		seen[e.ID] = e.CreatedAt
+		if handle != nil {
+			handle(Event{Node: e.Node, Kind: e.Kind, Key: e.Key})
+		}
+	}
+	for id, t := range seen {
+		if t.Before(since) {
+			delete(seen, id)
+		}
+	}
+	return nil
+}
+
+func (b *dbBackend) Acquire(name, owner string, ttl time.Duration) (bool, error) {
+	return db.AcquireClusterLock(name, owner, ttl)
+}
+
+func (b *dbBackend) Release(name, owner string) error {
+	return db.ReleaseClusterLock(name, owner)
+}
+
+func (b *dbBackend) Close() error {
+	b.once.Do(func() { close(b.stop) })
+	return nil
+}
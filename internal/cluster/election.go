@@ -0,0 +1,90 @@
+package cluster
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Election keeps campaigning for a lease, only one instance of the cluster is its leader at a time
+type Election struct {
+	name    string
+	leader  atomic.Bool
+	mu      sync.Mutex
+	elected []func()
+	stop    chan struct{}
+	once    sync.Once
+}
+
+// Elect starts campaigning for the lease name, out of a cluster the instance is always the leader
+func Elect(name string) *Election {
+	e := &Election{name: name, stop: make(chan struct{})}
+	if !Enabled() {
+		e.leader.Store(true)
+		return e
+	}
+	e.campaign()
+	go e.run()
+	return e
+}
+
+func (e *Election) IsLeader() bool {
+	return e.leader.Load()
+}
+
+// OnElected registers f to be called when the instance becomes the leader,
+// it's called at once if the instance is the leader already
+func (e *Election) OnElected(f func()) {
+	e.mu.Lock()
+	e.elected = append(e.elected, f)
+	e.mu.Unlock()
+	if e.IsLeader() {
+		go f()
+	}
+}
+
+func (e *Election) run() {
+	ticker := time.NewTicker(ttl / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-e.stop:
+			return
+		case <-ticker.C:
+			e.campaign()
+		}
+	}
+}
+
+func (e *Election) campaign() {
+	ok, err := backend.Acquire(e.name, nodeID, ttl)
+	if err != nil {
+		// the lease may expire before it can be renewed, so the leadership is given up
+		log.Errorf("failed campaign for %s: %+v", e.name, err)
+		ok = false
+	}
+	if ok && !e.leader.Swap(true) {
+		log.Infof("elected as the leader of %s", e.name)
+		e.mu.Lock()
+		elected := append([]func(){}, e.elected...)
+		e.mu.Unlock()
+		for _, f := range elected {
+			go f()
+		}
+	}
+	if !ok && e.leader.Swap(false) {
+		log.Warnf("lost the leadership of %s", e.name)
+	}
+}
+
+// Resign stops campaigning and releases the lease
+func (e *Election) Resign() {
+	e.once.Do(func() {
+		close(e.stop)
+		if e.leader.Swap(false) && Enabled() {
+			Release(e.name)
+		}
+	})
+}
@@ -0,0 +1,70 @@
+package cluster
+
+import (
+	"sync"
+	"time"
+)
+
+// localBackend is an in-process stand-in for a shared backend, the instances
+// of a cluster using it must share the process, e.g. in tests
+type localBackend struct {
+	hub *localHub
+}
+
+type localHub struct {
+	mu       sync.Mutex
+	handlers []func(Event)
+	leases   map[string]localLease
+}
+
+type localLease struct {
+	owner     string
+	expiresAt time.Time
+}
+
+var defaultHub = &localHub{leases: make(map[string]localLease)}
+
+func newLocalBackend() *localBackend {
+	return &localBackend{hub: defaultHub}
+}
+
+func (b *localBackend) Publish(e Event) error {
+	b.hub.mu.Lock()
+	handlers := append([]func(Event){}, b.hub.handlers...)
+	b.hub.mu.Unlock()
+	for _, handle := range handlers {
+		handle(e)
+	}
+	return nil
+}
+
+func (b *localBackend) Subscribe(handle func(Event)) error {
+	b.hub.mu.Lock()
+	defer b.hub.mu.Unlock()
+	b.hub.handlers = append(b.hub.handlers, handle)
+	return nil
+}
+
+func (b *localBackend) Acquire(name, owner string, ttl time.Duration) (bool, error) {
+	b.hub.mu.Lock()
+	defer b.hub.mu.Unlock()
+	now := time.Now()
+	if l, ok := b.hub.leases[name]; ok && l.owner != owner && l.expiresAt.After(now) {
+		return false, nil
+	}
+	b.hub.leases[name] = localLease{owner: owner, expiresAt: now.Add(ttl)}
+	return true, nil
+}
+
+func (b *localBackend) Release(name, owner string) error {
+	b.hub.mu.Lock()
+	defer b.hub.mu.Unlock()
+	if l, ok := b.hub.leases[name]; ok && l.owner == owner {
+		delete(b.hub.leases, name)
+	}
+	return nil
+}
+
+func (b *localBackend) Close() error {
+	return nil
+}
@@ -0,0 +1,113 @@
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/redis/go-redis/v9"
+	log "github.com/sirupsen/logrus"
+)
+
+// redisChannel is the pub/sub channel the events are published to
+const redisChannel = "alist:cluster:events"
+
+var (
+	// renew the lease if it's held by the owner, otherwise take it if it's free
+	acquireScript = redis.NewScript(`if redis.call("GET", KEYS[1]) == ARGV[1] then return redis.call("PEXPIRE", KEYS[1], ARGV[2]) end
+if redis.call("SET", KEYS[1], ARGV[1], "NX", "PX", ARGV[2]) then return 1 end
+return 0`)
+	releaseScript = redis.NewScript(`if redis.call("GET", KEYS[1]) == ARGV[1] then return redis.call("DEL", KEYS[1]) end
+return 0`)
+)
+
+// redisTimeout bounds each command sent to the server
+const redisTimeout = 10 * time.Second
+
+// redisBackend shares the events with pub/sub and the leases as expiring keys of a
+// Redis compatible server, its url is redis://[user:password@]host:port[/db] or rediss:// for tls
+type redisBackend struct {
+	client *redis.Client
+	sub    *redis.PubSub
+}
+
+func newRedisBackend(rawURL string) (*redisBackend, error) {
+	if rawURL == "" {
+		return nil, errors.New("redis_url is required by the redis cluster backend")
+	}
+	opts, err := redis.ParseURL(rawURL)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	client := redis.NewClient(opts)
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		_ = client.Close()
+		return nil, errors.WithMessage(err, "failed connect to redis")
+	}
+	return &redisBackend{client: client}, nil
+}
+
+func (b *redisBackend) Publish(e Event) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	return errors.WithStack(b.client.Publish(ctx, redisChannel, data).Err())
+}
+
+// Subscribe receives the events until the backend is closed, the subscription is restored
+// after a broken connection but the events published meanwhile are lost
+func (b *redisBackend) Subscribe(handle func(Event)) error {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	sub := b.client.Subscribe(ctx, redisChannel)
+	// wait for the confirmation so the events published after returning are received
+	if _, err := sub.Receive(ctx); err != nil {
+		_ = sub.Close()
+		return errors.WithMessage(err, "failed subscribe to redis")
+	}
+	b.sub = sub
+	go func() {
+		for msg := range sub.Channel() {
+			var e Event
+			if err := json.Unmarshal([]byte(msg.Payload), &e); err != nil {
+				log.Warnf("invalid cluster event: %s", msg.Payload)
+				continue
+			}
+			handle(e)
+		}
+	}()
+	return nil
+}
+
+func (b *redisBackend) Acquire(name, owner string, ttl time.Duration) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	n, err := acquireScript.Run(ctx, b.client, []string{redisKey(name)}, owner, ttl.Milliseconds()).Int64()
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+	return n == 1, nil
+}
+
+func (b *redisBackend) Release(name, owner string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	return errors.WithStack(releaseScript.Run(ctx, b.client, []string{redisKey(name)}, owner).Err())
+}
+
+func (b *redisBackend) Close() error {
+	if b.sub != nil {
+		_ = b.sub.Close()
+	}
+	return errors.WithStack(b.client.Close())
+}
+
+func redisKey(name string) string {
+	return "alist:cluster:lease:" + name
+}
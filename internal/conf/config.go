@@ -61,6 +61,8 @@ type TasksConfig struct {
 	Decompress         TaskConfig `json:"decompress" envPrefix:"DECOMPRESS_"`
 	DecompressUpload   TaskConfig `json:"decompress_upload" envPrefix:"DECOMPRESS_UPLOAD_"`
 	S3Transition       TaskConfig `json:"s3_transition" envPrefix:"S3_TRANSITION_"`
+	Hash               TaskConfig `json:"hash" envPrefix:"HASH_"`
+	Dedupe             TaskConfig `json:"dedupe" envPrefix:"DEDUPE_"`
 	AllowRetryCanceled bool       `json:"allow_retry_canceled" env:"ALLOW_RETRY_CANCELED"`
 }
 
@@ -99,6 +101,19 @@ type MCP struct {
 	Port   int  `json:"port" env:"PORT"`
 }
 
+// Cluster runs several instances on the same database. The crypt storages
+// with per user keys can't be used in a cluster and fail to load.
+type Cluster struct {
+	Enable bool `json:"enable" env:"ENABLE"`
+	// Backend is db, redis or local, local only works in the same process and is meant for testing
+	Backend  string `json:"backend" env:"BACKEND"`
+	RedisURL string `json:"redis_url" env:"REDIS_URL"`
+	// NodeID must be unique in the cluster, a random one is generated if it's empty
+	NodeID       string `json:"node_id" env:"NODE_ID"`
+	PollInterval int    `json:"poll_interval" env:"POLL_INTERVAL"`
+	LeaseTTL     int    `json:"lease_ttl" env:"LEASE_TTL"`
+}
+
 type Config struct {
 	Force                 bool        `json:"force" env:"FORCE"`
 	SiteURL               string      `json:"site_url" env:"SITE_URL"`
@@ -122,6 +137,8 @@ type Config struct {
 	FTP                   FTP         `json:"ftp" envPrefix:"FTP_"`
 	SFTP                  SFTP        `json:"sftp" envPrefix:"SFTP_"`
 	MCP                   MCP         `json:"mcp" envPrefix:"MCP_"`
+	Cluster               Cluster     `json:"cluster" envPrefix:"CLUSTER_"`
+	ProvisionFile         string      `json:"provision_file" env:"PROVISION_FILE"`
 	LastLaunchedVersion   string      `json:"last_launched_version"`
 }
 
@@ -196,6 +213,14 @@ func DefaultConfig() *Config {
 				MaxRetry: 2,
 				// TaskPersistant: true,
 			},
+			Hash: TaskConfig{
+				Workers:  1,
+				MaxRetry: 1,
+			},
+			Dedupe: TaskConfig{
+				Workers:  1,
+				MaxRetry: 1,
+			},
 			AllowRetryCanceled: false,
 		},
 		Cors: Cors{
@@ -228,6 +253,12 @@ func DefaultConfig() *Config {
 			Enable: false,
 			Port:   5248,
 		},
+		Cluster: Cluster{
+			Enable:       false,
+			Backend:      "db",
+			PollInterval: 1,
+			LeaseTTL:     30,
+		},
 		LastLaunchedVersion: "",
 	}
 }
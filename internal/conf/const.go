@@ -43,6 +43,7 @@ const (
 	CustomizeHead           = "customize_head"
 	CustomizeBody           = "customize_body"
 	LinkExpiration          = "link_expiration"
+	DownloadLinkMaxTTL      = "download_link_max_ttl"
 	SignAll                 = "sign_all"
 	PrivacyRegs             = "privacy_regs"
 	OcrApi                  = "ocr_api"
@@ -54,6 +55,9 @@ const (
 	DeviceEvictPolicy       = "device_evict_policy"
 	DeviceSessionTTL        = "device_session_ttl"
 	MetaNotFoundCacheExpire = "meta_not_found_cache_expire"
+	ShareAccessLogDays      = "share_access_log_days"
+	ShareAccessLogMax       = "share_access_log_max"
+	PipelineWebhookHosts    = "pipeline_webhook_hosts"
 
 	// index
 	SearchIndex     = "search_index"
@@ -100,6 +104,13 @@ const (
 	SSODefaultDir        = "sso_default_dir"
 	SSODefaultPermission = "sso_default_permission"
 	SSOCompatibilityMode = "sso_compatibility_mode"
+	SSOOIDCGroupsKey     = "sso_oidc_groups_key"
+	SSOGroupRoleMapping  = "sso_group_role_mapping"
+
+	// oidc provider
+	OIDCProviderEnabled        = "oidc_provider_enabled"
+	OIDCProviderSigningKey     = "oidc_provider_signing_key"
+	OIDCProviderTokenExpiresIn = "oidc_provider_token_expires_in"
 
 	// ldap
 	LdapLoginEnabled      = "ldap_login_enabled"
@@ -111,6 +122,10 @@ const (
 	LdapDefaultPermission = "ldap_default_permission"
 	LdapDefaultDir        = "ldap_default_dir"
 	LdapLoginTips         = "ldap_login_tips"
+	LdapGroupAttribute    = "ldap_group_attribute"
+	LdapGroupSearchBase   = "ldap_group_search_base"
+	LdapGroupSearchFilter = "ldap_group_search_filter"
+	LdapGroupRoleMapping  = "ldap_group_role_mapping"
 
 	// s3
 	S3Buckets         = "s3_buckets"
@@ -170,5 +185,7 @@ const (
 
 // ContextKey is the type of context keys.
 const (
-	NoTaskKey = "no_task"
+	NoTaskKey          = "no_task"
+	PipelineKey        = "pipeline"
+	StorageUploadLimit = "storage_upload_limit"
 )
@@ -0,0 +1,137 @@
+package cryptkey
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/nacl/box"
+	"golang.org/x/crypto/nacl/secretbox"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	scryptN = 1 << 15
+	scryptR = 8
+	scryptP = 1
+
+	saltSize  = 16
+	nonceSize = 24
+)
+
+var encoding = base64.StdEncoding
+
+// deriveKEK derives the key wrapping the private key of a user from the passphrase
+func deriveKEK(passphrase string, salt []byte) (*[32]byte, error) {
+	k, err := scrypt.Key([]byte(passphrase), salt, scryptN, scryptR, scryptP, 32)
+	if err != nil {
+		return nil, err
+	}
+	var kek [32]byte
+	copy(kek[:], k)
+	return &kek, nil
+}
+
+// newUserKey generates a key pair for the user, wrapped with the passphrase
+func newUserKey(userID uint, passphrase string) (*model.UserKey, *[32]byte, error) {
+	if passphrase == "" {
+		return nil, nil, fmt.Errorf("passphrase is required")
+	}
+	pub, priv, err := box.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	k := &model.UserKey{UserID: userID, PublicKey: encoding.EncodeToString(pub[:])}
+	if err := wrapPrivateKey(k, priv, passphrase); err != nil {
+		return nil, nil, err
+	}
+	return k, priv, nil
+}
+
+// wrapPrivateKey seals the private key with a key derived from the passphrase
+// and a fresh salt
+func wrapPrivateKey(k *model.UserKey, priv *[32]byte, passphrase string) error {
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return err
+	}
+	kek, err := deriveKEK(passphrase, salt)
+	if err != nil {
+		return err
+	}
+	var nonce [nonceSize]byte
+	if _, err := rand.Read(nonce[:]); err != nil {
+		return err
+	}
+	k.Salt = encoding.EncodeToString(salt)
+	k.WrappedPrivateKey = encoding.EncodeToString(secretbox.Seal(nonce[:], priv[:], &nonce, kek))
+	return nil
+}
+
+// openPrivateKey unwraps the private key of the user with the passphrase
+func openPrivateKey(k *model.UserKey, passphrase string) (*[32]byte, error) {
+	salt, err := encoding.DecodeString(k.Salt)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid salt")
+	}
+	wrapped, err := encoding.DecodeString(k.WrappedPrivateKey)
+	if err != nil || len(wrapped) < nonceSize {
+		return nil, errors.New("invalid wrapped private key")
+	}
+	kek, err := deriveKEK(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	var nonce [nonceSize]byte
+	copy(nonce[:], wrapped[:nonceSize])
+	raw, ok := secretbox.Open(nil, wrapped[nonceSize:], &nonce, kek)
+	if !ok || len(raw) != 32 {
+		return nil, errors.WithStack(errs.WrongPassword)
+	}
+	var priv [32]byte
+	copy(priv[:], raw)
+	return &priv, nil
+}
+
+func decodePublicKey(s string) (*[32]byte, error) {
+	raw, err := encoding.DecodeString(s)
+	if err != nil || len(raw) != 32 {
+		return nil, errors.New("invalid public key")
+	}
+	var pub [32]byte
+	copy(pub[:], raw)
+	return &pub, nil
+}
+
+// sealMountKey seals the key of a storage to the public key of a user
+func sealMountKey(key []byte, publicKey string) (string, error) {
+	pub, err := decodePublicKey(publicKey)
+	if err != nil {
+		return "", err
+	}
+	sealed, err := box.SealAnonymous(nil, key, pub, rand.Reader)
+	if err != nil {
+		return "", err
+	}
+	return encoding.EncodeToString(sealed), nil
+}
+
+// openMountKey opens the key of a storage sealed to the user
+func openMountKey(sealed, publicKey string, priv *[32]byte) ([]byte, error) {
+	pub, err := decodePublicKey(publicKey)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := encoding.DecodeString(sealed)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid sealed key")
+	}
+	key, ok := box.OpenAnonymous(nil, raw, pub, priv)
+	if !ok {
+		return nil, errors.New("failed to open the mount key")
+	}
+	return key, nil
+}
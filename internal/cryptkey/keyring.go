@@ -0,0 +1,362 @@
+package cryptkey
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/cluster"
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// unlockIdle locks the keys of a user not used for a while
+const unlockIdle = 2 * time.Hour
+
+// Per user keys are refused in a cluster: the unlocked private keys stay in
+// the memory of the instance they were unlocked on, sharing them through the
+// cluster backend would write them to the database or redis in clear.
+
+// Mount is a storage whose key is sealed to each user allowed to read it
+type Mount interface {
+	PerUserKeys() bool
+	// InitialKey returns the key the storage was set up with, or a new one
+	InitialKey() ([]byte, error)
+	// ForgetStorageKey removes the key from the storage once a saved backup
+	// holds it, see ForgetBackedUp
+	ForgetStorageKey()
+	// NewKey returns a new random key
+	NewKey() ([]byte, error)
+	// Reencrypt encrypts the files again with newKey, save stores the new
+	// keys along the storage before the storage switches to them
+	Reencrypt(ctx context.Context, key, newKey []byte, save func(storage *model.Storage) error) error
+}
+
+type unlocked struct {
+	publicKey  string
+	privateKey *[32]byte
+	mountKeys  map[uint][]byte
+	lastUsed   time.Time
+}
+
+var (
+	mu   sync.Mutex
+	ring = make(map[uint]*unlocked)
+	// rotating holds the storages whose key is being rotated, their key is
+	// not granted meanwhile
+	rotating = make(map[uint]bool)
+)
+
+// Available fails when per user keys can't be used, i.e. in a cluster
+func Available() error {
+	if cluster.Enabled() {
+		return errors.WithStack(errs.KeysInCluster)
+	}
+	return nil
+}
+
+// Setup creates the key pair of the user, protected by the passphrase
+func Setup(userID uint, passphrase string) error {
+	if err := Available(); err != nil {
+		return err
+	}
+	if _, err := db.GetUserKey(userID); err == nil {
+		return errors.WithStack(errs.UserKeyExists)
+	}
+	k, priv, err := newUserKey(userID, passphrase)
+	if err != nil {
+		return err
+	}
+	if err := db.CreateUserKey(k); err != nil {
+		return err
+	}
+	mu.Lock()
+	ring[userID] = &unlocked{publicKey: k.PublicKey, privateKey: priv, mountKeys: make(map[uint][]byte), lastUsed: time.Now()}
+	mu.Unlock()
+	return nil
+}
+
+// Unlock opens the private key of the user for this session of the server,
+// it's locked again after some idle time
+func Unlock(userID uint, passphrase string) error {
+	if err := Available(); err != nil {
+		return err
+	}
+	k, err := getUserKey(userID)
+	if err != nil {
+		return err
+	}
+	priv, err := openPrivateKey(k, passphrase)
+	if err != nil {
+		return err
+	}
+	mu.Lock()
+	ring[userID] = &unlocked{publicKey: k.PublicKey, privateKey: priv, mountKeys: make(map[uint][]byte), lastUsed: time.Now()}
+	mu.Unlock()
+	return nil
+}
+
+func Lock(userID uint) {
+	mu.Lock()
+	delete(ring, userID)
+	mu.Unlock()
+}
+
+// LockAll locks the keys of all users, e.g. when the storages get new ids
+func LockAll() {
+	mu.Lock()
+	ring = make(map[uint]*unlocked)
+	mu.Unlock()
+}
+
+func IsUnlocked(userID uint) bool {
+	mu.Lock()
+	defer mu.Unlock()
+	return get(userID) != nil
+}
+
+// get returns the unlocked keys of the user, mu must be held
+func get(userID uint) *unlocked {
+	u, ok := ring[userID]
+	if !ok {
+		return nil
+	}
+	now := time.Now()
+	if now.Sub(u.lastUsed) > unlockIdle {
+		delete(ring, userID)
+		return nil
+	}
+	u.lastUsed = now
+	return u
+}
+
+func getUserKey(userID uint) (*model.UserKey, error) {
+	k, err := db.GetUserKey(userID)
+	if errors.Is(errors.Cause(err), gorm.ErrRecordNotFound) {
+		return nil, errors.WithStack(errs.NoUserKey)
+	}
+	return k, err
+}
+
+// MountKey returns the key of the storage if the user holds it and has
+// unlocked the keys
+func MountKey(userID, storageID uint) ([]byte, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	u := get(userID)
+	if u == nil {
+		return nil, errors.WithStack(errs.KeysLocked)
+	}
+	if key, ok := u.mountKeys[storageID]; ok {
+		return key, nil
+	}
+	mk, err := db.GetMountKey(storageID, userID)
+	if err != nil {
+		if errors.Is(errors.Cause(err), gorm.ErrRecordNotFound) {
+			return nil, errors.WithStack(errs.NoMountKey)
+		}
+		return nil, err
+	}
+	key, err := openMountKey(mk.SealedKey, u.publicKey, u.privateKey)
+	if err != nil {
+		return nil, err
+	}
+	u.mountKeys[storageID] = key
+	return key, nil
+}
+
+// InitMount seals the initial key of the storage to the user, the first
+// holder of the key. The storage keeps its password and salt until a saved
+// backup holds the key, a passphrase forgotten before would lose the files.
+func InitMount(userID, storageID uint, m Mount) error {
+	if err := Available(); err != nil {
+		return err
+	}
+	count, err := db.CountMountKeys(storageID)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return errors.WithStack(errs.MountKeyExists)
+	}
+	key, err := m.InitialKey()
+	if err != nil {
+		return err
+	}
+	return grant(userID, storageID, userID, key)
+}
+
+// KeyBackedUp reports whether a saved backup holds the key of the storage
+func KeyBackedUp(storageID uint) bool {
+	ok, err := db.HasBackedUpMountKey(storageID)
+	if err != nil {
+		log.Errorf("failed check the backup of the key of storage %d: %+v", storageID, err)
+	}
+	return ok
+}
+
+// ForgetBackedUp removes the password and salt of the loaded storages with
+// per user keys whose key is held by a saved backup. The storages loaded by
+// another process forget them when they are loaded again.
+func ForgetBackedUp() {
+	for _, s := range op.GetAllStorages() {
+		if m, ok := s.(Mount); ok && m.PerUserKeys() && KeyBackedUp(s.GetStorage().ID) {
+			m.ForgetStorageKey()
+		}
+	}
+}
+
+// Grant seals the key of the storage held by the granter to the grantee
+func Grant(granterID, storageID, granteeID uint) error {
+	mu.Lock()
+	busy := rotating[storageID]
+	mu.Unlock()
+	if busy {
+		return errors.WithStack(errs.KeyRotating)
+	}
+	key, err := MountKey(granterID, storageID)
+	if err != nil {
+		return err
+	}
+	return grant(granterID, storageID, granteeID, key)
+}
+
+func grant(granterID, storageID, granteeID uint, key []byte) error {
+	k, err := getUserKey(granteeID)
+	if err != nil {
+		return err
+	}
+	sealed, err := sealMountKey(key, k.PublicKey)
+	if err != nil {
+		return err
+	}
+	return db.CreateMountKey(&model.MountKey{
+		StorageID: storageID,
+		UserID:    granteeID,
+		SealedKey: sealed,
+		GrantedBy: granterID,
+	})
+}
+
+// Revoke removes the key of the storage from the user and rotates it, the
+// revoker must hold the key. The files are encrypted again with a new key
+// sealed to the other holders, so a copy of the old key kept by the revoked
+// user doesn't open them anymore. The copies of the files made before are
+// still readable with the old key, and so are the backups saved before.
+// The last holder is never revoked, the files of the storage would be lost.
+func Revoke(ctx context.Context, revokerID, storageID, userID uint, m Mount) error {
+	if _, err := db.GetMountKey(storageID, userID); err != nil {
+		if errors.Is(errors.Cause(err), gorm.ErrRecordNotFound) {
+			return errors.WithStack(errs.NoMountKey)
+		}
+		return err
+	}
+	count, err := db.CountMountKeys(storageID)
+	if err != nil {
+		return err
+	}
+	if count <= 1 {
+		return errors.WithStack(errs.LastMountHolder)
+	}
+	key, err := MountKey(revokerID, storageID)
+	if err != nil {
+		return err
+	}
+	newKey, err := m.NewKey()
+	if err != nil {
+		return err
+	}
+	mu.Lock()
+	if rotating[storageID] {
+		mu.Unlock()
+		return errors.WithStack(errs.KeyRotating)
+	}
+	rotating[storageID] = true
+	mu.Unlock()
+	defer func() {
+		mu.Lock()
+		delete(rotating, storageID)
+		mu.Unlock()
+	}()
+	return m.Reencrypt(ctx, key, newKey, func(storage *model.Storage) error {
+		// the holders are read last to keep the grants made before the rotation started
+		holders, err := db.GetMountKeysByStorage(storageID)
+		if err != nil {
+			return err
+		}
+		keys := make([]model.MountKey, 0, len(holders))
+		for _, h := range holders {
+			if h.UserID == userID {
+				continue
+			}
+			k, err := getUserKey(h.UserID)
+			if err != nil {
+				return err
+			}
+			sealed, err := sealMountKey(newKey, k.PublicKey)
+			if err != nil {
+				return err
+			}
+			keys = append(keys, model.MountKey{StorageID: storageID, UserID: h.UserID, SealedKey: sealed,
+				GrantedBy: h.GrantedBy, CreatedAt: h.CreatedAt})
+		}
+		if err := db.ReplaceMountKeys(storage, storageID, keys); err != nil {
+			return err
+		}
+		mu.Lock()
+		for _, u := range ring {
+			delete(u.mountKeys, storageID)
+		}
+		mu.Unlock()
+		return nil
+	})
+}
+
+// Rotate replaces the key pair of the user and re-seals the keys of the
+// storages it holds, the files themselves are not re-encrypted. The
+// passphrase is changed at the same time when newPassphrase is given.
+func Rotate(userID uint, passphrase, newPassphrase string) error {
+	k, err := getUserKey(userID)
+	if err != nil {
+		return err
+	}
+	priv, err := openPrivateKey(k, passphrase)
+	if err != nil {
+		return err
+	}
+	mountKeys, err := db.GetMountKeysByUser(userID)
+	if err != nil {
+		return err
+	}
+	if newPassphrase == "" {
+		newPassphrase = passphrase
+	}
+	nk, newPriv, err := newUserKey(userID, newPassphrase)
+	if err != nil {
+		return err
+	}
+	nk.CreatedAt = k.CreatedAt
+	opened := make(map[uint][]byte, len(mountKeys))
+	for i := range mountKeys {
+		key, err := openMountKey(mountKeys[i].SealedKey, k.PublicKey, priv)
+		if err != nil {
+			return errors.WithMessagef(err, "storage %d", mountKeys[i].StorageID)
+		}
+		if mountKeys[i].SealedKey, err = sealMountKey(key, nk.PublicKey); err != nil {
+			return err
+		}
+		opened[mountKeys[i].StorageID] = key
+	}
+	if err := db.RotateUserKey(nk, mountKeys); err != nil {
+		return err
+	}
+	mu.Lock()
+	ring[userID] = &unlocked{publicKey: nk.PublicKey, privateKey: newPriv, mountKeys: opened, lastUsed: time.Now()}
+	mu.Unlock()
+	return nil
+}
@@ -0,0 +1,116 @@
+package cryptkey
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/alist-org/alist/v3/internal/cluster"
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/db/dbtest"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	pkgerr "github.com/pkg/errors"
+)
+
+func init() {
+	dbtest.Init()
+}
+
+type testMount struct {
+	key       []byte
+	forgotten bool
+}
+
+func (m *testMount) PerUserKeys() bool           { return true }
+func (m *testMount) InitialKey() ([]byte, error) { return m.key, nil }
+func (m *testMount) ForgetStorageKey()           { m.forgotten = true }
+func (m *testMount) NewKey() ([]byte, error)     { return []byte("new mount key"), nil }
+
+func (m *testMount) Reencrypt(ctx context.Context, key, newKey []byte, save func(storage *model.Storage) error) error {
+	if !bytes.Equal(key, m.key) {
+		return errors.New("re-encrypted with a wrong key")
+	}
+	if err := save(nil); err != nil {
+		return err
+	}
+	m.key = newKey
+	return nil
+}
+
+func isErr(err, target error) bool {
+	return errors.Is(pkgerr.Cause(err), target)
+}
+
+func TestMountKeys(t *testing.T) {
+	const storageID, alice, bob = 7, 1, 2
+	if err := Setup(alice, "alice-pass"); err != nil {
+		t.Fatalf("setup alice: %+v", err)
+	}
+	if err := Setup(bob, "bob-pass"); err != nil {
+		t.Fatalf("setup bob: %+v", err)
+	}
+	m := &testMount{key: []byte("mount key")}
+	if err := InitMount(alice, storageID, m); err != nil || m.forgotten {
+		t.Fatalf("init mount must keep the storage key until it's backed up: %+v", err)
+	}
+	if KeyBackedUp(storageID) {
+		t.Fatal("expected the key to not be backed up yet")
+	}
+	if _, err := MountKey(bob, storageID); !isErr(err, errs.NoMountKey) {
+		t.Fatalf("expected bob to not hold the key, got %v", err)
+	}
+	if err := Grant(alice, storageID, bob); err != nil {
+		t.Fatalf("grant bob: %+v", err)
+	}
+	if key, err := MountKey(bob, storageID); err != nil || !bytes.Equal(key, m.key) {
+		t.Fatalf("expected bob to open the key, got %q %v", key, err)
+	}
+
+	Lock(bob)
+	if _, err := MountKey(bob, storageID); !isErr(err, errs.KeysLocked) {
+		t.Errorf("expected locked keys, got %v", err)
+	}
+	if err := Rotate(bob, "bob-pass", "bob-new-pass"); err != nil {
+		t.Fatalf("rotate bob: %+v", err)
+	}
+	Lock(bob)
+	if err := Unlock(bob, "bob-pass"); !isErr(err, errs.WrongPassword) {
+		t.Errorf("expected the old passphrase to be refused, got %v", err)
+	}
+	if err := Unlock(bob, "bob-new-pass"); err != nil {
+		t.Fatalf("unlock bob: %+v", err)
+	}
+	if key, err := MountKey(bob, storageID); err != nil || !bytes.Equal(key, m.key) {
+		t.Fatalf("expected the key to survive the rotation, got %q %v", key, err)
+	}
+
+	if err := Revoke(context.Background(), alice, storageID, bob, m); err != nil {
+		t.Fatalf("revoke bob: %+v", err)
+	}
+	if _, err := MountKey(bob, storageID); !isErr(err, errs.NoMountKey) {
+		t.Errorf("expected bob to lose the key, got %v", err)
+	}
+	if key, err := MountKey(alice, storageID); err != nil || string(key) != "new mount key" {
+		t.Errorf("expected alice to hold the rotated key, got %q %v", key, err)
+	}
+	if err := Revoke(context.Background(), alice, storageID, alice, m); !isErr(err, errs.LastMountHolder) {
+		t.Errorf("expected the last holder to be kept, got %v", err)
+	}
+}
+
+func TestRefusedInCluster(t *testing.T) {
+	conf.Conf.Cluster = conf.Cluster{Enable: true, Backend: "local", NodeID: "cryptkey"}
+	defer func() { conf.Conf.Cluster = conf.Cluster{} }()
+	if err := cluster.Init(); err != nil {
+		t.Fatal(err)
+	}
+	defer cluster.Close()
+	if err := Setup(30, "pass"); !isErr(err, errs.KeysInCluster) {
+		t.Errorf("expected setup to be refused in a cluster, got %v", err)
+	}
+	if err := Unlock(1, "alice-pass"); !isErr(err, errs.KeysInCluster) {
+		t.Errorf("expected unlock to be refused in a cluster, got %v", err)
+	}
+}
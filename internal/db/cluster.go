@@ -0,0 +1,48 @@
+package db
+
+import (
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+	"gorm.io/gorm/clause"
+)
+
+func CreateClusterEvent(e *model.ClusterEvent) error {
+	return errors.WithStack(db.Create(e).Error)
+}
+
+// GetClusterEventsSince returns the events created at or after t in order
+func GetClusterEventsSince(t time.Time) ([]model.ClusterEvent, error) {
+	var events []model.ClusterEvent
+	err := db.Where("created_at >= ?", t).Order("id").Find(&events).Error
+	return events, errors.WithStack(err)
+}
+
+func DeleteClusterEventsBefore(t time.Time) error {
+	return errors.WithStack(db.Where("created_at < ?", t).Delete(&model.ClusterEvent{}).Error)
+}
+
+// AcquireClusterLock takes the lock if it's free, expired or already held by owner, and extends it by ttl
+func AcquireClusterLock(name, owner string, ttl time.Duration) (bool, error) {
+	now := time.Now()
+	res := db.Model(&model.ClusterLock{}).
+		Where("name = ? AND (owner = ? OR expires_at < ?)", name, owner, now).
+		Updates(map[string]any{"owner": owner, "expires_at": now.Add(ttl)})
+	if res.Error != nil {
+		return false, errors.WithStack(res.Error)
+	}
+	if res.RowsAffected > 0 {
+		return true, nil
+	}
+	res = db.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&model.ClusterLock{Name: name, Owner: owner, ExpiresAt: now.Add(ttl)})
+	if res.Error != nil {
+		return false, errors.WithStack(res.Error)
+	}
+	return res.RowsAffected > 0, nil
+}
+
+func ReleaseClusterLock(name, owner string) error {
+	return errors.WithStack(db.Where("name = ? AND owner = ?", name, owner).Delete(&model.ClusterLock{}).Error)
+}
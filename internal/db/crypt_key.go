@@ -0,0 +1,112 @@
+package db
+
+import (
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+func GetUserKey(userID uint) (*model.UserKey, error) {
+	var k model.UserKey
+	if err := db.Where("user_id = ?", userID).First(&k).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get user key")
+	}
+	return &k, nil
+}
+
+func CreateUserKey(k *model.UserKey) error {
+	return errors.WithStack(db.Create(k).Error)
+}
+
+// RotateUserKey replaces the key pair of a user along with the mount keys
+// sealed to it
+func RotateUserKey(k *model.UserKey, mountKeys []model.MountKey) error {
+	return errors.WithStack(db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(k).Error; err != nil {
+			return err
+		}
+		for i := range mountKeys {
+			if err := tx.Model(&model.MountKey{}).Where("id = ?", mountKeys[i].ID).
+				Update("sealed_key", mountKeys[i].SealedKey).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+}
+
+func DeleteUserKey(userID uint) error {
+	return errors.WithStack(db.Where("user_id = ?", userID).Delete(&model.UserKey{}).Error)
+}
+
+func GetMountKey(storageID, userID uint) (*model.MountKey, error) {
+	var k model.MountKey
+	if err := db.Where("storage_id = ? AND user_id = ?", storageID, userID).First(&k).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get mount key")
+	}
+	return &k, nil
+}
+
+func GetMountKeysByStorage(storageID uint) ([]model.MountKey, error) {
+	var keys []model.MountKey
+	err := db.Where("storage_id = ?", storageID).Order(columnName("id")).Find(&keys).Error
+	return keys, errors.WithStack(err)
+}
+
+func GetMountKeysByUser(userID uint) ([]model.MountKey, error) {
+	var keys []model.MountKey
+	err := db.Where("user_id = ?", userID).Order(columnName("id")).Find(&keys).Error
+	return keys, errors.WithStack(err)
+}
+
+func CountMountKeys(storageID uint) (int64, error) {
+	var count int64
+	err := db.Model(&model.MountKey{}).Where("storage_id = ?", storageID).Count(&count).Error
+	return count, errors.WithStack(err)
+}
+
+func CreateMountKey(k *model.MountKey) error {
+	return errors.WithStack(db.Create(k).Error)
+}
+
+// ReplaceMountKeys replaces the mount keys of a storage after its key is
+// rotated, the storage is saved along when it's not nil
+func ReplaceMountKeys(storage *model.Storage, storageID uint, keys []model.MountKey) error {
+	return errors.WithStack(db.Transaction(func(tx *gorm.DB) error {
+		if storage != nil {
+			if err := tx.Save(storage).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Where("storage_id = ?", storageID).Delete(&model.MountKey{}).Error; err != nil {
+			return err
+		}
+		if len(keys) == 0 {
+			return nil
+		}
+		return tx.Create(&keys).Error
+	}))
+}
+
+func DeleteMountKeysByStorage(storageID uint) error {
+	return errors.WithStack(db.Where("storage_id = ?", storageID).Delete(&model.MountKey{}).Error)
+}
+
+func DeleteMountKeysByUser(userID uint) error {
+	return errors.WithStack(db.Where("user_id = ?", userID).Delete(&model.MountKey{}).Error)
+}
+
+// MarkMountKeysBackedUp flags the keys held by a saved backup
+func MarkMountKeysBackedUp(ids []uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return errors.WithStack(db.Model(&model.MountKey{}).Where("id IN ?", ids).Update("backed_up", true).Error)
+}
+
+// HasBackedUpMountKey reports whether a saved backup holds the key of the storage
+func HasBackedUpMountKey(storageID uint) (bool, error) {
+	var count int64
+	err := db.Model(&model.MountKey{}).Where("storage_id = ? AND backed_up = ?", storageID, true).Count(&count).Error
+	return count > 0, errors.WithStack(err)
+}
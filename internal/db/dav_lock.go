@@ -0,0 +1,31 @@
+package db
+
+import (
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+func GetDavLocks(namespace string) ([]model.DavLock, error) {
+	var locks []model.DavLock
+	if err := db.Where("namespace = ?", namespace).Find(&locks).Error; err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return locks, nil
+}
+
+// ReplaceDavLocks replaces the locks of namespace in one transaction
+func ReplaceDavLocks(namespace string, locks []model.DavLock) error {
+	return errors.WithStack(db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("namespace = ?", namespace).Delete(&model.DavLock{}).Error; err != nil {
+			return err
+		}
+		for i := range locks {
+			locks[i].Namespace = namespace
+		}
+		if len(locks) == 0 {
+			return nil
+		}
+		return tx.Create(&locks).Error
+	}))
+}
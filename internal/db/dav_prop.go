@@ -0,0 +1,134 @@
+package db
+
+import (
+	"strings"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+func GetDavPropsByPath(path string) ([]model.DavProp, error) {
+	var props []model.DavProp
+	if err := db.Where("path = ?", path).Order(columnName("id")).Find(&props).Error; err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return props, nil
+}
+
+// GetDavPropsOfChildren returns the dead properties of the direct children of parent
+func GetDavPropsOfChildren(parent string) ([]model.DavProp, error) {
+	var props []model.DavProp
+	prefix := utils.PathAddSeparatorSuffix(parent)
+	// the deeper descendants have one more separator
+	if err := db.Where("path LIKE ? AND path NOT LIKE ?", prefix+"%", prefix+"%/%").
+		Order(columnName("id")).Find(&props).Error; err != nil {
+		return nil, errors.WithStack(err)
+	}
+	// LIKE treats '%' and '_' in the prefix as wildcards, filter out false matches
+	res := props[:0]
+	for _, p := range props {
+		if name, ok := strings.CutPrefix(p.Path, prefix); ok && name != "" && !strings.Contains(name, "/") {
+			res = append(res, p)
+		}
+	}
+	return res, nil
+}
+
+// PatchDavProps applies the instructions to the dead properties of path in order, in one transaction
+func PatchDavProps(path string, patches []model.DavPropPatch) error {
+	return errors.WithStack(db.Transaction(func(tx *gorm.DB) error {
+		for _, p := range patches {
+			if err := tx.Where("path = ? AND space = ? AND local = ?", path, p.Space, p.Local).
+				Delete(&model.DavProp{}).Error; err != nil {
+				return err
+			}
+			if p.Remove {
+				continue
+			}
+			prop := p.DavProp
+			prop.ID = 0
+			prop.Path = path
+			if err := tx.Create(&prop).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+}
+
+// getDavPropsUnder returns the dead properties of path and all of its descendants
+func getDavPropsUnder(tx *gorm.DB, path string) ([]model.DavProp, error) {
+	var props []model.DavProp
+	prefix := utils.PathAddSeparatorSuffix(path)
+	if err := tx.Where("path = ? OR path LIKE ?", path, prefix+"%").Find(&props).Error; err != nil {
+		return nil, err
+	}
+	// LIKE treats '%' and '_' in the prefix as wildcards, filter out false matches
+	res := props[:0]
+	for _, p := range props {
+		if p.Path == path || strings.HasPrefix(p.Path, prefix) {
+			res = append(res, p)
+		}
+	}
+	return res, nil
+}
+
+// MoveDavProps rebinds the dead properties of srcPath and its descendants to dstPath
+func MoveDavProps(srcPath, dstPath string) error {
+	return errors.WithStack(db.Transaction(func(tx *gorm.DB) error {
+		if err := deleteDavPropsUnder(tx, dstPath); err != nil {
+			return err
+		}
+		props, err := getDavPropsUnder(tx, srcPath)
+		if err != nil {
+			return err
+		}
+		for _, p := range props {
+			newPath := dstPath + strings.TrimPrefix(p.Path, srcPath)
+			if err := tx.Model(&model.DavProp{}).Where("id = ?", p.ID).Update("path", newPath).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+}
+
+// CopyDavProps duplicates the dead properties of srcPath and its descendants to dstPath
+func CopyDavProps(srcPath, dstPath string) error {
+	return errors.WithStack(db.Transaction(func(tx *gorm.DB) error {
+		if err := deleteDavPropsUnder(tx, dstPath); err != nil {
+			return err
+		}
+		props, err := getDavPropsUnder(tx, srcPath)
+		if err != nil {
+			return err
+		}
+		for _, p := range props {
+			p.ID = 0
+			p.Path = dstPath + strings.TrimPrefix(p.Path, srcPath)
+			if err := tx.Create(&p).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+}
+
+func deleteDavPropsUnder(tx *gorm.DB, path string) error {
+	props, err := getDavPropsUnder(tx, path)
+	if err != nil || len(props) == 0 {
+		return err
+	}
+	ids := make([]uint, 0, len(props))
+	for _, p := range props {
+		ids = append(ids, p.ID)
+	}
+	return tx.Where("id IN ?", ids).Delete(&model.DavProp{}).Error
+}
+
+// DeleteDavProps removes the dead properties of path and its descendants
+func DeleteDavProps(path string) error {
+	return errors.WithStack(deleteDavPropsUnder(db, path))
+}
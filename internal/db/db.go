@@ -12,7 +12,7 @@ var db *gorm.DB
 
 func Init(d *gorm.DB) {
 	db = d
-	err := AutoMigrate(new(model.Storage), new(model.User), new(model.Meta), new(model.SettingItem), new(model.SearchNode), new(model.TaskItem), new(model.SSHPublicKey), new(model.Role), new(model.Label), new(model.LabelFileBinding), new(model.ObjFile), new(model.Session), new(model.Share))
+	err := AutoMigrate(new(model.Storage), new(model.User), new(model.Meta), new(model.SettingItem), new(model.SearchNode), new(model.TaskItem), new(model.SSHPublicKey), new(model.Role), new(model.Label), new(model.LabelFileBinding), new(model.ObjFile), new(model.Session), new(model.Share), new(model.DavProp), new(model.DavLock), new(model.Pipeline), new(model.ClusterEvent), new(model.ClusterLock), new(model.OAuthClient), new(model.OAuthCode), new(model.Group), new(model.GroupMember), new(model.ShareAccess), new(model.InternalShare), new(model.DownloadLink), new(model.DownloadSession), new(model.UserKey), new(model.MountKey), new(model.FileHash))
 	if err != nil {
 		log.Fatalf("failed migrate database: %s", err.Error())
 	}
@@ -0,0 +1,20 @@
+// Package dbtest sets up the database of the tests
+package dbtest
+
+import (
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/db"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// Init opens an in-memory sqlite database shared by the tests of the package
+// and loads the default config
+func Init() {
+	dB, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
+	if err != nil {
+		panic("failed to connect database")
+	}
+	conf.Conf = conf.DefaultConfig()
+	db.Init(dB)
+}
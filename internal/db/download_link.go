@@ -0,0 +1,91 @@
+package db
+
+import (
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+func GetDownloadLink(id uint) (*model.DownloadLink, error) {
+	var l model.DownloadLink
+	if err := db.First(&l, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get download link")
+	}
+	return &l, nil
+}
+
+func GetDownloadLinkByToken(token string) (*model.DownloadLink, error) {
+	var l model.DownloadLink
+	if err := db.Where("token = ?", token).First(&l).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get download link")
+	}
+	return &l, nil
+}
+
+func GetDownloadLinksByCreator(creatorID uint, pageIndex, pageSize int) (links []model.DownloadLink, count int64, err error) {
+	tx := db.Model(&model.DownloadLink{}).Where("creator_id = ?", creatorID)
+	if err = tx.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get download links count")
+	}
+	if err = tx.Order(columnName("id") + " DESC").Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&links).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find download links")
+	}
+	return links, count, nil
+}
+
+func CreateDownloadLink(l *model.DownloadLink) error {
+	return errors.WithStack(db.Create(l).Error)
+}
+
+// InDownloadSession reports whether a download of the link by the client is
+// in progress
+func InDownloadSession(token, client string, now time.Time) (bool, error) {
+	var count int64
+	err := db.Model(&model.DownloadSession{}).
+		Where("token = ? AND client = ? AND expires_at > ?", token, client, now).Count(&count).Error
+	return count > 0, errors.WithStack(err)
+}
+
+// UseDownloadLink counts a download of the link and opens the session of the
+// client until sessionEnd, false if the link is expired or has no download left
+func UseDownloadLink(token, client string, sessionEnd time.Time) (bool, error) {
+	now := time.Now()
+	used := false
+	err := db.Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&model.DownloadLink{}).
+			Where("token = ? AND expires_at > ?", token, now).
+			Where("(max_downloads = 0 OR downloads < max_downloads)").
+			UpdateColumns(map[string]interface{}{
+				"downloads":        gorm.Expr("downloads + ?", 1),
+				"last_download_at": now,
+			})
+		if res.Error != nil || res.RowsAffected != 1 {
+			return res.Error
+		}
+		used = true
+		return tx.Save(&model.DownloadSession{Token: token, Client: client, ExpiresAt: sessionEnd}).Error
+	})
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+	return used, nil
+}
+
+func DeleteDownloadLink(id uint) error {
+	return errors.WithStack(db.Delete(&model.DownloadLink{}, id).Error)
+}
+
+func DeleteDownloadLinksByCreator(creatorID uint) error {
+	return errors.WithStack(db.Where("creator_id = ?", creatorID).Delete(&model.DownloadLink{}).Error)
+}
+
+// DeleteDownloadLinksExpiredBefore removes the links expired for a while
+func DeleteDownloadLinksExpiredBefore(t time.Time) error {
+	return errors.WithStack(db.Where("expires_at < ?", t).Delete(&model.DownloadLink{}).Error)
+}
+
+func DeleteDownloadSessionsExpiredBefore(t time.Time) error {
+	return errors.WithStack(db.Where("expires_at < ?", t).Delete(&model.DownloadSession{}).Error)
+}
@@ -0,0 +1,60 @@
+package db
+
+import (
+	stdpath "path"
+	"strings"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func GetFileHashesByParent(parent string) ([]model.FileHash, error) {
+	var hashes []model.FileHash
+	err := db.Where("parent = ?", parent).Find(&hashes).Error
+	return hashes, errors.WithStack(err)
+}
+
+func GetFileHash(parent, name string) (*model.FileHash, error) {
+	var h model.FileHash
+	if err := db.Where("parent = ? AND name = ?", parent, name).First(&h).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get file hash")
+	}
+	return &h, nil
+}
+
+// SaveFileHash replaces the hashes stored for the file
+func SaveFileHash(h *model.FileHash) error {
+	return errors.WithStack(db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "parent"}, {Name: "name"}},
+		DoUpdates: clause.AssignmentColumns([]string{"size", "modified", "md5", "sha1", "sha256", "updated_at"}),
+	}).Create(h).Error)
+}
+
+// DeleteFileHashesUnder removes the hashes of the file at path, or of all the
+// files under it for a folder
+func DeleteFileHashesUnder(path string) error {
+	if path == "/" {
+		return errors.WithStack(db.Where("1 = 1").Delete(&model.FileHash{}).Error)
+	}
+	return errors.WithStack(db.Transaction(func(tx *gorm.DB) error {
+		prefix := path + "/"
+		var hashes []model.FileHash
+		if err := tx.Select("id", "parent", "name").Where("(parent = ? AND name = ?) OR parent = ? OR parent LIKE ?",
+			stdpath.Dir(path), stdpath.Base(path), path, prefix+"%").Find(&hashes).Error; err != nil {
+			return err
+		}
+		// LIKE treats '%' and '_' in the prefix as wildcards and may ignore the case, filter out false matches
+		ids := make([]uint, 0, len(hashes))
+		for _, h := range hashes {
+			if (h.Parent == stdpath.Dir(path) && h.Name == stdpath.Base(path)) || h.Parent == path || strings.HasPrefix(h.Parent, prefix) {
+				ids = append(ids, h.ID)
+			}
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+		return tx.Where("id IN ?", ids).Delete(&model.FileHash{}).Error
+	}))
+}
@@ -0,0 +1,103 @@
+package db
+
+import (
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func GetGroup(id uint) (*model.Group, error) {
+	var g model.Group
+	if err := db.First(&g, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get group")
+	}
+	return &g, nil
+}
+
+func GetGroupByName(name string) (*model.Group, error) {
+	var g model.Group
+	if err := db.Where("name = ?", name).First(&g).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get group")
+	}
+	return &g, nil
+}
+
+func GetGroups(pageIndex, pageSize int) (groups []model.Group, count int64, err error) {
+	groupDB := db.Model(&model.Group{})
+	if err = groupDB.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get groups count")
+	}
+	if err = groupDB.Order(columnName("id")).Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&groups).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find groups")
+	}
+	return groups, count, nil
+}
+
+// GetGroupsByUserId returns the groups the user is a member of
+func GetGroupsByUserId(userId uint) ([]model.Group, error) {
+	var ids []uint
+	if err := db.Model(&model.GroupMember{}).Where("user_id = ?", userId).Pluck("group_id", &ids).Error; err != nil {
+		return nil, errors.WithStack(err)
+	}
+	groups := make([]model.Group, 0, len(ids))
+	if len(ids) == 0 {
+		return groups, nil
+	}
+	err := db.Where("id IN ?", ids).Order(columnName("id")).Find(&groups).Error
+	return groups, errors.WithStack(err)
+}
+
+func CreateGroup(g *model.Group) error {
+	return errors.WithStack(db.Create(g).Error)
+}
+
+func UpdateGroup(g *model.Group) error {
+	return errors.WithStack(db.Save(g).Error)
+}
+
+// DeleteGroup deletes the group with its members, the shares and labels of the group are left to their creators
+func DeleteGroup(id uint) error {
+	return errors.WithStack(db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("group_id = ?", id).Delete(&model.GroupMember{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&model.Share{}).Where("group_id = ?", id).Update("group_id", 0).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&model.LabelFileBinding{}).Where("group_id = ?", id).Update("group_id", 0).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&model.Group{}, id).Error
+	}))
+}
+
+func GetGroupMembers(groupId uint) ([]model.GroupMember, error) {
+	var members []model.GroupMember
+	err := db.Where("group_id = ?", groupId).Order("user_id").Find(&members).Error
+	return members, errors.WithStack(err)
+}
+
+func GetGroupMember(groupId, userId uint) (*model.GroupMember, error) {
+	var m model.GroupMember
+	if err := db.Where("group_id = ? AND user_id = ?", groupId, userId).Take(&m).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get group member")
+	}
+	return &m, nil
+}
+
+// SaveGroupMember adds the member or updates its admin flag
+func SaveGroupMember(m *model.GroupMember) error {
+	return errors.WithStack(db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "group_id"}, {Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"admin"}),
+	}).Create(m).Error)
+}
+
+func DeleteGroupMember(groupId, userId uint) error {
+	return errors.WithStack(db.Where("group_id = ? AND user_id = ?", groupId, userId).Delete(&model.GroupMember{}).Error)
+}
+
+func DeleteGroupMembersByUserId(userId uint) error {
+	return errors.WithStack(db.Where("user_id = ?", userId).Delete(&model.GroupMember{}).Error)
+}
@@ -0,0 +1,57 @@
+package db
+
+import (
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+)
+
+func GetInternalShare(id uint) (*model.InternalShare, error) {
+	var s model.InternalShare
+	if err := db.First(&s, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get internal share")
+	}
+	return &s, nil
+}
+
+func GetInternalSharesByOwner(ownerID uint, pageIndex, pageSize int) (shares []model.InternalShare, count int64, err error) {
+	tx := db.Model(&model.InternalShare{}).Where("owner_id = ?", ownerID)
+	if err = tx.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get internal shares count")
+	}
+	if err = tx.Order(columnName("id")).Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&shares).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find internal shares")
+	}
+	return shares, count, nil
+}
+
+// GetInternalSharesByTargets returns the shares granted to the user, to one of
+// the roles or to one of the groups, ordered by id
+func GetInternalSharesByTargets(userID uint, roleIDs, groupIDs []uint) ([]model.InternalShare, error) {
+	tx := db.Where("target_type = ? AND target_id = ?", model.InternalShareUser, userID)
+	if len(roleIDs) > 0 {
+		tx = tx.Or("target_type = ? AND target_id IN ?", model.InternalShareRole, roleIDs)
+	}
+	if len(groupIDs) > 0 {
+		tx = tx.Or("target_type = ? AND target_id IN ?", model.InternalShareGroup, groupIDs)
+	}
+	var shares []model.InternalShare
+	err := db.Where(tx).Order(columnName("id")).Find(&shares).Error
+	return shares, errors.WithStack(err)
+}
+
+func CreateInternalShare(s *model.InternalShare) error {
+	return errors.WithStack(db.Create(s).Error)
+}
+
+func DeleteInternalShare(id uint) error {
+	return errors.WithStack(db.Delete(&model.InternalShare{}, id).Error)
+}
+
+// DeleteInternalSharesByTarget revokes the shares granted to a deleted user, role or group
+func DeleteInternalSharesByTarget(targetType string, targetID uint) error {
+	return errors.WithStack(db.Where("target_type = ? AND target_id = ?", targetType, targetID).Delete(&model.InternalShare{}).Error)
+}
+
+func DeleteInternalSharesByOwner(ownerID uint) error {
+	return errors.WithStack(db.Where("owner_id = ?", ownerID).Delete(&model.InternalShare{}).Error)
+}
@@ -9,20 +9,37 @@ import (
 	"time"
 )
 
+// labelReadScope selects the bindings of the user and the ones shared with its groups
+func labelReadScope(tx *gorm.DB, userId uint, groupIds []uint) *gorm.DB {
+	if len(groupIds) == 0 {
+		return tx.Where("user_id = ? AND group_id = 0", userId)
+	}
+	return tx.Where("((user_id = ? AND group_id = 0) OR group_id IN ?)", userId, groupIds)
+}
+
+// labelWriteScope selects the bindings of the group if groupId is set, otherwise the bindings of the user
+func labelWriteScope(tx *gorm.DB, userId, groupId uint) *gorm.DB {
+	if groupId != 0 {
+		return tx.Where("group_id = ?", groupId)
+	}
+	return tx.Where("user_id = ? AND group_id = 0", userId)
+}
+
 // GetLabelIds Get all label_ids from database order by file_name
-func GetLabelIds(userId uint, fileName string) ([]uint, error) {
+func GetLabelIds(userId uint, groupIds []uint, fileName string) ([]uint, error) {
 	//fmt.Printf(">>> [GetLabelIds] userId: %d, fileName: %s\n", userId, fileName)
 	labelFileBinDingDB := db.Model(&model.LabelFileBinding{})
 	var labelIds []uint
-	if err := labelFileBinDingDB.Where("file_name = ?", fileName).Where("user_id = ?", userId).Pluck("label_id", &labelIds).Error; err != nil {
+	if err := labelReadScope(labelFileBinDingDB.Where("file_name = ?", fileName), userId, groupIds).Pluck("label_id", &labelIds).Error; err != nil {
 		return nil, errors.WithStack(err)
 	}
 	return labelIds, nil
 }
 
-func CreateLabelFileBinDing(fileName string, labelId, userId uint) error {
+func CreateLabelFileBinDing(fileName string, labelId, userId, groupId uint) error {
 	var labelFileBinDing model.LabelFileBinding
 	labelFileBinDing.UserId = userId
+	labelFileBinDing.GroupId = groupId
 	labelFileBinDing.LabelId = labelId
 	labelFileBinDing.FileName = fileName
 	labelFileBinDing.CreateTime = time.Now()
@@ -42,17 +59,17 @@ func GetLabelFileBinDingByLabelIdExists(labelId, userId uint) bool {
 }
 
 // DelLabelFileBinDingByFileName used to del usually
-func DelLabelFileBinDingByFileName(userId uint, fileName string) error {
-	return errors.WithStack(db.Where("file_name = ?", fileName).Where("user_id = ?", userId).Delete(model.LabelFileBinding{}).Error)
+func DelLabelFileBinDingByFileName(userId, groupId uint, fileName string) error {
+	return errors.WithStack(labelWriteScope(db.Where("file_name = ?", fileName), userId, groupId).Delete(model.LabelFileBinding{}).Error)
 }
 
 // DelLabelFileBinDingById used to del usually
-func DelLabelFileBinDingById(labelId, userId uint, fileName string) error {
-	return errors.WithStack(db.Where("label_id = ?", labelId).Where("file_name = ?", fileName).Where("user_id = ?", userId).Delete(model.LabelFileBinding{}).Error)
+func DelLabelFileBinDingById(labelId, userId, groupId uint, fileName string) error {
+	return errors.WithStack(labelWriteScope(db.Where("label_id = ?", labelId).Where("file_name = ?", fileName), userId, groupId).Delete(model.LabelFileBinding{}).Error)
 }
 
-func GetLabelFileBinDingByLabelId(labelIds []uint, userId uint) (result []model.LabelFileBinding, err error) {
-	if err := db.Where("label_id in (?)", labelIds).Where("user_id = ?", userId).Find(&result).Error; err != nil {
+func GetLabelFileBinDingByLabelId(labelIds []uint, userId uint, groupIds []uint) (result []model.LabelFileBinding, err error) {
+	if err := labelReadScope(db.Where("label_id in (?)", labelIds), userId, groupIds).Find(&result).Error; err != nil {
 		return nil, errors.WithStack(err)
 	}
 	return result, nil
@@ -116,8 +133,8 @@ func GetLabelsByFileNamesPublic(fileNames []string) (map[string][]model.Label, e
 	return out, nil
 }
 
-func ListLabelFileBinDing(userId uint, labelIDs []uint, fileName string, page, pageSize int) ([]model.LabelFileBinding, int64, error) {
-	q := db.Model(&model.LabelFileBinding{}).Where("user_id = ?", userId)
+func ListLabelFileBinDing(userId uint, groupIds []uint, labelIDs []uint, fileName string, page, pageSize int) ([]model.LabelFileBinding, int64, error) {
+	q := labelReadScope(db.Model(&model.LabelFileBinding{}), userId, groupIds)
 
 	if len(labelIDs) > 0 {
 		q = q.Where("label_id IN ?", labelIDs)
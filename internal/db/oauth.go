@@ -0,0 +1,71 @@
+package db
+
+import (
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+)
+
+func GetOAuthClients(pageIndex, pageSize int) (clients []model.OAuthClient, count int64, err error) {
+	clientDB := db.Model(&model.OAuthClient{})
+	if err := clientDB.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get oauth clients count")
+	}
+	if err := clientDB.Order(columnName("id")).Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&clients).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find oauth clients")
+	}
+	return clients, count, nil
+}
+
+func GetOAuthClientByID(id uint) (*model.OAuthClient, error) {
+	var c model.OAuthClient
+	if err := db.First(&c, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get oauth client")
+	}
+	return &c, nil
+}
+
+func GetOAuthClientByClientID(clientID string) (*model.OAuthClient, error) {
+	c := model.OAuthClient{ClientID: clientID}
+	if err := db.Where(c).First(&c).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get oauth client")
+	}
+	return &c, nil
+}
+
+func CreateOAuthClient(c *model.OAuthClient) error {
+	return errors.WithStack(db.Create(c).Error)
+}
+
+func UpdateOAuthClient(c *model.OAuthClient) error {
+	return errors.WithStack(db.Save(c).Error)
+}
+
+func DeleteOAuthClientByID(id uint) error {
+	return errors.WithStack(db.Delete(&model.OAuthClient{}, id).Error)
+}
+
+func CreateOAuthCode(c *model.OAuthCode) error {
+	return errors.WithStack(db.Create(c).Error)
+}
+
+// TakeOAuthCode returns and deletes the code, so it can only be taken once even by concurrent requests
+func TakeOAuthCode(codeHash string) (*model.OAuthCode, error) {
+	var c model.OAuthCode
+	if err := db.Where("code_hash = ?", codeHash).First(&c).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get oauth code")
+	}
+	res := db.Where("code_hash = ?", codeHash).Delete(&model.OAuthCode{})
+	if res.Error != nil {
+		return nil, errors.WithStack(res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return nil, errors.New("oauth code is already used")
+	}
+	return &c, nil
+}
+
+func DeleteExpiredOAuthCodes() error {
+	return errors.WithStack(db.Where("expires_at < ?", time.Now()).Delete(&model.OAuthCode{}).Error)
+}
@@ -0,0 +1,39 @@
+package db
+
+import (
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+)
+
+func CreatePipeline(p *model.Pipeline) error {
+	return errors.WithStack(db.Create(p).Error)
+}
+
+func UpdatePipeline(p *model.Pipeline) error {
+	return errors.WithStack(db.Save(p).Error)
+}
+
+func GetPipelineByID(id uint) (*model.Pipeline, error) {
+	var p model.Pipeline
+	if err := db.First(&p, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get pipeline")
+	}
+	return &p, nil
+}
+
+// GetPipelines lists the pipelines of the creator, or of all users if creatorID is 0
+func GetPipelines(creatorID uint, pageIndex, pageSize int) (pipelines []model.Pipeline, count int64, err error) {
+	tx := db.Model(&model.Pipeline{})
+	if creatorID != 0 {
+		tx = tx.Where("creator_id = ?", creatorID)
+	}
+	if err = tx.Count(&count).Error; err != nil {
+		return nil, 0, errors.WithStack(err)
+	}
+	err = tx.Order(columnName("id") + " desc").Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&pipelines).Error
+	return pipelines, count, errors.WithStack(err)
+}
+
+func DeletePipelineByID(id uint) error {
+	return errors.WithStack(db.Delete(&model.Pipeline{}, id).Error)
+}
@@ -67,3 +67,9 @@ func ListSessions() ([]model.Session, error) {
 func MarkInactive(sessionID string) error {
 	return errors.WithStack(db.Model(&model.Session{}).Where("device_key = ?", sessionID).Update("status", model.SessionInactive).Error)
 }
+
+func CountActiveSessions() (int64, error) {
+	var count int64
+	err := db.Model(&model.Session{}).Where("status = ?", model.SessionActive).Count(&count).Error
+	return count, errors.WithStack(err)
+}
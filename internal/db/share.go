@@ -1,6 +1,7 @@
 package db
 
 import (
+	"errors"
 	"time"
 
 	"github.com/alist-org/alist/v3/internal/model"
@@ -24,6 +25,33 @@ func GetShareByCreatorAndShareID(creatorID uint, shareID string) (*model.Share,
 	return &share, nil
 }
 
+// ownedShares selects the shares of the user: created by the user and not
+// handed to a group, or owned by one of the groups
+func ownedShares(tx *gorm.DB, userID uint, groupIDs []uint) *gorm.DB {
+	if len(groupIDs) == 0 {
+		return tx.Where("creator_id = ? AND group_id = 0", userID)
+	}
+	return tx.Where("((creator_id = ? AND group_id = 0) OR group_id IN ?)", userID, groupIDs)
+}
+
+func GetShareByOwnerAndShareID(userID uint, groupIDs []uint, shareID string) (*model.Share, error) {
+	var share model.Share
+	if err := ownedShares(db, userID, groupIDs).Where("share_id = ?", shareID).Take(&share).Error; err != nil {
+		return nil, err
+	}
+	return &share, nil
+}
+
+func GetSharesByOwner(userID uint, groupIDs []uint, pageIndex, pageSize int) (shares []model.Share, count int64, err error) {
+	tx := ownedShares(db.Model(&model.Share{}), userID, groupIDs)
+	err = tx.Count(&count).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	err = tx.Order("created_at desc").Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&shares).Error
+	return
+}
+
 func ShareIDExists(shareID string) (bool, error) {
 	var count int64
 	if err := db.Model(&model.Share{}).Where("share_id = ?", shareID).Count(&count).Error; err != nil {
@@ -58,8 +86,21 @@ func GetSharesByCreator(creatorID uint, pageIndex, pageSize int) (shares []model
 	return
 }
 
+// DeleteShareByShareID deletes the share with its access log
 func DeleteShareByShareID(creatorID uint, shareID string) error {
-	return db.Where("creator_id = ? AND share_id = ?", creatorID, shareID).Delete(&model.Share{}).Error
+	return db.Transaction(func(tx *gorm.DB) error {
+		var share model.Share
+		if err := tx.Where("creator_id = ? AND share_id = ?", creatorID, shareID).Take(&share).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil
+			}
+			return err
+		}
+		if err := tx.Where("share_id = ?", share.ID).Delete(&model.ShareAccess{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&share).Error
+	})
 }
 
 func DisableShareByShareID(creatorID uint, shareID string) error {
@@ -122,3 +163,46 @@ func RecordShareAccess(shareID string) (*model.Share, error) {
 	}
 	return &updated, nil
 }
+
+// ReserveShareUpload counts an upload of size bytes on the share, it reports
+// false without counting when the upload count or quota of the share is reached
+func ReserveShareUpload(shareID string, size int64) (bool, error) {
+	res := db.Model(&model.Share{}).
+		Where("share_id = ?", shareID).
+		Where("(upload_limit = 0 OR upload_count < upload_limit)").
+		Where("(upload_quota = 0 OR uploaded_bytes + ? <= upload_quota)", size).
+		UpdateColumns(map[string]interface{}{
+			"upload_count":   gorm.Expr("upload_count + ?", 1),
+			"uploaded_bytes": gorm.Expr("uploaded_bytes + ?", size),
+			"last_upload_at": time.Now(),
+		})
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected == 1, nil
+}
+
+// ReleaseShareUpload takes back the reservation of a failed upload
+func ReleaseShareUpload(shareID string, size int64) error {
+	return db.Model(&model.Share{}).
+		Where("share_id = ?", shareID).
+		UpdateColumns(map[string]interface{}{
+			"upload_count":   gorm.Expr("upload_count - ?", 1),
+			"uploaded_bytes": gorm.Expr("uploaded_bytes - ?", size),
+		}).Error
+}
+
+func GetShares(pageIndex, pageSize int) (shares []model.Share, count int64, err error) {
+	tx := db.Model(&model.Share{})
+	err = tx.Count(&count).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	err = tx.Order("created_at desc").Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&shares).Error
+	return
+}
+
+// DisableShare disables the share regardless of its creator
+func DisableShare(shareID string) error {
+	return db.Model(&model.Share{}).Where("share_id = ?", shareID).Update("enabled", false).Error
+}
@@ -0,0 +1,97 @@
+package db
+
+import (
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+)
+
+func CreateShareAccesses(accesses []model.ShareAccess) error {
+	return errors.WithStack(db.CreateInBatches(accesses, 500).Error)
+}
+
+func GetShareAccesses(shareID uint, action string, pageIndex, pageSize int) (accesses []model.ShareAccess, count int64, err error) {
+	tx := db.Model(&model.ShareAccess{}).Where("share_id = ?", shareID)
+	if action != "" {
+		tx = tx.Where("action = ?", action)
+	}
+	if err = tx.Count(&count).Error; err != nil {
+		return nil, 0, errors.WithStack(err)
+	}
+	err = tx.Order("id desc").Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&accesses).Error
+	return accesses, count, errors.WithStack(err)
+}
+
+// shareStatsPageSize bounds the share ids summed up by one query
+const shareStatsPageSize = 100
+
+// GetShareStats sums up the access log of the shares, top files are the most
+// downloaded or previewed files of each share
+func GetShareStats(shareIDs []uint, topFiles int) (map[uint]*model.ShareStats, error) {
+	stats := make(map[uint]*model.ShareStats, len(shareIDs))
+	for _, id := range shareIDs {
+		stats[id] = &model.ShareStats{TopFiles: []model.ShareFileStat{}}
+	}
+	for start := 0; start < len(shareIDs); start += shareStatsPageSize {
+		end := min(start+shareStatsPageSize, len(shareIDs))
+		var totals []struct {
+			ShareID  uint
+			Visitors int64
+			Bytes    int64
+		}
+		err := db.Model(&model.ShareAccess{}).
+			Select("share_id, COUNT(DISTINCT ip) AS visitors, COALESCE(SUM(bytes), 0) AS bytes").
+			Where("share_id IN ?", shareIDs[start:end]).
+			Group("share_id").Scan(&totals).Error
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		for _, t := range totals {
+			stats[t.ShareID].UniqueVisitors = t.Visitors
+			stats[t.ShareID].BytesServed = t.Bytes
+		}
+		if topFiles <= 0 {
+			continue
+		}
+		// rank the files of each share in the same query, only the top ones are read
+		ranked := db.Model(&model.ShareAccess{}).
+			Select("share_id, path, COUNT(*) AS count, COALESCE(SUM(bytes), 0) AS bytes, "+
+				"ROW_NUMBER() OVER (PARTITION BY share_id ORDER BY COUNT(*) DESC, path) AS rank_in_share").
+			Where("share_id IN ? AND action IN ?", shareIDs[start:end], []string{model.ShareActionDownload, model.ShareActionProxy}).
+			Group("share_id, path")
+		var files []struct {
+			ShareID uint
+			Path    string
+			Count   int64
+			Bytes   int64
+		}
+		err = db.Table("(?) AS ranked", ranked).
+			Select("share_id, path, count, bytes").
+			Where("rank_in_share <= ?", topFiles).
+			Order("share_id, rank_in_share").Scan(&files).Error
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		for _, f := range files {
+			s := stats[f.ShareID]
+			s.TopFiles = append(s.TopFiles, model.ShareFileStat{Path: f.Path, Count: f.Count, Bytes: f.Bytes})
+		}
+	}
+	return stats, nil
+}
+
+func DeleteShareAccessesBefore(t time.Time) error {
+	return errors.WithStack(db.Where("created_at < ?", t).Delete(&model.ShareAccess{}).Error)
+}
+
+// TrimShareAccesses keeps the newest keep entries of the share
+func TrimShareAccesses(shareID uint, keep int) error {
+	var ids []uint
+	err := db.Model(&model.ShareAccess{}).Where("share_id = ?", shareID).
+		Order("id desc").Offset(keep).Limit(1).Pluck("id", &ids).Error
+	if err != nil || len(ids) == 0 {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(db.Where("share_id = ? AND id <= ?", shareID, ids[0]).Delete(&model.ShareAccess{}).Error)
+}
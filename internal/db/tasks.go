@@ -46,3 +46,15 @@ func UpdateTaskDataFunc(type_s string, enabled bool) func([]byte) error {
 		return UpdateTaskData(&model.TaskItem{Key: type_s, PersistData: s})
 	}
 }
+
+func GetTaskItems() ([]model.TaskItem, error) {
+	var tasks []model.TaskItem
+	if err := db.Find(&tasks).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find tasks")
+	}
+	return tasks, nil
+}
+
+func DeleteTaskData(key string) error {
+	return errors.WithStack(db.Where("key = ?", key).Delete(&model.TaskItem{}).Error)
+}
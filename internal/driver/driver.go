@@ -2,6 +2,7 @@ package driver
 
 import (
 	"context"
+	"time"
 
 	"github.com/alist-org/alist/v3/internal/model"
 )
@@ -29,6 +30,11 @@ type Other interface {
 	Other(ctx context.Context, args model.OtherArgs) (interface{}, error)
 }
 
+type WithDetails interface {
+	// GetDetails get the details of the storage, such as the total and free space
+	GetDetails(ctx context.Context) (*model.StorageDetails, error)
+}
+
 type Reader interface {
 	// List files in the path
 	// if identify files by path, need to set ID with path,like path.Join(dir.GetID(), obj.GetName())
@@ -76,6 +82,11 @@ type Remove interface {
 	Remove(ctx context.Context, obj model.Obj) error
 }
 
+type SetModTime interface {
+	// SetModTime set the modification time of the obj
+	SetModTime(ctx context.Context, obj model.Obj, modTime time.Time) error
+}
+
 type Put interface {
 	// Put a file (provided as a FileStreamer) into the driver
 	// Besides the most basic upload functionality, the following features also need to be implemented:
@@ -205,6 +216,13 @@ type ArchiveDecompressResult interface {
 	ArchiveDecompress(ctx context.Context, srcObj, dstDir model.Obj, args model.ArchiveDecompressArgs) ([]model.Obj, error)
 }
 
+// Mirror is implemented by the storages showing the files of other storages, e.g. alias
+type Mirror interface {
+	// MirrorPaths returns the paths in the storage showing the file at the full path,
+	// the root stands for all the files of the storage if they can't be told apart
+	MirrorPaths(fullPath string) []string
+}
+
 type Reference interface {
 	InitReference(storage Driver) error
 }
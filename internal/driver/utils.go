@@ -2,6 +2,7 @@ package driver
 
 import (
 	"context"
+	"github.com/alist-org/alist/v3/internal/conf"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/stream"
 	"io"
@@ -35,10 +36,20 @@ type RateLimitWriter = stream.RateLimitWriter
 
 type RateLimitFile = stream.RateLimitFile
 
+// uploadLimiter adds the limit of the storage set by op.Put to the global one
+func uploadLimiter(ctx context.Context) stream.Limiter {
+	if ctx != nil {
+		if l, ok := ctx.Value(conf.StorageUploadLimit).(stream.Limiter); ok {
+			return stream.ChainLimiters(stream.ServerUploadLimit, l)
+		}
+	}
+	return stream.ServerUploadLimit
+}
+
 func NewLimitedUploadStream(ctx context.Context, r io.Reader) *RateLimitReader {
 	return &RateLimitReader{
 		Reader:  r,
-		Limiter: stream.ServerUploadLimit,
+		Limiter: uploadLimiter(ctx),
 		Ctx:     ctx,
 	}
 }
@@ -46,13 +57,13 @@ func NewLimitedUploadStream(ctx context.Context, r io.Reader) *RateLimitReader {
 func NewLimitedUploadFile(ctx context.Context, f model.File) *RateLimitFile {
 	return &RateLimitFile{
 		File:    f,
-		Limiter: stream.ServerUploadLimit,
+		Limiter: uploadLimiter(ctx),
 		Ctx:     ctx,
 	}
 }
 
 func ServerUploadLimitWaitN(ctx context.Context, n int) error {
-	return stream.ServerUploadLimit.WaitN(ctx, n)
+	return uploadLimiter(ctx).WaitN(ctx, n)
 }
 
 type ReaderWithCtx = stream.ReaderWithCtx
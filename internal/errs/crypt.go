@@ -0,0 +1,15 @@
+package errs
+
+import "errors"
+
+var (
+	KeysLocked      = errors.New("encryption keys are locked, unlock them with your passphrase")
+	NoUserKey       = errors.New("encryption keys are not set up")
+	UserKeyExists   = errors.New("encryption keys are already set up")
+	NoMountKey      = errors.New("no access to the key of this storage")
+	MountKeyExists  = errors.New("the key of this storage is already set up")
+	LastMountHolder = errors.New("cannot revoke the last holder of the key of this storage")
+	KeysInCluster   = errors.New("per user keys can't be used in a cluster, unlocked keys only live on one instance")
+	KeyRotating     = errors.New("the key of this storage is being rotated, try again later")
+	KeyNotRotatable = errors.New("the key can't be rotated when the remote path is the root of a storage")
+)
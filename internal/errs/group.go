@@ -0,0 +1,8 @@
+package errs
+
+import "errors"
+
+var (
+	NotGroupMember = errors.New("not a member of the group")
+	NotGroupAdmin  = errors.New("not an admin of the group")
+)
@@ -57,6 +57,7 @@ func (t *ArchiveDownloadTask) Run() error {
 	if err != nil {
 		return err
 	}
+	uploadTask.JoinPipeline(t.PipelineID)
 	ArchiveContentUploadTaskManager.Add(uploadTask)
 	return nil
 }
@@ -197,7 +198,7 @@ func (t *ArchiveContentUploadTask) RunWithNextTaskCallback(f func(nextTsk *Archi
 				es = stderrors.Join(es, err)
 				continue
 			}
-			err = f(&ArchiveContentUploadTask{
+			nextTask := &ArchiveContentUploadTask{
 				TaskExtension: task.TaskExtension{
 					Creator: t.GetCreator(),
 				},
@@ -207,7 +208,9 @@ func (t *ArchiveContentUploadTask) RunWithNextTaskCallback(f func(nextTsk *Archi
 				DstDirPath:   nextDstPath,
 				dstStorage:   t.dstStorage,
 				DstStorageMp: t.DstStorageMp,
-			})
+			}
+			nextTask.JoinPipeline(t.PipelineID)
+			err = f(nextTask)
 			if err != nil {
 				es = stderrors.Join(es, err)
 			}
@@ -225,7 +228,7 @@ func (t *ArchiveContentUploadTask) RunWithNextTaskCallback(f func(nextTsk *Archi
 			Obj: &model.Object{
 				Name:     t.ObjName,
 				Size:     info.Size(),
-				Modified: time.Now(),
+				Modified: info.ModTime(),
 			},
 			Mimetype:     mime.TypeByExtension(filepath.Ext(t.ObjName)),
 			WebPutAsTask: true,
@@ -378,6 +381,7 @@ func archiveDecompress(ctx context.Context, srcObjPath, dstDirPath string, args
 		}
 		return nil, uploadTask.RunWithNextTaskCallback(callback)
 	} else {
+		tsk.JoinPipeline(task.PipelineIDFromCtx(ctx))
 		ArchiveDownloadTaskManager.Add(tsk)
 		return tsk, nil
 	}
@@ -72,6 +72,9 @@ func _copy(ctx context.Context, srcObjPath, dstDirPath string, lazyCache ...bool
 	// copy if in the same storage, just call driver.Copy
 	if srcStorage.GetStorage() == dstStorage.GetStorage() {
 		err = op.Copy(ctx, srcStorage, srcObjActualPath, dstDirActualPath, lazyCache...)
+		if err == nil {
+			copyDavProps(srcObjPath, stdpath.Join(dstDirPath, stdpath.Base(srcObjPath)))
+		}
 		if !errors.Is(err, errs.NotImplement) && !errors.Is(err, errs.NotSupport) {
 			return nil, err
 		}
@@ -98,7 +101,11 @@ func _copy(ctx context.Context, srcObjPath, dstDirPath string, lazyCache ...bool
 			if err != nil {
 				return nil, errors.WithMessagef(err, "failed get [%s] stream", srcObjPath)
 			}
-			return nil, op.Put(ctx, dstStorage, dstDirActualPath, ss, nil, false)
+			err = op.Put(ctx, dstStorage, dstDirActualPath, ss, nil, false)
+			if err == nil {
+				copyDavProps(srcObjPath, stdpath.Join(dstDirPath, srcObj.GetName()))
+			}
+			return nil, err
 		}
 	}
 	// not in the same storage
@@ -114,6 +121,7 @@ func _copy(ctx context.Context, srcObjPath, dstDirPath string, lazyCache ...bool
 		SrcStorageMp: srcStorage.GetStorage().MountPath,
 		DstStorageMp: dstStorage.GetStorage().MountPath,
 	}
+	t.JoinPipeline(task.PipelineIDFromCtx(ctx))
 	CopyTaskManager.Add(t)
 	return t, nil
 }
@@ -125,6 +133,8 @@ func copyBetween2Storages(t *CopyTask, srcStorage, dstStorage driver.Driver, src
 		return errors.WithMessagef(err, "failed get src [%s] file", srcObjPath)
 	}
 	if srcObj.IsDir() {
+		copyDavProps(utils.GetFullPath(srcStorage.GetStorage().MountPath, srcObjPath),
+			utils.GetFullPath(dstStorage.GetStorage().MountPath, stdpath.Join(dstDirPath, srcObj.GetName())))
 		t.Status = "src object is dir, listing objs"
 		objs, err := op.List(t.Ctx(), srcStorage, srcObjPath, model.ListArgs{})
 		if err != nil {
@@ -136,7 +146,7 @@ func copyBetween2Storages(t *CopyTask, srcStorage, dstStorage driver.Driver, src
 			}
 			srcObjPath := stdpath.Join(srcObjPath, obj.GetName())
 			dstObjPath := stdpath.Join(dstDirPath, srcObj.GetName())
-			CopyTaskManager.Add(&CopyTask{
+			subTask := &CopyTask{
 				TaskExtension: task.TaskExtension{
 					Creator: t.GetCreator(),
 				},
@@ -146,7 +156,9 @@ func copyBetween2Storages(t *CopyTask, srcStorage, dstStorage driver.Driver, src
 				DstDirPath:   dstObjPath,
 				SrcStorageMp: srcStorage.GetStorage().MountPath,
 				DstStorageMp: dstStorage.GetStorage().MountPath,
-			})
+			}
+			subTask.JoinPipeline(t.PipelineID)
+			CopyTaskManager.Add(subTask)
 		}
 		t.Status = "src object is dir, added all copy tasks of objs"
 		return nil
@@ -175,5 +187,10 @@ func copyFileBetween2Storages(tsk *CopyTask, srcStorage, dstStorage driver.Drive
 	if err != nil {
 		return errors.WithMessagef(err, "failed get [%s] stream", srcFilePath)
 	}
-	return op.Put(tsk.Ctx(), dstStorage, dstDirPath, ss, tsk.SetProgress, true)
+	err = op.Put(tsk.Ctx(), dstStorage, dstDirPath, ss, tsk.SetProgress, true)
+	if err == nil {
+		copyDavProps(utils.GetFullPath(srcStorage.GetStorage().MountPath, srcFilePath),
+			utils.GetFullPath(dstStorage.GetStorage().MountPath, stdpath.Join(dstDirPath, srcFile.GetName())))
+	}
+	return err
 }
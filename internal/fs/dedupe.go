@@ -0,0 +1,418 @@
+package fs
+
+import (
+	"context"
+	"fmt"
+	stdpath "path"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/task"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/xhofe/tache"
+)
+
+// wrapperDrivers show the files of other storages, deleting through them
+// deletes the wrapped file, so their files are never compared
+var wrapperDrivers = []string{"Alias", "Crypt", "Strm"}
+
+// TrashDir is the folder of a storage duplicates are moved to
+const TrashDir = "/.trash"
+
+// preferredHashTypes is the order hash types are picked in when the files of
+// a group report several of them
+var preferredHashTypes = []*utils.HashType{utils.SHA256, utils.SHA1, utils.MD5}
+
+// DedupeGroup is a set of files with the same size and hash
+type DedupeGroup struct {
+	Size     int64    `json:"size"`
+	HashType string   `json:"hash_type"`
+	Hash     string   `json:"hash"`
+	Paths    []string `json:"paths"`
+	// Reclaimable is the space freed by keeping a single copy
+	Reclaimable int64 `json:"reclaimable"`
+}
+
+// DedupeTask walks the given paths and groups the identical files, files are
+// grouped by size first and only the files sharing a size are compared by
+// hash, using the driver hashes or the catalog
+type DedupeTask struct {
+	task.TaskExtension
+	status string
+	Paths  []string `json:"paths"`
+	// MinSize skips the files smaller than it, empty files are always skipped
+	MinSize int64 `json:"min_size"`
+	// HashMissing computes the hashes of the files that can't be compared
+	// otherwise, and stores them in the catalog
+	HashMissing bool          `json:"hash_missing"`
+	Scanned     int           `json:"scanned"`
+	Unhashed    int           `json:"unhashed"`
+	Groups      []DedupeGroup `json:"groups"`
+	Reclaimable int64         `json:"reclaimable"`
+}
+
+var DedupeTaskManager *tache.Manager[*DedupeTask]
+
+type dedupeFile struct {
+	path string
+	obj  model.Obj
+	hash utils.HashInfo
+}
+
+func (t *DedupeTask) GetName() string {
+	return fmt.Sprintf("find duplicates in %v", t.Paths)
+}
+
+func (t *DedupeTask) GetStatus() string {
+	return t.status
+}
+
+func (t *DedupeTask) Run() error {
+	t.ReinitCtx()
+	t.ClearEndTime()
+	t.SetStartTime(time.Now())
+	defer func() { t.SetEndTime(time.Now()) }()
+	t.Scanned, t.Unhashed, t.Groups, t.Reclaimable = 0, 0, nil, 0
+
+	bySize := make(map[int64][]dedupeFile)
+	seen := make(map[string]struct{})
+	for _, p := range t.Paths {
+		root, err := Get(t.Ctx(), p, &GetArgs{NoLog: true})
+		if err != nil {
+			return errors.WithMessagef(err, "failed get [%s]", p)
+		}
+		err = WalkFS(t.Ctx(), -1, p, root, func(reqPath string, obj model.Obj) error {
+			if err := t.Ctx().Err(); err != nil {
+				return err
+			}
+			if !t.canAccess(reqPath) {
+				if obj.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if obj.IsDir() {
+				if isTrashDir(reqPath) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if obj.GetSize() <= 0 || obj.GetSize() < t.MinSize {
+				return nil
+			}
+			// the chosen paths may overlap, and a file may be reached by
+			// several paths, only one of them is compared
+			key, err := physicalFile(reqPath, obj)
+			if err != nil {
+				return nil
+			}
+			if _, ok := seen[key]; ok {
+				return nil
+			}
+			seen[key] = struct{}{}
+			t.Scanned++
+			t.status = fmt.Sprintf("scanned %d files", t.Scanned)
+			bySize[obj.GetSize()] = append(bySize[obj.GetSize()], dedupeFile{
+				path: reqPath,
+				obj:  obj,
+				hash: obj.GetHash(),
+			})
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	sizes := make([]int64, 0, len(bySize))
+	for size, files := range bySize {
+		if len(files) > 1 {
+			sizes = append(sizes, size)
+		}
+	}
+	for i, size := range sizes {
+		if err := t.Ctx().Err(); err != nil {
+			return err
+		}
+		t.status = fmt.Sprintf("comparing %d files of %d bytes", len(bySize[size]), size)
+		if err := t.compare(bySize[size]); err != nil {
+			return err
+		}
+		t.SetProgress(float64(i+1) / float64(len(sizes)) * 100)
+	}
+	sort.Slice(t.Groups, func(i, j int) bool {
+		if t.Groups[i].Reclaimable != t.Groups[j].Reclaimable {
+			return t.Groups[i].Reclaimable > t.Groups[j].Reclaimable
+		}
+		return t.Groups[i].Paths[0] < t.Groups[j].Paths[0]
+	})
+	t.status = fmt.Sprintf("found %d groups in %d files, %d bytes reclaimable", len(t.Groups), t.Scanned, t.Reclaimable)
+	t.SetProgress(100)
+	return nil
+}
+
+// canAccess reports whether the creator of the task can see the path, the
+// folders hidden from the creator or protected by a password are skipped
+func (t *DedupeTask) canAccess(reqPath string) bool {
+	user := t.GetCreator()
+	if user == nil {
+		return true
+	}
+	meta, err := op.GetNearestMeta(reqPath)
+	if err != nil && !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+		return false
+	}
+	return common.CheckPathLimitWithRoles(user, reqPath) && common.CanAccessWithRoles(user, meta, reqPath, "")
+}
+
+// compare groups the files of the same size by hash
+func (t *DedupeTask) compare(files []dedupeFile) error {
+	ht, all := pickHashType(files)
+	if !all && t.HashMissing {
+		for i := range files {
+			if files[i].hash.GetHash(utils.SHA256) != "" {
+				continue
+			}
+			hi, err := hashObject(t.Ctx(), files[i].path, files[i].obj, func(float64) {})
+			if err != nil {
+				if errors.Is(err, context.Canceled) {
+					return err
+				}
+				log.Warnf("failed hash [%s]: %+v", files[i].path, err)
+				continue
+			}
+			files[i].hash = hi
+			if !model.HasHash(files[i].obj) {
+				if err := saveFileHash(files[i].path, files[i].obj, hi); err != nil {
+					log.Errorf("failed save hash of [%s]: %+v", files[i].path, err)
+				}
+			}
+		}
+		ht = utils.SHA256
+	}
+	if ht == nil {
+		t.Unhashed += len(files)
+		return nil
+	}
+	byHash := make(map[string][]string)
+	for _, f := range files {
+		h := f.hash.GetHash(ht)
+		if h == "" {
+			t.Unhashed++
+			continue
+		}
+		byHash[h] = append(byHash[h], f.path)
+	}
+	size := files[0].obj.GetSize()
+	for h, paths := range byHash {
+		if len(paths) < 2 {
+			continue
+		}
+		sort.Strings(paths)
+		g := DedupeGroup{
+			Size:        size,
+			HashType:    ht.Name,
+			Hash:        h,
+			Paths:       paths,
+			Reclaimable: size * int64(len(paths)-1),
+		}
+		t.Groups = append(t.Groups, g)
+		t.Reclaimable += g.Reclaimable
+	}
+	return nil
+}
+
+// pickHashType returns the hash type reported for the most files, and
+// whether all the files have it
+func pickHashType(files []dedupeFile) (*utils.HashType, bool) {
+	counts := make(map[*utils.HashType]int)
+	for _, f := range files {
+		for ht, v := range f.hash.All() {
+			if v != "" {
+				counts[ht]++
+			}
+		}
+	}
+	var best *utils.HashType
+	rank := func(ht *utils.HashType) int {
+		for i, p := range preferredHashTypes {
+			if p == ht {
+				return i
+			}
+		}
+		return len(preferredHashTypes)
+	}
+	for ht, n := range counts {
+		if best == nil || n > counts[best] ||
+			(n == counts[best] && (rank(ht) < rank(best) || (rank(ht) == rank(best) && ht.Name < best.Name))) {
+			best = ht
+		}
+	}
+	if best == nil {
+		return nil, false
+	}
+	return best, counts[best] == len(files)
+}
+
+// FindDuplicates starts a task grouping the identical files under the paths
+func FindDuplicates(ctx context.Context, paths []string, minSize int64, hashMissing bool) (task.TaskExtensionInfo, error) {
+	if len(paths) == 0 {
+		return nil, errors.New("no path to search")
+	}
+	cleaned := make([]string, 0, len(paths))
+	for _, p := range paths {
+		cleaned = append(cleaned, utils.FixAndCleanPath(p))
+	}
+	creator, _ := ctx.Value("user").(*model.User)
+	t := &DedupeTask{
+		TaskExtension: task.TaskExtension{
+			Creator: creator,
+		},
+		Paths:       cleaned,
+		MinSize:     minSize,
+		HashMissing: hashMissing,
+	}
+	DedupeTaskManager.Add(t)
+	return t, nil
+}
+
+// MoveToTrash moves the object to the trash folder of its storage, keeping
+// its path under the trash folder
+func MoveToTrash(ctx context.Context, path string) error {
+	storage, actualPath, err := op.GetStorageAndActualPath(path)
+	if err != nil {
+		return errors.WithMessage(err, "failed get storage")
+	}
+	if utils.IsSubPath(TrashDir, actualPath) {
+		return errors.New("the object is in the trash already")
+	}
+	dstDir := stdpath.Join(TrashDir, stdpath.Dir(actualPath))
+	if err := op.MakeDir(ctx, storage, dstDir); err != nil {
+		return errors.WithMessagef(err, "failed make trash dir [%s]", dstDir)
+	}
+	return op.Move(ctx, storage, actualPath, dstDir)
+}
+
+// isTrashDir reports whether the path is the trash folder of its storage
+func isTrashDir(path string) bool {
+	storage, actualPath, err := op.GetStorageAndActualPath(path)
+	return err == nil && storage != nil && actualPath == TrashDir
+}
+
+// physicalFile returns a key naming the file stored at the path, two paths
+// with the same key are the same file, e.g. an account mounted twice. It
+// fails for the files of wrapper storages.
+func physicalFile(reqPath string, obj model.Obj) (string, error) {
+	storage, actualPath, err := op.GetStorageAndActualPath(reqPath)
+	if err != nil {
+		return "", errors.WithMessage(err, "failed get storage")
+	}
+	name := storage.Config().Name
+	if utils.SliceContains(wrapperDrivers, name) {
+		return "", errors.Errorf("[%s] is shown by the %s storage %s", reqPath, name, storage.GetStorage().MountPath)
+	}
+	if r, ok := storage.(driver.IRootPath); ok && name == "Local" {
+		return name + ":" + filepath.Join(r.GetRootPath(), actualPath), nil
+	}
+	if id := obj.GetID(); id != "" {
+		return name + ":id:" + id, nil
+	}
+	return fmt.Sprintf("%d:%s", storage.GetStorage().ID, actualPath), nil
+}
+
+// CheckDuplicate makes sure that the victim is still a copy of the kept file
+// right before acting on it: both must still exist, be distinct files of
+// storages that aren't wrappers, and have the size and the hash of the group
+func CheckDuplicate(ctx context.Context, g *DedupeGroup, kept, victim string) error {
+	if !utils.SliceContains(g.Paths, kept) || !utils.SliceContains(g.Paths, victim) {
+		return errors.New("the path is not in the group")
+	}
+	keptKey, err := checkGroupFile(ctx, g, kept)
+	if err != nil {
+		return err
+	}
+	victimKey, err := checkGroupFile(ctx, g, victim)
+	if err != nil {
+		return err
+	}
+	if keptKey == victimKey {
+		return errors.Errorf("[%s] and [%s] are the same file", kept, victim)
+	}
+	return nil
+}
+
+// checkGroupFile checks that the file at the path still has the size and the
+// hash of the group, and returns its physical key
+func checkGroupFile(ctx context.Context, g *DedupeGroup, path string) (string, error) {
+	// the cached listing may be older than the report
+	_, _ = List(ctx, stdpath.Dir(path), &ListArgs{Refresh: true, NoLog: true})
+	obj, err := Get(ctx, path, &GetArgs{NoLog: true})
+	if err != nil {
+		return "", errors.WithMessagef(err, "failed get [%s]", path)
+	}
+	if obj.IsDir() || obj.GetSize() != g.Size {
+		return "", errors.Errorf("[%s] changed since the report", path)
+	}
+	key, err := physicalFile(path, obj)
+	if err != nil {
+		return "", err
+	}
+	ht := utils.GetHashByName(g.HashType)
+	if ht == nil {
+		return "", errors.Errorf("unknown hash type %s", g.HashType)
+	}
+	h := obj.GetHash().GetHash(ht)
+	if h == "" {
+		if !utils.SliceContains(hashTypes, ht) {
+			return "", errors.Errorf("can't verify the %s hash of [%s]", g.HashType, path)
+		}
+		hi, err := hashObject(ctx, path, obj, func(float64) {})
+		if err != nil {
+			return "", errors.WithMessagef(err, "failed hash [%s]", path)
+		}
+		h = hi.GetHash(ht)
+	}
+	if h != g.Hash {
+		return "", errors.Errorf("[%s] changed since the report", path)
+	}
+	return key, nil
+}
+
+// ReplaceWithLink replaces the file with a link to the url, on the storages
+// that can store links. The link is written under a temporary name first so
+// that the file is only removed once its replacement exists.
+func ReplaceWithLink(ctx context.Context, path, url string) error {
+	storage, actualPath, err := op.GetStorageAndActualPath(path)
+	if err != nil {
+		return errors.WithMessage(err, "failed get storage")
+	}
+	_, ok := storage.(driver.PutURL)
+	_, okResult := storage.(driver.PutURLResult)
+	if !ok && !okResult {
+		return errs.NotImplement
+	}
+	dir, name := stdpath.Split(actualPath)
+	tmpName := fmt.Sprintf(".%s.link-%d", name, time.Now().UnixNano())
+	if err := op.PutURL(ctx, storage, dir, tmpName, url); err != nil {
+		return errors.WithMessage(err, "failed put link")
+	}
+	tmpPath := stdpath.Join(dir, tmpName)
+	if err := op.Remove(ctx, storage, actualPath); err != nil {
+		if err2 := op.Remove(ctx, storage, tmpPath); err2 != nil {
+			log.Errorf("failed remove temporary link [%s]: %+v", tmpPath, err2)
+		}
+		return err
+	}
+	if err := op.Rename(ctx, storage, tmpPath, name); err != nil {
+		return errors.WithMessagef(err, "the file is replaced by the link [%s] but it can't be renamed", stdpath.Join(stdpath.Dir(path), tmpName))
+	}
+	return nil
+}
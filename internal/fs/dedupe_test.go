@@ -0,0 +1,99 @@
+package fs_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	_ "github.com/alist-org/alist/v3/drivers/alias"
+	_ "github.com/alist-org/alist/v3/drivers/local"
+	"github.com/alist-org/alist/v3/internal/db/dbtest"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+)
+
+func init() {
+	dbtest.Init()
+}
+
+func writeFile(t *testing.T, path, content string) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDedupe(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "a", "x.bin"), "hello world")
+	writeFile(t, filepath.Join(dir, "b", "y.bin"), "hello world")
+	writeFile(t, filepath.Join(dir, "c", "z.bin"), "hello WORLD")
+	writeFile(t, filepath.Join(dir, "d", "w.bin"), "unique content")
+	ctx := context.Background()
+	// the same folder is mounted twice and shown by an alias
+	for _, s := range []model.Storage{
+		{Driver: "Local", MountPath: "/dedupe", Addition: fmt.Sprintf(`{"root_folder_path":%q}`, dir)},
+		{Driver: "Local", MountPath: "/dedupe2", Addition: fmt.Sprintf(`{"root_folder_path":%q}`, dir)},
+		{Driver: "Alias", MountPath: "/dedupe_alias", Addition: `{"paths":"/dedupe"}`},
+	} {
+		if _, err := op.CreateStorage(ctx, s); err != nil {
+			t.Fatalf("create storage %s: %+v", s.MountPath, err)
+		}
+	}
+
+	task := &fs.DedupeTask{Paths: []string{"/dedupe", "/dedupe2", "/dedupe_alias"}, HashMissing: true}
+	task.SetCtx(ctx)
+	if err := task.Run(); err != nil {
+		t.Fatalf("run: %+v", err)
+	}
+	if len(task.Groups) != 1 {
+		t.Fatalf("expected a single group, got %+v", task.Groups)
+	}
+	g := task.Groups[0]
+	if len(g.Paths) != 2 || g.Paths[0] != "/dedupe/a/x.bin" || g.Paths[1] != "/dedupe/b/y.bin" {
+		t.Fatalf("expected x.bin and y.bin of the first mount, got %v", g.Paths)
+	}
+	if g.Reclaimable != 11 || task.Reclaimable != 11 {
+		t.Errorf("expected 11 bytes reclaimable, got %d", task.Reclaimable)
+	}
+
+	if err := fs.CheckDuplicate(ctx, &g, "/dedupe/a/x.bin", "/dedupe/b/y.bin"); err != nil {
+		t.Errorf("expected the duplicate to be confirmed: %+v", err)
+	}
+	same := g
+	same.Paths = []string{"/dedupe/a/x.bin", "/dedupe2/a/x.bin", "/dedupe_alias/a/x.bin"}
+	if err := fs.CheckDuplicate(ctx, &same, "/dedupe/a/x.bin", "/dedupe2/a/x.bin"); err == nil {
+		t.Errorf("expected a file mounted twice not to be its own duplicate")
+	}
+	if err := fs.CheckDuplicate(ctx, &same, "/dedupe/a/x.bin", "/dedupe_alias/a/x.bin"); err == nil {
+		t.Errorf("expected the files shown by an alias to be refused")
+	}
+
+	// local storages can't store links, the file must survive the attempt
+	if err := fs.ReplaceWithLink(ctx, "/dedupe/b/y.bin", "https://example.com/x.bin"); err == nil {
+		t.Errorf("expected links to be refused by a local storage")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "b", "y.bin")); err != nil {
+		t.Errorf("expected the file to be kept when it can't be replaced: %v", err)
+	}
+
+	// the victim changed since the report
+	victim := filepath.Join(dir, "b", "y.bin")
+	writeFile(t, victim, "HELLO world")
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(victim, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if err := fs.CheckDuplicate(ctx, &g, "/dedupe/a/x.bin", "/dedupe/b/y.bin"); err == nil {
+		t.Errorf("expected a changed file to be refused")
+	}
+	if _, err := os.Stat(victim); err != nil {
+		t.Errorf("expected the changed file to be kept: %v", err)
+	}
+}
@@ -4,6 +4,9 @@ import (
 	"context"
 	log "github.com/sirupsen/logrus"
 	"io"
+	stdpath "path"
+	"strings"
+	"time"
 
 	"github.com/alist-org/alist/v3/internal/driver"
 	"github.com/alist-org/alist/v3/internal/errs"
@@ -69,6 +72,8 @@ func Move(ctx context.Context, srcPath, dstDirPath string, lazyCache ...bool) er
 	err := move(ctx, srcPath, dstDirPath, lazyCache...)
 	if err != nil {
 		log.Errorf("failed move %s to %s: %+v", srcPath, dstDirPath, err)
+	} else {
+		task.AddPipelineOutput(ctx, stdpath.Join(dstDirPath, stdpath.Base(srcPath)))
 	}
 	return err
 }
@@ -77,6 +82,8 @@ func Copy(ctx context.Context, srcObjPath, dstDirPath string, lazyCache ...bool)
 	res, err := _copy(ctx, srcObjPath, dstDirPath, lazyCache...)
 	if err != nil {
 		log.Errorf("failed copy %s to %s: %+v", srcObjPath, dstDirPath, err)
+	} else {
+		task.AddPipelineOutput(ctx, stdpath.Join(dstDirPath, stdpath.Base(srcObjPath)))
 	}
 	return res, err
 }
@@ -85,6 +92,8 @@ func Rename(ctx context.Context, srcPath, dstName string, lazyCache ...bool) err
 	err := rename(ctx, srcPath, dstName, lazyCache...)
 	if err != nil {
 		log.Errorf("failed rename %s to %s: %+v", srcPath, dstName, err)
+	} else {
+		task.AddPipelineOutput(ctx, stdpath.Join(stdpath.Dir(srcPath), dstName))
 	}
 	return err
 }
@@ -97,6 +106,14 @@ func Remove(ctx context.Context, path string) error {
 	return err
 }
 
+func SetModTime(ctx context.Context, path string, modTime time.Time) error {
+	err := setModTime(ctx, path, modTime)
+	if err != nil && !errors.Is(err, errs.NotImplement) {
+		log.Errorf("failed set mod time of %s: %+v", path, err)
+	}
+	return err
+}
+
 func PutDirectly(ctx context.Context, dstDirPath string, file model.FileStreamer, lazyCache ...bool) error {
 	err := putDirectly(ctx, dstDirPath, file, lazyCache...)
 	if err != nil {
@@ -133,6 +150,11 @@ func ArchiveDecompress(ctx context.Context, srcObjPath, dstDirPath string, args
 	t, err := archiveDecompress(ctx, srcObjPath, dstDirPath, args, lazyCache...)
 	if err != nil {
 		log.Errorf("failed decompress [%s]%s: %+v", srcObjPath, args.InnerPath, err)
+	} else if args.PutIntoNewDir {
+		name := stdpath.Base(srcObjPath)
+		task.AddPipelineOutput(ctx, stdpath.Join(dstDirPath, strings.TrimSuffix(name, stdpath.Ext(name))))
+	} else {
+		task.AddPipelineOutputDir(ctx, dstDirPath)
 	}
 	return t, err
 }
@@ -35,5 +35,9 @@ func get(ctx context.Context, path string) (model.Obj, error) {
 		}
 		return nil, errors.WithMessage(err, "failed get storage")
 	}
-	return op.Get(ctx, storage, actualPath)
+	obj, err := op.Get(ctx, storage, actualPath)
+	if err != nil {
+		return nil, err
+	}
+	return op.WithStoredHash(path, obj), nil
 }
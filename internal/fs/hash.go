@@ -0,0 +1,142 @@
+package fs
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	stdpath "path"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/stream"
+	"github.com/alist-org/alist/v3/internal/task"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/xhofe/tache"
+)
+
+// hashTypes are the hashes computed for the catalog
+var hashTypes = []*utils.HashType{utils.MD5, utils.SHA1, utils.SHA256}
+
+// HashTask walks a path and stores the hashes of the files whose driver
+// reports none and which are not in the catalog yet
+type HashTask struct {
+	task.TaskExtension
+	status  string
+	Path    string `json:"path"`
+	Hashed  int    `json:"hashed"`
+	Skipped int    `json:"skipped"`
+	Failed  int    `json:"failed"`
+}
+
+var HashTaskManager *tache.Manager[*HashTask]
+
+func (t *HashTask) GetName() string {
+	return fmt.Sprintf("hash [%s]", t.Path)
+}
+
+func (t *HashTask) GetStatus() string {
+	return t.status
+}
+
+func (t *HashTask) Run() error {
+	t.ReinitCtx()
+	t.ClearEndTime()
+	t.SetStartTime(time.Now())
+	defer func() { t.SetEndTime(time.Now()) }()
+	t.Hashed, t.Skipped, t.Failed = 0, 0, 0
+
+	root, err := Get(t.Ctx(), t.Path, &GetArgs{NoLog: true})
+	if err != nil {
+		return errors.WithMessagef(err, "failed get [%s]", t.Path)
+	}
+	err = WalkFS(t.Ctx(), -1, t.Path, root, func(reqPath string, obj model.Obj) error {
+		if err := t.Ctx().Err(); err != nil {
+			return err
+		}
+		if obj.IsDir() {
+			return nil
+		}
+		if model.HasHash(obj) {
+			t.Skipped++
+			return nil
+		}
+		t.status = fmt.Sprintf("hashing %s", reqPath)
+		if err := t.hashFile(reqPath, obj); err != nil {
+			if errors.Is(err, context.Canceled) {
+				return err
+			}
+			log.Warnf("failed hash [%s]: %+v", reqPath, err)
+			t.Failed++
+			return nil
+		}
+		t.Hashed++
+		return nil
+	})
+	t.status = fmt.Sprintf("hashed %d files, skipped %d, failed %d", t.Hashed, t.Skipped, t.Failed)
+	t.SetProgress(100)
+	return err
+}
+
+func (t *HashTask) hashFile(reqPath string, obj model.Obj) error {
+	hi, err := hashObject(t.Ctx(), reqPath, obj, t.SetProgress)
+	if err != nil {
+		return err
+	}
+	return saveFileHash(reqPath, obj, hi)
+}
+
+// hashObject reads the file at reqPath and computes the hashes of the catalog
+func hashObject(ctx context.Context, reqPath string, obj model.Obj, up model.UpdateProgress) (utils.HashInfo, error) {
+	storage, actualPath, err := op.GetStorageAndActualPath(reqPath)
+	if err != nil {
+		return utils.HashInfo{}, err
+	}
+	link, _, err := op.Link(ctx, storage, actualPath, model.LinkArgs{
+		Header: http.Header{},
+	})
+	if err != nil {
+		return utils.HashInfo{}, err
+	}
+	ss, err := stream.NewSeekableStream(stream.FileStream{Obj: obj, Ctx: ctx}, link)
+	if err != nil {
+		return utils.HashInfo{}, err
+	}
+	defer ss.Close()
+	hasher := utils.NewMultiHasher(hashTypes)
+	if err := utils.CopyWithCtx(ctx, hasher, ss, obj.GetSize(), up); err != nil {
+		return utils.HashInfo{}, err
+	}
+	if hasher.Size() != obj.GetSize() {
+		return utils.HashInfo{}, fmt.Errorf("read %d bytes of %d", hasher.Size(), obj.GetSize())
+	}
+	return *hasher.GetHashInfo(), nil
+}
+
+func saveFileHash(reqPath string, obj model.Obj, hi utils.HashInfo) error {
+	return op.SaveFileHash(&model.FileHash{
+		Parent:   stdpath.Dir(reqPath),
+		Name:     obj.GetName(),
+		Size:     obj.GetSize(),
+		Modified: obj.ModTime(),
+		MD5:      hi.GetHash(utils.MD5),
+		SHA1:     hi.GetHash(utils.SHA1),
+		SHA256:   hi.GetHash(utils.SHA256),
+	})
+}
+
+// HashFiles starts a task storing the hashes of the files under the path
+func HashFiles(ctx context.Context, path string) (task.TaskExtensionInfo, error) {
+	path = utils.FixAndCleanPath(path)
+	creator, _ := ctx.Value("user").(*model.User)
+	t := &HashTask{
+		TaskExtension: task.TaskExtension{
+			Creator: creator,
+		},
+		Path: path,
+	}
+	HashTaskManager.Add(t)
+	return t, nil
+}
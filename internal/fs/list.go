@@ -42,7 +42,7 @@ func list(ctx context.Context, path string, args *ListArgs) ([]model.Obj, error)
 		om.InitHideReg(meta.Hide)
 	}
 	objs := om.Merge(_objs, virtualFiles...)
-	return objs, nil
+	return op.WithStoredHashes(path, objs), nil
 }
 
 func whetherHide(user *model.User, meta *model.Meta, path string) bool {
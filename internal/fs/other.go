@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	stdpath "path"
 	"strings"
+	"time"
 
 	"github.com/alist-org/alist/v3/drivers/s3"
 	"github.com/alist-org/alist/v3/internal/errs"
@@ -12,6 +13,7 @@ import (
 	"github.com/alist-org/alist/v3/internal/op"
 	"github.com/alist-org/alist/v3/internal/task"
 	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
 )
 
 func makeDir(ctx context.Context, path string, lazyCache ...bool) error {
@@ -34,7 +36,11 @@ func move(ctx context.Context, srcPath, dstDirPath string, lazyCache ...bool) er
 	if srcStorage.GetStorage() != dstStorage.GetStorage() {
 		return errors.WithStack(errs.MoveBetweenTwoStorages)
 	}
-	return op.Move(ctx, srcStorage, srcActualPath, dstDirActualPath, lazyCache...)
+	err = op.Move(ctx, srcStorage, srcActualPath, dstDirActualPath, lazyCache...)
+	if err == nil {
+		moveDavProps(srcPath, stdpath.Join(dstDirPath, stdpath.Base(srcPath)))
+	}
+	return err
 }
 
 func rename(ctx context.Context, srcPath, dstName string, lazyCache ...bool) error {
@@ -42,7 +48,11 @@ func rename(ctx context.Context, srcPath, dstName string, lazyCache ...bool) err
 	if err != nil {
 		return errors.WithMessage(err, "failed get storage")
 	}
-	return op.Rename(ctx, storage, srcActualPath, dstName, lazyCache...)
+	err = op.Rename(ctx, storage, srcActualPath, dstName, lazyCache...)
+	if err == nil {
+		moveDavProps(srcPath, stdpath.Join(stdpath.Dir(srcPath), dstName))
+	}
+	return err
 }
 
 func remove(ctx context.Context, path string) error {
@@ -50,7 +60,21 @@ func remove(ctx context.Context, path string) error {
 	if err != nil {
 		return errors.WithMessage(err, "failed get storage")
 	}
-	return op.Remove(ctx, storage, actualPath)
+	err = op.Remove(ctx, storage, actualPath)
+	if err == nil {
+		if err := op.DeleteDavProps(path); err != nil {
+			log.Warnf("failed delete dav props of %s: %+v", path, err)
+		}
+	}
+	return err
+}
+
+func setModTime(ctx context.Context, path string, modTime time.Time) error {
+	storage, actualPath, err := op.GetStorageAndActualPath(path)
+	if err != nil {
+		return errors.WithMessage(err, "failed get storage")
+	}
+	return op.SetModTime(ctx, storage, actualPath, modTime)
 }
 
 func other(ctx context.Context, args model.FsOtherArgs) (interface{}, error) {
@@ -93,3 +117,15 @@ func other(ctx context.Context, args model.FsOtherArgs) (interface{}, error) {
 	args.Path = actualPath
 	return op.Other(ctx, storage, args)
 }
+
+func moveDavProps(srcPath, dstPath string) {
+	if err := op.MoveDavProps(srcPath, dstPath); err != nil {
+		log.Warnf("failed move dav props of %s to %s: %+v", srcPath, dstPath, err)
+	}
+}
+
+func copyDavProps(srcPath, dstPath string) {
+	if err := op.CopyDavProps(srcPath, dstPath); err != nil {
+		log.Warnf("failed copy dav props of %s to %s: %+v", srcPath, dstPath, err)
+	}
+}
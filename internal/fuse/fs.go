@@ -1,6 +1,14 @@
 package fuse
 
-import "github.com/winfsp/cgofuse/fuse"
+import (
+	"context"
+	stdpath "path"
+
+	"github.com/alist-org/alist/v3/internal/errs"
+	alistfs "github.com/alist-org/alist/v3/internal/fs"
+	"github.com/pkg/errors"
+	"github.com/winfsp/cgofuse/fuse"
+)
 
 type Fs struct {
 	RootFolder string
@@ -72,9 +80,22 @@ func (fs *Fs) Chown(path string, uid uint32, gid uint32) int {
 	panic("implement me")
 }
 
+// Utimens sets the modification time, the access time isn't kept by the storages
 func (fs *Fs) Utimens(path string, tmsp []fuse.Timespec) int {
-	//TODO implement me
-	panic("implement me")
+	if len(tmsp) < 2 {
+		return -fuse.EINVAL
+	}
+	err := alistfs.SetModTime(context.Background(), stdpath.Join(fs.RootFolder, path), tmsp[1].Time())
+	switch {
+	case err == nil:
+		return 0
+	case errs.IsObjectNotFound(err), errors.Is(err, errs.StorageNotFound):
+		return -fuse.ENOENT
+	case errors.Is(err, errs.NotImplement), errors.Is(err, errs.NotSupport):
+		return -fuse.ENOSYS
+	default:
+		return -fuse.EIO
+	}
 }
 
 func (fs *Fs) Access(path string, mask uint32) int {
@@ -0,0 +1,110 @@
+package idp
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"net/url"
+
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/pkg/utils/random"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrInvalidClient      = errors.New("invalid client")
+	ErrInvalidRedirectURI = errors.New("redirect_uri is not registered for the client")
+)
+
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func validateClient(c *model.OAuthClient) error {
+	if len(c.RedirectURIs) == 0 {
+		return errors.New("at least one redirect uri is required")
+	}
+	for _, uri := range c.RedirectURIs {
+		u, err := url.Parse(uri)
+		if err != nil || !u.IsAbs() || u.Fragment != "" {
+			return errors.Errorf("invalid redirect uri: %s", uri)
+		}
+	}
+	return nil
+}
+
+// CreateClient creates the client with a generated client id and returns its secret,
+// the secret is only stored as a hash and public clients have none
+func CreateClient(c *model.OAuthClient) (string, error) {
+	if err := validateClient(c); err != nil {
+		return "", err
+	}
+	c.ID = 0
+	c.ClientID = random.String(24)
+	secret := ""
+	if !c.Public {
+		secret = random.String(48)
+		c.SecretHash = hashSecret(secret)
+	}
+	return secret, db.CreateOAuthClient(c)
+}
+
+func UpdateClient(c *model.OAuthClient) error {
+	if err := validateClient(c); err != nil {
+		return err
+	}
+	old, err := db.GetOAuthClientByID(c.ID)
+	if err != nil {
+		return err
+	}
+	c.ClientID, c.SecretHash, c.CreatedAt = old.ClientID, old.SecretHash, old.CreatedAt
+	if c.Public {
+		c.SecretHash = ""
+	}
+	return db.UpdateOAuthClient(c)
+}
+
+// ResetClientSecret replaces the secret of a confidential client and returns the new one
+func ResetClientSecret(id uint) (string, error) {
+	c, err := db.GetOAuthClientByID(id)
+	if err != nil {
+		return "", err
+	}
+	if c.Public {
+		return "", errors.New("public client has no secret")
+	}
+	secret := random.String(48)
+	c.SecretHash = hashSecret(secret)
+	return secret, db.UpdateOAuthClient(c)
+}
+
+// GetClient returns the enabled client of clientID
+func GetClient(clientID string) (*model.OAuthClient, error) {
+	c, err := db.GetOAuthClientByClientID(clientID)
+	if err != nil || c.Disabled {
+		return nil, ErrInvalidClient
+	}
+	return c, nil
+}
+
+// AuthenticateClient checks the secret of a confidential client, public clients are identified by their id only
+func AuthenticateClient(clientID, secret string) (*model.OAuthClient, error) {
+	c, err := GetClient(clientID)
+	if err != nil {
+		return nil, err
+	}
+	if c.Public {
+		return c, nil
+	}
+	if secret == "" || subtle.ConstantTimeCompare([]byte(hashSecret(secret)), []byte(c.SecretHash)) != 1 {
+		return nil, ErrInvalidClient
+	}
+	return c, nil
+}
+
+func ValidRedirectURI(c *model.OAuthClient, uri string) bool {
+	return utils.SliceContains(c.RedirectURIs, uri)
+}
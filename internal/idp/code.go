@@ -0,0 +1,57 @@
+package idp
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils/random"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// codeExpiration is how long the client has to exchange an authorization code
+const codeExpiration = 5 * time.Minute
+
+var ErrInvalidGrant = errors.New("invalid or expired authorization code")
+
+// CreateCode stores the authorization and returns its code, the code is stored
+// in the database so any instance of a cluster can exchange it
+func CreateCode(c *model.OAuthCode) (string, error) {
+	if err := db.DeleteExpiredOAuthCodes(); err != nil {
+		log.Warnf("failed delete expired oauth codes: %+v", err)
+	}
+	code := random.String(43)
+	c.CodeHash = hashSecret(code)
+	c.ExpiresAt = time.Now().Add(codeExpiration)
+	return code, db.CreateOAuthCode(c)
+}
+
+// ExchangeCode takes the code for the client, checks it's issued to the redirect uri and verifies PKCE
+func ExchangeCode(code string, client *model.OAuthClient, redirectURI, verifier string) (*model.OAuthCode, error) {
+	c, err := db.TakeOAuthCode(hashSecret(code))
+	if err != nil {
+		return nil, ErrInvalidGrant
+	}
+	if time.Now().After(c.ExpiresAt) || c.ClientID != client.ClientID || c.RedirectURI != redirectURI {
+		return nil, ErrInvalidGrant
+	}
+	if c.CodeChallenge != "" || verifier != "" {
+		if !VerifyPKCE(c.CodeChallenge, verifier) {
+			return nil, errors.New("code_verifier doesn't match the code_challenge")
+		}
+	}
+	return c, nil
+}
+
+// VerifyPKCE checks the verifier against a S256 challenge, the plain method isn't supported
+func VerifyPKCE(challenge, verifier string) bool {
+	if challenge == "" || len(verifier) < 43 || len(verifier) > 128 {
+		return false
+	}
+	sum := sha256.Sum256([]byte(verifier))
+	return subtle.ConstantTimeCompare([]byte(base64.RawURLEncoding.EncodeToString(sum[:])), []byte(challenge)) == 1
+}
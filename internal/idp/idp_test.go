@@ -0,0 +1,107 @@
+package idp_test
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"testing"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/db/dbtest"
+	"github.com/alist-org/alist/v3/internal/idp"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+)
+
+func init() {
+	dbtest.Init()
+	if err := op.SaveSettingItem(&model.SettingItem{Key: conf.OIDCProviderSigningKey, Type: conf.TypeText, Flag: model.PRIVATE}); err != nil {
+		panic(err)
+	}
+}
+
+func challenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func TestVerifyPKCE(t *testing.T) {
+	verifier := "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	if !idp.VerifyPKCE(challenge(verifier), verifier) {
+		t.Error("matching verifier must pass")
+	}
+	if idp.VerifyPKCE(challenge(verifier), verifier+"x") {
+		t.Error("other verifier must fail")
+	}
+	if idp.VerifyPKCE("", verifier) {
+		t.Error("missing challenge must fail")
+	}
+}
+
+func TestAuthorizationCodeFlow(t *testing.T) {
+	role := model.Role{Name: "editors"}
+	if err := db.CreateRole(&role); err != nil {
+		t.Fatal(err)
+	}
+	user := model.User{Username: "oidc", BasePath: "/team", Role: model.Roles{int(role.ID)}, Authn: "[]"}
+	if err := db.CreateUser(&user); err != nil {
+		t.Fatal(err)
+	}
+	client := model.OAuthClient{Name: "wiki", Public: true, RedirectURIs: model.OAuthRedirectURIs{"https://wiki.example.com/callback"}}
+	if _, err := idp.CreateClient(&client); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := idp.AuthenticateClient(client.ClientID, ""); err != nil {
+		t.Fatalf("public client must not need a secret: %+v", err)
+	}
+	verifier := "a-verifier-that-is-long-enough-to-be-valid-for-pkce"
+	code, err := idp.CreateCode(&model.OAuthCode{
+		ClientID:      client.ClientID,
+		UserID:        user.ID,
+		RedirectURI:   "https://wiki.example.com/callback",
+		Scope:         idp.FilterScope("openid profile email"),
+		Nonce:         "n-0S6_WzA2Mj",
+		CodeChallenge: challenge(verifier),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := idp.ExchangeCode(code, &client, "https://wiki.example.com/callback", "wrong"); err == nil {
+		t.Fatal("wrong verifier must fail")
+	}
+	// the failed exchange used the code up
+	if _, err := idp.ExchangeCode(code, &client, "https://wiki.example.com/callback", verifier); err == nil {
+		t.Fatal("code must only be exchanged once")
+	}
+	code, _ = idp.CreateCode(&model.OAuthCode{
+		ClientID:      client.ClientID,
+		UserID:        user.ID,
+		RedirectURI:   "https://wiki.example.com/callback",
+		Scope:         "openid profile",
+		CodeChallenge: challenge(verifier),
+	})
+	exchanged, err := idp.ExchangeCode(code, &client, "https://wiki.example.com/callback", verifier)
+	if err != nil {
+		t.Fatalf("failed exchange code: %+v", err)
+	}
+	resp, err := idp.IssueTokens("https://files.example.com", exchanged, &user)
+	if err != nil {
+		t.Fatalf("failed issue tokens: %+v", err)
+	}
+	if resp.IDToken == "" {
+		t.Fatal("id token must be issued for the openid scope")
+	}
+	claims, err := idp.ParseAccessToken("https://files.example.com", resp.AccessToken)
+	if err != nil {
+		t.Fatalf("failed parse access token: %+v", err)
+	}
+	if claims.BasePath != "/team" || len(claims.Roles) != 1 || claims.Roles[0] != "editors" || claims.PreferredUsername != "oidc" {
+		t.Errorf("unexpected claims: %+v", claims)
+	}
+	if _, err := idp.ParseAccessToken("https://files.example.com", resp.IDToken); err == nil {
+		t.Error("id token must not be accepted as an access token")
+	}
+	if _, err := idp.ParseAccessToken("https://other.example.com", resp.AccessToken); err == nil {
+		t.Error("token of another issuer must fail")
+	}
+}
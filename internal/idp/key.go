@@ -0,0 +1,100 @@
+package idp
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"sync"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/pkg/errors"
+)
+
+type signingKey struct {
+	pem string
+	key *rsa.PrivateKey
+	kid string
+}
+
+var (
+	keyMu  sync.Mutex
+	cached *signingKey
+)
+
+// getSigningKey returns the key in the setting, which may be changed by the admin or another
+// instance, so it's parsed again when it changes. A key is generated if there is none.
+func getSigningKey() (*signingKey, error) {
+	keyMu.Lock()
+	defer keyMu.Unlock()
+	value := setting.GetStr(conf.OIDCProviderSigningKey)
+	if cached != nil && cached.pem == value {
+		return cached, nil
+	}
+	if value == "" {
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		value = string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}))
+		item, err := op.GetSettingItemByKey(conf.OIDCProviderSigningKey)
+		if err != nil {
+			return nil, err
+		}
+		item.Value = value
+		if err := op.SaveSettingItem(item); err != nil {
+			return nil, err
+		}
+	}
+	key, err := parseKey(value)
+	if err != nil {
+		return nil, err
+	}
+	// the kid changes with the key, so the clients fetch the jwks again
+	sum := sha256.Sum256(x509.MarshalPKCS1PublicKey(&key.PublicKey))
+	cached = &signingKey{pem: value, key: key, kid: base64.RawURLEncoding.EncodeToString(sum[:12])}
+	return cached, nil
+}
+
+func parseKey(value string) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(value))
+	if block == nil {
+		return nil, errors.New("invalid oidc provider signing key: no pem block")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, errors.WithMessage(err, "invalid oidc provider signing key")
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("oidc provider signing key must be a RSA key")
+	}
+	return rsaKey, nil
+}
+
+// JWKS returns the json web key set the clients verify the tokens with
+func JWKS() (map[string]any, error) {
+	k, err := getSigningKey()
+	if err != nil {
+		return nil, err
+	}
+	pub := k.key.PublicKey
+	return map[string]any{
+		"keys": []map[string]any{{
+			"kty": "RSA",
+			"use": "sig",
+			"alg": "RS256",
+			"kid": k.kid,
+			"n":   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}},
+	}, nil
+}
@@ -0,0 +1,144 @@
+package idp
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+// accessTokenType is the typ header of the access tokens, so an id token can't be used as one
+const accessTokenType = "at+jwt"
+
+// Scopes are the scopes the provider supports, the others are ignored
+var Scopes = []string{"openid", "profile"}
+
+// Claims are the claims of the access and id tokens and the userinfo response
+type Claims struct {
+	Scope             string   `json:"scope,omitempty"`
+	Nonce             string   `json:"nonce,omitempty"`
+	AuthTime          int64    `json:"auth_time,omitempty"`
+	PreferredUsername string   `json:"preferred_username,omitempty"`
+	Name              string   `json:"name,omitempty"`
+	Roles             []string `json:"roles"`
+	BasePath          string   `json:"base_path"`
+	jwt.RegisteredClaims
+}
+
+// TokenResponse is the response of the token endpoint
+type TokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"`
+	IDToken     string `json:"id_token,omitempty"`
+	Scope       string `json:"scope"`
+}
+
+// FilterScope keeps the supported scopes of a requested scope
+func FilterScope(scope string) string {
+	var scopes []string
+	for _, s := range strings.Fields(scope) {
+		if utils.SliceContains(Scopes, s) && !utils.SliceContains(scopes, s) {
+			scopes = append(scopes, s)
+		}
+	}
+	return strings.Join(scopes, " ")
+}
+
+func hasScope(scope, s string) bool {
+	return utils.SliceContains(strings.Fields(scope), s)
+}
+
+// UserClaims returns the claims of the user, the role names and the base path are always included
+func UserClaims(user *model.User, scope string) (*Claims, error) {
+	roles, err := op.GetRolesByUserID(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	claims := &Claims{Roles: make([]string, 0, len(roles)), BasePath: user.BasePath}
+	for _, r := range roles {
+		claims.Roles = append(claims.Roles, r.Name)
+	}
+	if hasScope(scope, "profile") {
+		claims.PreferredUsername = user.Username
+		claims.Name = user.Username
+	}
+	claims.Subject = strconv.FormatUint(uint64(user.ID), 10)
+	return claims, nil
+}
+
+// IssueTokens issues the tokens of an exchanged code, the id token is only issued for the openid scope
+func IssueTokens(issuer string, code *model.OAuthCode, user *model.User) (*TokenResponse, error) {
+	k, err := getSigningKey()
+	if err != nil {
+		return nil, err
+	}
+	expiresIn := setting.GetInt(conf.OIDCProviderTokenExpiresIn, 3600)
+	if expiresIn <= 0 {
+		expiresIn = 3600
+	}
+	now := time.Now()
+	sign := func(claims *Claims, typ string) (string, error) {
+		claims.Issuer = issuer
+		claims.Audience = jwt.ClaimStrings{code.ClientID}
+		claims.IssuedAt = jwt.NewNumericDate(now)
+		claims.ExpiresAt = jwt.NewNumericDate(now.Add(time.Duration(expiresIn) * time.Second))
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+		token.Header["kid"] = k.kid
+		token.Header["typ"] = typ
+		s, err := token.SignedString(k.key)
+		return s, errors.WithStack(err)
+	}
+	access, err := UserClaims(user, code.Scope)
+	if err != nil {
+		return nil, err
+	}
+	access.Scope = code.Scope
+	access.ID = uuid.NewString()
+	resp := &TokenResponse{TokenType: "Bearer", ExpiresIn: expiresIn, Scope: code.Scope}
+	if resp.AccessToken, err = sign(access, accessTokenType); err != nil {
+		return nil, err
+	}
+	if hasScope(code.Scope, "openid") {
+		id, err := UserClaims(user, code.Scope)
+		if err != nil {
+			return nil, err
+		}
+		id.Nonce = code.Nonce
+		id.AuthTime = code.AuthTime.Unix()
+		if resp.IDToken, err = sign(id, "JWT"); err != nil {
+			return nil, err
+		}
+	}
+	return resp, nil
+}
+
+// ParseAccessToken verifies an access token issued by issuer and returns its claims
+func ParseAccessToken(issuer, tokenString string) (*Claims, error) {
+	k, err := getSigningKey()
+	if err != nil {
+		return nil, err
+	}
+	claims := &Claims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		if token.Method != jwt.SigningMethodRS256 {
+			return nil, errors.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return &k.key.PublicKey, nil
+	})
+	if err != nil || !token.Valid {
+		return nil, errors.New("invalid access token")
+	}
+	if token.Header["typ"] != accessTokenType || !claims.VerifyIssuer(issuer, true) {
+		return nil, errors.New("invalid access token")
+	}
+	return claims, nil
+}
@@ -0,0 +1,206 @@
+package metrics
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	ProtocolHTTP   = "http"
+	ProtocolWebDAV = "webdav"
+	ProtocolFTP    = "ftp"
+	ProtocolSFTP   = "sftp"
+	ProtocolS3     = "s3"
+	ProtocolMCP    = "mcp"
+)
+
+const (
+	DirectionProxied  = "proxied"
+	DirectionUploaded = "uploaded"
+)
+
+// Registry holds all metrics of alist, it's separated from the default one
+// so the metrics of imported libraries are not exposed by accident
+var Registry = prometheus.NewRegistry()
+
+var (
+	requests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "alist",
+		Name:      "requests_total",
+		Help:      "Requests handled, by protocol and status.",
+	}, []string{"protocol", "status"})
+	requestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "alist",
+		Name:      "request_duration_seconds",
+		Help:      "Latency of the requests, by protocol.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"protocol"})
+	driverCalls = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "alist",
+		Name:      "driver_calls_total",
+		Help:      "Calls of the storage drivers, by storage, operation and result.",
+	}, []string{"storage", "driver", "op", "result"})
+	driverCallDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "alist",
+		Name:      "driver_call_duration_seconds",
+		Help:      "Latency of the calls of the storage drivers.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"storage", "driver", "op"})
+	cacheLookups = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "alist",
+		Name:      "cache_lookups_total",
+		Help:      "Lookups of the list and link caches, by result.",
+	}, []string{"cache", "result"})
+	transferredBytes = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "alist",
+		Name:      "transferred_bytes_total",
+		Help:      "Bytes proxied to the clients and uploaded to the storages.",
+	}, []string{"direction"})
+	activeSessions = prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: "alist",
+		Name:      "active_sessions",
+		Help:      "Device sessions that are active.",
+	}, func() float64 {
+		n, err := db.CountActiveSessions()
+		if err != nil {
+			log.Warnf("failed count active sessions: %+v", err)
+		}
+		return float64(n)
+	})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		requests, requestDuration,
+		driverCalls, driverCallDuration,
+		cacheLookups, transferredBytes,
+		activeSessions,
+	)
+}
+
+// Handler serves the metrics in the Prometheus text format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
+
+// ObserveRequest records a request of protocol started at start, status is the http status
+// or "ok" / "error" for the protocols without one
+func ObserveRequest(protocol, status string, start time.Time) {
+	requests.WithLabelValues(protocol, status).Inc()
+	requestDuration.WithLabelValues(protocol).Observe(time.Since(start).Seconds())
+}
+
+// ObserveHTTPRequest is ObserveRequest with an http status code
+func ObserveHTTPRequest(protocol string, code int, start time.Time) {
+	ObserveRequest(protocol, strconv.Itoa(code), start)
+}
+
+// ObserveResult is ObserveRequest for the protocols reporting an error instead of a status
+func ObserveResult(protocol string, err error, start time.Time) {
+	ObserveRequest(protocol, result(err), start)
+}
+
+// ObserveDriverCall records a call of op to the driver of the storage mounted at storage
+func ObserveDriverCall(storage, driver, op string, err error, start time.Time) {
+	driverCalls.WithLabelValues(storage, driver, op, result(err)).Inc()
+	driverCallDuration.WithLabelValues(storage, driver, op).Observe(time.Since(start).Seconds())
+}
+
+func ObserveCache(cache string, hit bool) {
+	if hit {
+		cacheLookups.WithLabelValues(cache, "hit").Inc()
+	} else {
+		cacheLookups.WithLabelValues(cache, "miss").Inc()
+	}
+}
+
+func AddBytes(direction string, n int64) {
+	if n > 0 {
+		transferredBytes.WithLabelValues(direction).Add(float64(n))
+	}
+}
+
+func result(err error) string {
+	if err != nil {
+		return "error"
+	}
+	return "ok"
+}
+
+type proxiedWriter struct {
+	http.ResponseWriter
+}
+
+func (w proxiedWriter) Write(p []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(p)
+	AddBytes(DirectionProxied, int64(n))
+	return n, err
+}
+
+// ReadFrom keeps the sendfile of the underlying writer, the copied bytes are counted too
+func (w proxiedWriter) ReadFrom(r io.Reader) (int64, error) {
+	if rf, ok := w.ResponseWriter.(io.ReaderFrom); ok {
+		n, err := rf.ReadFrom(r)
+		AddBytes(DirectionProxied, n)
+		return n, err
+	}
+	// hide ReadFrom from io.Copy, or it would call it again
+	return io.Copy(struct{ io.Writer }{w}, r)
+}
+
+// Flush keeps the streaming responses working
+func (w proxiedWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w proxiedWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// CountProxied counts the bytes written to w as proxied
+func CountProxied(w http.ResponseWriter) http.ResponseWriter {
+	return proxiedWriter{ResponseWriter: w}
+}
+
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	w.status = code
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Flush keeps the streaming responses working
+func (w *statusWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// InstrumentHandler records the requests served by h as protocol, for the servers not built on gin
+func InstrumentHandler(protocol string, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		h.ServeHTTP(sw, r)
+		ObserveHTTPRequest(protocol, sw.status, start)
+	})
+}
@@ -0,0 +1,42 @@
+package metrics
+
+import (
+	"github.com/alist-org/alist/v3/internal/task"
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+	"github.com/xhofe/tache"
+)
+
+// TaskCounter counts the tasks of each type by state, it's called on every scrape
+// because the managers don't report their changes
+type TaskCounter func() map[string]map[tache.State]int
+
+type taskCollector struct {
+	desc  *prometheus.Desc
+	count TaskCounter
+}
+
+func (c *taskCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *taskCollector) Collect(ch chan<- prometheus.Metric) {
+	for typ, states := range c.count() {
+		for state, n := range states {
+			ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, float64(n), typ, task.StateName(state))
+		}
+	}
+}
+
+// RegisterTaskCounter exposes the tasks kept by the managers, the finished ones stay
+// until they are cleared, so the outcomes are the counts of the final states
+func RegisterTaskCounter(count TaskCounter) {
+	err := Registry.Register(&taskCollector{
+		desc: prometheus.NewDesc("alist_tasks", "Tasks in the managers, by type and state.",
+			[]string{"type", "state"}, nil),
+		count: count,
+	})
+	if err != nil {
+		log.Warnf("failed register task metrics: %+v", err)
+	}
+}
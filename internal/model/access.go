@@ -0,0 +1,158 @@
+package model
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// AccessContext describes where a request comes from. It is attached to a
+// per-request copy of the user and consulted by conditional permission
+// entries.
+type AccessContext struct {
+	Protocol string `json:"protocol"`
+	ClientIP string `json:"client_ip"`
+}
+
+// PermissionCondition restricts when a permission entry applies. Every
+// non-empty field must match.
+type PermissionCondition struct {
+	// CIDRs lists client address ranges, plain addresses are accepted too.
+	CIDRs []string `json:"cidrs,omitempty"`
+	// Protocols lists the frontends the entry applies to: http, webdav, ftp,
+	// sftp, s3 or mcp.
+	Protocols []string `json:"protocols,omitempty"`
+	// TimeStart and TimeEnd bound a daily window in server local time using
+	// the "15:04" layout. A window ending before it starts wraps midnight.
+	TimeStart string `json:"time_start,omitempty"`
+	TimeEnd   string `json:"time_end,omitempty"`
+	// Weekdays limits the entry to the given days, 0 being Sunday.
+	Weekdays []int `json:"weekdays,omitempty"`
+}
+
+func (c *PermissionCondition) Validate() error {
+	for _, s := range c.CIDRs {
+		if _, err := parseCIDR(s); err != nil {
+			return err
+		}
+	}
+	if (c.TimeStart == "") != (c.TimeEnd == "") {
+		return fmt.Errorf("time_start and time_end must be set together")
+	}
+	if c.TimeStart != "" {
+		if _, err := time.Parse("15:04", c.TimeStart); err != nil {
+			return fmt.Errorf("invalid time_start %q", c.TimeStart)
+		}
+		if _, err := time.Parse("15:04", c.TimeEnd); err != nil {
+			return fmt.Errorf("invalid time_end %q", c.TimeEnd)
+		}
+	}
+	for _, d := range c.Weekdays {
+		if d < 0 || d > 6 {
+			return fmt.Errorf("invalid weekday %d", d)
+		}
+	}
+	return nil
+}
+
+// Match reports whether the condition holds for ac at now. known is false
+// when ac lacks the protocol or client address a condition needs, in which
+// case matched tells whether the remaining conditions hold.
+func (c *PermissionCondition) Match(ac *AccessContext, now time.Time) (matched, known bool) {
+	known = true
+	if len(c.Weekdays) > 0 {
+		ok := false
+		for _, d := range c.Weekdays {
+			if time.Weekday(d) == now.Weekday() {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return false, true
+		}
+	}
+	if c.TimeStart != "" && !inWindow(c.TimeStart, c.TimeEnd, now) {
+		return false, true
+	}
+	if len(c.Protocols) > 0 {
+		if ac == nil || ac.Protocol == "" {
+			known = false
+		} else {
+			ok := false
+			for _, p := range c.Protocols {
+				if strings.EqualFold(p, ac.Protocol) {
+					ok = true
+					break
+				}
+			}
+			if !ok {
+				return false, true
+			}
+		}
+	}
+	if len(c.CIDRs) > 0 {
+		ip := clientIP(ac)
+		if ip == nil {
+			known = false
+		} else {
+			ok := false
+			for _, s := range c.CIDRs {
+				if n, err := parseCIDR(s); err == nil && n.Contains(ip) {
+					ok = true
+					break
+				}
+			}
+			if !ok {
+				return false, true
+			}
+		}
+	}
+	return true, known
+}
+
+func inWindow(start, end string, now time.Time) bool {
+	s, err1 := time.Parse("15:04", start)
+	e, err2 := time.Parse("15:04", end)
+	if err1 != nil || err2 != nil {
+		return false
+	}
+	cur := now.Hour()*60 + now.Minute()
+	from, to := s.Hour()*60+s.Minute(), e.Hour()*60+e.Minute()
+	if from <= to {
+		return cur >= from && cur < to
+	}
+	return cur >= from || cur < to
+}
+
+func parseCIDR(s string) (*net.IPNet, error) {
+	s = strings.TrimSpace(s)
+	if !strings.Contains(s, "/") {
+		ip := net.ParseIP(s)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid address %q", s)
+		}
+		bits := 128
+		if ip4 := ip.To4(); ip4 != nil {
+			ip, bits = ip4, 32
+		}
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+	}
+	_, n, err := net.ParseCIDR(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cidr %q", s)
+	}
+	return n, nil
+}
+
+func clientIP(ac *AccessContext) net.IP {
+	if ac == nil || ac.ClientIP == "" {
+		return nil
+	}
+	host := ac.ClientIP
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return net.ParseIP(host)
+}
@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+// ClusterEvent is an invalidation broadcast by an instance through the database
+type ClusterEvent struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Node      string    `json:"node" gorm:"size:64"`
+	Kind      string    `json:"kind" gorm:"size:32"`
+	Key       string    `json:"key" gorm:"column:event_key;type:text"`
+	CreatedAt time.Time `json:"created_at" gorm:"index"`
+}
+
+// ClusterLock is a lease held by an instance, other instances take it over after it expires
+type ClusterLock struct {
+	Name      string    `json:"name" gorm:"primaryKey;size:128"`
+	Owner     string    `json:"owner" gorm:"size:64"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
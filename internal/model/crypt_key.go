@@ -0,0 +1,29 @@
+package model
+
+import "time"
+
+// UserKey is the key pair of a user for the storages encrypted with per user
+// keys, the private key is wrapped with a key derived from the passphrase of
+// the user and never stored in clear
+type UserKey struct {
+	UserID            uint      `json:"user_id" gorm:"primaryKey;autoIncrement:false"`
+	PublicKey         string    `json:"public_key" gorm:"size:64;not null"`
+	Salt              string    `json:"-" gorm:"size:64;not null"`
+	WrappedPrivateKey string    `json:"-" gorm:"size:256;not null"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// MountKey is the key of an encrypted storage sealed to the public key of a
+// user, holding it is what allows the user to read the storage
+type MountKey struct {
+	ID        uint   `json:"id" gorm:"primaryKey"`
+	StorageID uint   `json:"storage_id" gorm:"uniqueIndex:idx_mount_key_storage_user;not null"`
+	UserID    uint   `json:"user_id" gorm:"uniqueIndex:idx_mount_key_storage_user;index;not null"`
+	SealedKey string `json:"-" gorm:"type:text;not null"`
+	GrantedBy uint   `json:"granted_by"`
+	// BackedUp is set once a saved backup holds the key, the storage keeps
+	// its own password and salt until then
+	BackedUp  bool      `json:"backed_up"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,15 @@
+package model
+
+import "time"
+
+// DavLock is a WebDAV lock shared by the instances of a cluster, Namespace separates
+// the lock systems of the WebDAV server and of each share
+type DavLock struct {
+	Token     string        `json:"token" gorm:"primaryKey;size:64"`
+	Namespace string        `json:"namespace" gorm:"index;size:64"`
+	Root      string        `json:"root" gorm:"type:text"`
+	Duration  time.Duration `json:"duration"`
+	OwnerXML  string        `json:"owner_xml" gorm:"type:text"`
+	ZeroDepth bool          `json:"zero_depth"`
+	ExpiresAt time.Time     `json:"expires_at"`
+}
@@ -0,0 +1,18 @@
+package model
+
+// DavProp is a WebDAV dead property stored for a virtual path
+type DavProp struct {
+	ID       uint   `json:"id" gorm:"primaryKey"`
+	Path     string `json:"path" gorm:"index;not null"`
+	Space    string `json:"space" gorm:"size:255"`
+	Local    string `json:"local" gorm:"size:255;not null"`
+	Lang     string `json:"lang" gorm:"size:32"`
+	InnerXML string `json:"inner_xml" gorm:"type:text"`
+}
+
+// DavPropPatch is a set or remove instruction of a PROPPATCH, the instructions
+// are applied in the order of the document
+type DavPropPatch struct {
+	DavProp
+	Remove bool
+}
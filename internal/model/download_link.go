@@ -0,0 +1,127 @@
+package model
+
+import (
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/alist-org/alist/v3/pkg/utils"
+)
+
+// DownloadLink is a one-off link to download a file through /d, with its own
+// expiration and restrictions on who can use it
+type DownloadLink struct {
+	ID        uint   `json:"id" gorm:"primaryKey"`
+	Token     string `json:"token" gorm:"uniqueIndex;size:32;not null"`
+	CreatorID uint   `json:"creator_id" gorm:"index;not null"`
+	Path      string `json:"path" gorm:"size:4096;not null"`
+	// CIDRs binds the link to client addresses, comma separated, plain
+	// addresses are accepted too. Empty allows all.
+	CIDRs string `json:"cidrs" gorm:"size:1024"`
+	// Referers limits the link to pages of the given hosts and their
+	// subdomains, comma separated. Empty allows all, including no referer.
+	Referers string `json:"referers" gorm:"size:1024"`
+	// PasswordHash is the hash of the meta password the creator gave, the
+	// link stops working when the password changes
+	PasswordHash   string     `json:"-" gorm:"size:64"`
+	MaxDownloads   int64      `json:"max_downloads"` // 0 is unlimited
+	Downloads      int64      `json:"downloads"`
+	LastDownloadAt *time.Time `json:"last_download_at"`
+	ExpiresAt      time.Time  `json:"expires_at" gorm:"index"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// DownloadSession is the window in which the requests of a client for a link
+// count as a single download, e.g. the ranges of a resumed download. It's
+// kept in the database so it holds on every instance of a cluster.
+type DownloadSession struct {
+	Token string `gorm:"primaryKey;size:32"`
+	// Client is the hash of the address and user agent of the client
+	Client    string    `gorm:"primaryKey;size:64"`
+	ExpiresAt time.Time `gorm:"index"`
+}
+
+func (l DownloadLink) IsExpired(now time.Time) bool {
+	return !l.ExpiresAt.After(now)
+}
+
+func (l DownloadLink) IsExhausted() bool {
+	return l.MaxDownloads > 0 && l.Downloads >= l.MaxDownloads
+}
+
+func (l *DownloadLink) SetPassword(password string) {
+	l.PasswordHash = ""
+	if password != "" {
+		l.PasswordHash = l.hashPassword(password)
+	}
+}
+
+// MatchesPassword reports whether the password is the one the link was
+// created with
+func (l DownloadLink) MatchesPassword(password string) bool {
+	return l.PasswordHash != "" && password != "" && l.PasswordHash == l.hashPassword(password)
+}
+
+func (l DownloadLink) hashPassword(password string) string {
+	return utils.HashData(utils.SHA256, []byte(l.Token+":"+password))
+}
+
+// ValidateCIDRs reports the first invalid range of a comma separated list
+func ValidateCIDRs(cidrs string) error {
+	for _, s := range splitList(cidrs) {
+		if _, err := parseCIDR(s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AllowsIP reports whether the client address is in one of the ranges of the link
+func (l DownloadLink) AllowsIP(clientIP string) bool {
+	cidrs := splitList(l.CIDRs)
+	if len(cidrs) == 0 {
+		return true
+	}
+	ip := net.ParseIP(clientIP)
+	if ip == nil {
+		return false
+	}
+	for _, s := range cidrs {
+		if n, err := parseCIDR(s); err == nil && n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsReferer reports whether the request comes from a page of one of the
+// hosts of the link
+func (l DownloadLink) AllowsReferer(referer string) bool {
+	hosts := splitList(l.Referers)
+	if len(hosts) == 0 {
+		return true
+	}
+	u, err := url.Parse(referer)
+	if err != nil || u.Hostname() == "" {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	for _, h := range hosts {
+		h = strings.ToLower(h)
+		if host == h || strings.HasSuffix(host, "."+h) {
+			return true
+		}
+	}
+	return false
+}
+
+func splitList(s string) []string {
+	res := make([]string, 0)
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			res = append(res, item)
+		}
+	}
+	return res
+}
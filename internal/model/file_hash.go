@@ -0,0 +1,70 @@
+package model
+
+import (
+	"time"
+
+	"github.com/alist-org/alist/v3/pkg/utils"
+)
+
+// FileHash is the hashes of a file computed by a hash task, they stay valid
+// as long as the file keeps its size and modification time
+type FileHash struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Parent    string    `json:"parent" gorm:"uniqueIndex:idx_file_hash_path"`
+	Name      string    `json:"name" gorm:"uniqueIndex:idx_file_hash_path"`
+	Size      int64     `json:"size"`
+	Modified  time.Time `json:"modified"`
+	MD5       string    `json:"md5" gorm:"size:32"`
+	SHA1      string    `json:"sha1" gorm:"size:40"`
+	SHA256    string    `json:"sha256" gorm:"size:64;index"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Matches reports whether the hashes are still those of the object, the
+// modification time is compared to the second as some databases drop the rest
+func (h FileHash) Matches(obj Obj) bool {
+	return h.Size == obj.GetSize() && h.Modified.Unix() == obj.ModTime().Unix()
+}
+
+func (h FileHash) HashInfo() utils.HashInfo {
+	return utils.NewHashInfoByMap(map[*utils.HashType]string{
+		utils.MD5:    h.MD5,
+		utils.SHA1:   h.SHA1,
+		utils.SHA256: h.SHA256,
+	})
+}
+
+// ObjWrapHash adds the hashes of the catalog to an object its driver reports
+// no hash for
+type ObjWrapHash struct {
+	Obj
+	hash utils.HashInfo
+}
+
+func WrapObjHash(obj Obj, hash utils.HashInfo) Obj {
+	return &ObjWrapHash{Obj: obj, hash: hash}
+}
+
+func (o *ObjWrapHash) Unwrap() Obj {
+	return o.Obj
+}
+
+func (o *ObjWrapHash) GetHash() utils.HashInfo {
+	return o.hash
+}
+
+func (o *ObjWrapHash) SetPath(path string) {
+	if setter, ok := o.Obj.(SetPath); ok {
+		setter.SetPath(path)
+	}
+}
+
+// HasHash reports whether the object has at least one hash
+func HasHash(obj Obj) bool {
+	for _, v := range obj.GetHash().All() {
+		if v != "" {
+			return true
+		}
+	}
+	return false
+}
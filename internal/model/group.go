@@ -0,0 +1,24 @@
+package model
+
+import "time"
+
+// Group is a team of users owning a folder. Its members get Permission on
+// HomePath besides the permissions of their roles, and the shares and labels
+// owned by the group survive its members.
+type Group struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	Name        string    `json:"name" gorm:"unique" binding:"required"`
+	Description string    `json:"description"`
+	HomePath    string    `json:"home_path"`
+	Permission  int32     `json:"permission"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// GroupMember binds a user to a group. Group admins manage the members of
+// their group without being site admins.
+type GroupMember struct {
+	GroupID   uint      `json:"group_id" gorm:"primaryKey"`
+	UserID    uint      `json:"user_id" gorm:"primaryKey;index"`
+	Admin     bool      `json:"admin"`
+	CreatedAt time.Time `json:"created_at"`
+}
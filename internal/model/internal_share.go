@@ -0,0 +1,111 @@
+package model
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+const (
+	InternalShareUser  = "user"
+	InternalShareRole  = "role"
+	InternalShareGroup = "group"
+)
+
+// SharedWithMe is the virtual folder at the root of the users holding the
+// paths shared with them. It only exists for the users something is shared
+// with, and nothing is shared with a user having a real folder of the same name.
+const SharedWithMe = "/Shared with me"
+
+const (
+	// internalShareRead allows webdav, ftp, mcp and archive reads
+	internalShareRead int32 = 1<<PermWebdavRead | 1<<PermFTPAccess | 1<<PermReadArchives | 1<<PermMCPAccess
+	// internalShareWrite adds upload, rename, move, copy, remove and the writes of the protocols
+	internalShareWrite int32 = internalShareRead | 1<<PermWrite | 1<<PermRename | 1<<PermMove | 1<<PermCopy |
+		1<<PermRemove | 1<<PermWebdavManage | 1<<PermFTPManage | 1<<PermDecompress | 1<<PermMCPManage
+)
+
+// InternalShare grants a user, the users of a role or the members of a group
+// read or read-write access to a path of its owner without changing roles.
+// The share never grants more than its owner holds on the path, the bits are
+// capped when the share is created and again each time it is used.
+type InternalShare struct {
+	ID         uint   `json:"id" gorm:"primaryKey"`
+	OwnerID    uint   `json:"owner_id" gorm:"index"`
+	Path       string `json:"path" gorm:"type:text"`
+	Name       string `json:"name"`
+	TargetType string `json:"target_type" gorm:"size:16;index:idx_internal_share_target,priority:1"`
+	TargetID   uint   `json:"target_id" gorm:"index:idx_internal_share_target,priority:2"`
+	Write      bool   `json:"write"`
+	// Permission is the bits granted, within MaxPermission
+	Permission int32     `json:"permission"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// MaxPermission is the most a read or a read-write share can grant
+func (s InternalShare) MaxPermission() int32 {
+	if s.Write {
+		return internalShareWrite
+	}
+	return internalShareRead
+}
+
+// Granted is the bits the share grants if its owner still holds them
+func (s InternalShare) Granted() int32 {
+	return s.Permission & s.MaxPermission()
+}
+
+// FetchIncomingShares loads the internal shares granted to the user, it's set by the op package like FetchRole.
+var FetchIncomingShares func(u *User) ([]InternalShare, error)
+
+// SharedEntries names the shares in the SharedWithMe folder, the shares are
+// ordered by id and a name taken by an earlier share gets the id appended
+func SharedEntries(shares []InternalShare) map[string]InternalShare {
+	entries := make(map[string]InternalShare, len(shares))
+	for _, s := range shares {
+		name := s.Name
+		if _, ok := entries[name]; ok {
+			name = fmt.Sprintf("%s (%d)", s.Name, s.ID)
+		}
+		entries[name] = s
+	}
+	return entries
+}
+
+// HasSharedWithMe reports whether the SharedWithMe folder of the user exists
+func (u *User) HasSharedWithMe() bool {
+	if FetchIncomingShares == nil {
+		return false
+	}
+	shares, err := FetchIncomingShares(u)
+	return err == nil && len(shares) > 0
+}
+
+func IsSharedWithMePath(reqPath string) bool {
+	reqPath = utils.FixAndCleanPath(reqPath)
+	return reqPath == SharedWithMe || utils.IsSubPath(SharedWithMe, reqPath)
+}
+
+// resolveShared maps a path in the SharedWithMe folder to the shared path
+func (u *User) resolveShared(reqPath string) (string, error) {
+	rel := strings.TrimPrefix(utils.FixAndCleanPath(reqPath), SharedWithMe)
+	name, rest, _ := strings.Cut(strings.TrimPrefix(rel, "/"), "/")
+	if name == "" {
+		// the folder itself is virtual
+		return "", errs.NotSupport
+	}
+	shares, err := FetchIncomingShares(u)
+	if err != nil {
+		return "", err
+	}
+	s, ok := SharedEntries(shares)[name]
+	if !ok {
+		return "", errors.WithStack(errs.ObjectNotFound)
+	}
+	return utils.FixAndCleanPath(path.Join(s.Path, rest)), nil
+}
@@ -0,0 +1,44 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+)
+
+type OAuthRedirectURIs []string
+
+func (u OAuthRedirectURIs) Value() (driver.Value, error) {
+	return json.Marshal([]string(u))
+}
+
+func (u *OAuthRedirectURIs) Scan(value interface{}) error {
+	return scanJSON(value, (*[]string)(u))
+}
+
+// OAuthClient is an application logging users in with this server as its OpenID Connect provider
+type OAuthClient struct {
+	ID         uint   `json:"id" gorm:"primaryKey"`
+	ClientID   string `json:"client_id" gorm:"uniqueIndex;size:64"`
+	Name       string `json:"name" binding:"required"`
+	SecretHash string `json:"-"`
+	// Public clients can't keep a secret, e.g. single page and native apps, so they must use PKCE
+	Public bool `json:"public"`
+	// RedirectURIs are matched exactly against the redirect_uri of the requests
+	RedirectURIs OAuthRedirectURIs `json:"redirect_uris" gorm:"type:text"`
+	Disabled     bool              `json:"disabled"`
+	CreatedAt    time.Time         `json:"created_at"`
+}
+
+// OAuthCode is an authorization code waiting to be exchanged for tokens, only the hash of the code is stored
+type OAuthCode struct {
+	CodeHash      string `gorm:"primaryKey;size:64"`
+	ClientID      string `gorm:"size:64"`
+	UserID        uint   `gorm:"not null"`
+	RedirectURI   string `gorm:"type:text"`
+	Scope         string `gorm:"type:text"`
+	Nonce         string `gorm:"type:text"`
+	CodeChallenge string `gorm:"size:128"`
+	AuthTime      time.Time
+	ExpiresAt     time.Time `gorm:"index"`
+}
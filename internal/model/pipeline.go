@@ -0,0 +1,97 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+const (
+	PipelineDecompress = "decompress"
+	PipelineCopy       = "copy"
+	PipelineMove       = "move"
+	PipelineRename     = "rename"
+	PipelineRefresh    = "refresh"
+	PipelineWebhook    = "webhook"
+)
+
+const (
+	PipelineRunning   = "running"
+	PipelineSucceeded = "succeeded"
+	PipelineFailed    = "failed"
+)
+
+// PipelineStep is a follow-up action run on the paths produced by the previous step
+type PipelineStep struct {
+	Action string `json:"action"`
+	// DstDir is the target of decompress, copy and move, decompress defaults to the dir of the archive
+	DstDir        string `json:"dst_dir,omitempty"`
+	ArchivePass   string `json:"archive_pass,omitempty"`
+	InnerPath     string `json:"inner_path,omitempty"`
+	PutIntoNewDir bool   `json:"put_into_new_dir,omitempty"`
+	SrcNameRegex  string `json:"src_name_regex,omitempty"`
+	NewNameRegex  string `json:"new_name_regex,omitempty"`
+	URL           string `json:"url,omitempty"`
+	// RemoveInputs removes the paths the step worked on once it succeeded
+	RemoveInputs bool `json:"remove_inputs,omitempty"`
+}
+
+type PipelineSteps []PipelineStep
+
+func (s PipelineSteps) Value() (driver.Value, error) {
+	return json.Marshal([]PipelineStep(s))
+}
+
+func (s *PipelineSteps) Scan(value interface{}) error {
+	return scanJSON(value, (*[]PipelineStep)(s))
+}
+
+type PipelinePaths []string
+
+func (p PipelinePaths) Value() (driver.Value, error) {
+	return json.Marshal([]string(p))
+}
+
+func (p *PipelinePaths) Scan(value interface{}) error {
+	return scanJSON(value, (*[]string)(p))
+}
+
+func scanJSON(value interface{}, dst interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, dst)
+	case string:
+		return json.Unmarshal([]byte(v), dst)
+	case nil:
+		return nil
+	default:
+		return fmt.Errorf("cannot scan %T", value)
+	}
+}
+
+// Pipeline chains follow-up steps after the tasks of a request,
+// a step starts when all tasks of the previous step succeeded.
+type Pipeline struct {
+	ID        uint          `json:"id" gorm:"primaryKey"`
+	CreatorID uint          `json:"creator_id" gorm:"index;not null"`
+	Steps     PipelineSteps `json:"steps" gorm:"type:text"`
+	// Step is the number of steps started, 0 while the tasks of the request are running
+	Step int `json:"step"`
+	// Pending is the number of unfinished tasks of the current step
+	Pending int           `json:"pending"`
+	Inputs  PipelinePaths `json:"inputs" gorm:"type:text"`
+	Outputs PipelinePaths `json:"outputs" gorm:"type:text"`
+	// InputDirs and OutputDirs are the inputs and outputs that are folders
+	// results were put in rather than results, e.g. the folder of an
+	// extracted archive. remove_inputs never removes them.
+	InputDirs  PipelinePaths `json:"input_dirs" gorm:"type:text"`
+	OutputDirs PipelinePaths `json:"output_dirs" gorm:"type:text"`
+	// Moved are the sources of the moves between two storages of the
+	// current step, they are copied and removed once the step succeeded
+	Moved     PipelinePaths `json:"moved" gorm:"type:text"`
+	State     string        `json:"state" gorm:"index"`
+	Error     string        `json:"error" gorm:"type:text"`
+	CreatedAt time.Time     `json:"created_at"`
+	UpdatedAt time.Time     `json:"updated_at"`
+}
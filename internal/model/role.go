@@ -10,6 +10,17 @@ import (
 type PermissionEntry struct {
 	Path       string `json:"path"`       // path prefix, e.g. "/admin"
 	Permission int32  `json:"permission"` // bitmask permissions
+	// Deny revokes the bits instead of granting them and always takes
+	// precedence over grants. A deny entry without bits revokes every bit
+	// and hides the path.
+	Deny bool `json:"deny,omitempty"`
+	// Conditions optionally restricts when the entry applies.
+	Conditions *PermissionCondition `json:"conditions,omitempty"`
+}
+
+// DenyAll reports whether the entry hides its path entirely.
+func (e PermissionEntry) DenyAll() bool {
+	return e.Deny && e.Permission == 0
 }
 
 // Role represents a permission template which can be bound to users.
@@ -1,11 +1,16 @@
 package model
 
-import "time"
+import (
+	stdpath "path"
+	"strings"
+	"time"
+)
 
 type Share struct {
 	ID            uint       `json:"id" gorm:"primaryKey"`
 	ShareID       string     `json:"share_id" gorm:"uniqueIndex;size:32;not null"`
 	CreatorID     uint       `json:"creator_id" gorm:"index;not null"`
+	GroupID       uint       `json:"group_id" gorm:"index;default:0"` // owning group, its members manage the share
 	Name          string     `json:"name" gorm:"size:255;not null"`
 	RootPath      string     `json:"root_path" gorm:"size:4096;not null"`
 	IsDir         bool       `json:"is_dir"`
@@ -24,6 +29,18 @@ type Share struct {
 	ExpiresAt     *time.Time `json:"expires_at"`
 	CreatedAt     time.Time  `json:"created_at"`
 	UpdatedAt     time.Time  `json:"updated_at"`
+	// file request, visitors can upload into the shared folder
+	AllowUpload       bool       `json:"allow_upload" gorm:"default:false"`
+	UploadOnly        bool       `json:"upload_only" gorm:"default:false"`    // visitors don't see the existing files
+	MaxFileSize       int64      `json:"max_file_size"`                       // 0 is unlimited
+	AllowedExtensions string     `json:"allowed_extensions" gorm:"size:1024"` // comma separated, empty allows all
+	UploadQuota       int64      `json:"upload_quota"`                        // total bytes, 0 is unlimited
+	UploadLimit       int64      `json:"upload_limit"`                        // number of files, 0 is unlimited
+	UploadCount       int64      `json:"upload_count"`
+	UploadedBytes     int64      `json:"uploaded_bytes"`
+	LastUploadAt      *time.Time `json:"last_upload_at"`
+	// AllowWebDAV serves the shared folder at /dav-share/:share_id
+	AllowWebDAV bool `json:"allow_webdav" gorm:"default:false"`
 }
 
 func (s Share) HasPassword() bool {
@@ -60,3 +77,31 @@ func (s Share) IsConsumed() bool {
 func (s Share) IsExpired(now time.Time) bool {
 	return s.ExpiresAt != nil && !s.ExpiresAt.After(now)
 }
+
+// ExtensionAllowed reports whether a file with the name can be uploaded to the share
+func (s Share) ExtensionAllowed(name string) bool {
+	if s.AllowedExtensions == "" {
+		return true
+	}
+	ext := strings.ToLower(strings.TrimPrefix(stdpath.Ext(name), "."))
+	for _, allowed := range strings.Split(s.AllowedExtensions, ",") {
+		if allowed == ext {
+			return true
+		}
+	}
+	return false
+}
+
+func (s Share) RemainingUploads() int64 {
+	if s.UploadLimit <= 0 {
+		return 0
+	}
+	return max(s.UploadLimit-s.UploadCount, 0)
+}
+
+func (s Share) RemainingUploadBytes() int64 {
+	if s.UploadQuota <= 0 {
+		return 0
+	}
+	return max(s.UploadQuota-s.UploadedBytes, 0)
+}
@@ -0,0 +1,37 @@
+package model
+
+import "time"
+
+const (
+	ShareActionView     = "view"
+	ShareActionList     = "list"
+	ShareActionDownload = "download"
+	ShareActionProxy    = "proxy"
+	ShareActionUpload   = "upload"
+)
+
+// ShareAccess is a visit of a share. It references the share by its row id,
+// so the log survives renames of the share link.
+type ShareAccess struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	ShareID   uint      `json:"-" gorm:"index:idx_share_access_share,priority:1"`
+	Action    string    `json:"action" gorm:"size:16"`
+	Path      string    `json:"path" gorm:"type:text"` // relative to the share root
+	IP        string    `json:"ip" gorm:"size:64"`
+	UserAgent string    `json:"user_agent" gorm:"type:text"`
+	Bytes     int64     `json:"bytes"`
+	CreatedAt time.Time `json:"created_at" gorm:"index;index:idx_share_access_share,priority:2"`
+}
+
+type ShareFileStat struct {
+	Path  string `json:"path"`
+	Count int64  `json:"count"`
+	Bytes int64  `json:"bytes"`
+}
+
+// ShareStats sums up the access log of a share
+type ShareStats struct {
+	UniqueVisitors int64           `json:"unique_visitors"`
+	BytesServed    int64           `json:"bytes_served"`
+	TopFiles       []ShareFileStat `json:"top_files"`
+}
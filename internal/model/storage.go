@@ -19,6 +19,7 @@ type Storage struct {
 	EnableSign      bool      `json:"enable_sign"`
 	Sort
 	Proxy
+	Limit
 }
 
 type Sort struct {
@@ -35,6 +36,14 @@ type Proxy struct {
 	DownProxySign bool   `json:"down_proxy_sign" gorm:"default:true"`
 }
 
+// Limit is the budget of a storage shared by all entry points, zero means unlimited
+type Limit struct {
+	MaxTransfers      int     `json:"max_transfers"`       // concurrent uploads and downloads read by alist
+	RequestsPerSecond float64 `json:"requests_per_second"` // calls of List, Link and Put
+	MaxUploadSpeed    int     `json:"max_upload_speed"`    // KB/s
+	MaxDownloadSpeed  int     `json:"max_download_speed"`  // KB/s, only for the traffic proxied by alist
+}
+
 func (s *Storage) GetStorage() *Storage {
 	return s
 }
@@ -58,3 +67,13 @@ func (p Proxy) WebdavProxy() bool {
 func (p Proxy) WebdavNative() bool {
 	return !p.Webdav302() && !p.WebdavProxy()
 }
+
+type DiskUsage struct {
+	TotalSpace uint64 `json:"total_space"`
+	FreeSpace  uint64 `json:"free_space"`
+}
+
+// StorageDetails is the capacity information reported by a storage
+type StorageDetails struct {
+	DiskUsage
+}
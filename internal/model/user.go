@@ -20,6 +20,27 @@ const (
 	NEWGENERAL
 )
 
+// The bits of User.Permission
+const (
+	PermSeeHides = iota
+	PermAccessWithoutPassword
+	PermAddOfflineDownload
+	PermWrite
+	PermRename
+	PermMove
+	PermCopy
+	PermRemove
+	PermWebdavRead
+	PermWebdavManage
+	PermFTPAccess
+	PermFTPManage
+	PermReadArchives
+	PermDecompress
+	PermPathLimit
+	PermMCPAccess
+	PermMCPManage
+)
+
 const StaticHashSalt = "https://github.com/alist-org/alist"
 
 type User struct {
@@ -55,6 +76,20 @@ type User struct {
 	OtpSecret  string `json:"-"`
 	SsoID      string `json:"sso_id"` // unique by sso platform
 	Authn      string `gorm:"type:text" json:"-"`
+	// Access is set on per-request copies only, see WithAccess.
+	Access *AccessContext `json:"-" gorm:"-"`
+	// ActingGroup is set on the user a group acts as, e.g. for the shares
+	// owned by the group, see op.GroupUser.
+	ActingGroup *Group `json:"-" gorm:"-"`
+}
+
+// WithAccess returns a shallow copy of the user bound to the given request
+// origin. Users are shared through the cache, so the origin is never set in
+// place.
+func (u *User) WithAccess(protocol, clientIP string) *User {
+	cp := *u
+	cp.Access = &AccessContext{Protocol: protocol, ClientIP: clientIP}
+	return &cp
 }
 
 func (u *User) IsGuest() bool {
@@ -155,6 +190,9 @@ func (u *User) CanMCPManage() bool {
 }
 
 func (u *User) JoinPath(reqPath string) (string, error) {
+	if IsSharedWithMePath(reqPath) && u.HasSharedWithMe() {
+		return u.resolveShared(reqPath)
+	}
 	if reqPath == "/" {
 		return utils.FixAndCleanPath(u.BasePath), nil
 	}
@@ -223,7 +261,11 @@ func (u *User) WebAuthnIcon() string {
 // to avoid an import cycle between model and op.
 var FetchRole func(uint) (*Role, error)
 
-// GetAllBasePathsFromRoles returns all permission paths from user's roles
+// FetchGroups is used to load the groups of a user by its id, it's set by the op package like FetchRole.
+var FetchGroups func(uint) ([]Group, error)
+
+// GetAllBasePathsFromRoles returns all permission paths from user's roles,
+// the home paths of user's groups and the paths shared with the user
 func GetAllBasePathsFromRoles(u *User) []string {
 	basePaths := make([]string, 0)
 	seen := make(map[string]struct{})
@@ -246,5 +288,29 @@ func GetAllBasePathsFromRoles(u *User) []string {
 			}
 		}
 	}
+	if u.ID == 0 {
+		return basePaths
+	}
+	if FetchGroups != nil {
+		groups, _ := FetchGroups(u.ID)
+		for _, g := range groups {
+			if g.HomePath == "" {
+				continue
+			}
+			if _, ok := seen[g.HomePath]; !ok {
+				basePaths = append(basePaths, g.HomePath)
+				seen[g.HomePath] = struct{}{}
+			}
+		}
+	}
+	if FetchIncomingShares != nil {
+		shares, _ := FetchIncomingShares(u)
+		for _, s := range shares {
+			if _, ok := seen[s.Path]; !ok {
+				basePaths = append(basePaths, s.Path)
+				seen[s.Path] = struct{}{}
+			}
+		}
+	}
 	return basePaths
 }
@@ -85,7 +85,14 @@ func (s SimpleHttp) Run(task *tool.DownloadTask) error {
 	fileSize := resp.ContentLength
 	task.SetTotalBytes(fileSize)
 	err = utils.CopyWithCtx(task.Ctx(), file, resp.Body, fileSize, task.SetProgress)
-	return err
+	if err != nil {
+		return err
+	}
+	// keep the remote modification time, it is carried to the storage by the transfer task
+	if modTime, err := http.ParseTime(resp.Header.Get("Last-Modified")); err == nil {
+		_ = os.Chtimes(filePath, modTime, modTime)
+	}
+	return nil
 }
 
 func init() {
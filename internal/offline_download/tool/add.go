@@ -128,6 +128,7 @@ func AddURL(ctx context.Context, args *AddURLArgs) (task.TaskExtensionInfo, erro
 		Toolname:     args.Tool,
 		tool:         tool,
 	}
+	t.JoinPipeline(task.PipelineIDFromCtx(ctx))
 	DownloadTaskManager.Add(t)
 	return t, nil
 }
@@ -140,5 +141,9 @@ func tryPutUrl(ctx context.Context, path, urlStr string) error {
 	} else {
 		dstName = "UnnamedURL"
 	}
-	return fs.PutURL(ctx, path, dstName, urlStr)
+	err = fs.PutURL(ctx, path, dstName, urlStr)
+	if err == nil {
+		task.AddPipelineOutput(ctx, stdpath.Join(path, dstName))
+	}
+	return err
 }
@@ -167,6 +167,8 @@ func (t *DownloadTask) Transfer() error {
 		if t.TempDir != t.DstDirPath {
 			return transferObj(t.Ctx(), t.TempDir, t.DstDirPath, t.DeletePolicy)
 		}
+		// downloaded to the target directly, the names of the files are unknown
+		task.AddPipelineOutputDir(t.Ctx(), t.DstDirPath)
 		return nil
 	}
 	return transferStd(t.Ctx(), t.TempDir, t.DstDirPath, t.DeletePolicy)
@@ -59,6 +59,7 @@ func (t *TransferTask) OnSucceeded() {
 			removeObjTemp(t)
 		}
 	}
+	t.TaskExtension.OnSucceeded()
 }
 
 func (t *TransferTask) OnFailed() {
@@ -69,6 +70,7 @@ func (t *TransferTask) OnFailed() {
 			removeObjTemp(t)
 		}
 	}
+	t.TaskExtension.OnFailed()
 }
 
 var (
@@ -96,7 +98,9 @@ func transferStd(ctx context.Context, tempDir, dstDirPath string, deletePolicy D
 			DstStorageMp: dstStorage.GetStorage().MountPath,
 			DeletePolicy: deletePolicy,
 		}
+		t.JoinPipeline(task.PipelineIDFromCtx(ctx))
 		TransferTaskManager.Add(t)
+		task.AddPipelineOutput(ctx, stdpath.Join(dstDirPath, entry.Name()))
 	}
 	return nil
 }
@@ -116,7 +120,7 @@ func transferStdPath(t *TransferTask) error {
 		for _, entry := range entries {
 			srcRawPath := stdpath.Join(t.SrcObjPath, entry.Name())
 			dstObjPath := stdpath.Join(t.DstDirPath, info.Name())
-			t := &TransferTask{
+			subTask := &TransferTask{
 				TaskExtension: task.TaskExtension{
 					Creator: t.Creator,
 				},
@@ -127,7 +131,8 @@ func transferStdPath(t *TransferTask) error {
 				DstStorageMp: t.DstStorageMp,
 				DeletePolicy: t.DeletePolicy,
 			}
-			TransferTaskManager.Add(t)
+			subTask.JoinPipeline(t.PipelineID)
+			TransferTaskManager.Add(subTask)
 		}
 		t.Status = "src object is dir, added all transfer tasks of files"
 		return nil
@@ -198,7 +203,9 @@ func transferObj(ctx context.Context, tempDir, dstDirPath string, deletePolicy D
 			DstStorageMp: dstStorage.GetStorage().MountPath,
 			DeletePolicy: deletePolicy,
 		}
+		t.JoinPipeline(task.PipelineIDFromCtx(ctx))
 		TransferTaskManager.Add(t)
+		task.AddPipelineOutput(ctx, stdpath.Join(dstDirPath, obj.GetName()))
 	}
 	return nil
 }
@@ -221,7 +228,7 @@ func transferObjPath(t *TransferTask) error {
 			}
 			srcObjPath := stdpath.Join(t.SrcObjPath, obj.GetName())
 			dstObjPath := stdpath.Join(t.DstDirPath, srcObj.GetName())
-			TransferTaskManager.Add(&TransferTask{
+			subTask := &TransferTask{
 				TaskExtension: task.TaskExtension{
 					Creator: t.Creator,
 				},
@@ -232,7 +239,9 @@ func transferObjPath(t *TransferTask) error {
 				SrcStorageMp: t.SrcStorageMp,
 				DstStorageMp: t.DstStorageMp,
 				DeletePolicy: t.DeletePolicy,
-			})
+			}
+			subTask.JoinPipeline(t.PipelineID)
+			TransferTaskManager.Add(subTask)
 		}
 		t.Status = "src object is dir, added all transfer tasks of objs"
 		return nil
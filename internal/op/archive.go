@@ -44,6 +44,7 @@ func GetArchiveMeta(ctx context.Context, storage driver.Driver, path string, arg
 		}
 		if m.Expiration != nil {
 			archiveMetaCache.Set(key, m, cache.WithEx[*model.ArchiveMetaProvider](*m.Expiration))
+			trackArchiveKey(key, key, *m.Expiration)
 		}
 		return m, nil
 	}
@@ -222,7 +223,9 @@ func ListArchive(ctx context.Context, storage driver.Driver, path string, args m
 		if !storage.Config().NoCache {
 			if len(files) > 0 {
 				log.Debugf("set cache: %s => %+v", key, files)
-				archiveListCache.Set(key, files, cache.WithEx[[]model.Obj](time.Minute*time.Duration(storage.GetStorage().CacheExpiration)))
+				expiration := time.Minute * time.Duration(storage.GetStorage().CacheExpiration)
+				archiveListCache.Set(key, files, cache.WithEx[[]model.Obj](expiration))
+				trackArchiveKey(metaKey, key, expiration)
 			} else {
 				log.Debugf("del cache: %s", key)
 				archiveListCache.Del(key)
@@ -405,6 +408,7 @@ func DriverExtract(ctx context.Context, storage driver.Driver, path string, args
 				key = key + ":" + args.IP
 			}
 			extractCache.Set(key, link, cache.WithEx[*extractLink](*link.Link.Expiration))
+			trackArchiveKey(Key(storage, path), key, *link.Link.Expiration)
 		}
 		return link, nil
 	}
@@ -0,0 +1,69 @@
+package op
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/pkg/utils"
+)
+
+// archiveKeys records the keys cached for each archive, the key of its meta is the key of the
+// archive itself and the keys of its listings and extracted files are joined with the inner paths
+var archiveKeys = struct {
+	sync.Mutex
+	m      map[string]map[string]time.Time
+	pruned time.Time
+}{m: make(map[string]map[string]time.Time)}
+
+// trackArchiveKey records key cached until expiry for the archive whose key is archive
+func trackArchiveKey(archive, key string, expiry time.Duration) {
+	archiveKeys.Lock()
+	defer archiveKeys.Unlock()
+	now := time.Now()
+	if now.Sub(archiveKeys.pruned) > time.Minute {
+		archiveKeys.pruned = now
+		for a, keys := range archiveKeys.m {
+			for k, t := range keys {
+				if now.After(t) {
+					delete(keys, k)
+				}
+			}
+			if len(keys) == 0 {
+				delete(archiveKeys.m, a)
+			}
+		}
+	}
+	keys, ok := archiveKeys.m[archive]
+	if !ok {
+		keys = make(map[string]time.Time)
+		archiveKeys.m[archive] = keys
+	}
+	keys[key] = now.Add(expiry)
+}
+
+// invalidateArchives drops the cached metas, listings and extracted files of the archives at or under path
+func invalidateArchives(storage driver.Driver, path string) {
+	key := Key(storage, path)
+	clearArchiveCache(key)
+	invalidate(InvalidateArchive, key)
+}
+
+// clearArchiveCache is invalidateArchives with the key of the path
+func clearArchiveCache(key string) {
+	prefix := utils.PathAddSeparatorSuffix(key)
+	archiveKeys.Lock()
+	defer archiveKeys.Unlock()
+	for archive, keys := range archiveKeys.m {
+		if archive != key && !strings.HasPrefix(archive, prefix) {
+			continue
+		}
+		archiveMetaCache.Del(archive)
+		for k := range keys {
+			archiveListCache.Del(k)
+			extractCache.Del(k)
+		}
+		delete(archiveKeys.m, archive)
+	}
+}
@@ -0,0 +1,48 @@
+package op
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Xhofe/go-cache"
+	"github.com/alist-org/alist/v3/internal/model"
+)
+
+func TestClearArchiveCache(t *testing.T) {
+	set := func(archive, inner string) {
+		archiveMetaCache.Set(archive, &model.ArchiveMetaProvider{}, cache.WithEx[*model.ArchiveMetaProvider](time.Minute))
+		trackArchiveKey(archive, archive, time.Minute)
+		key := archive + inner
+		archiveListCache.Set(key, []model.Obj{&model.Object{Name: "a"}}, cache.WithEx[[]model.Obj](time.Minute))
+		trackArchiveKey(archive, key, time.Minute)
+		extractCache.Set(key+"/a", &extractLink{}, cache.WithEx[*extractLink](time.Minute))
+		trackArchiveKey(archive, key+"/a", time.Minute)
+	}
+	cached := func(archive, inner string) bool {
+		_, meta := archiveMetaCache.Get(archive)
+		_, list := archiveListCache.Get(archive + inner)
+		_, link := extractCache.Get(archive + inner + "/a")
+		return meta || list || link
+	}
+	set("/local/dir/a.zip", "/inner")
+	set("/local/dir2/b.zip", "/inner")
+	set("/local/c.zip", "/inner")
+
+	clearArchiveCache("/local/dir")
+	if cached("/local/dir/a.zip", "/inner") {
+		t.Error("archive under the changed folder must be dropped")
+	}
+	if !cached("/local/dir2/b.zip", "/inner") || !cached("/local/c.zip", "/inner") {
+		t.Error("archives out of the changed folder must be kept")
+	}
+	clearArchiveCache("/local/c.zip")
+	if cached("/local/c.zip", "/inner") {
+		t.Error("changed archive must be dropped")
+	}
+	if err := ApplyInvalidation(t.Context(), InvalidateArchive, "/local"); err != nil {
+		t.Fatal(err)
+	}
+	if cached("/local/dir2/b.zip", "/inner") {
+		t.Error("archive invalidated by another instance must be dropped")
+	}
+}
@@ -0,0 +1,51 @@
+package op
+
+import (
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils"
+)
+
+// The dead properties are keyed by virtual path, so they have to follow
+// the objects when they are moved, copied or removed through fs
+
+func GetDavProps(path string) ([]model.DavProp, error) {
+	return db.GetDavPropsByPath(utils.FixAndCleanPath(path))
+}
+
+// GetDavPropsOfChildren returns the dead properties of the children of the folder by their path
+func GetDavPropsOfChildren(parent string) (map[string][]model.DavProp, error) {
+	props, err := db.GetDavPropsOfChildren(utils.FixAndCleanPath(parent))
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[string][]model.DavProp)
+	for _, p := range props {
+		res[p.Path] = append(res[p.Path], p)
+	}
+	return res, nil
+}
+
+func PatchDavProps(path string, patches []model.DavPropPatch) error {
+	return db.PatchDavProps(utils.FixAndCleanPath(path), patches)
+}
+
+func MoveDavProps(srcPath, dstPath string) error {
+	srcPath, dstPath = utils.FixAndCleanPath(srcPath), utils.FixAndCleanPath(dstPath)
+	if srcPath == dstPath {
+		return nil
+	}
+	return db.MoveDavProps(srcPath, dstPath)
+}
+
+func CopyDavProps(srcPath, dstPath string) error {
+	srcPath, dstPath = utils.FixAndCleanPath(srcPath), utils.FixAndCleanPath(dstPath)
+	if srcPath == dstPath {
+		return nil
+	}
+	return db.CopyDavProps(srcPath, dstPath)
+}
+
+func DeleteDavProps(path string) error {
+	return db.DeleteDavProps(utils.FixAndCleanPath(path))
+}
@@ -0,0 +1,180 @@
+package op
+
+import (
+	stdpath "path"
+	"sync"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// fileHashTTL bounds how long the stored hashes of a folder are cached
+const fileHashTTL = 10 * time.Minute
+
+type fileHashEntry struct {
+	hashes map[string]model.FileHash
+	expiry time.Time
+}
+
+// fileHashCache caches the stored hashes by folder, the folders without any are cached too
+// so getting or listing their files doesn't query the database
+type fileHashCache struct {
+	mu      sync.Mutex
+	entries map[string]fileHashEntry
+	// gen is increased by each clear, a load started before a clear isn't cached
+	gen       uint64
+	lastPrune time.Time
+}
+
+var fileHashes = &fileHashCache{entries: make(map[string]fileHashEntry)}
+
+func (c *fileHashCache) get(parent string) (map[string]model.FileHash, error) {
+	now := time.Now()
+	c.mu.Lock()
+	if e, ok := c.entries[parent]; ok && now.Before(e.expiry) {
+		c.mu.Unlock()
+		return e.hashes, nil
+	}
+	gen := c.gen
+	c.mu.Unlock()
+	list, err := db.GetFileHashesByParent(parent)
+	if err != nil {
+		return nil, err
+	}
+	hashes := make(map[string]model.FileHash, len(list))
+	for _, h := range list {
+		hashes[h.Name] = h
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.gen == gen {
+		c.prune(now)
+		c.entries[parent] = fileHashEntry{hashes: hashes, expiry: now.Add(fileHashTTL)}
+	}
+	return hashes, nil
+}
+
+// prune drops the expired entries at most once per ttl, the caller holds the lock
+func (c *fileHashCache) prune(now time.Time) {
+	if now.Sub(c.lastPrune) < fileHashTTL {
+		return
+	}
+	c.lastPrune = now
+	for parent, e := range c.entries {
+		if !now.Before(e.expiry) {
+			delete(c.entries, parent)
+		}
+	}
+}
+
+// clear drops the cached hashes of the file at path, or of all the files under it for a folder
+func (c *fileHashCache) clear(path string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.gen++
+	delete(c.entries, stdpath.Dir(path))
+	for parent := range c.entries {
+		if utils.IsSubPath(path, parent) {
+			delete(c.entries, parent)
+		}
+	}
+}
+
+// SaveFileHash stores the hashes of a file computed by a task
+func SaveFileHash(h *model.FileHash) error {
+	if err := db.SaveFileHash(h); err != nil {
+		return err
+	}
+	path := stdpath.Join(h.Parent, h.Name)
+	fileHashes.clear(path)
+	invalidate(InvalidateFileHash, path)
+	return nil
+}
+
+// DeleteFileHashes removes the stored hashes of the file at path, or of all the files under it for a folder
+func DeleteFileHashes(path string) error {
+	if err := db.DeleteFileHashesUnder(path); err != nil {
+		return err
+	}
+	fileHashes.clear(path)
+	invalidate(InvalidateFileHash, path)
+	return nil
+}
+
+// invalidateFileHashes drops the stored hashes of a path changed by a write, and of the
+// paths showing it through other storages like alias and crypt
+func invalidateFileHashes(storage driver.Driver, path string) {
+	paths := []string{utils.GetFullPath(storage.GetStorage().MountPath, path)}
+	seen := make(map[string]struct{})
+	for len(paths) > 0 {
+		fullPath := paths[0]
+		paths = paths[1:]
+		if _, ok := seen[fullPath]; ok {
+			continue
+		}
+		seen[fullPath] = struct{}{}
+		if err := DeleteFileHashes(fullPath); err != nil {
+			log.Errorf("failed delete file hashes of %s: %+v", fullPath, err)
+		}
+		for _, s := range storagesMap.Values() {
+			mirror, ok := s.(driver.Mirror)
+			if !ok {
+				continue
+			}
+			for _, p := range mirror.MirrorPaths(fullPath) {
+				paths = append(paths, utils.GetFullPath(s.GetStorage().MountPath, p))
+			}
+		}
+	}
+}
+
+// WithStoredHashes adds the stored hashes to the files of the folder whose
+// driver reports none
+func WithStoredHashes(parent string, objs []model.Obj) []model.Obj {
+	missing := false
+	for _, obj := range objs {
+		if !obj.IsDir() && !model.HasHash(obj) {
+			missing = true
+			break
+		}
+	}
+	if !missing {
+		return objs
+	}
+	hashes, err := fileHashes.get(parent)
+	if err != nil || len(hashes) == 0 {
+		return objs
+	}
+	res := make([]model.Obj, len(objs))
+	for i, obj := range objs {
+		res[i] = obj
+		if obj.IsDir() || model.HasHash(obj) {
+			continue
+		}
+		if h, ok := hashes[obj.GetName()]; ok && h.Matches(obj) {
+			res[i] = model.WrapObjHash(obj, h.HashInfo())
+		}
+	}
+	return res
+}
+
+// WithStoredHash adds the stored hashes to the file at path if its driver
+// reports none
+func WithStoredHash(path string, obj model.Obj) model.Obj {
+	if obj.IsDir() || model.HasHash(obj) {
+		return obj
+	}
+	hashes, err := fileHashes.get(stdpath.Dir(path))
+	if err != nil {
+		return obj
+	}
+	h, ok := hashes[stdpath.Base(path)]
+	if !ok || !h.Matches(obj) {
+		return obj
+	}
+	return model.WrapObjHash(obj, h.HashInfo())
+}
@@ -0,0 +1,125 @@
+package op
+
+import (
+	stdpath "path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils"
+)
+
+// mirrorStorage shows the files of /hash_src under its root, like an alias
+type mirrorStorage struct {
+	driver.Driver
+	storage model.Storage
+}
+
+func (m *mirrorStorage) GetStorage() *model.Storage {
+	return &m.storage
+}
+
+func (m *mirrorStorage) MirrorPaths(fullPath string) []string {
+	if utils.IsSubPath("/hash_src", fullPath) {
+		return []string{strings.TrimPrefix(fullPath, "/hash_src")}
+	}
+	return nil
+}
+
+var fileHashModified = time.Unix(1700000000, 0)
+
+func saveTestFileHash(t *testing.T, path, md5 string) {
+	t.Helper()
+	h := &model.FileHash{Parent: stdpath.Dir(path), Name: stdpath.Base(path), Size: 10, Modified: fileHashModified, MD5: md5}
+	if err := SaveFileHash(h); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func storedMD5(path string) string {
+	obj := WithStoredHash(path, &model.Object{Name: stdpath.Base(path), Size: 10, Modified: fileHashModified})
+	return obj.GetHash().GetHash(utils.MD5)
+}
+
+func TestWithStoredHashes(t *testing.T) {
+	saveTestFileHash(t, "/hash_list/a", "aaaa")
+	saveTestFileHash(t, "/hash_list/b", "bbbb")
+	saveTestFileHash(t, "/hash_list/c", "cccc")
+	objs := []model.Obj{
+		&model.Object{Name: "a", Size: 10, Modified: fileHashModified},
+		// changed since its hashes were computed
+		&model.Object{Name: "b", Size: 11, Modified: fileHashModified},
+		// reported by the driver
+		&model.Object{Name: "c", Size: 10, Modified: fileHashModified,
+			HashInfo: utils.NewHashInfo(utils.MD5, "driver")},
+		&model.Object{Name: "d", IsFolder: true},
+	}
+	res := WithStoredHashes("/hash_list", objs)
+	want := []string{"aaaa", "", "driver", ""}
+	for i, obj := range res {
+		if got := obj.GetHash().GetHash(utils.MD5); got != want[i] {
+			t.Errorf("%s: expect %q, got %q", obj.GetName(), want[i], got)
+		}
+	}
+
+	// saving again replaces the hashes and refreshes the cache
+	saveTestFileHash(t, "/hash_list/a", "eeee")
+	if got := storedMD5("/hash_list/a"); got != "eeee" {
+		t.Errorf("expect the new hash, got %q", got)
+	}
+	if h, err := db.GetFileHashesByParent("/hash_list"); err != nil || len(h) != 3 {
+		t.Errorf("expect 3 stored hashes, got %d: %v", len(h), err)
+	}
+}
+
+func TestFileHashInvalidation(t *testing.T) {
+	saveTestFileHash(t, "/hash_inv/d_r/a", "aaaa")
+	saveTestFileHash(t, "/hash_inv/dir/b", "bbbb")
+	saveTestFileHash(t, "/hash_inv/DIR/c", "cccc")
+	// cache the folders
+	if storedMD5("/hash_inv/d_r/a") != "aaaa" || storedMD5("/hash_inv/dir/b") != "bbbb" || storedMD5("/hash_inv/DIR/c") != "cccc" {
+		t.Fatal("stored hashes must be added")
+	}
+
+	if err := DeleteFileHashes("/hash_inv/d_r"); err != nil {
+		t.Fatal(err)
+	}
+	if storedMD5("/hash_inv/d_r/a") != "" {
+		t.Error("hashes under the folder must be dropped")
+	}
+	// '_' is a wildcard of LIKE, and LIKE may ignore the case
+	if storedMD5("/hash_inv/dir/b") != "bbbb" || storedMD5("/hash_inv/DIR/c") != "cccc" {
+		t.Error("hashes out of the folder must be kept")
+	}
+	if err := DeleteFileHashes("/hash_inv/dir/b"); err != nil {
+		t.Fatal(err)
+	}
+	if storedMD5("/hash_inv/dir/b") != "" || storedMD5("/hash_inv/DIR/c") != "cccc" {
+		t.Error("only the hashes of the file must be dropped")
+	}
+}
+
+func TestFileHashInvalidationOfMirrors(t *testing.T) {
+	src := &mirrorStorage{storage: model.Storage{MountPath: "/hash_src"}}
+	mirror := &mirrorStorage{storage: model.Storage{MountPath: "/hash_mirror"}}
+	storagesMap.Store(mirror.storage.MountPath, mirror)
+	t.Cleanup(func() { storagesMap.Delete(mirror.storage.MountPath) })
+	saveTestFileHash(t, "/hash_src/dir/a", "aaaa")
+	saveTestFileHash(t, "/hash_mirror/dir/a", "aaaa")
+	saveTestFileHash(t, "/hash_mirror/other/b", "bbbb")
+	if storedMD5("/hash_mirror/dir/a") != "aaaa" {
+		t.Fatal("stored hashes must be added")
+	}
+
+	// a write through the source drops the hashes of the path showing it
+	invalidateFileHashes(src, "/dir/a")
+	if storedMD5("/hash_src/dir/a") != "" || storedMD5("/hash_mirror/dir/a") != "" {
+		t.Error("hashes of the written file must be dropped in all storages")
+	}
+	if storedMD5("/hash_mirror/other/b") != "bbbb" {
+		t.Error("hashes of other files must be kept")
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/Xhofe/go-cache"
 	"github.com/alist-org/alist/v3/internal/driver"
 	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/metrics"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/stream"
 	"github.com/alist-org/alist/v3/pkg/generic_sync"
@@ -25,6 +26,8 @@ var listG singleflight.Group[[]model.Obj]
 
 func updateCacheObj(storage driver.Driver, path string, oldObj model.Obj, newObj model.Obj) {
 	key := Key(storage, path)
+	// the other instances drop their listing instead of patching it
+	invalidate(InvalidateList, key)
 	objs, ok := listCache.Get(key)
 	if ok {
 		for i, obj := range objs {
@@ -45,6 +48,8 @@ func updateCacheObj(storage driver.Driver, path string, oldObj model.Obj, newObj
 
 func delCacheObj(storage driver.Driver, path string, obj model.Obj) {
 	key := Key(storage, path)
+	// the other instances drop their listing instead of patching it
+	invalidate(InvalidateList, key)
 	objs, ok := listCache.Get(key)
 	if ok {
 		for i, oldObj := range objs {
@@ -61,6 +66,8 @@ var addSortDebounceMap generic_sync.MapOf[string, func(func())]
 
 func addCacheObj(storage driver.Driver, path string, newObj model.Obj) {
 	key := Key(storage, path)
+	// the other instances drop their listing instead of patching it
+	invalidate(InvalidateList, key)
 	objs, ok := listCache.Get(key)
 	if ok {
 		for i, obj := range objs {
@@ -92,15 +99,10 @@ func addCacheObj(storage driver.Driver, path string, newObj model.Obj) {
 }
 
 func ClearCache(storage driver.Driver, path string) {
-	objs, ok := listCache.Get(Key(storage, path))
-	if ok {
-		for _, obj := range objs {
-			if obj.IsDir() {
-				ClearCache(storage, stdpath.Join(path, obj.GetName()))
-			}
-		}
-	}
-	listCache.Del(Key(storage, path))
+	key := Key(storage, path)
+	clearCacheByKey(key)
+	invalidate(InvalidateList, key)
+	invalidateArchives(storage, path)
 }
 
 func Key(storage driver.Driver, path string) string {
@@ -116,7 +118,9 @@ func List(ctx context.Context, storage driver.Driver, path string, args model.Li
 	log.Debugf("op.List %s", path)
 	key := Key(storage, path)
 	if !args.Refresh {
-		if files, ok := listCache.Get(key); ok {
+		files, ok := listCache.Get(key)
+		metrics.ObserveCache("list", ok)
+		if ok {
 			log.Debugf("use cache when list %s", path)
 			return files, nil
 		}
@@ -130,7 +134,12 @@ func List(ctx context.Context, storage driver.Driver, path string, args model.Li
 		return nil, errors.WithStack(errs.NotFolder)
 	}
 	objs, err, _ := listG.Do(key, func() ([]model.Obj, error) {
+		if err := waitRequest(ctx, storage); err != nil {
+			return nil, err
+		}
+		start := time.Now()
 		files, err := storage.List(ctx, dir, args)
+		metrics.ObserveDriverCall(storage.GetStorage().MountPath, storage.Config().Name, "list", err, start)
 		if err != nil {
 			return nil, errors.Wrapf(err, "failed to list objs")
 		}
@@ -259,11 +268,19 @@ func Link(ctx context.Context, storage driver.Driver, path string, args model.Li
 		return nil, nil, errors.WithStack(errs.NotFile)
 	}
 	key := Key(storage, path)
-	if link, ok := linkCache.Get(key); ok {
-		return link, file, nil
+	link, ok := linkCache.Get(key)
+	metrics.ObserveCache("link", ok)
+	if ok {
+		link, err = limitLink(ctx, storage, link, file)
+		return link, file, err
 	}
 	fn := func() (*model.Link, error) {
+		if err := waitRequest(ctx, storage); err != nil {
+			return nil, err
+		}
+		start := time.Now()
 		link, err := storage.Link(ctx, file, args)
+		metrics.ObserveDriverCall(storage.GetStorage().MountPath, storage.Config().Name, "link", err, start)
 		if err != nil {
 			return nil, errors.Wrapf(err, "failed get link")
 		}
@@ -277,11 +294,14 @@ func Link(ctx context.Context, storage driver.Driver, path string, args model.Li
 	}
 
 	if storage.Config().OnlyLocal {
-		link, err := fn()
-		return link, file, err
+		link, err = fn()
+	} else {
+		link, err, _ = linkG.Do(key, fn)
 	}
-
-	link, err, _ := linkG.Do(key, fn)
+	if err != nil {
+		return nil, file, err
+	}
+	link, err = limitLink(ctx, storage, link, file)
 	return link, file, err
 }
 
@@ -399,6 +419,10 @@ func Move(ctx context.Context, storage driver.Driver, srcPath, dstDirPath string
 	default:
 		return errs.NotImplement
 	}
+	if err == nil {
+		invalidateFileHashes(storage, srcPath)
+		invalidateArchives(storage, srcPath)
+	}
 	return errors.WithStack(err)
 }
 
@@ -433,6 +457,10 @@ func Rename(ctx context.Context, storage driver.Driver, srcPath, dstName string,
 	default:
 		return errs.NotImplement
 	}
+	if err == nil {
+		invalidateFileHashes(storage, srcPath)
+		invalidateArchives(storage, srcPath)
+	}
 	return errors.WithStack(err)
 }
 
@@ -471,6 +499,10 @@ func Copy(ctx context.Context, storage driver.Driver, srcPath, dstDirPath string
 	default:
 		return errs.NotImplement
 	}
+	if err == nil {
+		invalidateFileHashes(storage, stdpath.Join(dstDirPath, srcObj.GetName()))
+		invalidateArchives(storage, stdpath.Join(dstDirPath, srcObj.GetName()))
+	}
 	return errors.WithStack(err)
 }
 
@@ -506,6 +538,33 @@ func Remove(ctx context.Context, storage driver.Driver, path string) error {
 	default:
 		return errs.NotImplement
 	}
+	if err == nil {
+		invalidateFileHashes(storage, path)
+		invalidateArchives(storage, path)
+	}
+	return errors.WithStack(err)
+}
+
+func SetModTime(ctx context.Context, storage driver.Driver, path string, modTime time.Time) error {
+	if storage.Config().CheckStatus && storage.GetStorage().Status != WORK {
+		return errors.Errorf("storage not init: %s", storage.GetStorage().Status)
+	}
+	path = utils.FixAndCleanPath(path)
+	s, ok := storage.(driver.SetModTime)
+	if !ok {
+		return errs.NotImplement
+	}
+	obj, err := GetUnwrap(ctx, storage, path)
+	if err != nil {
+		return errors.WithMessage(err, "failed to get obj")
+	}
+	err = s.SetModTime(ctx, obj, modTime)
+	if err == nil {
+		listCache.Del(Key(storage, stdpath.Dir(path)))
+		invalidate(InvalidateList, Key(storage, stdpath.Dir(path)))
+		invalidateFileHashes(storage, path)
+		invalidateArchives(storage, path)
+	}
 	return errors.WithStack(err)
 }
 
@@ -518,6 +577,11 @@ func Put(ctx context.Context, storage driver.Driver, dstDirPath string, file mod
 			log.Errorf("failed to close file streamer, %v", err)
 		}
 	}()
+	ctx, release, err := acquireTransfer(ctx, storage)
+	if err != nil {
+		return err
+	}
+	defer release()
 	// UrlTree PUT
 	if storage.GetStorage().Driver == "UrlTree" {
 		var link string
@@ -559,7 +623,11 @@ func Put(ctx context.Context, storage driver.Driver, dstDirPath string, file mod
 	if up == nil {
 		up = func(p float64) {}
 	}
+	if err = waitRequest(ctx, storage); err != nil {
+		return err
+	}
 
+	start := time.Now()
 	switch s := storage.(type) {
 	case driver.PutResult:
 		var newObj model.Obj
@@ -579,6 +647,12 @@ func Put(ctx context.Context, storage driver.Driver, dstDirPath string, file mod
 	default:
 		return errs.NotImplement
 	}
+	metrics.ObserveDriverCall(storage.GetStorage().MountPath, storage.Config().Name, "put", err, start)
+	if err == nil {
+		metrics.AddBytes(metrics.DirectionUploaded, file.GetSize())
+		invalidateFileHashes(storage, dstPath)
+		invalidateArchives(storage, dstPath)
+	}
 	log.Debugf("put file [%s] done", file.GetName())
 	if storage.Config().NoOverwriteUpload && fi != nil && fi.GetSize() > 0 {
 		if err != nil {
@@ -595,6 +669,7 @@ func Put(ctx context.Context, storage driver.Driver, dstDirPath string, file mod
 			} else {
 				key := Key(storage, stdpath.Join(dstDirPath, file.GetName()))
 				linkCache.Del(key)
+				invalidate(InvalidateLink, key)
 			}
 		}
 	}
@@ -618,6 +693,9 @@ func PutURL(ctx context.Context, storage driver.Driver, dstDirPath, dstName, url
 	if err != nil {
 		return errors.WithMessagef(err, "failed to put url")
 	}
+	if err = waitRequest(ctx, storage); err != nil {
+		return err
+	}
 	switch s := storage.(type) {
 	case driver.PutURLResult:
 		var newObj model.Obj
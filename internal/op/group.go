@@ -0,0 +1,161 @@
+package op
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Xhofe/go-cache"
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/singleflight"
+	"github.com/alist-org/alist/v3/pkg/utils"
+)
+
+// groupCache holds the groups of a user by its id
+var groupCache = cache.NewMemCache(cache.WithShards[[]model.Group](2))
+var groupG singleflight.Group[[]model.Group]
+
+func init() {
+	model.FetchGroups = GetGroupsByUserID
+}
+
+func GetGroupsByUserID(userID uint) ([]model.Group, error) {
+	key := fmt.Sprint(userID)
+	if groups, ok := groupCache.Get(key); ok {
+		return groups, nil
+	}
+	groups, err, _ := groupG.Do(key, func() ([]model.Group, error) {
+		groups, err := db.GetGroupsByUserId(userID)
+		if err != nil {
+			return nil, err
+		}
+		groupCache.Set(key, groups, cache.WithEx[[]model.Group](time.Hour))
+		return groups, nil
+	})
+	return groups, err
+}
+
+// GetGroupIDsByUserID returns the ids of the groups of the user, nil if they can't be loaded
+func GetGroupIDsByUserID(userID uint) []uint {
+	groups, err := GetGroupsByUserID(userID)
+	if err != nil {
+		return nil
+	}
+	ids := make([]uint, 0, len(groups))
+	for _, g := range groups {
+		ids = append(ids, g.ID)
+	}
+	return ids
+}
+
+func IsGroupMember(userID, groupID uint) bool {
+	for _, id := range GetGroupIDsByUserID(userID) {
+		if id == groupID {
+			return true
+		}
+	}
+	return false
+}
+
+// GetAdminGroupIDsByUserID returns the ids of the groups the user is an admin of
+func GetAdminGroupIDsByUserID(userID uint) []uint {
+	ids := make([]uint, 0)
+	for _, id := range GetGroupIDsByUserID(userID) {
+		if m, err := db.GetGroupMember(id, userID); err == nil && m.Admin {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// GroupUser is the user a group acts as, it only holds the permission of the
+// group on its home path and outlives the members of the group
+func GroupUser(g *model.Group) *model.User {
+	return &model.User{Username: "group:" + g.Name, BasePath: "/", ActingGroup: g}
+}
+
+// CanManageGroup reports whether the user is a site admin or an admin of the group
+func CanManageGroup(u *model.User, groupID uint) bool {
+	if u.IsAdmin() {
+		return true
+	}
+	m, err := db.GetGroupMember(groupID, u.ID)
+	return err == nil && m.Admin
+}
+
+func GetGroup(id uint) (*model.Group, error) {
+	return db.GetGroup(id)
+}
+
+func GetGroupByName(name string) (*model.Group, error) {
+	return db.GetGroupByName(name)
+}
+
+func GetGroups(pageIndex, pageSize int) ([]model.Group, int64, error) {
+	return db.GetGroups(pageIndex, pageSize)
+}
+
+func CreateGroup(g *model.Group) error {
+	if g.HomePath != "" {
+		g.HomePath = utils.FixAndCleanPath(g.HomePath)
+	}
+	if err := db.CreateGroup(g); err != nil {
+		return err
+	}
+	groupsChanged()
+	return nil
+}
+
+func UpdateGroup(g *model.Group) error {
+	if g.HomePath != "" {
+		g.HomePath = utils.FixAndCleanPath(g.HomePath)
+	}
+	if err := db.UpdateGroup(g); err != nil {
+		return err
+	}
+	groupsChanged()
+	return nil
+}
+
+func DeleteGroup(id uint) error {
+	if err := db.DeleteGroup(id); err != nil {
+		return err
+	}
+	if err := db.DeleteInternalSharesByTarget(model.InternalShareGroup, id); err != nil {
+		return err
+	}
+	groupsChanged()
+	return nil
+}
+
+func GetGroupMembers(groupID uint) ([]model.GroupMember, error) {
+	return db.GetGroupMembers(groupID)
+}
+
+func SaveGroupMember(m *model.GroupMember) error {
+	if _, err := db.GetUserById(m.UserID); err != nil {
+		return err
+	}
+	if err := db.SaveGroupMember(m); err != nil {
+		return err
+	}
+	groupsChanged()
+	return nil
+}
+
+func RemoveGroupMember(groupID, userID uint) error {
+	if _, err := db.GetGroupMember(groupID, userID); err != nil {
+		return errs.NotGroupMember
+	}
+	if err := db.DeleteGroupMember(groupID, userID); err != nil {
+		return err
+	}
+	groupsChanged()
+	return nil
+}
+
+func groupsChanged() {
+	groupCache.Clear()
+	invalidate(InvalidateUsers, "")
+}
@@ -0,0 +1,108 @@
+package op
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Xhofe/go-cache"
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/singleflight"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+// incomingShareCache holds the internal shares granted to a user by its id
+var incomingShareCache = cache.NewMemCache(cache.WithShards[[]model.InternalShare](2))
+var incomingShareG singleflight.Group[[]model.InternalShare]
+
+func init() {
+	model.FetchIncomingShares = GetIncomingShares
+}
+
+// GetIncomingShares returns the internal shares granted to the user directly,
+// by its roles or by its groups, except its own ones
+func GetIncomingShares(u *model.User) ([]model.InternalShare, error) {
+	if u.ID == 0 || u.IsGuest() {
+		return nil, nil
+	}
+	key := fmt.Sprint(u.ID)
+	if shares, ok := incomingShareCache.Get(key); ok {
+		return shares, nil
+	}
+	shares, err, _ := incomingShareG.Do(key, func() ([]model.InternalShare, error) {
+		roleIDs := make([]uint, 0, len(u.Role))
+		for _, id := range u.Role {
+			roleIDs = append(roleIDs, uint(id))
+		}
+		all, err := db.GetInternalSharesByTargets(u.ID, roleIDs, GetGroupIDsByUserID(u.ID))
+		if err != nil {
+			return nil, err
+		}
+		shares := make([]model.InternalShare, 0, len(all))
+		for _, s := range all {
+			if s.OwnerID != u.ID {
+				shares = append(shares, s)
+			}
+		}
+		incomingShareCache.Set(key, shares, cache.WithEx[[]model.InternalShare](time.Hour))
+		return shares, nil
+	})
+	return shares, err
+}
+
+func GetInternalShare(id uint) (*model.InternalShare, error) {
+	return db.GetInternalShare(id)
+}
+
+func GetInternalSharesByOwner(ownerID uint, pageIndex, pageSize int) ([]model.InternalShare, int64, error) {
+	return db.GetInternalSharesByOwner(ownerID, pageIndex, pageSize)
+}
+
+func CreateInternalShare(s *model.InternalShare) error {
+	s.Path = utils.FixAndCleanPath(s.Path)
+	var err error
+	switch s.TargetType {
+	case model.InternalShareUser:
+		var u *model.User
+		if u, err = db.GetUserById(s.TargetID); err == nil && u.IsGuest() {
+			err = errors.New("can't share with the guest")
+		}
+	case model.InternalShareRole:
+		_, err = db.GetRole(s.TargetID)
+	case model.InternalShareGroup:
+		_, err = db.GetGroup(s.TargetID)
+	default:
+		return errors.Errorf("unknown target type: %s", s.TargetType)
+	}
+	if err != nil {
+		return errors.WithMessagef(err, "invalid %s target %d", s.TargetType, s.TargetID)
+	}
+	if err := db.CreateInternalShare(s); err != nil {
+		return err
+	}
+	internalSharesChanged()
+	return nil
+}
+
+// DeleteInternalShare revokes the share, only its owner or an admin can do it
+func DeleteInternalShare(u *model.User, id uint) error {
+	s, err := db.GetInternalShare(id)
+	if err != nil {
+		return err
+	}
+	if s.OwnerID != u.ID && !u.IsAdmin() {
+		return errs.PermissionDenied
+	}
+	if err := db.DeleteInternalShare(id); err != nil {
+		return err
+	}
+	internalSharesChanged()
+	return nil
+}
+
+func internalSharesChanged() {
+	incomingShareCache.Clear()
+	invalidate(InvalidateUsers, "")
+}
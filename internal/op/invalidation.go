@@ -0,0 +1,130 @@
+package op
+
+import (
+	"context"
+	stdpath "path"
+	"strconv"
+
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// Kinds of the invalidations, the key of a list, link or archive invalidation is Key of the path,
+// the key of a file hash invalidation is the full path,
+// the key of a storage invalidation is its id or empty for all storages, the others have no key
+const (
+	InvalidateList     = "list"
+	InvalidateLink     = "link"
+	InvalidateArchive  = "archive"
+	InvalidateFileHash = "file_hash"
+	InvalidateStorage  = "storage"
+	InvalidateSettings = "settings"
+	InvalidateUsers    = "users"
+	InvalidateMetas    = "metas"
+)
+
+// InvalidationHook is called when the runtime state is changed by this instance,
+// other instances sharing the database have to apply the invalidation with ApplyInvalidation
+type InvalidationHook func(kind, key string)
+
+var invalidationHooks = make([]InvalidationHook, 0)
+
+func RegisterInvalidationHook(hook InvalidationHook) {
+	invalidationHooks = append(invalidationHooks, hook)
+}
+
+func invalidate(kind, key string) {
+	for _, hook := range invalidationHooks {
+		hook(kind, key)
+	}
+}
+
+// ApplyInvalidation drops the state changed by another instance, it doesn't call the hooks
+func ApplyInvalidation(ctx context.Context, kind, key string) error {
+	switch kind {
+	case InvalidateList:
+		clearCacheByKey(key)
+	case InvalidateLink:
+		linkCache.Del(key)
+	case InvalidateArchive:
+		clearArchiveCache(key)
+	case InvalidateFileHash:
+		fileHashes.clear(key)
+	case InvalidateStorage:
+		if key == "" {
+			reloadStorages(ctx)
+			return nil
+		}
+		id, err := strconv.ParseUint(key, 10, 64)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		return reloadStorage(ctx, uint(id))
+	case InvalidateSettings:
+		return reloadSettings()
+	case InvalidateUsers:
+		clearUserCaches()
+	case InvalidateMetas:
+		metaCache.Clear()
+	default:
+		return errors.Errorf("unknown invalidation: %s", kind)
+	}
+	return nil
+}
+
+// clearCacheByKey is ClearCache without the driver, the keys of the sub folders are joined to the key
+func clearCacheByKey(key string) {
+	if objs, ok := listCache.Get(key); ok {
+		for _, obj := range objs {
+			if obj.IsDir() {
+				clearCacheByKey(stdpath.Join(key, obj.GetName()))
+			}
+		}
+	}
+	listCache.Del(key)
+}
+
+// reloadStorage makes the loaded storage match the database
+func reloadStorage(ctx context.Context, id uint) error {
+	// the mount path may have been changed, so the loaded storage is found by its id
+	for _, d := range storagesMap.Values() {
+		if d.GetStorage().ID != id {
+			continue
+		}
+		ClearCache(d, "/")
+		if err := d.Drop(ctx); err != nil {
+			log.Warnf("failed drop storage %s: %+v", d.GetStorage().MountPath, err)
+		}
+		storagesMap.Delete(d.GetStorage().MountPath)
+		go callStorageHooks("del", d)
+	}
+	storage, err := db.GetStorageById(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		dropStorageLimiter(id)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if storage.Disabled {
+		return nil
+	}
+	return LoadStorage(ctx, *storage)
+}
+
+// reloadSettings drops the cached settings and runs the hooks of all items with their new values
+func reloadSettings() error {
+	items, err := db.GetSettingItems()
+	if err != nil {
+		return err
+	}
+	for i := range items {
+		if _, err := HandleSettingItemHook(&items[i]); err != nil {
+			log.Warnf("failed handle setting hook of %s: %+v", items[i].Key, err)
+		}
+	}
+	settingCacheUpdate()
+	return nil
+}
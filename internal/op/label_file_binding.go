@@ -3,6 +3,7 @@ package op
 import (
 	"fmt"
 	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/errs"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/pkg/errors"
 	"strconv"
@@ -24,6 +25,7 @@ type CreateLabelFileBinDingReq struct {
 	HashInfoStr string    `json:"hashinfo"`
 	LabelIds    string    `json:"label_ids"`
 	LabelIDs    []uint64  `json:"labelIdList"`
+	GroupId     uint      `json:"group_id"` // binds the labels for the members of the group
 }
 
 type ObjLabelResp struct {
@@ -42,7 +44,7 @@ type ObjLabelResp struct {
 }
 
 func GetLabelByFileName(userId uint, fileName string) ([]model.Label, error) {
-	labelIds, err := db.GetLabelIds(userId, fileName)
+	labelIds, err := db.GetLabelIds(userId, GetGroupIDsByUserID(userId), fileName)
 	if err != nil {
 		return nil, errors.WithMessage(err, "failed get label_file_binding")
 	}
@@ -60,7 +62,10 @@ func GetLabelsByFileNamesPublic(fileNames []string) (map[string][]model.Label, e
 }
 
 func CreateLabelFileBinDing(req CreateLabelFileBinDingReq, userId uint) error {
-	if err := db.DelLabelFileBinDingByFileName(userId, req.Name); err != nil {
+	if req.GroupId != 0 && !IsGroupMember(userId, req.GroupId) {
+		return errs.NotGroupMember
+	}
+	if err := db.DelLabelFileBinDingByFileName(userId, req.GroupId, req.Name); err != nil {
 		return errors.WithMessage(err, "failed del label_file_bin_ding in database")
 	}
 
@@ -73,7 +78,7 @@ func CreateLabelFileBinDing(req CreateLabelFileBinDingReq, userId uint) error {
 	}
 
 	for _, id := range ids {
-		if err = db.CreateLabelFileBinDing(req.Name, uint(id), userId); err != nil {
+		if err = db.CreateLabelFileBinDing(req.Name, uint(id), userId, req.GroupId); err != nil {
 			return errors.WithMessage(err, "failed labels in database")
 		}
 	}
@@ -118,16 +123,21 @@ func GetFileByLabel(userId uint, labelId string) (result []ObjLabelResp, err err
 		labelsMap[val.ID] = val
 	}
 	//查询标签对应文件名列表
-	if labelsFile, err = db.GetLabelFileBinDingByLabelId(labelIds, userId); err != nil {
+	if labelsFile, err = db.GetLabelFileBinDingByLabelId(labelIds, userId, GetGroupIDsByUserID(userId)); err != nil {
 		return nil, errors.WithMessage(err, "failed labels in database")
 	}
+	// the files bound for a group are recorded by the member binding them
+	var fileOwners = make(map[string]uint)
 	for _, value := range labelsFile {
 		var labelTemp model.Label
 		labelTemp = labelsMap[value.LabelId]
 		labelsFileMap[value.FileName] = append(labelsFileMap[value.FileName], labelTemp)
+		if _, ok := fileOwners[value.FileName]; !ok {
+			fileOwners[value.FileName] = value.UserId
+		}
 	}
 	for index, v := range labelsFileMap {
-		objFile, err := db.GetFileByName(index, userId)
+		objFile, err := db.GetFileByName(index, fileOwners[index])
 		if err != nil {
 			return nil, errors.WithMessage(err, "failed GetFileByName in database")
 		}
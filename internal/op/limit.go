@@ -0,0 +1,232 @@
+package op
+
+import (
+	"context"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/stream"
+	"github.com/alist-org/alist/v3/pkg/http_range"
+	"golang.org/x/time/rate"
+)
+
+// storageLimiter enforces the model.Limit of a storage, it's shared by all entry points
+// because they all reach the driver through this package
+type storageLimiter struct {
+	limit     model.Limit
+	transfers chan struct{}
+	requests  *rate.Limiter
+	upload    stream.Limiter
+	download  stream.Limiter
+}
+
+func newStorageLimiter(limit model.Limit) *storageLimiter {
+	l := &storageLimiter{limit: limit}
+	if limit.MaxTransfers > 0 {
+		l.transfers = make(chan struct{}, limit.MaxTransfers)
+	}
+	if limit.RequestsPerSecond > 0 {
+		l.requests = rate.NewLimiter(rate.Limit(limit.RequestsPerSecond), max(1, int(limit.RequestsPerSecond)))
+	}
+	if limit.MaxUploadSpeed > 0 {
+		l.upload = stream.NewLimiter(rate.Limit(limit.MaxUploadSpeed)*1024, limit.MaxUploadSpeed*1024)
+	}
+	if limit.MaxDownloadSpeed > 0 {
+		l.download = stream.NewLimiter(rate.Limit(limit.MaxDownloadSpeed)*1024, limit.MaxDownloadSpeed*1024)
+	}
+	return l
+}
+
+var (
+	storageLimitersMu sync.Mutex
+	storageLimiters   = make(map[uint]*storageLimiter)
+)
+
+// getStorageLimiter returns nil if the storage is unlimited,
+// the limiter is rebuilt when the limit of the storage is changed
+func getStorageLimiter(storage driver.Driver) *storageLimiter {
+	s := storage.GetStorage()
+	storageLimitersMu.Lock()
+	defer storageLimitersMu.Unlock()
+	if s.Limit == (model.Limit{}) {
+		delete(storageLimiters, s.ID)
+		return nil
+	}
+	l, ok := storageLimiters[s.ID]
+	if !ok || l.limit != s.Limit {
+		l = newStorageLimiter(s.Limit)
+		storageLimiters[s.ID] = l
+	}
+	return l
+}
+
+func dropStorageLimiter(id uint) {
+	storageLimitersMu.Lock()
+	defer storageLimitersMu.Unlock()
+	delete(storageLimiters, id)
+}
+
+// waitRequest blocks until the storage may be called again
+func waitRequest(ctx context.Context, storage driver.Driver) error {
+	if l := getStorageLimiter(storage); l != nil && l.requests != nil {
+		return l.requests.Wait(ctx)
+	}
+	return nil
+}
+
+// acquire takes a transfer slot, release must be called once the transfer is done
+func (l *storageLimiter) acquire(ctx context.Context) (func(), error) {
+	select {
+	case l.transfers <- struct{}{}:
+		return func() { <-l.transfers }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// acquireTransfer takes a transfer slot of the storage and sets its upload limit to ctx,
+// release must be called once the transfer is done
+func acquireTransfer(ctx context.Context, storage driver.Driver) (context.Context, func(), error) {
+	l := getStorageLimiter(storage)
+	if l == nil {
+		return ctx, func() {}, nil
+	}
+	if l.upload != nil {
+		ctx = context.WithValue(ctx, conf.StorageUploadLimit, l.upload)
+	}
+	if l.transfers == nil {
+		return ctx, func() {}, nil
+	}
+	release, err := l.acquire(ctx)
+	if err != nil {
+		return ctx, nil, err
+	}
+	return ctx, release, nil
+}
+
+// limitLink applies the download and transfer limits of the storage to the link read by alist,
+// the link may be cached so a copy is returned. Redirects to URL can't be limited.
+func limitLink(ctx context.Context, storage driver.Driver, link *model.Link, file model.Obj) (*model.Link, error) {
+	l := getStorageLimiter(storage)
+	if l == nil || (l.download == nil && l.transfers == nil) {
+		return link, nil
+	}
+	limited := *link
+	switch {
+	case link.MFile != nil:
+		var f model.File = link.MFile
+		if l.download != nil {
+			f = &stream.RateLimitFile{
+				File:    f,
+				Limiter: l.download,
+				Ctx:     ctx,
+			}
+		}
+		if l.transfers != nil {
+			f = &transferFile{File: f, slot: transferSlot{limiter: l}, ctx: ctx}
+		}
+		limited.MFile = f
+	case link.RangeReadCloser != nil || link.URL != "":
+		// keep URL for redirects, the proxies prefer RangeReadCloser
+		rrc := link.RangeReadCloser
+		if rrc == nil {
+			var err error
+			if rrc, err = stream.GetRangeReadCloserFromLink(file.GetSize(), link); err != nil {
+				return nil, err
+			}
+		}
+		if l.download != nil {
+			rrc = &stream.RateLimitRangeReadCloser{
+				RangeReadCloserIF: rrc,
+				Limiter:           l.download,
+			}
+		}
+		if l.transfers != nil {
+			rrc = &transferRangeReadCloser{RangeReadCloserIF: rrc, slot: transferSlot{limiter: l}}
+		}
+		limited.RangeReadCloser = rrc
+	}
+	return &limited, nil
+}
+
+// transferSlot is the transfer slot of a link, it's taken by the first read and released by the close,
+// so a link that is never read, like a redirect, doesn't take any
+type transferSlot struct {
+	limiter *storageLimiter
+	mu      sync.Mutex
+	release func()
+	closed  bool
+}
+
+func (s *transferSlot) take(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.release != nil {
+		return nil
+	}
+	if s.closed {
+		return os.ErrClosed
+	}
+	release, err := s.limiter.acquire(ctx)
+	if err != nil {
+		return err
+	}
+	s.release = release
+	return nil
+}
+
+func (s *transferSlot) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	if s.release != nil {
+		s.release()
+		s.release = nil
+	}
+}
+
+type transferFile struct {
+	model.File
+	slot transferSlot
+	ctx  context.Context
+}
+
+func (f *transferFile) Read(p []byte) (int, error) {
+	if err := f.slot.take(f.ctx); err != nil {
+		return 0, err
+	}
+	return f.File.Read(p)
+}
+
+func (f *transferFile) ReadAt(p []byte, off int64) (int, error) {
+	if err := f.slot.take(f.ctx); err != nil {
+		return 0, err
+	}
+	return f.File.ReadAt(p, off)
+}
+
+func (f *transferFile) Close() error {
+	f.slot.close()
+	return f.File.Close()
+}
+
+type transferRangeReadCloser struct {
+	model.RangeReadCloserIF
+	slot transferSlot
+}
+
+func (rrc *transferRangeReadCloser) RangeRead(ctx context.Context, httpRange http_range.Range) (io.ReadCloser, error) {
+	if err := rrc.slot.take(ctx); err != nil {
+		return nil, err
+	}
+	return rrc.RangeReadCloserIF.RangeRead(ctx, httpRange)
+}
+
+func (rrc *transferRangeReadCloser) Close() error {
+	rrc.slot.close()
+	return rrc.RangeReadCloserIF.Close()
+}
@@ -0,0 +1,99 @@
+package op
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/http_range"
+)
+
+type limitedDriver struct {
+	model.Storage
+}
+
+func (d *limitedDriver) Config() driver.Config          { return driver.Config{} }
+func (d *limitedDriver) GetAddition() driver.Additional { return nil }
+func (d *limitedDriver) Init(ctx context.Context) error { return nil }
+func (d *limitedDriver) Drop(ctx context.Context) error { return nil }
+func (d *limitedDriver) List(ctx context.Context, dir model.Obj, args model.ListArgs) ([]model.Obj, error) {
+	return nil, nil
+}
+func (d *limitedDriver) Link(ctx context.Context, file model.Obj, args model.LinkArgs) (*model.Link, error) {
+	return nil, nil
+}
+
+func TestAcquireTransfer(t *testing.T) {
+	d := &limitedDriver{Storage: model.Storage{ID: 1000, Limit: model.Limit{MaxTransfers: 1}}}
+	_, release, err := acquireTransfer(context.Background(), d)
+	if err != nil {
+		t.Fatalf("failed acquire transfer: %+v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, _, err := acquireTransfer(ctx, d); err == nil {
+		t.Fatal("the second transfer must wait for the first one")
+	}
+	release()
+	_, release, err = acquireTransfer(context.Background(), d)
+	if err != nil {
+		t.Fatalf("failed acquire released transfer: %+v", err)
+	}
+	release()
+
+	// a changed limit takes effect without reloading the storage
+	d.Limit = model.Limit{}
+	if getStorageLimiter(d) != nil {
+		t.Error("unlimited storage must not have a limiter")
+	}
+}
+
+func TestLimitLinkTransfer(t *testing.T) {
+	d := &limitedDriver{Storage: model.Storage{ID: 1001, Limit: model.Limit{MaxTransfers: 1}}}
+	file := &model.Object{Name: "a", Size: 3}
+	rrc := &model.RangeReadCloser{RangeReader: func(ctx context.Context, httpRange http_range.Range) (io.ReadCloser, error) {
+		return io.NopCloser(strings.NewReader("abc")), nil
+	}}
+	link := &model.Link{URL: "http://example.com/a", RangeReadCloser: rrc}
+	first, err := limitLink(context.Background(), d, link, file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a link that isn't read, like a redirect, takes no slot
+	second, err := limitLink(context.Background(), d, link, file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.URL != link.URL {
+		t.Error("url must be kept for redirects")
+	}
+	if _, err := first.RangeReadCloser.RangeRead(context.Background(), http_range.Range{Length: -1}); err != nil {
+		t.Fatal(err)
+	}
+	// the slot is held by the first link until it's closed, and all its ranges share it
+	if _, err := first.RangeReadCloser.RangeRead(context.Background(), http_range.Range{Length: -1}); err != nil {
+		t.Fatalf("ranges of the same link must share the slot: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := second.RangeReadCloser.RangeRead(ctx, http_range.Range{Length: -1}); err == nil {
+		t.Fatal("the second download must wait for the first one")
+	}
+	if _, _, err := acquireTransfer(ctx, d); err == nil {
+		t.Fatal("an upload must wait for the download")
+	}
+	_ = first.RangeReadCloser.Close()
+	if _, err := second.RangeReadCloser.RangeRead(context.Background(), http_range.Range{Length: -1}); err != nil {
+		t.Fatalf("failed read after the first download is closed: %v", err)
+	}
+	_ = second.RangeReadCloser.Close()
+	if _, release, err := acquireTransfer(context.Background(), d); err != nil {
+		t.Fatalf("closed download must release its slot: %v", err)
+	} else {
+		release()
+	}
+}
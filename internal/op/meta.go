@@ -98,7 +98,11 @@ func DeleteMetaById(id uint) error {
 		return err
 	}
 	metaCache.Del(old.Path)
-	return db.DeleteMetaById(id)
+	if err := db.DeleteMetaById(id); err != nil {
+		return err
+	}
+	invalidate(InvalidateMetas, "")
+	return nil
 }
 
 func UpdateMeta(u *model.Meta) error {
@@ -109,13 +113,21 @@ func UpdateMeta(u *model.Meta) error {
 	}
 	metaCache.Del(old.Path)
 	metaCache.Del(u.Path)
-	return db.UpdateMeta(u)
+	if err := db.UpdateMeta(u); err != nil {
+		return err
+	}
+	invalidate(InvalidateMetas, "")
+	return nil
 }
 
 func CreateMeta(u *model.Meta) error {
 	u.Path = utils.FixAndCleanPath(u.Path)
 	metaCache.Del(u.Path)
-	return db.CreateMeta(u)
+	if err := db.CreateMeta(u); err != nil {
+		return err
+	}
+	invalidate(InvalidateMetas, "")
+	return nil
 }
 
 func GetMetaById(id uint) (*model.Meta, error) {
@@ -12,6 +12,7 @@ import (
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/pkg/singleflight"
 	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
 )
 
 var roleCache = cache.NewMemCache[*model.Role](cache.WithShards[*model.Role](2))
@@ -136,14 +137,15 @@ func GetRoles(pageIndex, pageSize int) ([]model.Role, int64, error) {
 }
 
 func CreateRole(r *model.Role) error {
-	for i := range r.PermissionScopes {
-		r.PermissionScopes[i].Path = utils.FixAndCleanPath(r.PermissionScopes[i].Path)
+	if err := normalizeScopes(r); err != nil {
+		return err
 	}
 	roleCache.Del(fmt.Sprint(r.ID))
 	roleCache.Del(r.Name)
 	if err := db.CreateRole(r); err != nil {
 		return err
 	}
+	invalidate(InvalidateUsers, "")
 	if r.Default {
 		roleCache.Clear()
 		item, err := GetSettingItemByKey(conf.DefaultRole)
@@ -158,6 +160,20 @@ func CreateRole(r *model.Role) error {
 	return nil
 }
 
+func normalizeScopes(r *model.Role) error {
+	for i := range r.PermissionScopes {
+		entry := &r.PermissionScopes[i]
+		entry.Path = utils.FixAndCleanPath(entry.Path)
+		if entry.Conditions == nil {
+			continue
+		}
+		if err := entry.Conditions.Validate(); err != nil {
+			return errors.WithMessagef(err, "permission scope %s", entry.Path)
+		}
+	}
+	return nil
+}
+
 func UpdateRole(r *model.Role) error {
 	old, err := db.GetRole(r.ID)
 	if err != nil {
@@ -169,8 +185,8 @@ func UpdateRole(r *model.Role) error {
 	case "guest":
 		r.Name = "guest"
 	}
-	for i := range r.PermissionScopes {
-		r.PermissionScopes[i].Path = utils.FixAndCleanPath(r.PermissionScopes[i].Path)
+	if err := normalizeScopes(r); err != nil {
+		return err
 	}
 	//if len(old.PermissionScopes) > 0 && len(r.PermissionScopes) > 0 &&
 	//	old.PermissionScopes[0].Path != r.PermissionScopes[0].Path {
@@ -197,6 +213,7 @@ func UpdateRole(r *model.Role) error {
 	if err := db.UpdateRole(r); err != nil {
 		return err
 	}
+	invalidate(InvalidateUsers, "")
 	if r.Default {
 		roleCache.Clear()
 		item, err := GetSettingItemByKey(conf.DefaultRole)
@@ -221,5 +238,12 @@ func DeleteRole(id uint) error {
 	}
 	roleCache.Del(fmt.Sprint(id))
 	roleCache.Del(old.Name)
-	return db.DeleteRole(id)
+	if err := db.DeleteRole(id); err != nil {
+		return err
+	}
+	if err := db.DeleteInternalSharesByTarget(model.InternalShareRole, id); err != nil {
+		return err
+	}
+	invalidate(InvalidateUsers, "")
+	return nil
 }
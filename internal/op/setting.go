@@ -33,6 +33,11 @@ func RegisterSettingChangingCallback(f func()) {
 }
 
 func SettingCacheUpdate() {
+	settingCacheUpdate()
+	invalidate(InvalidateSettings, "")
+}
+
+func settingCacheUpdate() {
 	settingCache.Clear()
 	settingGroupCache.Clear()
 	for _, cb := range settingChangingCallbacks {
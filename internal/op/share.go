@@ -0,0 +1,28 @@
+package op
+
+import (
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+)
+
+// GetShareActor returns the user the share acts as when it's used: its group
+// for the shares owned by a group, so they don't depend on their creator,
+// its creator otherwise
+func GetShareActor(share *model.Share) (*model.User, error) {
+	if share.GroupID != 0 {
+		g, err := GetGroup(share.GroupID)
+		if err != nil {
+			return nil, err
+		}
+		return GroupUser(g), nil
+	}
+	creator, err := GetUserById(share.CreatorID)
+	if err != nil {
+		return nil, err
+	}
+	if creator.Disabled {
+		return nil, errors.WithStack(errs.PermissionDenied)
+	}
+	return creator, nil
+}
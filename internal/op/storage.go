@@ -76,6 +76,7 @@ func CreateStorage(ctx context.Context, storage model.Storage) (uint, error) {
 	if err != nil {
 		return storage.ID, errors.WithMessage(err, "failed create storage in database")
 	}
+	defer invalidate(InvalidateStorage, fmt.Sprint(storage.ID))
 	// already has an id
 	err = initStorage(ctx, storage, storageDriver)
 	go callStorageHooks("add", storageDriver)
@@ -174,6 +175,7 @@ func EnableStorage(ctx context.Context, id uint) error {
 	if err != nil {
 		return errors.WithMessage(err, "failed update storage in db")
 	}
+	defer invalidate(InvalidateStorage, fmt.Sprint(id))
 	err = LoadStorage(ctx, *storage)
 	if err != nil {
 		return errors.WithMessage(err, "failed load storage")
@@ -206,6 +208,7 @@ func DisableStorage(ctx context.Context, id uint) error {
 	}
 	storagesMap.Delete(storage.MountPath)
 	go callStorageHooks("del", storageDriver)
+	invalidate(InvalidateStorage, fmt.Sprint(id))
 	return nil
 }
 
@@ -229,6 +232,7 @@ func UpdateStorage(ctx context.Context, storage model.Storage) error {
 	if err != nil {
 		return errors.WithMessage(err, "failed update storage in database")
 	}
+	defer invalidate(InvalidateStorage, fmt.Sprint(storage.ID))
 	storageDriver, err := GetStorageByMountPath(oldStorage.MountPath)
 	if err == nil {
 		ClearCache(storageDriver, "/")
@@ -262,6 +266,7 @@ func UpdateStorage(ctx context.Context, storage model.Storage) error {
 				userCache.Del(user.Username)
 			}
 		}
+		invalidate(InvalidateUsers, "")
 	}
 	if err != nil {
 		return errors.WithMessage(err, "failed get storage driver")
@@ -327,6 +332,11 @@ func DeleteStorageById(ctx context.Context, id uint) error {
 	if err := db.DeleteStorageById(id); err != nil {
 		return errors.WithMessage(err, "failed delete storage in database")
 	}
+	if err := db.DeleteMountKeysByStorage(id); err != nil {
+		return errors.WithMessage(err, "failed delete mount keys of storage")
+	}
+	dropStorageLimiter(id)
+	invalidate(InvalidateStorage, fmt.Sprint(id))
 	return nil
 }
 
@@ -436,3 +446,47 @@ func GetBalancedStorage(path string) driver.Driver {
 		return storages[i]
 	}
 }
+
+// GetStorageDetails get the capacity details of the storage
+func GetStorageDetails(ctx context.Context, storage driver.Driver) (*model.StorageDetails, error) {
+	if storage.Config().CheckStatus && storage.GetStorage().Status != WORK {
+		return nil, errors.Errorf("storage not init: %s", storage.GetStorage().Status)
+	}
+	wd, ok := storage.(driver.WithDetails)
+	if !ok {
+		return nil, errs.NotImplement
+	}
+	details, err := wd.GetDetails(ctx)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return details, nil
+}
+
+// ReloadStorages drops all loaded storages and loads the enabled ones in the database again,
+// it's used after the storages are replaced in the database
+func ReloadStorages(ctx context.Context) {
+	reloadStorages(ctx)
+	invalidate(InvalidateStorage, "")
+}
+
+func reloadStorages(ctx context.Context) {
+	for _, storageDriver := range storagesMap.Values() {
+		if err := storageDriver.Drop(ctx); err != nil {
+			log.Errorf("failed drop storage [%s]: %+v", storageDriver.GetStorage().MountPath, err)
+		}
+		storagesMap.Delete(storageDriver.GetStorage().MountPath)
+		dropStorageLimiter(storageDriver.GetStorage().ID)
+		go callStorageHooks("del", storageDriver)
+	}
+	storages, err := db.GetEnabledStorages()
+	if err != nil {
+		log.Errorf("failed get enabled storages: %+v", err)
+		return
+	}
+	for _, storage := range storages {
+		if err := LoadStorage(ctx, storage); err != nil {
+			log.Errorf("failed load storage [%s]: %+v", storage.MountPath, err)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package op
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/Xhofe/go-cache"
@@ -9,6 +10,7 @@ import (
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/pkg/singleflight"
 	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
 )
 
 var userCache = cache.NewMemCache(cache.WithShards[*model.User](2))
@@ -129,7 +131,7 @@ func CreateUser(u *model.User) error {
 		_ = db.UpdateUser(u)
 		userCache.Del(u.Username)
 	}
-
+	invalidate(InvalidateUsers, "")
 	return nil
 }
 
@@ -141,8 +143,45 @@ func DeleteUserById(id uint) error {
 	if old.IsAdmin() || old.IsGuest() {
 		return errs.DeleteAdminOrGuest
 	}
+	// the key of a storage with per user keys is lost with its last holder
+	mountKeys, err := db.GetMountKeysByUser(id)
+	if err != nil {
+		return err
+	}
+	for _, k := range mountKeys {
+		count, err := db.CountMountKeys(k.StorageID)
+		if err != nil {
+			return err
+		}
+		if count <= 1 {
+			return errors.WithMessagef(errs.LastMountHolder, "storage %d, grant its key to another user first", k.StorageID)
+		}
+	}
 	userCache.Del(old.Username)
-	return db.DeleteUserById(id)
+	if err := db.DeleteUserById(id); err != nil {
+		return err
+	}
+	if err := db.DeleteGroupMembersByUserId(id); err != nil {
+		return err
+	}
+	if err := db.DeleteInternalSharesByOwner(id); err != nil {
+		return err
+	}
+	if err := db.DeleteInternalSharesByTarget(model.InternalShareUser, id); err != nil {
+		return err
+	}
+	if err := db.DeleteDownloadLinksByCreator(id); err != nil {
+		return err
+	}
+	if err := db.DeleteMountKeysByUser(id); err != nil {
+		return err
+	}
+	if err := db.DeleteUserKey(id); err != nil {
+		return err
+	}
+	groupCache.Del(fmt.Sprint(id))
+	invalidate(InvalidateUsers, "")
+	return nil
 }
 
 func UpdateUser(u *model.User) error {
@@ -169,7 +208,11 @@ func UpdateUser(u *model.User) error {
 	//		}
 	//	}
 	//}
-	return db.UpdateUser(u)
+	if err := db.UpdateUser(u); err != nil {
+		return err
+	}
+	invalidate(InvalidateUsers, "")
+	return nil
 }
 
 func Cancel2FAByUser(u *model.User) error {
@@ -197,6 +240,7 @@ func DelUserCache(username string) error {
 		guestUser = nil
 	}
 	userCache.Del(username)
+	invalidate(InvalidateUsers, "")
 	return nil
 }
 
@@ -207,3 +251,18 @@ func CountEnabledAdminsExcluding(userID uint) (int64, error) {
 	}
 	return db.CountUsersByRoleAndEnabledExclude(adminRole.ID, userID)
 }
+
+// ClearUserCaches drops all cached users and roles, it's used after they are replaced in the database
+func ClearUserCaches() {
+	clearUserCaches()
+	invalidate(InvalidateUsers, "")
+}
+
+func clearUserCaches() {
+	userCache.Clear()
+	roleCache.Clear()
+	groupCache.Clear()
+	incomingShareCache.Clear()
+	adminUser = nil
+	guestUser = nil
+}
@@ -0,0 +1,40 @@
+package op_test
+
+import (
+	"testing"
+
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/pkg/errors"
+)
+
+func TestDeleteLastMountHolder(t *testing.T) {
+	alice := &model.User{Username: "mount_alice", Password: "alice"}
+	bob := &model.User{Username: "mount_bob", Password: "bob"}
+	for _, u := range []*model.User{alice, bob} {
+		if err := db.CreateUser(u); err != nil {
+			t.Fatalf("create user: %+v", err)
+		}
+	}
+	const storageID = 4242
+	if err := db.CreateMountKey(&model.MountKey{StorageID: storageID, UserID: alice.ID, SealedKey: "sealed"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := op.DeleteUserById(alice.ID); !errors.Is(err, errs.LastMountHolder) {
+		t.Fatalf("expected the last holder not to be deleted, got %v", err)
+	}
+	if _, err := db.GetMountKey(storageID, alice.ID); err != nil {
+		t.Fatalf("expected the key to be kept: %+v", err)
+	}
+	if err := db.CreateMountKey(&model.MountKey{StorageID: storageID, UserID: bob.ID, SealedKey: "sealed"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := op.DeleteUserById(alice.ID); err != nil {
+		t.Fatalf("expected the user to be deleted once another user holds the key: %+v", err)
+	}
+	if n, _ := db.CountMountKeys(storageID); n != 1 {
+		t.Errorf("expected a single holder left, got %d", n)
+	}
+}
@@ -0,0 +1,212 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sync"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/task"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// mu serializes the updates of pipelines, the events of tasks arrive from many workers
+var mu sync.Mutex
+
+// Validate checks that the steps are complete, paths are not resolved here
+func Validate(steps []model.PipelineStep) error {
+	for i, step := range steps {
+		switch step.Action {
+		case model.PipelineDecompress, model.PipelineRefresh:
+		case model.PipelineCopy, model.PipelineMove:
+			if step.DstDir == "" {
+				return fmt.Errorf("step %d: dst_dir is required", i+1)
+			}
+		case model.PipelineRename:
+			if step.SrcNameRegex == "" {
+				return fmt.Errorf("step %d: src_name_regex is required", i+1)
+			}
+			if _, err := regexp.Compile(step.SrcNameRegex); err != nil {
+				return fmt.Errorf("step %d: invalid src_name_regex: %w", i+1, err)
+			}
+		case model.PipelineWebhook:
+			if step.URL == "" {
+				return fmt.Errorf("step %d: url is required", i+1)
+			}
+		default:
+			return fmt.Errorf("step %d: unknown action %q", i+1, step.Action)
+		}
+	}
+	return nil
+}
+
+// Start creates a pipeline running the steps after the tasks created with the returned ctx,
+// Finish must be called with the returned ctx once all of them have been created.
+func Start(ctx context.Context, creator *model.User, steps []model.PipelineStep) (context.Context, *model.Pipeline, error) {
+	if err := Validate(steps); err != nil {
+		return ctx, nil, err
+	}
+	p := &model.Pipeline{
+		CreatorID: creator.ID,
+		Steps:     steps,
+		// hold the step until Finish, so it can't complete while tasks are still being created
+		Pending: 1,
+		State:   model.PipelineRunning,
+	}
+	if err := db.CreatePipeline(p); err != nil {
+		return ctx, nil, err
+	}
+	return context.WithValue(ctx, conf.PipelineKey, p.ID), p, nil
+}
+
+// Finish releases the step held by Start, the pipeline fails if err is not nil
+func Finish(ctx context.Context, err error) {
+	if id := task.PipelineIDFromCtx(ctx); id != 0 {
+		finish(id, err)
+	}
+}
+
+func finish(id uint, err error) {
+	update(id, func(p *model.Pipeline) {
+		if err != nil {
+			// keep the step held, a failed request can't be retried
+			p.State = model.PipelineFailed
+			p.Error = err.Error()
+			return
+		}
+		p.Pending--
+	})
+}
+
+// update applies fn to the pipeline and starts the next step once the current one is done
+func update(id uint, fn func(p *model.Pipeline)) {
+	mu.Lock()
+	defer mu.Unlock()
+	p, err := db.GetPipelineByID(id)
+	if err != nil {
+		log.Warnf("failed get pipeline %d: %+v", id, err)
+		return
+	}
+	if p.State == model.PipelineSucceeded {
+		return
+	}
+	fn(p)
+	if p.Pending <= 0 {
+		advance(p)
+	}
+	if err := db.UpdatePipeline(p); err != nil {
+		log.Errorf("failed update pipeline %d: %+v", id, err)
+	}
+}
+
+func advance(p *model.Pipeline) {
+	// the tasks failed before are all retried successfully if the step is done
+	p.State = model.PipelineRunning
+	p.Error = ""
+	remove := removals(p)
+	p.Moved = nil
+	if p.Step >= len(p.Steps) {
+		p.State = model.PipelineSucceeded
+		if len(remove) > 0 {
+			go run(p.ID, p.CreatorID, remove, nil, nil)
+		}
+		return
+	}
+	step := p.Steps[p.Step]
+	inputs := p.Outputs
+	p.Step++
+	p.Inputs = inputs
+	p.InputDirs = p.OutputDirs
+	p.Outputs = nil
+	p.OutputDirs = nil
+	p.Pending = 1
+	go run(p.ID, p.CreatorID, remove, &step, inputs)
+}
+
+// removals returns the paths to remove once the current step succeeded, the
+// inputs of a step with remove_inputs but the folders results were put in,
+// and the sources of the moves between two storages
+func removals(p *model.Pipeline) []string {
+	var remove []string
+	if p.Step > 0 && p.Steps[p.Step-1].RemoveInputs {
+		for _, path := range p.Inputs {
+			if !utils.SliceContains(p.InputDirs, path) {
+				remove = append(remove, path)
+			}
+		}
+	}
+	for _, path := range p.Moved {
+		if !utils.SliceContains(remove, path) {
+			remove = append(remove, path)
+		}
+	}
+	return remove
+}
+
+func run(id, creatorID uint, remove []string, step *model.PipelineStep, inputs []string) {
+	creator, err := op.GetUserById(creatorID)
+	if err != nil {
+		if step != nil {
+			finish(id, errors.WithMessage(err, "failed get creator"))
+		}
+		return
+	}
+	ctx := context.WithValue(context.Background(), "user", creator)
+	for _, path := range remove {
+		if err := fs.Remove(ctx, path); err != nil {
+			log.Warnf("pipeline %d: failed remove %s: %+v", id, path, err)
+		}
+	}
+	if step == nil {
+		return
+	}
+	ctx = context.WithValue(ctx, conf.PipelineKey, id)
+	finish(id, runStep(ctx, id, *step, inputs))
+}
+
+type hook struct{}
+
+func (hook) Join(id uint) {
+	update(id, func(p *model.Pipeline) {
+		p.Pending++
+	})
+}
+
+func (hook) Output(id uint, paths ...string) {
+	update(id, func(p *model.Pipeline) {
+		p.Outputs = append(p.Outputs, paths...)
+	})
+}
+
+func (hook) OutputDir(id uint, path string) {
+	update(id, func(p *model.Pipeline) {
+		p.Outputs = append(p.Outputs, path)
+		p.OutputDirs = append(p.OutputDirs, path)
+	})
+}
+
+func (hook) Succeed(id uint) {
+	update(id, func(p *model.Pipeline) {
+		p.Pending--
+	})
+}
+
+func (hook) Fail(id uint, err error) {
+	update(id, func(p *model.Pipeline) {
+		p.State = model.PipelineFailed
+		if err != nil {
+			p.Error = err.Error()
+		}
+	})
+}
+
+func init() {
+	task.SetPipelineHook(hook{})
+}
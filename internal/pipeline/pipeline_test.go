@@ -0,0 +1,134 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alist-org/alist/v3/drivers/base"
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/db/dbtest"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/task"
+)
+
+func init() {
+	dbtest.Init()
+	base.InitClient()
+}
+
+func TestValidate(t *testing.T) {
+	var tests = []struct {
+		step  model.PipelineStep
+		isErr bool
+	}{
+		{step: model.PipelineStep{Action: model.PipelineDecompress}, isErr: false},
+		{step: model.PipelineStep{Action: model.PipelineCopy}, isErr: true},
+		{step: model.PipelineStep{Action: model.PipelineMove, DstDir: "/dst"}, isErr: false},
+		{step: model.PipelineStep{Action: model.PipelineRename, SrcNameRegex: "("}, isErr: true},
+		{step: model.PipelineStep{Action: model.PipelineRename, SrcNameRegex: `\.tmp$`}, isErr: false},
+		{step: model.PipelineStep{Action: model.PipelineWebhook}, isErr: true},
+		{step: model.PipelineStep{Action: "unknown"}, isErr: true},
+	}
+	for _, test := range tests {
+		err := Validate([]model.PipelineStep{test.step})
+		if (err != nil) != test.isErr {
+			t.Errorf("validate %+v: got error %v, expect error: %v", test.step, err, test.isErr)
+		}
+	}
+}
+
+func waitState(t *testing.T, id uint, state string) *model.Pipeline {
+	for range 100 {
+		p, err := db.GetPipelineByID(id)
+		if err != nil {
+			t.Fatalf("failed get pipeline: %+v", err)
+		}
+		if p.State == state {
+			return p
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("pipeline %d didn't reach state %s", id, state)
+	return nil
+}
+
+func TestPipelineWaitsForTasks(t *testing.T) {
+	user := &model.User{Username: "pipeline", Role: model.Roles{}}
+	if err := db.CreateUser(user); err != nil {
+		t.Fatalf("failed create user: %+v", err)
+	}
+	received := make(chan webhookPayload, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload webhookPayload
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		received <- payload
+	}))
+	defer srv.Close()
+
+	ctx, p, err := Start(context.Background(), user, []model.PipelineStep{{Action: model.PipelineWebhook, URL: srv.URL}})
+	if err != nil {
+		t.Fatalf("failed start pipeline: %+v", err)
+	}
+	var t1, t2 task.TaskExtension
+	t1.JoinPipeline(task.PipelineIDFromCtx(ctx))
+	t2.JoinPipeline(task.PipelineIDFromCtx(ctx))
+	task.AddPipelineOutput(ctx, "/a")
+	Finish(ctx, nil)
+	t1.OnSucceeded()
+	task.AddPipelineOutput(ctx, "/b")
+	t2.OnFailed()
+	p = waitState(t, p.ID, model.PipelineFailed)
+	if p.Step != 0 || p.Pending != 1 {
+		t.Fatalf("failed task must hold the step, got step %d pending %d", p.Step, p.Pending)
+	}
+	// the failed task is retried successfully
+	t2.OnSucceeded()
+	select {
+	case payload := <-received:
+		if payload.PipelineID != p.ID || len(payload.Paths) != 2 {
+			t.Errorf("unexpected webhook payload: %+v", payload)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook was not called")
+	}
+	p = waitState(t, p.ID, model.PipelineSucceeded)
+	if len(p.Inputs) != 2 || len(p.Outputs) != 2 {
+		t.Errorf("webhook must pass the paths through, got inputs %v outputs %v", p.Inputs, p.Outputs)
+	}
+}
+
+func TestPipelineFailedRequest(t *testing.T) {
+	user := &model.User{ID: 1}
+	ctx, p, err := Start(context.Background(), user, []model.PipelineStep{{Action: model.PipelineRefresh}})
+	if err != nil {
+		t.Fatalf("failed start pipeline: %+v", err)
+	}
+	Finish(ctx, errors.New("boom"))
+	p = waitState(t, p.ID, model.PipelineFailed)
+	if p.Error != "boom" || p.Step != 0 {
+		t.Errorf("unexpected pipeline after failed request: %+v", p)
+	}
+}
+
+func TestPipelineRemovals(t *testing.T) {
+	p := &model.Pipeline{
+		Steps:     model.PipelineSteps{{Action: model.PipelineCopy, DstDir: "/dst", RemoveInputs: true}},
+		Step:      1,
+		Inputs:    model.PipelinePaths{"/downloads", "/downloads/a.zip", "/other/b"},
+		InputDirs: model.PipelinePaths{"/downloads"},
+		Moved:     model.PipelinePaths{"/other/b", "/src/c"},
+	}
+	remove := removals(p)
+	if len(remove) != 3 || remove[0] != "/downloads/a.zip" || remove[1] != "/other/b" || remove[2] != "/src/c" {
+		t.Errorf("expected the folder results were put in to be kept, got %v", remove)
+	}
+	p.Steps[0].RemoveInputs = false
+	if remove := removals(p); len(remove) != 2 {
+		t.Errorf("expected only the moved sources to be removed, got %v", remove)
+	}
+}
@@ -0,0 +1,175 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	stdpath "path"
+	"regexp"
+	"strings"
+
+	"github.com/alist-org/alist/v3/drivers/base"
+	"github.com/alist-org/alist/v3/internal/archive/tool"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/task"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+// runStep starts the step on the outputs of the previous step, the tasks created join the step
+// and the paths produced are recorded by the fs functions through ctx.
+func runStep(ctx context.Context, id uint, step model.PipelineStep, inputs []string) error {
+	switch step.Action {
+	case model.PipelineDecompress:
+		return decompress(ctx, step, inputs)
+	case model.PipelineCopy:
+		for _, path := range inputs {
+			if _, err := fs.Copy(ctx, path, step.DstDir); err != nil {
+				return err
+			}
+		}
+	case model.PipelineMove:
+		for _, path := range inputs {
+			if err := move(ctx, id, path, step.DstDir); err != nil {
+				return err
+			}
+		}
+	case model.PipelineRename:
+		return rename(ctx, step, inputs)
+	case model.PipelineRefresh:
+		refreshed := make(map[string]struct{})
+		for _, path := range inputs {
+			dir := stdpath.Dir(path)
+			if _, ok := refreshed[dir]; ok {
+				continue
+			}
+			refreshed[dir] = struct{}{}
+			// the search index is updated by the hook of op.List
+			if _, err := fs.List(ctx, dir, &fs.ListArgs{Refresh: true}); err != nil {
+				return err
+			}
+		}
+		task.AddPipelineOutput(ctx, inputs...)
+	case model.PipelineWebhook:
+		if err := webhook(ctx, id, step, inputs); err != nil {
+			return err
+		}
+		task.AddPipelineOutput(ctx, inputs...)
+	}
+	return nil
+}
+
+// move moves the path to dstDir, between two storages the path is copied and
+// removed once the copy succeeded
+func move(ctx context.Context, id uint, path, dstDir string) error {
+	err := fs.Move(ctx, path, dstDir)
+	if !errors.Is(err, errs.MoveBetweenTwoStorages) {
+		return err
+	}
+	if _, err := fs.Copy(ctx, path, dstDir); err != nil {
+		return err
+	}
+	update(id, func(p *model.Pipeline) {
+		p.Moved = append(p.Moved, path)
+	})
+	return nil
+}
+
+func isArchive(name string) bool {
+	if _, ext, found := strings.Cut(name, "."); found {
+		if _, _, err := tool.GetArchiveTool("." + ext); err == nil {
+			return true
+		}
+	}
+	_, _, err := tool.GetArchiveTool(stdpath.Ext(name))
+	return err == nil
+}
+
+func decompress(ctx context.Context, step model.PipelineStep, inputs []string) error {
+	var archives []string
+	for _, path := range inputs {
+		obj, err := fs.Get(ctx, path, &fs.GetArgs{})
+		if err != nil {
+			return err
+		}
+		if !obj.IsDir() {
+			if isArchive(obj.GetName()) {
+				archives = append(archives, path)
+			}
+			continue
+		}
+		// a download may produce a directory, extract the archives directly in it
+		objs, err := fs.List(ctx, path, &fs.ListArgs{})
+		if err != nil {
+			return err
+		}
+		for _, obj := range objs {
+			if !obj.IsDir() && isArchive(obj.GetName()) {
+				archives = append(archives, stdpath.Join(path, obj.GetName()))
+			}
+		}
+	}
+	args := model.ArchiveDecompressArgs{
+		ArchiveInnerArgs: model.ArchiveInnerArgs{
+			ArchiveArgs: model.ArchiveArgs{
+				LinkArgs: model.LinkArgs{Header: http.Header{}},
+				Password: step.ArchivePass,
+			},
+			InnerPath: utils.FixAndCleanPath(step.InnerPath),
+		},
+		PutIntoNewDir: step.PutIntoNewDir,
+	}
+	for _, path := range archives {
+		dstDir := step.DstDir
+		if dstDir == "" {
+			dstDir = stdpath.Dir(path)
+		}
+		if _, err := fs.ArchiveDecompress(ctx, path, dstDir, args); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func rename(ctx context.Context, step model.PipelineStep, inputs []string) error {
+	srcRegexp, err := regexp.Compile(step.SrcNameRegex)
+	if err != nil {
+		return err
+	}
+	for _, path := range inputs {
+		name := stdpath.Base(path)
+		newName := srcRegexp.ReplaceAllString(name, step.NewNameRegex)
+		if newName == name {
+			task.AddPipelineOutput(ctx, path)
+			continue
+		}
+		if newName == "" || strings.Contains(newName, "/") {
+			return fmt.Errorf("invalid new name %q of %s", newName, path)
+		}
+		if err := fs.Rename(ctx, path, newName); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type webhookPayload struct {
+	PipelineID uint     `json:"pipeline_id"`
+	Paths      []string `json:"paths"`
+}
+
+func webhook(ctx context.Context, id uint, step model.PipelineStep, inputs []string) error {
+	res, err := base.RestyClient.R().
+		SetContext(ctx).
+		SetBody(webhookPayload{PipelineID: id, Paths: inputs}).
+		Post(step.URL)
+	if err != nil {
+		return errors.WithMessage(err, "failed call webhook")
+	}
+	if res.IsError() {
+		return fmt.Errorf("webhook responded with status %d", res.StatusCode())
+	}
+	return nil
+}
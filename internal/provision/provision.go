@@ -0,0 +1,180 @@
+package provision
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+)
+
+// Spec is the configuration declared in a provision file. The sections left out of
+// the file are not touched, if storages are declared the undeclared ones are disabled.
+type Spec struct {
+	Roles    []model.Role      `json:"roles"`
+	Users    []User            `json:"users"`
+	Metas    []model.Meta      `json:"metas"`
+	Settings map[string]string `json:"settings"`
+	Storages []Storage         `json:"storages"`
+}
+
+// Storage is a model.Storage with the driver addition as an object instead of a json string
+type Storage struct {
+	model.Storage
+	Addition map[string]any `json:"addition"`
+}
+
+// User is a user matched by its username, the empty fields are not managed
+type User struct {
+	Username   string   `json:"username"`
+	Password   string   `json:"password"`
+	Roles      []string `json:"roles"`
+	BasePath   string   `json:"base_path"`
+	Permission *int32   `json:"permission"`
+	Disabled   bool     `json:"disabled"`
+}
+
+// secretRef matches ${env:NAME} and ${file:/path}, so secrets don't have to be written in the file
+var secretRef = regexp.MustCompile(`\$\{(env|file):([^}]+)}`)
+
+// Load reads a yaml or json provision file and resolves the secret references in its strings
+func Load(path string) (*Spec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return Parse(data)
+}
+
+func Parse(data []byte) (*Spec, error) {
+	data, err := yaml.YAMLToJSON(data)
+	if err != nil {
+		return nil, errors.WithMessage(err, "invalid provision file")
+	}
+	var tree any
+	if err := json.Unmarshal(data, &tree); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if tree, err = resolveSecrets(tree); err != nil {
+		return nil, err
+	}
+	if err := fillStorageDefaults(tree); err != nil {
+		return nil, err
+	}
+	if data, err = json.Marshal(tree); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	// unknown fields are usually typos, they would be ignored silently
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	var spec Spec
+	if err := dec.Decode(&spec); err != nil {
+		return nil, errors.WithMessage(err, "invalid provision file")
+	}
+	return &spec, nil
+}
+
+func resolveSecrets(v any) (any, error) {
+	switch v := v.(type) {
+	case string:
+		var err error
+		resolved := secretRef.ReplaceAllStringFunc(v, func(ref string) string {
+			m := secretRef.FindStringSubmatch(ref)
+			secret, e := resolveSecret(m[1], m[2])
+			if e != nil && err == nil {
+				err = e
+			}
+			return secret
+		})
+		return resolved, err
+	case []any:
+		for i := range v {
+			var err error
+			if v[i], err = resolveSecrets(v[i]); err != nil {
+				return nil, err
+			}
+		}
+	case map[string]any:
+		for k := range v {
+			var err error
+			if v[k], err = resolveSecrets(v[k]); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return v, nil
+}
+
+func resolveSecret(kind, name string) (string, error) {
+	switch kind {
+	case "env":
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			return "", errors.Errorf("environment variable %s is not set", name)
+		}
+		return value, nil
+	default:
+		data, err := os.ReadFile(name)
+		if err != nil {
+			return "", errors.WithMessagef(err, "failed read secret file %s", name)
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	}
+}
+
+// fillStorageDefaults sets the common fields missing from the declared storages to
+// the defaults of their drivers, as the web UI does when a storage is created
+func fillStorageDefaults(tree any) error {
+	root, _ := tree.(map[string]any)
+	storages, _ := root["storages"].([]any)
+	infos := op.GetDriverInfoMap()
+	for _, s := range storages {
+		storage, ok := s.(map[string]any)
+		if !ok {
+			return errors.New("invalid provision file: storage must be an object")
+		}
+		name, _ := storage["driver"].(string)
+		info, ok := infos[name]
+		if !ok {
+			return errors.Errorf("unknown driver [%s] of storage [%v]", name, storage["mount_path"])
+		}
+		for k, v := range itemDefaults(info.Common) {
+			if _, ok := storage[k]; !ok {
+				storage[k] = v
+			}
+		}
+	}
+	return nil
+}
+
+// itemDefaults converts the defaults of the driver items to the types of their fields
+func itemDefaults(items []driver.Item) map[string]any {
+	defaults := make(map[string]any)
+	for _, item := range items {
+		if item.Default == "" {
+			continue
+		}
+		var value any = item.Default
+		switch {
+		case item.Type == "bool":
+			value, _ = strconv.ParseBool(item.Default)
+		case item.Type == "number" || strings.HasPrefix(item.Type, "int") || strings.HasPrefix(item.Type, "uint"):
+			if n, err := strconv.ParseInt(item.Default, 10, 64); err == nil {
+				value = n
+			}
+		case strings.HasPrefix(item.Type, "float"):
+			if f, err := strconv.ParseFloat(item.Default, 64); err == nil {
+				value = f
+			}
+		}
+		defaults[item.Name] = value
+	}
+	return defaults
+}
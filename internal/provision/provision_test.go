@@ -0,0 +1,103 @@
+package provision_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	_ "github.com/alist-org/alist/v3/drivers/local"
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/db/dbtest"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/provision"
+	"github.com/alist-org/alist/v3/pkg/utils"
+)
+
+func init() {
+	dbtest.Init()
+}
+
+func TestParseSecrets(t *testing.T) {
+	t.Setenv("PROVISION_TEST_ROOT", "/srv")
+	secretFile := filepath.Join(t.TempDir(), "password")
+	if err := os.WriteFile(secretFile, []byte("s3cret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	spec, err := provision.Parse([]byte(`
+storages:
+  - mount_path: /local
+    driver: Local
+    addition:
+      root_folder_path: ${env:PROVISION_TEST_ROOT}/data
+users:
+  - username: bob
+    password: ${file:` + secretFile + `}
+`))
+	if err != nil {
+		t.Fatalf("failed parse: %+v", err)
+	}
+	if got := spec.Storages[0].Addition["root_folder_path"]; got != "/srv/data" {
+		t.Errorf("env secret is not resolved: %v", got)
+	}
+	if spec.Users[0].Password != "s3cret" {
+		t.Errorf("file secret is not resolved: %q", spec.Users[0].Password)
+	}
+	// the defaults of the driver are filled in
+	if !spec.Storages[0].DownProxySign {
+		t.Error("down_proxy_sign must default to true")
+	}
+	if _, err := provision.Parse([]byte("users:\n  - username: ${env:PROVISION_TEST_MISSING}\n")); err == nil {
+		t.Error("missing environment variable must fail")
+	}
+	if _, err := provision.Parse([]byte("storage: []\n")); err == nil {
+		t.Error("unknown field must fail")
+	}
+}
+
+func TestReconcileStorages(t *testing.T) {
+	if err := db.CreateStorage(&model.Storage{MountPath: "/old", Driver: "Local", Addition: `{"root_folder_path":"/old"}`}); err != nil {
+		t.Fatal(err)
+	}
+	spec, err := provision.Parse([]byte(`
+storages:
+  - mount_path: /local
+    driver: Local
+    addition:
+      root_folder_path: /data
+`))
+	if err != nil {
+		t.Fatalf("failed parse: %+v", err)
+	}
+	changes, err := provision.Plan(spec)
+	if err != nil {
+		t.Fatalf("failed plan: %+v", err)
+	}
+	if len(changes) != 2 || changes[0].Action != provision.ActionCreate || changes[1].Action != provision.ActionDisable {
+		t.Fatalf("unexpected plan: %+v", changes)
+	}
+	if _, err := db.GetStorageByMountPath("/local"); err == nil {
+		t.Fatal("plan must not change the database")
+	}
+	if _, err := provision.Apply(spec); err != nil {
+		t.Fatalf("failed apply: %+v", err)
+	}
+	storage, err := db.GetStorageByMountPath("/local")
+	if err != nil {
+		t.Fatalf("storage is not created: %+v", err)
+	}
+	if utils.Json.Get([]byte(storage.Addition), "root_folder_path").ToString() != "/data" {
+		t.Errorf("unexpected addition: %s", storage.Addition)
+	}
+	old, _ := db.GetStorageByMountPath("/old")
+	if !old.Disabled {
+		t.Error("undeclared storage must be disabled")
+	}
+	spec.Storages[0].Remark = "changed"
+	changes, err = provision.Apply(spec)
+	if err != nil {
+		t.Fatalf("failed apply again: %+v", err)
+	}
+	if len(changes) != 1 || changes[0].Action != provision.ActionUpdate || changes[0].Fields[0] != "remark" {
+		t.Fatalf("unexpected changes: %+v", changes)
+	}
+}
@@ -0,0 +1,349 @@
+package provision
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+const (
+	ActionCreate  = "create"
+	ActionUpdate  = "update"
+	ActionDisable = "disable"
+)
+
+// Change is a difference between the database and the spec, Fields are the names
+// of the changed fields, their values are left out because they may be secrets
+type Change struct {
+	Kind   string   `json:"kind"`
+	Name   string   `json:"name"`
+	Action string   `json:"action"`
+	Fields []string `json:"fields,omitempty"`
+}
+
+// Plan returns the changes needed to make the database match the spec without applying them
+func Plan(spec *Spec) ([]Change, error) {
+	r := reconciler{}
+	return r.run(spec)
+}
+
+// Apply makes the database match the spec and returns the applied changes. The storages
+// are only written to the database, it must be called before they are loaded.
+func Apply(spec *Spec) ([]Change, error) {
+	r := reconciler{apply: true}
+	return r.run(spec)
+}
+
+type reconciler struct {
+	apply   bool
+	changes []Change
+}
+
+func (r *reconciler) run(spec *Spec) ([]Change, error) {
+	// roles go first, the users refer to them
+	for _, step := range []struct {
+		name string
+		fn   func(spec *Spec) error
+	}{
+		{"roles", r.roles},
+		{"users", r.users},
+		{"metas", r.metas},
+		{"settings", r.settings},
+		{"storages", r.storages},
+	} {
+		if err := step.fn(spec); err != nil {
+			return r.changes, errors.WithMessagef(err, "failed provision %s", step.name)
+		}
+	}
+	return r.changes, nil
+}
+
+// record adds a change and tells whether it should be applied
+func (r *reconciler) record(kind, name, action string, fields []string) bool {
+	if action == ActionUpdate && len(fields) == 0 {
+		return false
+	}
+	r.changes = append(r.changes, Change{Kind: kind, Name: name, Action: action, Fields: fields})
+	return r.apply
+}
+
+// diffFields returns the json names of the fields differing between a and b
+func diffFields(a, b any, ignore ...string) []string {
+	ma, mb := toMap(a), toMap(b)
+	var fields []string
+	for k, v := range mb {
+		if utils.SliceContains(ignore, k) {
+			continue
+		}
+		if !reflect.DeepEqual(ma[k], v) {
+			fields = append(fields, k)
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// jsonEqual compares the values as they are stored, so numbers of different types are equal
+func jsonEqual(a, b any) bool {
+	ja, _ := json.Marshal(a)
+	jb, _ := json.Marshal(b)
+	return string(ja) == string(jb)
+}
+
+func toMap(v any) map[string]any {
+	data, _ := json.Marshal(v)
+	m := make(map[string]any)
+	_ = json.Unmarshal(data, &m)
+	return m
+}
+
+func notFound(err error) bool {
+	return errors.Is(err, gorm.ErrRecordNotFound)
+}
+
+func (r *reconciler) roles(spec *Spec) error {
+	for _, role := range spec.Roles {
+		for i := range role.PermissionScopes {
+			role.PermissionScopes[i].Path = utils.FixAndCleanPath(role.PermissionScopes[i].Path)
+		}
+		old, err := db.GetRoleByName(role.Name)
+		if notFound(err) {
+			if r.record("role", role.Name, ActionCreate, nil) {
+				if err := op.CreateRole(&role); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		role.ID = old.ID
+		if r.record("role", role.Name, ActionUpdate, diffFields(old, role)) {
+			if err := op.UpdateRole(&role); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (r *reconciler) roleIDs(names []string) (model.Roles, error) {
+	roles := make(model.Roles, 0, len(names))
+	for _, name := range names {
+		role, err := db.GetRoleByName(name)
+		if err != nil {
+			// the role may be created by this spec in check mode
+			if notFound(err) && !r.apply {
+				continue
+			}
+			return nil, errors.WithMessagef(err, "failed get role [%s]", name)
+		}
+		roles = append(roles, int(role.ID))
+	}
+	return roles, nil
+}
+
+func (r *reconciler) users(spec *Spec) error {
+	for _, u := range spec.Users {
+		old, err := db.GetUserByName(u.Username)
+		if err != nil && !notFound(err) {
+			return err
+		}
+		user := model.User{Username: u.Username, Authn: "[]"}
+		if old != nil {
+			user = *old
+		}
+		if u.Roles != nil {
+			roles, err := r.roleIDs(u.Roles)
+			if err != nil {
+				return err
+			}
+			if old == nil || !utils.SliceEqual(old.Role, roles) {
+				if user.Username == "admin" && old != nil {
+					return errors.New("cannot change role of admin user")
+				}
+				if roles.Contains(model.ADMIN) || roles.Contains(model.GUEST) {
+					return errors.Errorf("cannot assign admin or guest role to user [%s]", u.Username)
+				}
+			}
+			user.Role = roles
+		}
+		if u.BasePath != "" {
+			user.BasePath = utils.FixAndCleanPath(u.BasePath)
+		}
+		if u.Permission != nil {
+			user.Permission = *u.Permission
+		}
+		user.Disabled = u.Disabled
+		if old == nil {
+			if u.Password == "" {
+				return errors.Errorf("password of new user [%s] is required", u.Username)
+			}
+			if len(user.Role) == 0 {
+				user.Role = model.Roles{op.GetDefaultRoleID()}
+			}
+			if r.record("user", u.Username, ActionCreate, nil) {
+				user.SetPassword(u.Password)
+				if err := op.CreateUser(&user); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		fields := diffFields(old, user)
+		// the password is only reset if it changed, resetting it logs out the user
+		if u.Password != "" && old.ValidateRawPassword(u.Password) != nil {
+			fields = append(fields, "password")
+			user.SetPassword(u.Password)
+		}
+		if r.record("user", u.Username, ActionUpdate, fields) {
+			if err := op.UpdateUser(&user); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (r *reconciler) metas(spec *Spec) error {
+	for _, meta := range spec.Metas {
+		meta.Path = utils.FixAndCleanPath(meta.Path)
+		old, err := db.GetMetaByPath(meta.Path)
+		if err != nil && !notFound(err) {
+			return err
+		}
+		if old == nil {
+			if r.record("meta", meta.Path, ActionCreate, nil) {
+				if err := op.CreateMeta(&meta); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		meta.ID = old.ID
+		if r.record("meta", meta.Path, ActionUpdate, diffFields(old, meta)) {
+			if err := op.UpdateMeta(&meta); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (r *reconciler) settings(spec *Spec) error {
+	keys := make([]string, 0, len(spec.Settings))
+	for key := range spec.Settings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		old, err := db.GetSettingItemByKey(key)
+		if err != nil {
+			return errors.WithMessagef(err, "failed get setting [%s]", key)
+		}
+		item := *old
+		item.Value = spec.Settings[key]
+		if key == conf.DefaultRole {
+			if _, err := strconv.Atoi(item.Value); err != nil {
+				role, err := db.GetRoleByName(item.Value)
+				if err != nil && r.apply {
+					return errors.WithMessagef(err, "failed get role [%s]", item.Value)
+				}
+				if role != nil {
+					item.Value = strconv.Itoa(int(role.ID))
+				}
+			}
+		}
+		if item.Value == old.Value {
+			continue
+		}
+		if r.record("setting", key, ActionUpdate, []string{"value"}) {
+			if err := op.SaveSettingItem(&item); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (r *reconciler) storages(spec *Spec) error {
+	if spec.Storages == nil {
+		return nil
+	}
+	existing, _, err := db.GetStorages(1, -1)
+	if err != nil {
+		return err
+	}
+	byPath := make(map[string]model.Storage, len(existing))
+	for _, s := range existing {
+		byPath[s.MountPath] = s
+	}
+	declared := make(map[string]struct{}, len(spec.Storages))
+	for _, s := range spec.Storages {
+		storage := s.Storage
+		storage.MountPath = utils.FixAndCleanPath(storage.MountPath)
+		declared[storage.MountPath] = struct{}{}
+		old, ok := byPath[storage.MountPath]
+		// the driver may save refreshed tokens in the addition, so only the declared keys are managed
+		addition := make(map[string]any)
+		if ok {
+			_ = json.Unmarshal([]byte(old.Addition), &addition)
+		} else {
+			addition = itemDefaults(op.GetDriverInfoMap()[storage.Driver].Additional)
+		}
+		var fields []string
+		for k, v := range s.Addition {
+			if !jsonEqual(addition[k], v) {
+				fields = append(fields, "addition."+k)
+			}
+			addition[k] = v
+		}
+		data, err := json.Marshal(addition)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		storage.Addition = string(data)
+		if !ok {
+			storage.ID, storage.Status = 0, ""
+			if r.record("storage", storage.MountPath, ActionCreate, nil) {
+				storage.Modified = time.Now()
+				if err := db.CreateStorage(&storage); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		storage.ID, storage.Status, storage.Modified = old.ID, old.Status, old.Modified
+		fields = append(diffFields(old, storage, "addition"), fields...)
+		sort.Strings(fields)
+		if r.record("storage", storage.MountPath, ActionUpdate, fields) {
+			storage.Modified = time.Now()
+			if err := db.UpdateStorage(&storage); err != nil {
+				return err
+			}
+		}
+	}
+	for _, old := range existing {
+		if _, ok := declared[old.MountPath]; ok || old.Disabled {
+			continue
+		}
+		if r.record("storage", old.MountPath, ActionDisable, nil) {
+			old.Disabled = true
+			if err := db.UpdateStorage(&old); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,244 @@
+package rolesync
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+	"gopkg.in/ldap.v3"
+)
+
+// Sources of the groups, each has its own mapping setting
+const (
+	SourceLDAP = "ldap"
+	SourceOIDC = "oidc"
+)
+
+var mappingKeys = map[string]string{
+	SourceLDAP: conf.LdapGroupRoleMapping,
+	SourceOIDC: conf.SSOGroupRoleMapping,
+}
+
+// Mapping maps the groups to the roles their members get. A group is matched by its full name,
+// case-insensitively, and a DN by all its RDNs whatever their spacing. A role is its name or its id as a number.
+type Mapping map[string][]any
+
+// Match is a group of the user that matches the mapping
+type Match struct {
+	Group string   `json:"group"`
+	Roles []string `json:"roles"`
+}
+
+// Result is the outcome of a sync, Roles are the ones the user has afterward
+type Result struct {
+	Groups  []string    `json:"groups"`
+	Matches []Match     `json:"matches"`
+	Roles   model.Roles `json:"roles"`
+	Added   []string    `json:"added"`
+	Removed []string    `json:"removed"`
+	Changed bool        `json:"changed"`
+}
+
+func init() {
+	for _, key := range mappingKeys {
+		op.RegisterSettingItemHook(key, func(item *model.SettingItem) error {
+			m, err := parseMapping(item.Value)
+			if err != nil {
+				return err
+			}
+			_, err = m.resolve()
+			return err
+		})
+	}
+}
+
+func parseMapping(value string) (Mapping, error) {
+	m := Mapping{}
+	if strings.TrimSpace(value) == "" {
+		return m, nil
+	}
+	if err := json.Unmarshal([]byte(value), &m); err != nil {
+		return nil, errors.WithMessage(err, "group role mapping must be a json object of group to role names or ids")
+	}
+	return m, nil
+}
+
+// getMapping returns the mapping of the source, it's empty if the sync is disabled
+func getMapping(source string) (Mapping, error) {
+	key, ok := mappingKeys[source]
+	if !ok {
+		return nil, errors.Errorf("unknown group source: %s", source)
+	}
+	return parseMapping(setting.GetStr(key))
+}
+
+// resolve returns the roles of each group of the mapping, the admin and guest roles can't be mapped
+func (m Mapping) resolve() (map[string][]*model.Role, error) {
+	resolved := make(map[string][]*model.Role, len(m))
+	for group, refs := range m {
+		for _, ref := range refs {
+			var role *model.Role
+			var err error
+			switch v := ref.(type) {
+			case string:
+				role, err = op.GetRoleByName(v)
+			case float64:
+				role, err = op.GetRole(uint(v))
+			default:
+				return nil, errors.Errorf("invalid role %v of group %s", ref, group)
+			}
+			if err != nil {
+				return nil, errors.WithMessagef(err, "failed get role %v of group %s", ref, group)
+			}
+			if role.ID == model.ADMIN || role.ID == model.GUEST {
+				return nil, errors.Errorf("admin or guest role can't be mapped to group %s", group)
+			}
+			resolved[group] = append(resolved[group], role)
+		}
+	}
+	return resolved, nil
+}
+
+func matchGroup(pattern, group string) bool {
+	if strings.EqualFold(pattern, group) {
+		return true
+	}
+	if !strings.Contains(pattern, "=") || !strings.Contains(group, "=") {
+		return false
+	}
+	// CN=Devs, OU=Groups,dc=example,dc=org is matched by cn=devs,ou=groups,dc=example,dc=org
+	p, err := ldap.ParseDN(pattern)
+	if err != nil {
+		return false
+	}
+	g, err := ldap.ParseDN(group)
+	if err != nil || len(p.RDNs) != len(g.RDNs) {
+		return false
+	}
+	for i := range p.RDNs {
+		if !matchRDN(p.RDNs[i], g.RDNs[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func matchRDN(pattern, rdn *ldap.RelativeDN) bool {
+	if len(pattern.Attributes) != len(rdn.Attributes) {
+		return false
+	}
+	for _, pa := range pattern.Attributes {
+		matched := false
+		for _, a := range rdn.Attributes {
+			if strings.EqualFold(pa.Type, a.Type) && strings.EqualFold(pa.Value, a.Value) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// Evaluate computes the roles of the user with the groups, the roles in the mapping are
+// managed by it, so the ones of the groups the user left are removed, the others are kept
+func Evaluate(source string, user *model.User, groups []string) (*Result, error) {
+	m, err := getMapping(source)
+	if err != nil {
+		return nil, err
+	}
+	resolved, err := m.resolve()
+	if err != nil {
+		return nil, err
+	}
+	res := &Result{Groups: groups, Matches: []Match{}, Added: []string{}, Removed: []string{}}
+	managed := make(map[int]string)
+	for _, roles := range resolved {
+		for _, r := range roles {
+			managed[int(r.ID)] = r.Name
+		}
+	}
+	granted := make(map[int]string)
+	patterns := make([]string, 0, len(resolved))
+	for pattern := range resolved {
+		patterns = append(patterns, pattern)
+	}
+	sort.Strings(patterns)
+	for _, group := range groups {
+		for _, pattern := range patterns {
+			if !matchGroup(pattern, group) {
+				continue
+			}
+			match := Match{Group: group}
+			for _, r := range resolved[pattern] {
+				granted[int(r.ID)] = r.Name
+				match.Roles = append(match.Roles, r.Name)
+			}
+			res.Matches = append(res.Matches, match)
+		}
+	}
+	for _, id := range user.Role {
+		if _, ok := managed[id]; !ok {
+			res.Roles = append(res.Roles, id)
+		} else if _, ok := granted[id]; ok {
+			res.Roles = append(res.Roles, id)
+		} else {
+			res.Removed = append(res.Removed, managed[id])
+		}
+	}
+	ids := make([]int, 0, len(granted))
+	for id := range granted {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	for _, id := range ids {
+		if !user.Role.Contains(id) {
+			res.Roles = append(res.Roles, id)
+			res.Added = append(res.Added, granted[id])
+		}
+	}
+	if len(res.Roles) == 0 {
+		res.Roles = model.Roles{op.GetDefaultRoleID()}
+	}
+	res.Changed = !utils.SliceEqual(res.Roles, user.Role)
+	return res, nil
+}
+
+// Enabled tells whether the groups of the source are mapped to roles
+func Enabled(source string) bool {
+	m, err := getMapping(source)
+	return err == nil && len(m) > 0
+}
+
+// Sync updates the roles of the user with the groups it has when it logs in, nothing is changed if the
+// mapping of the source is empty. The admins are never changed, so they can't be locked out by a mapping.
+// The user may be shared by a cache, so the updated one is a copy, it's the user itself if nothing changed.
+func Sync(source string, user *model.User, groups []string) (*model.User, error) {
+	if !Enabled(source) || user.IsAdmin() || user.IsGuest() {
+		return user, nil
+	}
+	res, err := Evaluate(source, user, groups)
+	if err != nil {
+		return nil, err
+	}
+	if !res.Changed {
+		return user, nil
+	}
+	synced := *user
+	synced.Role = res.Roles
+	if synced.ID == 0 {
+		return &synced, nil
+	}
+	if err := op.UpdateUser(&synced); err != nil {
+		return nil, err
+	}
+	return &synced, nil
+}
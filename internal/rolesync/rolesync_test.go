@@ -0,0 +1,93 @@
+package rolesync_test
+
+import (
+	"testing"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/db/dbtest"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/rolesync"
+)
+
+func init() {
+	dbtest.Init()
+	// the builtin roles take the ids of model.GUEST and model.ADMIN
+	for _, name := range []string{"guest", "admin"} {
+		if err := db.CreateRole(&model.Role{Name: name}); err != nil {
+			panic(err)
+		}
+	}
+}
+
+func createRole(t *testing.T, name string) int {
+	role := model.Role{Name: name}
+	if err := db.CreateRole(&role); err != nil {
+		t.Fatal(err)
+	}
+	return int(role.ID)
+}
+
+func TestEvaluate(t *testing.T) {
+	editors := createRole(t, "editors")
+	developers := createRole(t, "developers")
+	custom := createRole(t, "custom")
+	mapping := &model.SettingItem{Key: conf.LdapGroupRoleMapping, Type: conf.TypeText, Flag: model.PRIVATE,
+		Value: `{"cn=devs,ou=groups,dc=example,dc=org": ["developers"], "cn=editors,ou=groups,dc=example,dc=org": ["editors"]}`}
+	if err := op.SaveSettingItem(mapping); err != nil {
+		t.Fatalf("failed save mapping: %+v", err)
+	}
+	user := &model.User{Username: "alice", Role: model.Roles{editors, custom}}
+	// the DN is matched whatever the case and spacing, a group of another branch isn't matched by its name
+	res, err := rolesync.Evaluate(rolesync.SourceLDAP, user,
+		[]string{"CN=Devs, OU=Groups,dc=example,dc=org", "cn=editors,ou=other,dc=example,dc=org"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the role of the left group is removed, the unmanaged role is kept
+	want := model.Roles{custom, developers}
+	if !res.Changed || len(res.Roles) != 2 || res.Roles[0] != want[0] || res.Roles[1] != want[1] {
+		t.Fatalf("unexpected roles: %+v", res)
+	}
+	if len(res.Added) != 1 || res.Added[0] != "developers" || len(res.Removed) != 1 || res.Removed[0] != "editors" {
+		t.Errorf("unexpected changes: %+v", res)
+	}
+	res, err = rolesync.Evaluate(rolesync.SourceLDAP, &model.User{Role: model.Roles{editors}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Roles) != 1 || res.Roles[0] != op.GetDefaultRoleID() {
+		t.Errorf("user without roles must get the default role: %+v", res.Roles)
+	}
+	admin := &model.User{Username: "root", Role: model.Roles{model.ADMIN}}
+	if synced, err := rolesync.Sync(rolesync.SourceLDAP, admin, []string{"cn=devs,ou=groups,dc=example,dc=org"}); err != nil || len(synced.Role) != 1 {
+		t.Errorf("admin must not be synced: %v %+v", err, synced)
+	}
+
+	// the user of the cache isn't changed while its update is saved
+	if err := db.CreateUser(&model.User{Username: "bob", Role: model.Roles{editors}}); err != nil {
+		t.Fatal(err)
+	}
+	cached, err := op.GetUserByName("bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	synced, err := rolesync.Sync(rolesync.SourceLDAP, cached, []string{"cn=devs,ou=groups,dc=example,dc=org"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cached.Role) != 1 || cached.Role[0] != editors {
+		t.Errorf("cached user must be kept: %+v", cached.Role)
+	}
+	if len(synced.Role) != 1 || synced.Role[0] != developers {
+		t.Errorf("unexpected synced roles: %+v", synced.Role)
+	}
+	if saved, err := db.GetUserByName("bob"); err != nil || len(saved.Role) != 1 || saved.Role[0] != developers {
+		t.Errorf("synced roles must be saved: %v %+v", err, saved)
+	}
+	mapping.Value = `{"devs": ["admin"]}`
+	if err := op.SaveSettingItem(mapping); err == nil {
+		t.Error("mapping to the admin role must be rejected")
+	}
+}
@@ -0,0 +1,115 @@
+package share
+
+import (
+	"sync"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/alist-org/alist/v3/pkg/mq"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// pruneInterval limits how often the retention of the access log is applied
+	pruneInterval = 10 * time.Minute
+	// flushInterval is how long the visits wait before they are written
+	flushInterval = time.Second
+	// maxQueuedAccesses bounds the visits waiting to be written, more are dropped
+	maxQueuedAccesses = 10000
+)
+
+var (
+	pruneMu     sync.Mutex
+	lastPruned  time.Time
+	lastTrimmed = make(map[uint]time.Time)
+
+	accessMQ   = mq.NewInMemoryMQ[model.ShareAccess]()
+	writerOnce sync.Once
+	flushMu    sync.Mutex
+)
+
+// LogAccess queues a visit of the share, the visits are written in batches
+// so the requests don't wait for the database
+func LogAccess(share *model.Share, action, relPath, ip, userAgent string, bytes int64) {
+	writerOnce.Do(func() {
+		go func() {
+			for range time.Tick(flushInterval) {
+				FlushAccessLog()
+			}
+		}()
+	})
+	if accessMQ.Len() >= maxQueuedAccesses {
+		log.Warnf("access log of shares is full, dropped the access of share %s", share.ShareID)
+		return
+	}
+	accessMQ.Publish(mq.Message[model.ShareAccess]{Content: model.ShareAccess{
+		ShareID:   share.ID,
+		Action:    action,
+		Path:      relPath,
+		IP:        ip,
+		UserAgent: userAgent,
+		Bytes:     bytes,
+		CreatedAt: time.Now(),
+	}})
+}
+
+// FlushAccessLog writes the queued visits, then applies the retention of the
+// access log now and then
+func FlushAccessLog() {
+	flushMu.Lock()
+	defer flushMu.Unlock()
+	var accesses []model.ShareAccess
+	accessMQ.ConsumeAll(func(messages []mq.Message[model.ShareAccess]) {
+		accesses = utils.MustSliceConvert(messages, func(m mq.Message[model.ShareAccess]) model.ShareAccess {
+			return m.Content
+		})
+	})
+	if len(accesses) == 0 {
+		return
+	}
+	if err := db.CreateShareAccesses(accesses); err != nil {
+		log.Errorf("failed log %d accesses of shares: %+v", len(accesses), err)
+		return
+	}
+	shareIDs := make(map[uint]struct{})
+	for _, a := range accesses {
+		if _, ok := shareIDs[a.ShareID]; !ok {
+			shareIDs[a.ShareID] = struct{}{}
+			prune(a.ShareID)
+		}
+	}
+}
+
+func prune(shareID uint) {
+	now := time.Now()
+	pruneMu.Lock()
+	pruneAll := now.Sub(lastPruned) > pruneInterval
+	if pruneAll {
+		lastPruned = now
+		for id, t := range lastTrimmed {
+			if now.Sub(t) > pruneInterval {
+				delete(lastTrimmed, id)
+			}
+		}
+	}
+	trim := now.Sub(lastTrimmed[shareID]) > pruneInterval
+	if trim {
+		lastTrimmed[shareID] = now
+	}
+	pruneMu.Unlock()
+
+	if days := setting.GetInt(conf.ShareAccessLogDays, 90); pruneAll && days > 0 {
+		if err := db.DeleteShareAccessesBefore(now.AddDate(0, 0, -days)); err != nil {
+			log.Errorf("failed prune share access log: %+v", err)
+		}
+	}
+	if keep := setting.GetInt(conf.ShareAccessLogMax, 10000); trim && keep > 0 {
+		if err := db.TrimShareAccesses(shareID, keep); err != nil {
+			log.Errorf("failed trim share access log: %+v", err)
+		}
+	}
+}
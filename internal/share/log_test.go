@@ -0,0 +1,64 @@
+package share
+
+import (
+	"testing"
+
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/db/dbtest"
+	"github.com/alist-org/alist/v3/internal/model"
+)
+
+func init() {
+	dbtest.Init()
+}
+
+func TestLogAccess(t *testing.T) {
+	share := &model.Share{ShareID: "logged", CreatorID: 1, Name: "logged", RootPath: "/"}
+	if err := db.CreateShare(share); err != nil {
+		t.Fatal(err)
+	}
+	other := &model.Share{ShareID: "logged_other", CreatorID: 1, Name: "logged_other", RootPath: "/"}
+	if err := db.CreateShare(other); err != nil {
+		t.Fatal(err)
+	}
+	LogAccess(other, model.ShareActionDownload, "/z", "10.0.0.2", "agent", 100)
+	LogAccess(share, model.ShareActionDownload, "/a", "192.168.1.10", "agent", 10)
+	LogAccess(share, model.ShareActionDownload, "/a", "192.168.2.10", "agent", 10)
+	LogAccess(share, model.ShareActionProxy, "/b", "10.0.0.1", "agent", 5)
+	LogAccess(share, model.ShareActionDownload, "/c", "10.0.0.1", "agent", 1)
+	// the visits are written in the background
+	if _, total, _ := db.GetShareAccesses(share.ID, "", 1, 10); total != 0 {
+		t.Errorf("expect the visits to be queued, got %d written", total)
+	}
+	FlushAccessLog()
+
+	accesses, total, err := db.GetShareAccesses(share.ID, "", 1, 10)
+	if err != nil || total != 4 {
+		t.Fatalf("expect 4 accesses, got %d: %v", total, err)
+	}
+	if ip := accesses[len(accesses)-1].IP; ip != "192.168.1.10" {
+		t.Errorf("the ip must be kept, got %s", ip)
+	}
+
+	stats, err := db.GetShareStats([]uint{share.ID, other.ID}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := stats[share.ID]
+	if s.BytesServed != 26 || s.UniqueVisitors != 3 {
+		t.Errorf("unexpected totals: %+v", s)
+	}
+	if len(s.TopFiles) != 2 || s.TopFiles[0].Path != "/a" || s.TopFiles[0].Count != 2 || s.TopFiles[1].Path != "/b" {
+		t.Errorf("expect the 2 top files, got %+v", s.TopFiles)
+	}
+	if o := stats[other.ID]; len(o.TopFiles) != 1 || o.TopFiles[0].Path != "/z" || o.BytesServed != 100 {
+		t.Errorf("expect the stats of each share apart, got %+v", o)
+	}
+
+	if err := db.DeleteShareByShareID(share.CreatorID, share.ShareID); err != nil {
+		t.Fatal(err)
+	}
+	if _, total, err := db.GetShareAccesses(share.ID, "", 1, 10); err != nil || total != 0 {
+		t.Errorf("the access log must be deleted with the share, got %d: %v", total, err)
+	}
+}
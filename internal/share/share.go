@@ -0,0 +1,34 @@
+package share
+
+import (
+	"fmt"
+
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils/random"
+)
+
+// GenerateID returns a random share id that is not used by any share yet
+func GenerateID() (string, error) {
+	for range 10 {
+		shareID := random.String(8)
+		exists, err := db.ShareIDExists(shareID)
+		if err != nil {
+			return "", err
+		}
+		if !exists {
+			return shareID, nil
+		}
+	}
+	return "", fmt.Errorf("failed to generate unique share id")
+}
+
+func HashPassword(password, salt string) string {
+	return model.HashPwd(model.StaticHash(password), salt)
+}
+
+// SetPassword sets the password of the share with a new salt
+func SetPassword(share *model.Share, password string) {
+	share.PasswordSalt = random.String(16)
+	share.PasswordHash = HashPassword(password, share.PasswordSalt)
+}
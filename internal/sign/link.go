@@ -0,0 +1,33 @@
+package sign
+
+import (
+	"sync"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/alist-org/alist/v3/pkg/sign"
+)
+
+var onceLink sync.Once
+var instanceLink sign.Sign
+
+// SignLink signs a download link, the token is part of the signed data so
+// the sign can't be used without its link
+func SignLink(path, token string, expiresAt time.Time) string {
+	onceLink.Do(InstanceLink)
+	return instanceLink.Sign(linkData(path, token), expiresAt.Unix())
+}
+
+func VerifyLink(path, token, sign string) error {
+	onceLink.Do(InstanceLink)
+	return instanceLink.Verify(linkData(path, token), sign)
+}
+
+func linkData(path, token string) string {
+	return path + "?link=" + token
+}
+
+func InstanceLink() {
+	instanceLink = sign.NewHMACSign([]byte(setting.GetStr(conf.Token) + "-link"))
+}
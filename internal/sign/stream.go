@@ -0,0 +1,48 @@
+package sign
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/alist-org/alist/v3/pkg/sign"
+)
+
+var onceStream sync.Once
+var instanceStream sign.Sign
+
+// SignStream signs a short-lived token for the event streams of a user, which browsers open
+// without the Authorization header. The token has the same layout as the signs of SignUser.
+func SignStream(userID uint, d time.Duration) string {
+	onceStream.Do(InstanceStream)
+	return fmt.Sprintf("%d.%s", userID, instanceStream.Sign(streamData(userID), time.Now().Add(d).Unix()))
+}
+
+// VerifyStream verifies a token made by SignStream and returns the id of its user
+func VerifyStream(s string) (uint, error) {
+	onceStream.Do(InstanceStream)
+	id, rest, ok := strings.Cut(s, ".")
+	if !ok {
+		return 0, sign.ErrSignInvalid
+	}
+	userID, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return 0, sign.ErrSignInvalid
+	}
+	if err := instanceStream.Verify(streamData(uint(userID)), rest); err != nil {
+		return 0, err
+	}
+	return uint(userID), nil
+}
+
+func streamData(userID uint) string {
+	return fmt.Sprintf("stream?user=%d", userID)
+}
+
+func InstanceStream() {
+	instanceStream = sign.NewHMACSign([]byte(setting.GetStr(conf.Token) + "-stream"))
+}
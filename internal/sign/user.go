@@ -0,0 +1,51 @@
+package sign
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/alist-org/alist/v3/pkg/sign"
+)
+
+// SignUser signs the path for a user, the download then acts on behalf of
+// the user, e.g. for storages with per user keys. The id of the user leads
+// the sign, separated by a dot that a base64url sign never holds, and is part
+// of the signed data.
+func SignUser(path string, userID uint) string {
+	once.Do(Instance)
+	var expire int64
+	if hours := setting.GetInt(conf.LinkExpiration, 0); hours > 0 {
+		expire = time.Now().Add(time.Duration(hours) * time.Hour).Unix()
+	}
+	return fmt.Sprintf("%d.%s", userID, instance.Sign(userData(path, userID), expire))
+}
+
+// IsUserSign tells if the sign was made by SignUser
+func IsUserSign(s string) bool {
+	return strings.Contains(s, ".")
+}
+
+// VerifyUser verifies a sign made by SignUser and returns the id of its user
+func VerifyUser(path, s string) (uint, error) {
+	once.Do(Instance)
+	id, rest, ok := strings.Cut(s, ".")
+	if !ok {
+		return 0, sign.ErrSignInvalid
+	}
+	userID, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return 0, sign.ErrSignInvalid
+	}
+	if err := instance.Verify(userData(path, uint(userID)), rest); err != nil {
+		return 0, err
+	}
+	return uint(userID), nil
+}
+
+func userData(path string, userID uint) string {
+	return fmt.Sprintf("%s?user=%d", path, userID)
+}
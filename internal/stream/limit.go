@@ -34,6 +34,58 @@ var (
 	ServerUploadLimit   Limiter
 )
 
+// blockBurstLimiter splits the waits larger than the burst instead of failing
+type blockBurstLimiter struct {
+	*rate.Limiter
+}
+
+func (l blockBurstLimiter) WaitN(ctx context.Context, total int) error {
+	for total > 0 {
+		n := l.Burst()
+		if l.Limiter.Limit() == rate.Inf || n > total {
+			n = total
+		}
+		err := l.Limiter.WaitN(ctx, n)
+		if err != nil {
+			return err
+		}
+		total -= n
+	}
+	return nil
+}
+
+func NewLimiter(limit rate.Limit, burst int) Limiter {
+	return blockBurstLimiter{Limiter: rate.NewLimiter(limit, burst)}
+}
+
+// chainLimiter waits for both limiters, the other methods report the first one
+type chainLimiter struct {
+	Limiter
+	next Limiter
+}
+
+func (l chainLimiter) Wait(ctx context.Context) error {
+	return l.WaitN(ctx, 1)
+}
+
+func (l chainLimiter) WaitN(ctx context.Context, n int) error {
+	if err := l.Limiter.WaitN(ctx, n); err != nil {
+		return err
+	}
+	return l.next.WaitN(ctx, n)
+}
+
+// ChainLimiters combines two limiters, any of them can be nil
+func ChainLimiters(a, b Limiter) Limiter {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+	return chainLimiter{Limiter: a, next: b}
+}
+
 type RateLimitReader struct {
 	io.Reader
 	Limiter Limiter
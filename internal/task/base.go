@@ -14,11 +14,44 @@ type TaskExtension struct {
 	ctx          context.Context
 	ctxInitMutex sync.Mutex
 	Creator      *model.User
+	PipelineID   uint `json:"pipeline_id,omitempty"`
 	startTime    *time.Time
 	endTime      *time.Time
 	totalBytes   int64
 }
 
+// changed is signaled when any task changes its state or progress, or is removed, so the
+// watchers only look at the tasks when something happened
+var changed = make(chan struct{}, 1)
+
+// Changed returns the channel signaled by Notify, the signals are coalesced
+func Changed() <-chan struct{} {
+	return changed
+}
+
+// Notify tells the watchers that a task has changed, it never blocks
+func Notify() {
+	select {
+	case changed <- struct{}{}:
+	default:
+	}
+}
+
+func (t *TaskExtension) SetState(state tache.State) {
+	t.Base.SetState(state)
+	Notify()
+}
+
+func (t *TaskExtension) SetProgress(progress float64) {
+	t.Base.SetProgress(progress)
+	Notify()
+}
+
+func (t *TaskExtension) SetErr(err error) {
+	t.Base.SetErr(err)
+	Notify()
+}
+
 func (t *TaskExtension) SetCreator(creator *model.User) {
 	t.Creator = creator
 	t.Persist()
@@ -61,6 +94,9 @@ func (t *TaskExtension) Ctx() context.Context {
 		t.ctxInitMutex.Lock()
 		if t.ctx == nil {
 			t.ctx = context.WithValue(t.Base.Ctx(), "user", t.Creator)
+			if t.PipelineID != 0 {
+				t.ctx = context.WithValue(t.ctx, conf.PipelineKey, t.PipelineID)
+			}
 		}
 		t.ctxInitMutex.Unlock()
 	}
@@ -87,4 +123,5 @@ type TaskExtensionInfo interface {
 	GetStartTime() *time.Time
 	GetEndTime() *time.Time
 	GetTotalBytes() int64
+	GetPipelineID() uint
 }
@@ -18,3 +18,16 @@ type Manager[T tache.Task] interface {
 	Retry(id string)
 	RetryAllFailed()
 }
+
+// stateNames are indexed by tache.State
+var stateNames = []string{
+	"pending", "running", "succeeded", "canceling", "canceled",
+	"errored", "failing", "failed", "waiting_retry", "before_retry",
+}
+
+func StateName(state tache.State) string {
+	if int(state) < 0 || int(state) >= len(stateNames) {
+		return "unknown"
+	}
+	return stateNames[state]
+}
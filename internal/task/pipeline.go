@@ -0,0 +1,76 @@
+package task
+
+import (
+	"context"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+)
+
+// PipelineHook receives the events of the tasks belonging to a pipeline,
+// it's implemented by the pipeline package to avoid an import cycle with fs.
+type PipelineHook interface {
+	// Join is called when a task joins the current step of the pipeline
+	Join(id uint)
+	// Output records the paths produced by the current step of the pipeline
+	Output(id uint, paths ...string)
+	// OutputDir records a folder the current step put its results in
+	OutputDir(id uint, path string)
+	// Succeed is called when a task of the current step succeeded
+	Succeed(id uint)
+	// Fail is called when a task of the current step failed and won't be retried
+	Fail(id uint, err error)
+}
+
+var pipelineHook PipelineHook
+
+func SetPipelineHook(hook PipelineHook) {
+	pipelineHook = hook
+}
+
+// PipelineIDFromCtx returns the id of the pipeline the operations of ctx belong to, 0 if none
+func PipelineIDFromCtx(ctx context.Context) uint {
+	id, _ := ctx.Value(conf.PipelineKey).(uint)
+	return id
+}
+
+// AddPipelineOutput records the paths produced by an operation of ctx if it runs in a pipeline
+func AddPipelineOutput(ctx context.Context, paths ...string) {
+	if id := PipelineIDFromCtx(ctx); id != 0 && pipelineHook != nil {
+		pipelineHook.Output(id, paths...)
+	}
+}
+
+// AddPipelineOutputDir records a folder an operation of ctx put its results in
+// when the results themselves are unknown, the folder is passed to the next
+// step but never removed as its input
+func AddPipelineOutputDir(ctx context.Context, path string) {
+	if id := PipelineIDFromCtx(ctx); id != 0 && pipelineHook != nil {
+		pipelineHook.OutputDir(id, path)
+	}
+}
+
+// JoinPipeline makes the pipeline wait for the task before starting the next step,
+// it must be called before the task is added to its manager.
+func (t *TaskExtension) JoinPipeline(id uint) {
+	if id == 0 || pipelineHook == nil {
+		return
+	}
+	t.PipelineID = id
+	pipelineHook.Join(id)
+}
+
+func (t *TaskExtension) GetPipelineID() uint {
+	return t.PipelineID
+}
+
+func (t *TaskExtension) OnSucceeded() {
+	if t.PipelineID != 0 && pipelineHook != nil {
+		pipelineHook.Succeed(t.PipelineID)
+	}
+}
+
+func (t *TaskExtension) OnFailed() {
+	if t.PipelineID != 0 && pipelineHook != nil {
+		pipelineHook.Fail(t.PipelineID, t.GetErr())
+	}
+}
@@ -251,6 +251,40 @@ func (c *Client) Stat(path string) (os.FileInfo, error) {
 	return f, err
 }
 
+// SetModTime sets the modification time of a remote file by PROPPATCH
+func (c *Client) SetModTime(path string, modTime time.Time) error {
+	path = FixSlashes(path)
+	applied := false
+	parse := func(resp interface{}) error {
+		r := resp.(*response)
+		for _, p := range r.Props {
+			if !strings.Contains(p.Status, "200") {
+				return newPathError("SetModTime", path, 403)
+			}
+			applied = true
+		}
+		r.Props = nil
+		return nil
+	}
+	err := c.proppatch(path,
+		`<d:propertyupdate xmlns:d='DAV:'>
+			<d:set>
+				<d:prop>
+					<d:getlastmodified>`+modTime.UTC().Format(http.TimeFormat)+`</d:getlastmodified>
+				</d:prop>
+			</d:set>
+		</d:propertyupdate>`,
+		&response{},
+		parse)
+	if err != nil {
+		return err
+	}
+	if !applied {
+		return newPathError("SetModTime", path, 403)
+	}
+	return nil
+}
+
 // Remove removes a remote file
 func (c *Client) Remove(path string) error {
 	return c.RemoveAll(path)
@@ -76,6 +76,11 @@ func RegisterHashWithParam(name, alias string, width int, newFunc func(...any) h
 	return newType
 }
 
+// GetHashByName returns the registered HashType of name, or nil if there is none
+func GetHashByName(name string) *HashType {
+	return name2hash[name]
+}
+
 var (
 	// MD5 indicates MD5 support
 	MD5 = RegisterHash("md5", "MD5", 32, md5.New)
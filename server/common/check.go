@@ -3,9 +3,11 @@ package common
 import (
 	"github.com/alist-org/alist/v3/internal/conf"
 	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/errs"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/op"
 	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
 )
 
 func IsStorageSignEnabled(rawPath string) bool {
@@ -20,6 +22,21 @@ func CanWrite(meta *model.Meta, path string) bool {
 	return meta.WSub || meta.Path == path
 }
 
+// CanUploadTo reports whether the user can write into dir by its roles or the
+// meta of dir. Uploads to shares are made with the permissions of the share
+// creator, or of its group.
+func CanUploadTo(user *model.User, dir string) bool {
+	if user.Disabled {
+		return false
+	}
+	meta, err := op.GetNearestMeta(dir)
+	if err != nil && !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+		return false
+	}
+	perm := MergeRolePermissions(user, dir)
+	return HasPermission(perm, PermWrite) || CanWrite(meta, dir)
+}
+
 func IsApply(metaPath, reqPath string, applySub bool) bool {
 	if utils.PathEqual(metaPath, reqPath) {
 		return true
@@ -0,0 +1,14 @@
+package common
+
+import (
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/setting"
+)
+
+// DownloadLinkMaxTTL is the longest life of a download link, the links
+// created before the setting was lowered expire with it too
+func DownloadLinkMaxTTL() time.Duration {
+	return time.Duration(setting.GetInt(conf.DownloadLinkMaxTTL, 720)) * time.Hour
+}
@@ -11,6 +11,7 @@ import (
 
 	"maps"
 
+	"github.com/alist-org/alist/v3/internal/metrics"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/net"
 	"github.com/alist-org/alist/v3/internal/sign"
@@ -21,6 +22,7 @@ import (
 )
 
 func Proxy(w http.ResponseWriter, r *http.Request, link *model.Link, file model.Obj) error {
+	w = metrics.CountProxied(w)
 	if link.MFile != nil {
 		defer link.MFile.Close()
 		attachHeader(w, file)
@@ -0,0 +1,169 @@
+package common
+
+import (
+	"fmt"
+	"path"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils"
+)
+
+// PermNames are the names of the permission bits, indexed by bit.
+var PermNames = []string{
+	PermSeeHides:              "see_hides",
+	PermAccessWithoutPassword: "access_without_password",
+	PermAddOfflineDownload:    "add_offline_download",
+	PermWrite:                 "write",
+	PermRename:                "rename",
+	PermMove:                  "move",
+	PermCopy:                  "copy",
+	PermRemove:                "remove",
+	PermWebdavRead:            "webdav_read",
+	PermWebdavManage:          "webdav_manage",
+	PermFTPAccess:             "ftp_access",
+	PermFTPManage:             "ftp_manage",
+	PermReadArchives:          "read_archives",
+	PermDecompress:            "decompress",
+	PermPathLimit:             "path_limit",
+	PermMCPAccess:             "mcp_access",
+	PermMCPManage:             "mcp_manage",
+}
+
+// PermissionRuleRef points at the role entry, group or meta that affects a bit.
+type PermissionRuleRef struct {
+	Kind       string                     `json:"kind"` // role, group, share or meta
+	RoleID     uint                       `json:"role_id,omitempty"`
+	RoleName   string                     `json:"role_name,omitempty"`
+	GroupID    uint                       `json:"group_id,omitempty"`
+	GroupName  string                     `json:"group_name,omitempty"`
+	ShareID    uint                       `json:"share_id,omitempty"`
+	Index      int                        `json:"index"` // position in permission_scopes
+	Path       string                     `json:"path"`
+	Deny       bool                       `json:"deny,omitempty"`
+	Conditions *model.PermissionCondition `json:"conditions,omitempty"`
+	Note       string                     `json:"note,omitempty"`
+}
+
+type PermissionBitExplanation struct {
+	Bit       uint                `json:"bit"`
+	Name      string              `json:"name"`
+	Granted   bool                `json:"granted"`
+	GrantedBy []PermissionRuleRef `json:"granted_by"`
+	DeniedBy  []PermissionRuleRef `json:"denied_by"`
+}
+
+type PermissionExplanation struct {
+	Path       string                     `json:"path"`
+	Protocol   string                     `json:"protocol"`
+	ClientIP   string                     `json:"client_ip"`
+	Permission int32                      `json:"permission"`
+	Readable   bool                       `json:"readable"`
+	HiddenBy   *PermissionRuleRef         `json:"hidden_by"`
+	Bits       []PermissionBitExplanation `json:"bits"`
+	// Inactive lists entries covering the path whose conditions do not hold.
+	Inactive []PermissionRuleRef `json:"inactive"`
+	// Meta lists the meta rules applying to the path.
+	Meta []PermissionRuleRef `json:"meta"`
+}
+
+func ruleRef(rule permRule) PermissionRuleRef {
+	if rule.Share != nil {
+		return PermissionRuleRef{
+			Kind:    "share",
+			ShareID: rule.Share.ID,
+			Path:    rule.Entry.Path,
+			Note:    fmt.Sprintf("shared with the %s", rule.Share.TargetType),
+		}
+	}
+	if rule.Group != nil {
+		return PermissionRuleRef{
+			Kind:      "group",
+			GroupID:   rule.Group.ID,
+			GroupName: rule.Group.Name,
+			Path:      rule.Entry.Path,
+			Note:      "home path",
+		}
+	}
+	return PermissionRuleRef{
+		Kind:       "role",
+		RoleID:     rule.Role.ID,
+		RoleName:   rule.Role.Name,
+		Index:      rule.Index,
+		Path:       rule.Entry.Path,
+		Deny:       rule.Entry.Deny,
+		Conditions: rule.Entry.Conditions,
+	}
+}
+
+// ExplainPermissions resolves the permissions of u on reqPath like
+// MergeRolePermissions does and records which rule produced each bit. The
+// access context of u selects the conditional entries.
+func ExplainPermissions(u *model.User, meta *model.Meta, reqPath string) *PermissionExplanation {
+	res := &PermissionExplanation{
+		Path:       reqPath,
+		Permission: MergeRolePermissions(u, reqPath),
+		Readable:   CanReadPathByRole(u, reqPath),
+		Bits:       make([]PermissionBitExplanation, 0, len(PermNames)),
+		Inactive:   []PermissionRuleRef{},
+		Meta:       []PermissionRuleRef{},
+	}
+	if u.Access != nil {
+		res.Protocol = u.Access.Protocol
+		res.ClientIP = u.Access.ClientIP
+	}
+	rules, inactive := userRules(u)
+	if rule := hiddenBy(rules, reqPath); rule != nil {
+		ref := ruleRef(*rule)
+		res.HiddenBy = &ref
+	}
+	for _, rule := range inactive {
+		if rule.Entry.Deny && utils.IsSubPath(rule.Entry.Path, reqPath) ||
+			!rule.Entry.Deny && grantsAt(u, rule.Entry, reqPath) {
+			res.Inactive = append(res.Inactive, ruleRef(rule))
+		}
+	}
+	for bit, name := range PermNames {
+		b := PermissionBitExplanation{
+			Bit:       uint(bit),
+			Name:      name,
+			Granted:   HasPermission(res.Permission, uint(bit)),
+			GrantedBy: []PermissionRuleRef{},
+			DeniedBy:  []PermissionRuleRef{},
+		}
+		for _, rule := range rules {
+			switch {
+			case rule.Entry.Deny:
+				if utils.IsSubPath(rule.Entry.Path, reqPath) && HasPermission(deniedBits(rule.Entry), uint(bit)) {
+					b.DeniedBy = append(b.DeniedBy, ruleRef(rule))
+				}
+			case grantsAt(u, rule.Entry, reqPath) && HasPermission(rule.Entry.Permission, uint(bit)):
+				b.GrantedBy = append(b.GrantedBy, ruleRef(rule))
+			}
+		}
+		if bit == PermWrite && !b.Granted && CanWrite(meta, reqPath) {
+			b.Granted = true
+			b.GrantedBy = append(b.GrantedBy, PermissionRuleRef{Kind: "meta", Path: meta.Path, Note: "write"})
+		}
+		res.Bits = append(res.Bits, b)
+	}
+	if meta != nil {
+		if meta.Password != "" && IsApply(meta.Path, reqPath, meta.PSub) {
+			note := "password required"
+			if HasPermission(res.Permission, PermAccessWithoutPassword) {
+				note = "password bypassed by access_without_password"
+			}
+			res.Meta = append(res.Meta, PermissionRuleRef{Kind: "meta", Path: meta.Path, Note: note})
+		}
+		if meta.Hide != "" && IsApply(meta.Path, path.Dir(reqPath), meta.HSub) {
+			note := "hides matching names"
+			if HasPermission(res.Permission, PermSeeHides) {
+				note = "hide bypassed by see_hides"
+			}
+			res.Meta = append(res.Meta, PermissionRuleRef{Kind: "meta", Path: meta.Path, Note: note})
+		}
+		if CanWrite(meta, reqPath) {
+			res.Meta = append(res.Meta, PermissionRuleRef{Kind: "meta", Path: meta.Path, Note: "allows upload and mkdir"})
+		}
+	}
+	return res
+}
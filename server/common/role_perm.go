@@ -3,6 +3,7 @@ package common
 import (
 	"path"
 	"strings"
+	"time"
 
 	"github.com/dlclark/regexp2"
 
@@ -12,52 +13,189 @@ import (
 )
 
 const (
-	PermSeeHides = iota
-	PermAccessWithoutPassword
-	PermAddOfflineDownload
-	PermWrite
-	PermRename
-	PermMove
-	PermCopy
-	PermRemove
-	PermWebdavRead
-	PermWebdavManage
-	PermFTPAccess
-	PermFTPManage
-	PermReadArchives
-	PermDecompress
-	PermPathLimit
-	PermMCPAccess
-	PermMCPManage
+	PermSeeHides              = model.PermSeeHides
+	PermAccessWithoutPassword = model.PermAccessWithoutPassword
+	PermAddOfflineDownload    = model.PermAddOfflineDownload
+	PermWrite                 = model.PermWrite
+	PermRename                = model.PermRename
+	PermMove                  = model.PermMove
+	PermCopy                  = model.PermCopy
+	PermRemove                = model.PermRemove
+	PermWebdavRead            = model.PermWebdavRead
+	PermWebdavManage          = model.PermWebdavManage
+	PermFTPAccess             = model.PermFTPAccess
+	PermFTPManage             = model.PermFTPManage
+	PermReadArchives          = model.PermReadArchives
+	PermDecompress            = model.PermDecompress
+	PermPathLimit             = model.PermPathLimit
+	PermMCPAccess             = model.PermMCPAccess
+	PermMCPManage             = model.PermMCPManage
 )
 
 func HasPermission(perm int32, bit uint) bool {
 	return (perm>>bit)&1 == 1
 }
 
-func MergeRolePermissions(u *model.User, reqPath string) int32 {
-	if u == nil {
-		return 0
+// permRule is a permission entry of one of the user's roles, the grant of
+// the home path of one of the user's groups, or an internal share granted to
+// the user.
+type permRule struct {
+	Role  *model.Role
+	Group *model.Group
+	Share *model.InternalShare
+	Index int
+	Entry model.PermissionEntry
+}
+
+// userRules returns the entries of the user's roles whose conditions hold for
+// the user's access context, followed by the grants of the user's groups and
+// of the internal shares granted to the user. Entries whose conditions cannot be evaluated,
+// e.g. a protocol condition outside of a request, are kept when they deny and
+// dropped when they grant.
+func userRules(u *model.User) (active, inactive []permRule) {
+	active, inactive = ownRules(u)
+	if u.ID == 0 {
+		return active, inactive
 	}
-	var perm int32
+	shares, _ := op.GetIncomingShares(u)
+	for i := range shares {
+		perm, ok := SharePermission(&shares[i])
+		if !ok {
+			continue
+		}
+		active = append(active, permRule{
+			Share: &shares[i],
+			Entry: model.PermissionEntry{Path: shares[i].Path, Permission: perm},
+		})
+	}
+	return active, inactive
+}
+
+// ownRules is userRules without the internal shares granted to the user, so
+// that what was shared with a user can't be shared again
+func ownRules(u *model.User) (active, inactive []permRule) {
+	if g := u.ActingGroup; g != nil {
+		if g.HomePath == "" {
+			return nil, nil
+		}
+		return []permRule{{Group: g, Entry: model.PermissionEntry{Path: g.HomePath, Permission: g.Permission}}}, nil
+	}
+	now := time.Now()
 	for _, rid := range u.Role {
 		role, err := op.GetRole(uint(rid))
 		if err != nil {
 			continue
 		}
-		if reqPath == "/" || utils.PathEqual(reqPath, u.BasePath) {
-			for _, entry := range role.PermissionScopes {
-				perm |= entry.Permission
+		for i, entry := range role.PermissionScopes {
+			rule := permRule{Role: role, Index: i, Entry: entry}
+			if ruleApplies(entry, u.Access, now) {
+				active = append(active, rule)
+			} else {
+				inactive = append(inactive, rule)
 			}
-		} else {
-			for _, entry := range role.PermissionScopes {
-				if utils.IsSubPath(entry.Path, reqPath) {
-					perm |= entry.Permission
-				}
+		}
+	}
+	if u.ID == 0 {
+		return active, inactive
+	}
+	groups, _ := op.GetGroupsByUserID(u.ID)
+	for i := range groups {
+		if groups[i].HomePath == "" {
+			continue
+		}
+		active = append(active, permRule{
+			Group: &groups[i],
+			Entry: model.PermissionEntry{Path: groups[i].HomePath, Permission: groups[i].Permission},
+		})
+	}
+	return active, inactive
+}
+
+// SharePermission returns the bits an internal share grants, capped by what
+// its owner holds on the shared path at the moment. It reports false when
+// the owner is gone, disabled or can't read the path anymore.
+func SharePermission(s *model.InternalShare) (int32, bool) {
+	owner, err := op.GetUserById(s.OwnerID)
+	if err != nil || owner.Disabled {
+		return 0, false
+	}
+	perm, ok := OwnPermissions(owner, s.Path)
+	if !ok {
+		return 0, false
+	}
+	return s.Granted() & perm, true
+}
+
+// OwnPermissions returns the bits the user holds on reqPath through its roles
+// and groups, and whether they let the user read the path. The internal
+// shares granted to the user don't count.
+func OwnPermissions(u *model.User, reqPath string) (int32, bool) {
+	if u == nil {
+		return 0, false
+	}
+	rules, _ := ownRules(u)
+	return mergeRules(u, rules, reqPath), canReadByRules(u, rules, reqPath)
+}
+
+func ruleApplies(entry model.PermissionEntry, ac *model.AccessContext, now time.Time) bool {
+	if entry.Conditions == nil {
+		return true
+	}
+	matched, known := entry.Conditions.Match(ac, now)
+	if !known {
+		return matched && entry.Deny
+	}
+	return matched
+}
+
+// deniedBits returns the bits revoked by the rule, all of them for a deny-all
+// entry.
+func deniedBits(entry model.PermissionEntry) int32 {
+	if entry.DenyAll() {
+		return -1
+	}
+	return entry.Permission
+}
+
+// grantsAt reports whether a grant rule contributes to reqPath. At the root
+// of the user every grant counts so that the user can navigate to it.
+func grantsAt(u *model.User, entry model.PermissionEntry, reqPath string) bool {
+	if reqPath == "/" || utils.PathEqual(reqPath, u.BasePath) {
+		return true
+	}
+	return utils.IsSubPath(entry.Path, reqPath)
+}
+
+func MergeRolePermissions(u *model.User, reqPath string) int32 {
+	if u == nil {
+		return 0
+	}
+	rules, _ := userRules(u)
+	return mergeRules(u, rules, reqPath)
+}
+
+func mergeRules(u *model.User, rules []permRule, reqPath string) int32 {
+	var perm, deny int32
+	for _, rule := range rules {
+		if rule.Entry.Deny {
+			if utils.IsSubPath(rule.Entry.Path, reqPath) {
+				deny |= deniedBits(rule.Entry)
 			}
+		} else if grantsAt(u, rule.Entry, reqPath) {
+			perm |= rule.Entry.Permission
+		}
+	}
+	return perm &^ deny
+}
+
+// hiddenBy returns the deny-all rule hiding reqPath, if any.
+func hiddenBy(rules []permRule, reqPath string) *permRule {
+	for i, rule := range rules {
+		if rule.Entry.DenyAll() && utils.IsSubPath(rule.Entry.Path, reqPath) {
+			return &rules[i]
 		}
 	}
-	return perm
+	return nil
 }
 
 func CanAccessWithRoles(u *model.User, meta *model.Meta, reqPath, password string) bool {
@@ -90,18 +228,24 @@ func CanReadPathByRole(u *model.User, reqPath string) bool {
 	if u == nil {
 		return false
 	}
-	if reqPath == "/" || utils.PathEqual(reqPath, u.BasePath) {
+	rules, _ := userRules(u)
+	return canReadByRules(u, rules, reqPath)
+}
+
+func canReadByRules(u *model.User, rules []permRule, reqPath string) bool {
+	if hiddenBy(rules, reqPath) != nil {
+		return false
+	}
+	if (reqPath == "/" || utils.PathEqual(reqPath, u.BasePath)) && u.ActingGroup == nil {
 		return len(u.Role) > 0
 	}
-	for _, rid := range u.Role {
-		role, err := op.GetRole(uint(rid))
-		if err != nil {
+	for _, rule := range rules {
+		if rule.Entry.Deny {
 			continue
 		}
-		for _, entry := range role.PermissionScopes {
-			if utils.PathEqual(entry.Path, reqPath) || utils.IsSubPath(entry.Path, reqPath) || utils.IsSubPath(reqPath, entry.Path) {
-				return true
-			}
+		entry := rule.Entry
+		if utils.PathEqual(entry.Path, reqPath) || utils.IsSubPath(entry.Path, reqPath) || utils.IsSubPath(reqPath, entry.Path) {
+			return true
 		}
 	}
 	return false
@@ -113,27 +257,37 @@ func HasChildPermission(u *model.User, reqPath string, bit uint) bool {
 	if u == nil {
 		return false
 	}
-	for _, rid := range u.Role {
-		role, err := op.GetRole(uint(rid))
-		if err != nil {
+	rules, _ := userRules(u)
+	for _, rule := range rules {
+		entry := rule.Entry
+		if entry.Deny || !utils.IsSubPath(reqPath, entry.Path) || !HasPermission(entry.Permission, bit) {
 			continue
 		}
-		for _, entry := range role.PermissionScopes {
-			if utils.IsSubPath(reqPath, entry.Path) && HasPermission(entry.Permission, bit) {
-				return true
+		denied := false
+		for _, other := range rules {
+			if other.Entry.Deny && utils.IsSubPath(other.Entry.Path, entry.Path) && HasPermission(deniedBits(other.Entry), bit) {
+				denied = true
+				break
 			}
 		}
+		if !denied {
+			return true
+		}
 	}
 	return false
 }
 
 // CheckPathLimitWithRoles checks whether the path is allowed when the user has
 // the `PermPathLimit` permission for the target path. When the user does not
-// have this permission, the check passes by default.
+// have this permission, the check passes unless a deny rule hides the path.
 func CheckPathLimitWithRoles(u *model.User, reqPath string) bool {
 	perm := MergeRolePermissions(u, reqPath)
 	if HasPermission(perm, PermPathLimit) {
 		return CanReadPathByRole(u, reqPath)
 	}
-	return true
+	if u == nil {
+		return true
+	}
+	rules, _ := userRules(u)
+	return hiddenBy(rules, reqPath) == nil
 }
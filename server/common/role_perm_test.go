@@ -0,0 +1,251 @@
+package common
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/db/dbtest"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+)
+
+func init() {
+	dbtest.Init()
+}
+
+const writeBits = 1<<PermWrite | 1<<PermRename | 1<<PermRemove
+
+func createRole(t *testing.T, name string, scopes ...model.PermissionEntry) int {
+	role := &model.Role{Name: name, PermissionScopes: scopes}
+	if err := op.CreateRole(role); err != nil {
+		t.Fatalf("create role %s: %+v", name, err)
+	}
+	return int(role.ID)
+}
+
+func TestDenyRules(t *testing.T) {
+	team := createRole(t, "team",
+		model.PermissionEntry{Path: "/team", Permission: writeBits | 1<<PermWebdavRead | 1<<PermWebdavManage},
+		model.PermissionEntry{Path: "/team/hr", Deny: true},
+	)
+	davReadOnly := createRole(t, "dav_read_only",
+		model.PermissionEntry{Path: "/", Deny: true, Permission: writeBits | 1<<PermWebdavManage,
+			Conditions: &model.PermissionCondition{Protocols: []string{"webdav"}}},
+	)
+	office := createRole(t, "office",
+		model.PermissionEntry{Path: "/team", Permission: 1 << PermCopy,
+			Conditions: &model.PermissionCondition{CIDRs: []string{"10.0.0.0/8"}}},
+	)
+	u := &model.User{Username: "alice", BasePath: "/", Role: model.Roles{team, davReadOnly, office}}
+
+	web := u.WithAccess("http", "10.1.2.3")
+	if perm := MergeRolePermissions(web, "/team/docs"); perm&writeBits != writeBits {
+		t.Errorf("expected write bits under /team, got %b", perm)
+	}
+	if !CanReadPathByRole(web, "/team/docs") || CanReadPathByRole(web, "/team/hr/pay") {
+		t.Errorf("expected /team/hr to be hidden and /team/docs to be readable")
+	}
+	if perm := MergeRolePermissions(web, "/team/hr"); perm != 0 {
+		t.Errorf("expected no permission under /team/hr, got %b", perm)
+	}
+	if CheckPathLimitWithRoles(web, "/team/hr/pay") {
+		t.Errorf("expected path limit check to fail under /team/hr")
+	}
+
+	dav := u.WithAccess("webdav", "10.1.2.3")
+	perm := MergeRolePermissions(dav, "/team/docs")
+	if perm&writeBits != 0 || HasPermission(perm, PermWebdavManage) || !HasPermission(perm, PermWebdavRead) {
+		t.Errorf("expected webdav to be read only, got %b", perm)
+	}
+	if HasChildPermission(dav, "/", PermWebdavManage) {
+		t.Errorf("expected no child with webdav manage")
+	}
+	// a deny whose condition can't be evaluated applies
+	if perm := MergeRolePermissions(u, "/team/docs"); perm&writeBits != 0 {
+		t.Errorf("expected deny to apply without access context, got %b", perm)
+	}
+
+	if !HasPermission(MergeRolePermissions(web, "/team/docs"), PermCopy) {
+		t.Errorf("expected copy from the office network")
+	}
+	if HasPermission(MergeRolePermissions(u.WithAccess("http", "192.168.1.2:5000"), "/team/docs"), PermCopy) {
+		t.Errorf("expected no copy from outside the office network")
+	}
+
+	exp := ExplainPermissions(dav, nil, "/team/docs")
+	write := exp.Bits[PermWrite]
+	if write.Granted || len(write.GrantedBy) != 1 || len(write.DeniedBy) != 1 || write.DeniedBy[0].RoleName != "dav_read_only" {
+		t.Errorf("unexpected explanation of write: %+v", write)
+	}
+	exp = ExplainPermissions(web, nil, "/team/hr/pay")
+	if exp.Readable || exp.HiddenBy == nil || exp.HiddenBy.Index != 1 {
+		t.Errorf("unexpected explanation of hidden path: %+v", exp)
+	}
+}
+
+func TestPermissionConditionWindow(t *testing.T) {
+	c := &model.PermissionCondition{TimeStart: "22:00", TimeEnd: "06:00", Weekdays: []int{1, 2, 3, 4, 5}}
+	if err := c.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	datas := []struct {
+		now    time.Time
+		result bool
+	}{
+		{time.Date(2024, 1, 1, 23, 30, 0, 0, time.Local), true},  // monday night
+		{time.Date(2024, 1, 2, 5, 59, 0, 0, time.Local), true},   // tuesday morning
+		{time.Date(2024, 1, 2, 6, 0, 0, 0, time.Local), false},   // window closed
+		{time.Date(2024, 1, 6, 23, 30, 0, 0, time.Local), false}, // saturday
+	}
+	for i, data := range datas {
+		if matched, _ := c.Match(nil, data.now); matched != data.result {
+			t.Errorf("TestPermissionConditionWindow %d failed", i)
+		}
+	}
+	if err := (&model.PermissionCondition{CIDRs: []string{"10.0.0.0/33"}}).Validate(); err == nil {
+		t.Errorf("expected invalid cidr to be rejected")
+	}
+}
+
+func TestGroupHome(t *testing.T) {
+	reader := createRole(t, "reader", model.PermissionEntry{Path: "/public"})
+	u := &model.User{Username: "bob", BasePath: "/", Role: model.Roles{reader}}
+	if err := op.CreateUser(u); err != nil {
+		t.Fatalf("create user: %+v", err)
+	}
+	if CanReadPathByRole(u, "/groups/ops/runbooks") {
+		t.Fatalf("expected the group home to be unreadable before joining")
+	}
+	group := &model.Group{Name: "ops", HomePath: "/groups/ops/", Permission: 1<<PermWrite | 1<<PermRemove}
+	if err := op.CreateGroup(group); err != nil {
+		t.Fatalf("create group: %+v", err)
+	}
+	if err := op.SaveGroupMember(&model.GroupMember{GroupID: group.ID, UserID: u.ID}); err != nil {
+		t.Fatalf("add member: %+v", err)
+	}
+	if !CanReadPathByRole(u, "/groups/ops/runbooks") || !CanReadPathByRole(u, "/groups") {
+		t.Errorf("expected the group home and its parents to be readable")
+	}
+	if perm := MergeRolePermissions(u, "/groups/ops/runbooks"); !HasPermission(perm, PermWrite) || !HasPermission(perm, PermRemove) {
+		t.Errorf("expected the group permission on its home, got %b", perm)
+	}
+	if perm := MergeRolePermissions(u, "/public/docs"); HasPermission(perm, PermWrite) {
+		t.Errorf("expected no group permission outside of its home, got %b", perm)
+	}
+	if err := op.RemoveGroupMember(group.ID, u.ID); err != nil {
+		t.Fatalf("remove member: %+v", err)
+	}
+	if CanReadPathByRole(u, "/groups/ops/runbooks") {
+		t.Errorf("expected the group home to be unreadable after leaving")
+	}
+}
+
+func TestGroupShareActor(t *testing.T) {
+	creator := &model.User{Username: "heidi", BasePath: "/", Role: model.Roles{createRole(t, "heidi_all", model.PermissionEntry{Path: "/", Permission: writeBits})}}
+	if err := op.CreateUser(creator); err != nil {
+		t.Fatalf("create user: %+v", err)
+	}
+	group := &model.Group{Name: "design", HomePath: "/groups/design", Permission: 1 << PermWrite}
+	if err := op.CreateGroup(group); err != nil {
+		t.Fatalf("create group: %+v", err)
+	}
+	own := &model.Share{ShareID: "heidi_own", CreatorID: creator.ID, RootPath: "/groups/design/logos"}
+	grouped := &model.Share{ShareID: "heidi_group", CreatorID: creator.ID, GroupID: group.ID, RootPath: "/groups/design/logos"}
+
+	actor, err := op.GetShareActor(grouped)
+	if err != nil {
+		t.Fatalf("group actor: %+v", err)
+	}
+	if !CanReadPathByRole(actor, "/groups/design/logos") || !CanUploadTo(actor, "/groups/design/logos") {
+		t.Errorf("expected the group to read and write its home")
+	}
+	if CanReadPathByRole(actor, "/private") || HasPermission(MergeRolePermissions(actor, "/private"), PermWrite) {
+		t.Errorf("expected the group to hold nothing of its creator's roles")
+	}
+
+	creator.Disabled = true
+	if err := op.UpdateUser(creator); err != nil {
+		t.Fatalf("disable user: %+v", err)
+	}
+	if _, err := op.GetShareActor(own); err == nil {
+		t.Errorf("expected the share of a disabled creator to stop working")
+	}
+	if actor, err := op.GetShareActor(grouped); err != nil || !CanReadPathByRole(actor, "/groups/design/logos") {
+		t.Errorf("expected the group share to outlive its creator, got %v", err)
+	}
+}
+
+func TestInternalShare(t *testing.T) {
+	owner := &model.User{Username: "carol", BasePath: "/", Role: model.Roles{createRole(t, "owner", model.PermissionEntry{Path: "/", Permission: writeBits})}}
+	target := &model.User{Username: "dave", BasePath: "/", Role: model.Roles{createRole(t, "nobody", model.PermissionEntry{Path: "/public"})}}
+	for _, u := range []*model.User{owner, target} {
+		if err := op.CreateUser(u); err != nil {
+			t.Fatalf("create user: %+v", err)
+		}
+	}
+	if CanReadPathByRole(target, "/projects/plan") {
+		t.Fatalf("expected the path to be unreadable before sharing")
+	}
+	share := &model.InternalShare{OwnerID: owner.ID, Path: "/projects/plan", Name: "plan",
+		TargetType: model.InternalShareUser, TargetID: target.ID}
+	share.Permission = share.MaxPermission()
+	if err := op.CreateInternalShare(share); err != nil {
+		t.Fatalf("create internal share: %+v", err)
+	}
+	if !CanReadPathByRole(target, "/projects/plan/q1") {
+		t.Errorf("expected the shared path to be readable")
+	}
+	if perm := MergeRolePermissions(target, "/projects/plan"); HasPermission(perm, PermWrite) {
+		t.Errorf("expected a read only share, got %b", perm)
+	}
+	if p, err := target.JoinPath("/Shared with me/plan/q1"); err != nil || p != "/projects/plan/q1" {
+		t.Errorf("expected the shared with me path to map to the shared path, got %s %v", p, err)
+	}
+	if err := op.DeleteInternalShare(owner, share.ID); err != nil {
+		t.Fatalf("delete internal share: %+v", err)
+	}
+	if CanReadPathByRole(target, "/projects/plan/q1") {
+		t.Errorf("expected the path to be unreadable after revoking")
+	}
+	// without shares the folder is a real one again
+	if p, err := target.JoinPath("/Shared with me/plan"); err != nil || p != "/Shared with me/plan" {
+		t.Errorf("expected the real path once nothing is shared, got %s %v", p, err)
+	}
+}
+
+func TestInternalShareCapped(t *testing.T) {
+	owner := &model.User{Username: "erin", BasePath: "/", Role: model.Roles{createRole(t, "writer", model.PermissionEntry{Path: "/", Permission: 1 << PermWrite})}}
+	target := &model.User{Username: "frank", BasePath: "/", Role: model.Roles{createRole(t, "nobody2", model.PermissionEntry{Path: "/public"})}}
+	other := &model.User{Username: "grace", BasePath: "/", Role: model.Roles{createRole(t, "nobody3", model.PermissionEntry{Path: "/public"})}}
+	for _, u := range []*model.User{owner, target, other} {
+		if err := op.CreateUser(u); err != nil {
+			t.Fatalf("create user: %+v", err)
+		}
+	}
+	ownPerm, ok := OwnPermissions(owner, "/docs")
+	if !ok || HasPermission(ownPerm, PermRemove) {
+		t.Fatalf("expected the owner to read /docs without remove, got %b %v", ownPerm, ok)
+	}
+	// a share written with more bits than its owner holds is capped on use
+	share := &model.InternalShare{OwnerID: owner.ID, Path: "/docs", Name: "docs",
+		TargetType: model.InternalShareUser, TargetID: target.ID, Write: true}
+	share.Permission = share.MaxPermission()
+	if err := op.CreateInternalShare(share); err != nil {
+		t.Fatalf("create internal share: %+v", err)
+	}
+	perm := MergeRolePermissions(target, "/docs/a")
+	if !HasPermission(perm, PermWrite) || HasPermission(perm, PermRemove) || HasPermission(perm, PermRename) {
+		t.Errorf("expected the share to grant write only, got %b", perm)
+	}
+	if _, ok := OwnPermissions(target, "/docs"); ok {
+		t.Errorf("expected a shared path not to be shareable again")
+	}
+	// the grant follows the permission of the owner
+	owner.Disabled = true
+	if err := op.UpdateUser(owner); err != nil {
+		t.Fatalf("update user: %+v", err)
+	}
+	if CanReadPathByRole(target, "/docs/a") {
+		t.Errorf("expected the share to stop working once its owner is disabled")
+	}
+}
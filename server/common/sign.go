@@ -4,14 +4,35 @@ import (
 	stdpath "path"
 
 	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/cryptkey"
 	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
 	"github.com/alist-org/alist/v3/internal/setting"
 	"github.com/alist-org/alist/v3/internal/sign"
 )
 
-func Sign(obj model.Obj, parent string, encrypt bool) string {
-	if obj.IsDir() || (!encrypt && !setting.GetBool(conf.SignAll)) {
+// Sign signs the download of the file, for the user when it's on a storage
+// with per user keys since the download needs the keys of the user
+func Sign(user *model.User, obj model.Obj, parent string, encrypt bool) string {
+	if obj.IsDir() {
 		return ""
 	}
-	return sign.Sign(stdpath.Join(parent, obj.GetName()))
+	path := stdpath.Join(parent, obj.GetName())
+	if user != nil && PerUserKeys(path) {
+		return sign.SignUser(path, user.ID)
+	}
+	if !encrypt && !setting.GetBool(conf.SignAll) {
+		return ""
+	}
+	return sign.Sign(path)
+}
+
+// PerUserKeys tells if the path is on a storage with per user keys
+func PerUserKeys(path string) bool {
+	storage, _, err := op.GetStorageAndActualPath(path)
+	if err != nil {
+		return false
+	}
+	m, ok := storage.(cryptkey.Mount)
+	return ok && m.PerUserKeys()
 }
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	ftpserver "github.com/KirCute/ftpserverlib-pasvportmap"
 	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/metrics"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/op"
 	"github.com/alist-org/alist/v3/internal/setting"
@@ -67,13 +68,13 @@ func NewMainDriver() (*FtpMainDriver, error) {
 			ConnectionTimeout:         conf.Conf.FTP.ConnectionTimeout,
 			DisableMLSD:               false,
 			DisableMLST:               false,
-			DisableMFMT:               true,
+			DisableMFMT:               false,
 			Banner:                    setting.GetStr(conf.Announcement),
 			TLSRequired:               tlsRequired,
 			DisableLISTArgs:           false,
 			DisableSite:               false,
 			DisableActiveMode:         conf.Conf.FTP.DisableActiveMode,
-			EnableHASH:                false,
+			EnableHASH:                true,
 			DisableSTAT:               false,
 			DisableSYST:               false,
 			EnableCOMB:                false,
@@ -131,6 +132,7 @@ func (d *FtpMainDriver) AuthUser(cc ftpserver.ClientContext, user, pass string)
 			return nil, err
 		}
 	}
+	userObj = userObj.WithAccess(metrics.ProtocolFTP, cc.RemoteAddr().String())
 	perm := common.MergeRolePermissions(userObj, userObj.BasePath)
 	if userObj.Disabled || !common.HasPermission(perm, common.PermFTPAccess) {
 		return nil, errors.New("user is not allowed to access via FTP")
@@ -145,7 +147,7 @@ func (d *FtpMainDriver) AuthUser(cc ftpserver.ClientContext, user, pass string)
 	}
 	ctx = context.WithValue(ctx, "client_ip", cc.RemoteAddr().String())
 	ctx = context.WithValue(ctx, "proxy_header", d.proxyHeader)
-	return ftp.NewAferoAdapter(ctx), nil
+	return ftp.NewAferoAdapter(ctx, metrics.ProtocolFTP), nil
 }
 
 func (d *FtpMainDriver) GetTLSConfig() (*tls.Config, error) {
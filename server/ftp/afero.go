@@ -6,7 +6,9 @@ import (
 	ftpserver "github.com/KirCute/ftpserverlib-pasvportmap"
 	"github.com/alist-org/alist/v3/internal/errs"
 	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/metrics"
 	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils"
 	"github.com/spf13/afero"
 	"os"
 	"time"
@@ -15,10 +17,16 @@ import (
 type AferoAdapter struct {
 	ctx          context.Context
 	nextFileSize int64
+	// protocol is the server using the adapter, SFTP reuses it
+	protocol string
 }
 
-func NewAferoAdapter(ctx context.Context) *AferoAdapter {
-	return &AferoAdapter{ctx: ctx}
+func NewAferoAdapter(ctx context.Context, protocol string) *AferoAdapter {
+	return &AferoAdapter{ctx: ctx, protocol: protocol}
+}
+
+func (a *AferoAdapter) observe(start time.Time, err *error) {
+	metrics.ObserveResult(a.protocol, *err, start)
 }
 
 func (a *AferoAdapter) Create(_ string) (afero.File, error) {
@@ -26,7 +34,8 @@ func (a *AferoAdapter) Create(_ string) (afero.File, error) {
 	return nil, errs.NotImplement
 }
 
-func (a *AferoAdapter) Mkdir(name string, _ os.FileMode) error {
+func (a *AferoAdapter) Mkdir(name string, _ os.FileMode) (err error) {
+	defer a.observe(time.Now(), &err)
 	return Mkdir(a.ctx, name)
 }
 
@@ -44,7 +53,8 @@ func (a *AferoAdapter) OpenFile(_ string, _ int, _ os.FileMode) (afero.File, err
 	return nil, errs.NotImplement
 }
 
-func (a *AferoAdapter) Remove(name string) error {
+func (a *AferoAdapter) Remove(name string) (err error) {
+	defer a.observe(time.Now(), &err)
 	return Remove(a.ctx, name)
 }
 
@@ -52,11 +62,13 @@ func (a *AferoAdapter) RemoveAll(path string) error {
 	return a.Remove(path)
 }
 
-func (a *AferoAdapter) Rename(oldName, newName string) error {
+func (a *AferoAdapter) Rename(oldName, newName string) (err error) {
+	defer a.observe(time.Now(), &err)
 	return Rename(a.ctx, oldName, newName)
 }
 
-func (a *AferoAdapter) Stat(name string) (os.FileInfo, error) {
+func (a *AferoAdapter) Stat(name string) (_ os.FileInfo, err error) {
+	defer a.observe(time.Now(), &err)
 	return Stat(a.ctx, name)
 }
 
@@ -72,15 +84,43 @@ func (a *AferoAdapter) Chown(_ string, _, _ int) error {
 	return errs.NotSupport
 }
 
-func (a *AferoAdapter) Chtimes(_ string, _ time.Time, _ time.Time) error {
-	return errs.NotSupport
+func (a *AferoAdapter) Chtimes(name string, _ time.Time, mtime time.Time) (err error) {
+	defer a.observe(time.Now(), &err)
+	return Chtimes(a.ctx, name, mtime)
+}
+
+func (a *AferoAdapter) Link(name, target string) (err error) {
+	defer a.observe(time.Now(), &err)
+	return Link(a.ctx, name, target)
+}
+
+func (a *AferoAdapter) GetHash(name string, ht *utils.HashType) (string, error) {
+	return GetHash(a.ctx, name, ht)
+}
+
+func (a *AferoAdapter) GetStorageDetails(name string) (*model.StorageDetails, error) {
+	return GetStorageDetails(a.ctx, name)
+}
+
+func (a *AferoAdapter) ComputeHash(name string, algo ftpserver.HASHAlgo, startOffset, endOffset int64) (string, error) {
+	return ComputeHash(a.ctx, name, algo, startOffset, endOffset)
+}
+
+func (a *AferoAdapter) GetAvailableSpace(dirName string) (int64, error) {
+	details, err := GetStorageDetails(a.ctx, dirName)
+	if err != nil {
+		return 0, err
+	}
+	return int64(details.FreeSpace), nil
 }
 
-func (a *AferoAdapter) ReadDir(name string) ([]os.FileInfo, error) {
+func (a *AferoAdapter) ReadDir(name string) (_ []os.FileInfo, err error) {
+	defer a.observe(time.Now(), &err)
 	return List(a.ctx, name)
 }
 
-func (a *AferoAdapter) GetHandle(name string, flags int, offset int64) (ftpserver.FileTransfer, error) {
+func (a *AferoAdapter) GetHandle(name string, flags int, offset int64) (_ ftpserver.FileTransfer, err error) {
+	defer a.observe(time.Now(), &err)
 	fileSize := a.nextFileSize
 	a.nextFileSize = 0
 	if (flags & os.O_SYNC) != 0 {
@@ -7,9 +7,12 @@ import (
 	"github.com/alist-org/alist/v3/internal/fs"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/stream"
+	"github.com/alist-org/alist/v3/pkg/utils"
 	"github.com/alist-org/alist/v3/server/common"
 	"github.com/pkg/errors"
 	stdpath "path"
+	"time"
 )
 
 func Mkdir(ctx context.Context, path string) error {
@@ -83,3 +86,69 @@ func Rename(ctx context.Context, oldPath, newPath string) error {
 		return nil
 	}
 }
+
+func Chtimes(ctx context.Context, path string, mtime time.Time) error {
+	user := ctx.Value("user").(*model.User)
+	reqPath, err := user.JoinPath(path)
+	if err != nil {
+		return err
+	}
+	perm := common.MergeRolePermissions(user, reqPath)
+	if !common.HasPermission(perm, common.PermWrite) || !common.HasPermission(perm, common.PermFTPManage) {
+		meta, err := op.GetNearestMeta(stdpath.Dir(reqPath))
+		if err != nil {
+			if !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+				return err
+			}
+		}
+		if !common.CanWrite(meta, reqPath) {
+			return errs.PermissionDenied
+		}
+	}
+	return fs.SetModTime(ctx, reqPath, mtime)
+}
+
+// Link creates the file linkPath with the content of targetPath,
+// storages have no concept of links, so the link is materialized as a copy
+func Link(ctx context.Context, linkPath, targetPath string) error {
+	user := ctx.Value("user").(*model.User)
+	srcPath, err := user.JoinPath(targetPath)
+	if err != nil {
+		return err
+	}
+	dstPath, err := user.JoinPath(linkPath)
+	if err != nil {
+		return err
+	}
+	permSrc := common.MergeRolePermissions(user, srcPath)
+	if !common.HasPermission(permSrc, common.PermCopy) || !common.HasPermission(permSrc, common.PermFTPManage) {
+		return errs.PermissionDenied
+	}
+	if err = uploadAuth(ctx, dstPath); err != nil {
+		return err
+	}
+	stat, err := Stat(ctx, targetPath)
+	if err != nil {
+		return err
+	}
+	if stat.IsDir() {
+		return errs.NotSupport
+	}
+	obj := stat.(*OsFileInfoAdapter).obj
+	reader, err := OpenDownload(ctx, srcPath, 0)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = reader.Close() }()
+	s := &stream.FileStream{
+		Obj: &model.Object{
+			Name:     stdpath.Base(dstPath),
+			Size:     obj.GetSize(),
+			Modified: obj.ModTime(),
+			HashInfo: obj.GetHash(),
+		},
+		Mimetype: utils.GetMimeType(dstPath),
+		Reader:   reader,
+	}
+	return fs.PutDirectly(ctx, stdpath.Dir(dstPath), s, true)
+}
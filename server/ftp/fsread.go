@@ -161,3 +161,25 @@ func List(ctx context.Context, path string) ([]os.FileInfo, error) {
 	}
 	return ret, nil
 }
+
+func GetStorageDetails(ctx context.Context, path string) (*model.StorageDetails, error) {
+	user := ctx.Value("user").(*model.User)
+	reqPath, err := user.JoinPath(path)
+	if err != nil {
+		return nil, err
+	}
+	meta, err := op.GetNearestMeta(reqPath)
+	if err != nil {
+		if !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+			return nil, err
+		}
+	}
+	if !common.CanAccessWithRoles(user, meta, reqPath, ctx.Value("meta_pass").(string)) {
+		return nil, errs.PermissionDenied
+	}
+	storage, err := fs.GetStorage(reqPath, &fs.GetStoragesArgs{})
+	if err != nil {
+		return nil, err
+	}
+	return op.GetStorageDetails(ctx, storage)
+}
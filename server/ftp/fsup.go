@@ -21,10 +21,11 @@ import (
 
 type FileUploadProxy struct {
 	ftpserver.FileTransfer
-	buffer *os.File
-	path   string
-	ctx    context.Context
-	trunc  bool
+	buffer  *os.File
+	path    string
+	ctx     context.Context
+	trunc   bool
+	modTime time.Time
 }
 
 func uploadAuth(ctx context.Context, path string) error {
@@ -93,11 +94,15 @@ func (f *FileUploadProxy) Close() error {
 	if f.trunc {
 		_ = fs.Remove(f.ctx, f.path)
 	}
+	modTime := f.modTime
+	if modTime.IsZero() {
+		modTime = time.Now()
+	}
 	s := &stream.FileStream{
 		Obj: &model.Object{
 			Name:     name,
 			Size:     size,
-			Modified: time.Now(),
+			Modified: modTime,
 		},
 		Mimetype:     contentType,
 		WebPutAsTask: true,
@@ -107,6 +112,11 @@ func (f *FileUploadProxy) Close() error {
 	return err
 }
 
+// SetModTime sets the modification time the file will be uploaded with
+func (f *FileUploadProxy) SetModTime(modTime time.Time) {
+	f.modTime = modTime
+}
+
 type FileUploadWithLengthProxy struct {
 	ftpserver.FileTransfer
 	ctx           context.Context
@@ -0,0 +1,84 @@
+package ftp
+
+import (
+	"context"
+	"crypto/sha512"
+	"hash"
+	"hash/crc32"
+	"io"
+	"strings"
+
+	ftpserver "github.com/KirCute/ftpserverlib-pasvportmap"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils"
+)
+
+var (
+	// hashCRC32 and hashSHA512 are never provided by storages, so they are not registered
+	hashCRC32 = &utils.HashType{
+		Name:    "crc32",
+		Alias:   "CRC32",
+		Width:   8,
+		NewFunc: func(...any) hash.Hash { return crc32.NewIEEE() },
+	}
+	hashSHA512 = &utils.HashType{
+		Name:    "sha512",
+		Alias:   "SHA-512",
+		Width:   128,
+		NewFunc: func(...any) hash.Hash { return sha512.New() },
+	}
+	ftpHashTypes = map[ftpserver.HASHAlgo]*utils.HashType{
+		ftpserver.HASHAlgoCRC32:  hashCRC32,
+		ftpserver.HASHAlgoMD5:    utils.MD5,
+		ftpserver.HASHAlgoSHA1:   utils.SHA1,
+		ftpserver.HASHAlgoSHA256: utils.SHA256,
+		ftpserver.HASHAlgoSHA512: hashSHA512,
+	}
+)
+
+// GetHash returns the hex encoded hash of the file, the one provided by the storage is used if present,
+// otherwise it is computed by streaming the file
+func GetHash(ctx context.Context, path string, ht *utils.HashType) (string, error) {
+	return getHash(ctx, path, ht, 0, -1)
+}
+
+// getHash hashes the range [start, end) of the file, end < 0 means the end of the file
+func getHash(ctx context.Context, path string, ht *utils.HashType, start, end int64) (string, error) {
+	stat, err := Stat(ctx, path)
+	if err != nil {
+		return "", err
+	}
+	if stat.IsDir() {
+		return "", errs.NotFile
+	}
+	obj := stat.(*OsFileInfoAdapter).obj
+	if start == 0 && (end < 0 || end == obj.GetSize()) {
+		if h := obj.GetHash().GetHash(ht); len(h) == ht.Width {
+			return strings.ToLower(h), nil
+		}
+	}
+	user := ctx.Value("user").(*model.User)
+	reqPath, err := user.JoinPath(path)
+	if err != nil {
+		return "", err
+	}
+	reader, err := OpenDownload(ctx, reqPath, start)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = reader.Close() }()
+	var r io.Reader = reader
+	if end >= 0 {
+		r = io.LimitReader(reader, end-start)
+	}
+	return utils.HashReader(ht, r)
+}
+
+func ComputeHash(ctx context.Context, path string, algo ftpserver.HASHAlgo, start, end int64) (string, error) {
+	ht, ok := ftpHashTypes[algo]
+	if !ok {
+		return "", errs.NotSupport
+	}
+	return getHash(ctx, path, ht, start, end)
+}
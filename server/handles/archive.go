@@ -13,6 +13,7 @@ import (
 	"github.com/alist-org/alist/v3/internal/fs"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/pipeline"
 	"github.com/alist-org/alist/v3/internal/setting"
 	"github.com/alist-org/alist/v3/internal/sign"
 	"github.com/alist-org/alist/v3/pkg/utils"
@@ -238,13 +239,14 @@ func (s *StringOrArray) UnmarshalJSON(data []byte) error {
 }
 
 type ArchiveDecompressReq struct {
-	SrcDir        string        `json:"src_dir" form:"src_dir"`
-	DstDir        string        `json:"dst_dir" form:"dst_dir"`
-	Name          StringOrArray `json:"name" form:"name"`
-	ArchivePass   string        `json:"archive_pass" form:"archive_pass"`
-	InnerPath     string        `json:"inner_path" form:"inner_path"`
-	CacheFull     bool          `json:"cache_full" form:"cache_full"`
-	PutIntoNewDir bool          `json:"put_into_new_dir" form:"put_into_new_dir"`
+	SrcDir        string               `json:"src_dir" form:"src_dir"`
+	DstDir        string               `json:"dst_dir" form:"dst_dir"`
+	Name          StringOrArray        `json:"name" form:"name"`
+	ArchivePass   string               `json:"archive_pass" form:"archive_pass"`
+	InnerPath     string               `json:"inner_path" form:"inner_path"`
+	CacheFull     bool                 `json:"cache_full" form:"cache_full"`
+	PutIntoNewDir bool                 `json:"put_into_new_dir" form:"put_into_new_dir"`
+	Pipeline      []model.PipelineStep `json:"pipeline"`
 }
 
 func FsArchiveDecompress(c *gin.Context) {
@@ -281,14 +283,20 @@ func FsArchiveDecompress(c *gin.Context) {
 		common.ErrorResp(c, errs.PermissionDenied, 403)
 		return
 	}
-	tasks := make([]task.TaskExtensionInfo, 0, len(srcPaths))
 	for _, srcPath := range srcPaths {
 		perm := common.MergeRolePermissions(user, srcPath)
 		if !common.HasPermission(perm, common.PermDecompress) {
 			common.ErrorResp(c, errs.PermissionDenied, 403)
 			return
 		}
-		t, e := fs.ArchiveDecompress(c, srcPath, dstDir, model.ArchiveDecompressArgs{
+	}
+	ctx, ok := startPipeline(c, user, dstDir, req.Pipeline)
+	if !ok {
+		return
+	}
+	tasks := make([]task.TaskExtensionInfo, 0, len(srcPaths))
+	for _, srcPath := range srcPaths {
+		t, e := fs.ArchiveDecompress(ctx, srcPath, dstDir, model.ArchiveDecompressArgs{
 			ArchiveInnerArgs: model.ArchiveInnerArgs{
 				ArchiveArgs: model.ArchiveArgs{
 					LinkArgs: model.LinkArgs{
@@ -304,6 +312,7 @@ func FsArchiveDecompress(c *gin.Context) {
 			PutIntoNewDir: req.PutIntoNewDir,
 		})
 		if e != nil {
+			pipeline.Finish(ctx, e)
 			if errors.Is(e, errs.WrongArchivePassword) {
 				common.ErrorResp(c, e, 202)
 			} else {
@@ -315,8 +324,10 @@ func FsArchiveDecompress(c *gin.Context) {
 			tasks = append(tasks, t)
 		}
 	}
+	pipeline.Finish(ctx, nil)
 	common.SuccessResp(c, gin.H{
-		"task": getTaskInfos(tasks),
+		"task":        getTaskInfos(tasks),
+		"pipeline_id": task.PipelineIDFromCtx(ctx),
 	})
 }
 
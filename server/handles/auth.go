@@ -31,6 +31,28 @@ var (
 	invalidLoginCredentialsMsg = "username or password is incorrect"
 )
 
+// LoginLocked reports whether the ip made too many unsuccessful sign-in attempts,
+// the lockout lasts while the attempts go on
+func LoginLocked(ip string) bool {
+	count, ok := loginCache.Get(ip)
+	if ok && count >= defaultTimes {
+		loginCache.Expire(ip, defaultDuration)
+		return true
+	}
+	return false
+}
+
+// LoginFailed counts an unsuccessful sign-in attempt of the ip
+func LoginFailed(ip string) {
+	count, _ := loginCache.Get(ip)
+	loginCache.Set(ip, count+1)
+}
+
+// LoginSucceeded resets the unsuccessful sign-in attempts of the ip
+func LoginSucceeded(ip string) {
+	loginCache.Del(ip)
+}
+
 type LoginReq struct {
 	Username string `json:"username" binding:"required"`
 	Password string `json:"password"`
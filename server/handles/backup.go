@@ -0,0 +1,63 @@
+package handles
+
+import (
+	"context"
+
+	"github.com/alist-org/alist/v3/internal/backup"
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/cryptkey"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+type ExportBackupReq struct {
+	Passphrase string `json:"passphrase"`
+}
+
+func ExportBackup(c *gin.Context) {
+	var req ExportBackupReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	b, err := backup.Export(req.Passphrase)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, b)
+	if err := backup.Saved(b); err != nil {
+		utils.Log.Errorf("failed to mark the keys of the backup as backed up: %+v", err)
+	}
+}
+
+type ImportBackupReq struct {
+	Backup     *backup.Backup `json:"backup" binding:"required"`
+	Mode       string         `json:"mode"`
+	Passphrase string         `json:"passphrase"`
+}
+
+func ImportBackup(c *gin.Context) {
+	var req ImportBackupReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	report, err := backup.Import(req.Backup, req.Mode, req.Passphrase)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	op.ClearUserCaches()
+	op.SettingCacheUpdate()
+	// the storages and users may get new ids
+	cryptkey.LockAll()
+	conf.StoragesLoaded = false
+	go func() {
+		op.ReloadStorages(context.Background())
+		conf.StoragesLoaded = true
+	}()
+	common.SuccessResp(c, report)
+}
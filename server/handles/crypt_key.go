@@ -0,0 +1,233 @@
+package handles
+
+import (
+	"context"
+
+	"github.com/alist-org/alist/v3/internal/cryptkey"
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+type CryptPassphraseReq struct {
+	Passphrase string `json:"passphrase" binding:"required"`
+}
+
+type CryptRotateReq struct {
+	Passphrase    string `json:"passphrase" binding:"required"`
+	NewPassphrase string `json:"new_passphrase"`
+}
+
+type CryptMountReq struct {
+	MountPath string `json:"mount_path" binding:"required"`
+	Username  string `json:"username"`
+}
+
+type CryptMountResp struct {
+	StorageID uint   `json:"storage_id"`
+	MountPath string `json:"mount_path"`
+}
+
+type CryptStatusResp struct {
+	SetUp    bool             `json:"set_up"`
+	Unlocked bool             `json:"unlocked"`
+	Mounts   []CryptMountResp `json:"mounts"`
+}
+
+type CryptHolderResp struct {
+	model.MountKey
+	Username string `json:"username"`
+}
+
+func cryptMountPath(storageID uint) string {
+	for _, s := range op.GetAllStorages() {
+		if s.GetStorage().ID == storageID {
+			return s.GetStorage().MountPath
+		}
+	}
+	return ""
+}
+
+// getCryptMount finds the storage with per user keys mounted at the path
+func getCryptMount(c *gin.Context, mountPath string) (driver.Driver, cryptkey.Mount, bool) {
+	storage, err := op.GetStorageByMountPath(mountPath)
+	if err != nil {
+		common.ErrorResp(c, err, 404)
+		return nil, nil, false
+	}
+	m, ok := storage.(cryptkey.Mount)
+	if !ok || !m.PerUserKeys() {
+		common.ErrorStrResp(c, "the storage doesn't use per user keys", 400)
+		return nil, nil, false
+	}
+	return storage, m, true
+}
+
+// CryptStatus tells whether the encryption keys of the user are set up and
+// unlocked, and the storages the user holds the key of
+func CryptStatus(c *gin.Context) {
+	user := c.MustGet("user").(*model.User)
+	resp := CryptStatusResp{Mounts: []CryptMountResp{}}
+	if _, err := db.GetUserKey(user.ID); err == nil {
+		resp.SetUp = true
+		resp.Unlocked = cryptkey.IsUnlocked(user.ID)
+	}
+	keys, err := db.GetMountKeysByUser(user.ID)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	for _, k := range keys {
+		resp.Mounts = append(resp.Mounts, CryptMountResp{StorageID: k.StorageID, MountPath: cryptMountPath(k.StorageID)})
+	}
+	common.SuccessResp(c, resp)
+}
+
+// SetupCryptKey creates the encryption keys of the user
+func SetupCryptKey(c *gin.Context) {
+	var req CryptPassphraseReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.MustGet("user").(*model.User)
+	if err := cryptkey.Setup(user.ID, req.Passphrase); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+// UnlockCryptKey opens the encryption keys of the user with the passphrase
+func UnlockCryptKey(c *gin.Context) {
+	var req CryptPassphraseReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.MustGet("user").(*model.User)
+	if err := cryptkey.Unlock(user.ID, req.Passphrase); err != nil {
+		common.ErrorResp(c, err, 403)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+func LockCryptKey(c *gin.Context) {
+	user := c.MustGet("user").(*model.User)
+	cryptkey.Lock(user.ID)
+	common.SuccessResp(c)
+}
+
+// RotateCryptKey replaces the key pair of the user, and the passphrase if a
+// new one is given. The storages are not re-encrypted.
+func RotateCryptKey(c *gin.Context) {
+	var req CryptRotateReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.MustGet("user").(*model.User)
+	if err := cryptkey.Rotate(user.ID, req.Passphrase, req.NewPassphrase); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+// InitCryptMount seals the key of a storage with per user keys to the admin,
+// the key the storage was set up with is removed from the storage once a
+// saved backup holds it
+func InitCryptMount(c *gin.Context) {
+	var req CryptMountReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	storage, m, ok := getCryptMount(c, req.MountPath)
+	if !ok {
+		return
+	}
+	user := c.MustGet("user").(*model.User)
+	if err := cryptkey.InitMount(user.ID, storage.GetStorage().ID, m); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+// GrantCryptMount seals the key of a storage held by the admin to a user
+func GrantCryptMount(c *gin.Context) {
+	var req CryptMountReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	storage, _, ok := getCryptMount(c, req.MountPath)
+	if !ok {
+		return
+	}
+	grantee, err := op.GetUserByName(req.Username)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.MustGet("user").(*model.User)
+	if err := cryptkey.Grant(user.ID, storage.GetStorage().ID, grantee.ID); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+// RevokeCryptMount removes the key of a storage from a user and rotates it,
+// the files are encrypted again with the new key before it returns
+func RevokeCryptMount(c *gin.Context) {
+	var req CryptMountReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	storage, m, ok := getCryptMount(c, req.MountPath)
+	if !ok {
+		return
+	}
+	u, err := op.GetUserByName(req.Username)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.MustGet("user").(*model.User)
+	// the files are not left half re-encrypted when the client goes away
+	ctx := context.WithoutCancel(c.Request.Context())
+	if err := cryptkey.Revoke(ctx, user.ID, storage.GetStorage().ID, u.ID, m); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+// ListCryptMountHolders lists the users holding the key of a storage
+func ListCryptMountHolders(c *gin.Context) {
+	storage, _, ok := getCryptMount(c, c.Query("mount_path"))
+	if !ok {
+		return
+	}
+	keys, err := db.GetMountKeysByStorage(storage.GetStorage().ID)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	resp := make([]CryptHolderResp, 0, len(keys))
+	for _, k := range keys {
+		item := CryptHolderResp{MountKey: k}
+		if u, err := op.GetUserById(k.UserID); err == nil {
+			item.Username = u.Username
+		}
+		resp = append(resp, item)
+	}
+	common.SuccessResp(c, resp)
+}
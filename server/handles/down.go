@@ -29,7 +29,7 @@ func Down(c *gin.Context) {
 		common.ErrorResp(c, err, 500)
 		return
 	}
-	if common.ShouldProxy(storage, filename) {
+	if common.ShouldProxy(storage, filename) || c.GetBool("download_link") {
 		Proxy(c)
 		return
 	} else {
@@ -70,9 +70,11 @@ func Proxy(c *gin.Context) {
 		localProxy(c, link, file, storage.GetStorage().ProxyRange)
 		return
 	}
-	if canProxy(storage, filename) {
+	// download links are always served here so their restrictions hold
+	viaLink := c.GetBool("download_link")
+	if viaLink || canProxy(storage, filename) {
 		downProxyUrl := storage.GetStorage().DownProxyUrl
-		if downProxyUrl != "" {
+		if downProxyUrl != "" && !viaLink {
 			_, ok := c.GetQuery("d")
 			if !ok {
 				URL := common.BuildDownProxyURL(downProxyUrl, rawPath, storage.GetStorage().DownProxySign)
@@ -0,0 +1,214 @@
+package handles
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/alist-org/alist/v3/internal/sign"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/pkg/utils/random"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultDownloadLinkTTL = 24 * time.Hour
+	// expired links are kept for a while so their creator can still see them
+	downloadLinkRetention = 7 * 24 * time.Hour
+)
+
+type CreateDownloadLinkReq struct {
+	Path     string `json:"path" binding:"required"`
+	Password string `json:"password"`
+	// TTL is in seconds, it defaults to the link expiration setting or a
+	// day, within the max ttl of download links
+	TTL          int64    `json:"ttl"`
+	CIDRs        []string `json:"cidrs"`
+	Referers     []string `json:"referers"`
+	MaxDownloads int64    `json:"max_downloads"`
+}
+
+type DownloadLinkResp struct {
+	model.DownloadLink
+	URL string `json:"url"`
+}
+
+func downloadLinkResp(c *gin.Context, l *model.DownloadLink) DownloadLinkResp {
+	return DownloadLinkResp{
+		DownloadLink: *l,
+		URL: fmt.Sprintf("%s/d%s?link=%s&sign=%s",
+			common.GetApiUrl(c.Request),
+			utils.EncodePath(l.Path, true),
+			l.Token,
+			sign.SignLink(l.Path, l.Token, l.ExpiresAt)),
+	}
+}
+
+func downloadLinkTTL(ttl int64) (time.Duration, error) {
+	if ttl < 0 {
+		return 0, fmt.Errorf("ttl must be 0 or greater")
+	}
+	maxTTL := common.DownloadLinkMaxTTL()
+	if ttl > 0 {
+		if d := time.Duration(ttl) * time.Second; d <= maxTTL {
+			return d, nil
+		}
+		return 0, fmt.Errorf("ttl must be at most %d seconds", int64(maxTTL/time.Second))
+	}
+	d := defaultDownloadLinkTTL
+	if hours := setting.GetInt(conf.LinkExpiration, 0); hours > 0 {
+		d = time.Duration(hours) * time.Hour
+	}
+	return min(d, maxTTL), nil
+}
+
+// normalizeReferers keeps the host of the given referers, "https://Example.com/a"
+// becomes "example.com"
+func normalizeReferers(referers []string) (string, error) {
+	hosts := make([]string, 0, len(referers))
+	for _, r := range referers {
+		r = strings.ToLower(strings.TrimSpace(r))
+		if i := strings.Index(r, "://"); i >= 0 {
+			r = r[i+3:]
+		}
+		r = strings.TrimSuffix(strings.SplitN(r, "/", 2)[0], ".")
+		if r == "" {
+			continue
+		}
+		if strings.ContainsAny(r, ", ") {
+			return "", fmt.Errorf("invalid referer %q", r)
+		}
+		hosts = append(hosts, r)
+	}
+	return strings.Join(hosts, ","), nil
+}
+
+// CreateDownloadLink mints a one-off link to download a file
+func CreateDownloadLink(c *gin.Context) {
+	var req CreateDownloadLinkReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	ttl, err := downloadLinkTTL(req.TTL)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if req.MaxDownloads < 0 {
+		common.ErrorStrResp(c, "max_downloads must be 0 or greater", 400)
+		return
+	}
+	cidrs := strings.Join(req.CIDRs, ",")
+	if err := model.ValidateCIDRs(cidrs); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	referers, err := normalizeReferers(req.Referers)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.MustGet("user").(*model.User)
+	reqPath, err := user.JoinPath(req.Path)
+	if err != nil {
+		common.ErrorResp(c, err, 403)
+		return
+	}
+	meta, err := op.GetNearestMeta(reqPath)
+	if err != nil && !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	if !common.CheckPathLimitWithRoles(user, reqPath) || !common.CanAccessWithRoles(user, meta, reqPath, req.Password) {
+		common.ErrorStrResp(c, "password is incorrect or you have no permission", 403)
+		return
+	}
+	obj, err := fs.Get(c, reqPath, &fs.GetArgs{})
+	if err != nil {
+		common.ErrorResp(c, err, 404)
+		return
+	}
+	if obj.IsDir() {
+		common.ErrorStrResp(c, "only files can be downloaded", 400)
+		return
+	}
+	now := time.Now()
+	link := &model.DownloadLink{
+		Token:        random.String(32),
+		CreatorID:    user.ID,
+		Path:         reqPath,
+		CIDRs:        cidrs,
+		Referers:     referers,
+		MaxDownloads: req.MaxDownloads,
+		ExpiresAt:    now.Add(ttl),
+	}
+	if meta != nil && meta.Password != "" && req.Password == meta.Password {
+		link.SetPassword(req.Password)
+	}
+	if err := db.CreateDownloadLink(link); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	_ = db.DeleteDownloadLinksExpiredBefore(now.Add(-downloadLinkRetention))
+	_ = db.DeleteDownloadSessionsExpiredBefore(now)
+	common.SuccessResp(c, downloadLinkResp(c, link))
+}
+
+// ListDownloadLinks lists the download links of the user, newest first
+func ListDownloadLinks(c *gin.Context) {
+	var req model.PageReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	user := c.MustGet("user").(*model.User)
+	links, total, err := db.GetDownloadLinksByCreator(user.ID, req.Page, req.PerPage)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	resp := make([]DownloadLinkResp, 0, len(links))
+	for i := range links {
+		resp = append(resp, downloadLinkResp(c, &links[i]))
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: resp,
+		Total:   total,
+	})
+}
+
+// RevokeDownloadLink deletes a download link, it stops working at once
+func RevokeDownloadLink(c *gin.Context) {
+	id, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	link, err := db.GetDownloadLink(uint(id))
+	if err != nil {
+		common.ErrorResp(c, err, 404)
+		return
+	}
+	user := c.MustGet("user").(*model.User)
+	if link.CreatorID != user.ID && !user.IsAdmin() {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
+	if err := db.DeleteDownloadLink(link.ID); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c)
+}
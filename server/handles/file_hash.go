@@ -0,0 +1,59 @@
+package handles
+
+import (
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/task"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+type HashCatalogReq struct {
+	Paths []string `json:"paths" binding:"required"`
+}
+
+type ClearHashCatalogReq struct {
+	Path string `json:"path" binding:"required"`
+}
+
+// BuildHashCatalog starts a hash task for each of the paths
+func BuildHashCatalog(c *gin.Context) {
+	var req HashCatalogReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	tasks := make([]task.TaskExtensionInfo, 0, len(req.Paths))
+	for _, p := range req.Paths {
+		if _, err := fs.Get(c, p, &fs.GetArgs{NoLog: true}); err != nil {
+			common.ErrorResp(c, err, 400)
+			return
+		}
+	}
+	for _, p := range req.Paths {
+		t, err := fs.HashFiles(c, p)
+		if err != nil {
+			common.ErrorResp(c, err, 500)
+			return
+		}
+		tasks = append(tasks, t)
+	}
+	common.SuccessResp(c, gin.H{
+		"tasks": getTaskInfos(tasks),
+	})
+}
+
+// ClearHashCatalog drops the stored hashes of the files under the path
+func ClearHashCatalog(c *gin.Context) {
+	var req ClearHashCatalogReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if err := op.DeleteFileHashes(utils.FixAndCleanPath(req.Path)); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c)
+}
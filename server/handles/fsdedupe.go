@@ -0,0 +1,242 @@
+package handles
+
+import (
+	"fmt"
+	stdpath "path"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"github.com/xhofe/tache"
+)
+
+const (
+	DedupeRemove = "remove"
+	DedupeTrash  = "trash"
+	DedupeLink   = "link"
+)
+
+type FsDedupeReq struct {
+	Paths       []string `json:"paths" binding:"required"`
+	MinSize     int64    `json:"min_size"`
+	HashMissing bool     `json:"hash_missing"`
+}
+
+type FsDedupeReportResp struct {
+	Task        TaskInfo         `json:"task"`
+	Scanned     int              `json:"scanned"`
+	Unhashed    int              `json:"unhashed"`
+	Reclaimable int64            `json:"reclaimable"`
+	Groups      []fs.DedupeGroup `json:"groups"`
+}
+
+type FsDedupeApplyReq struct {
+	TaskID string `json:"task_id" binding:"required"`
+	// Action is one of remove, trash and link
+	Action string `json:"action" binding:"required"`
+	// Groups are the indexes of the groups of the report to act on, all the
+	// groups if empty
+	Groups []int `json:"groups"`
+	// Keep are the paths to keep, one per group, the first path of a group
+	// is kept if none of its paths is listed
+	Keep []string `json:"keep"`
+}
+
+type FsDedupeApplyResp struct {
+	Done      []string          `json:"done"`
+	Failed    map[string]string `json:"failed"`
+	Reclaimed int64             `json:"reclaimed"`
+}
+
+// FsDedupe starts a task finding the duplicated files under the paths
+func FsDedupe(c *gin.Context) {
+	var req FsDedupeReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if len(req.Paths) == 0 {
+		common.ErrorStrResp(c, "Empty paths", 400)
+		return
+	}
+	if req.MinSize < 0 {
+		common.ErrorStrResp(c, "min_size must be 0 or greater", 400)
+		return
+	}
+	user := c.MustGet("user").(*model.User)
+	// hashing reads whole trees on the server
+	if req.HashMissing && !user.IsAdmin() {
+		common.ErrorStrResp(c, "only admins can hash the missing files", 403)
+		return
+	}
+	paths := make([]string, 0, len(req.Paths))
+	for _, p := range req.Paths {
+		reqPath, err := user.JoinPath(p)
+		if err != nil {
+			common.ErrorResp(c, err, 403)
+			return
+		}
+		meta, err := op.GetNearestMeta(reqPath)
+		if err != nil && !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+			common.ErrorResp(c, err, 500, true)
+			return
+		}
+		if !common.CheckPathLimitWithRoles(user, reqPath) || !common.CanAccessWithRoles(user, meta, reqPath, "") {
+			common.ErrorResp(c, errs.PermissionDenied, 403)
+			return
+		}
+		paths = append(paths, reqPath)
+	}
+	t, err := fs.FindDuplicates(c, paths, req.MinSize, req.HashMissing)
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	common.SuccessResp(c, gin.H{
+		"task": getTaskInfo(t),
+	})
+}
+
+// FsDedupeReport returns the groups of identical files found by a task
+func FsDedupeReport(c *gin.Context) {
+	getTargetedHandler(fs.DedupeTaskManager, func(c *gin.Context, t *fs.DedupeTask) {
+		groups := t.Groups
+		if groups == nil {
+			groups = []fs.DedupeGroup{}
+		}
+		common.SuccessResp(c, FsDedupeReportResp{
+			Task:        getTaskInfo(t),
+			Scanned:     t.Scanned,
+			Unhashed:    t.Unhashed,
+			Reclaimable: t.Reclaimable,
+			Groups:      groups,
+		})
+	})(c)
+}
+
+// FsDedupeApply removes, trashes or replaces with links the duplicates of
+// the groups of a finished report, keeping one file of each group
+func FsDedupeApply(c *gin.Context) {
+	var req FsDedupeApplyReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	var bit uint
+	switch req.Action {
+	case DedupeRemove:
+		bit = common.PermRemove
+	case DedupeTrash:
+		bit = common.PermMove
+	case DedupeLink:
+		bit = common.PermWrite
+	default:
+		common.ErrorStrResp(c, fmt.Sprintf("invalid action %q", req.Action), 400)
+		return
+	}
+	user := c.MustGet("user").(*model.User)
+	t, ok := fs.DedupeTaskManager.GetByID(req.TaskID)
+	if !ok || (!user.IsAdmin() && (t.GetCreator() == nil || t.GetCreator().ID != user.ID)) {
+		common.ErrorStrResp(c, "task not found", 404)
+		return
+	}
+	if t.GetState() != tache.StateSucceeded {
+		common.ErrorStrResp(c, "the task has not finished", 400)
+		return
+	}
+	indexes := req.Groups
+	if len(indexes) == 0 {
+		indexes = make([]int, len(t.Groups))
+		for i := range indexes {
+			indexes[i] = i
+		}
+	}
+	keep := make(map[string]struct{}, len(req.Keep))
+	for _, p := range req.Keep {
+		keep[utils.FixAndCleanPath(p)] = struct{}{}
+	}
+	resp := FsDedupeApplyResp{Done: []string{}, Failed: map[string]string{}}
+	for _, i := range indexes {
+		if i < 0 || i >= len(t.Groups) {
+			common.ErrorStrResp(c, fmt.Sprintf("group %d not found", i), 400)
+			return
+		}
+		g := t.Groups[i]
+		kept := g.Paths[0]
+		for _, p := range g.Paths {
+			if _, ok := keep[p]; ok {
+				kept = p
+				break
+			}
+		}
+		if err := dedupeCheckKept(user, kept, req.Action); err != nil {
+			for _, p := range g.Paths {
+				if p != kept {
+					resp.Failed[p] = err.Error()
+				}
+			}
+			continue
+		}
+		for _, p := range g.Paths {
+			if p == kept {
+				continue
+			}
+			perm := common.MergeRolePermissions(user, stdpath.Dir(p))
+			if !common.CheckPathLimitWithRoles(user, p) || !common.HasPermission(perm, bit) ||
+				(bit == common.PermWrite && !common.HasPermission(perm, common.PermRemove)) {
+				resp.Failed[p] = errs.PermissionDenied.Error()
+				continue
+			}
+			if err := fs.CheckDuplicate(c, &g, kept, p); err != nil {
+				resp.Failed[p] = err.Error()
+				continue
+			}
+			var err error
+			switch req.Action {
+			case DedupeRemove:
+				err = fs.Remove(c, p)
+			case DedupeTrash:
+				err = fs.MoveToTrash(c, p)
+			case DedupeLink:
+				err = fs.ReplaceWithLink(c, p, dedupeLinkURL(c, kept))
+			}
+			if err != nil {
+				resp.Failed[p] = err.Error()
+				continue
+			}
+			resp.Done = append(resp.Done, p)
+			resp.Reclaimed += g.Size
+		}
+	}
+	common.SuccessResp(c, resp)
+}
+
+// dedupeCheckKept checks that the user can read the kept file, and that the
+// links to it work without a sign since a stored link can't carry one that
+// follows the later changes of passwords and permissions
+func dedupeCheckKept(user *model.User, kept, action string) error {
+	meta, err := op.GetNearestMeta(kept)
+	if err != nil && !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+		return err
+	}
+	if !common.CheckPathLimitWithRoles(user, kept) || !common.CanAccessWithRoles(user, meta, kept, "") {
+		return errs.PermissionDenied
+	}
+	if action == DedupeLink && (setting.GetBool(conf.SignAll) || isEncrypt(meta, kept)) {
+		return errors.New("the kept file can only be downloaded with a signed link, remove or trash the duplicates instead")
+	}
+	return nil
+}
+
+// dedupeLinkURL is the download url of the kept file, it has no sign so the
+// download keeps following the access rules of the kept file
+func dedupeLinkURL(c *gin.Context, path string) string {
+	return fmt.Sprintf("%s/d%s", common.GetApiUrl(c.Request), utils.EncodePath(path, true))
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/alist-org/alist/v3/internal/fs"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/pipeline"
 	"github.com/alist-org/alist/v3/internal/sign"
 	"github.com/alist-org/alist/v3/pkg/generic"
 	"github.com/alist-org/alist/v3/pkg/utils"
@@ -135,8 +136,13 @@ func FsMove(c *gin.Context) {
 	common.SuccessResp(c)
 }
 
+type CopyReq struct {
+	MoveCopyReq
+	Pipeline []model.PipelineStep `json:"pipeline"`
+}
+
 func FsCopy(c *gin.Context) {
-	var req MoveCopyReq
+	var req CopyReq
 	if err := c.ShouldBind(&req); err != nil {
 		common.ErrorResp(c, err, 400)
 		return
@@ -182,29 +188,38 @@ func FsCopy(c *gin.Context) {
 			}
 		}
 	}
+	ctx, ok := startPipeline(c, user, dstDir, req.Pipeline)
+	if !ok {
+		return
+	}
 	var addedTasks []task.TaskExtensionInfo
 	for i, name := range req.Names {
 		srcPath, err := utils.JoinUnderBase(srcDir, name)
 		if err != nil {
+			pipeline.Finish(ctx, err)
 			common.ErrorResp(c, err, 400)
 			return
 		}
 		_, err = utils.JoinUnderBase(dstDir, name)
 		if err != nil {
+			pipeline.Finish(ctx, err)
 			common.ErrorResp(c, err, 400)
 			return
 		}
-		t, err := fs.Copy(c, srcPath, dstDir, len(req.Names) > i+1)
+		t, err := fs.Copy(ctx, srcPath, dstDir, len(req.Names) > i+1)
 		if t != nil {
 			addedTasks = append(addedTasks, t)
 		}
 		if err != nil {
+			pipeline.Finish(ctx, err)
 			common.ErrorResp(c, err, 500)
 			return
 		}
 	}
+	pipeline.Finish(ctx, nil)
 	common.SuccessResp(c, gin.H{
-		"tasks": getTaskInfos(addedTasks),
+		"tasks":       getTaskInfos(addedTasks),
+		"pipeline_id": task.PipelineIDFromCtx(ctx),
 	})
 }
 
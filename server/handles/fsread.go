@@ -97,6 +97,10 @@ func FsList(c *gin.Context) {
 	req.Page = effPage
 	req.PerPage = effPerPage
 	user := c.MustGet("user").(*model.User)
+	if utils.FixAndCleanPath(req.Path) == model.SharedWithMe && user.HasSharedWithMe() {
+		listSharedWithMe(c, user, &req)
+		return
+	}
 	reqPath, err := user.JoinPath(req.Path)
 	if err != nil {
 		common.ErrorResp(c, err, 403)
@@ -136,8 +140,13 @@ func FsList(c *gin.Context) {
 			filtered = append(filtered, obj)
 		}
 	}
+	if isUserRoot(req.Path) {
+		if folder := sharedWithMeFolder(user); folder != nil {
+			filtered = append(filtered, folder)
+		}
+	}
 	total, pageObjs := pagination(filtered, &req.PageReq)
-	respContent := toObjsResp(pageObjs, reqPath, isEncrypt(meta, reqPath))
+	respContent := toObjsResp(user, pageObjs, reqPath, isEncrypt(meta, reqPath))
 	pagesTotal := calcPagesTotal(total, req.PerPage)
 	hasMore := req.PerPage != AllPerPage && req.Page*req.PerPage < total
 
@@ -298,7 +307,7 @@ func pagination(objs []model.Obj, req *model.PageReq) (int, []model.Obj) {
 	return total, objs[start:end]
 }
 
-func toObjsResp(objs []model.Obj, parent string, encrypt bool) []ObjLabelResp {
+func toObjsResp(user *model.User, objs []model.Obj, parent string, encrypt bool) []ObjLabelResp {
 	var resp []ObjLabelResp
 
 	names := make([]string, 0, len(objs))
@@ -328,7 +337,7 @@ func toObjsResp(objs []model.Obj, parent string, encrypt bool) []ObjLabelResp {
 			Created:      obj.CreateTime(),
 			HashInfoStr:  obj.GetHash().String(),
 			HashInfo:     obj.GetHash().Export(),
-			Sign:         common.Sign(obj, parent, encrypt),
+			Sign:         common.Sign(user, obj, parent, encrypt),
 			Thumb:        thumb,
 			Type:         utils.GetObjType(obj.GetName(), obj.IsDir()),
 			LabelList:    labels,
@@ -360,6 +369,26 @@ func FsGet(c *gin.Context) {
 		return
 	}
 	user := c.MustGet("user").(*model.User)
+	if utils.FixAndCleanPath(req.Path) == model.SharedWithMe && user.HasSharedWithMe() {
+		folder := sharedWithMeFolder(user)
+		if folder == nil {
+			common.ErrorResp(c, errs.ObjectNotFound, 404)
+			return
+		}
+		common.SuccessResp(c, FsGetResp{
+			ObjResp: ObjResp{
+				Path:        model.SharedWithMe,
+				VirtualPath: model.SharedWithMe,
+				Name:        folder.GetName(),
+				IsDir:       true,
+				Modified:    folder.ModTime(),
+				Type:        utils.GetFileType(folder.GetName()),
+			},
+			Provider: "virtual",
+			Related:  []ObjLabelResp{},
+		})
+		return
+	}
 	reqPath, err := user.JoinPath(req.Path)
 	if err != nil {
 		common.ErrorResp(c, err, 403)
@@ -463,7 +492,7 @@ func FsGet(c *gin.Context) {
 			Created:      obj.CreateTime(),
 			HashInfoStr:  obj.GetHash().String(),
 			HashInfo:     obj.GetHash().Export(),
-			Sign:         common.Sign(obj, parentPath, isEncrypt(meta, reqPath)),
+			Sign:         common.Sign(user, obj, parentPath, isEncrypt(meta, reqPath)),
 			Type:         utils.GetFileType(obj.GetName()),
 			Thumb:        thumb,
 			StorageClass: storageClass,
@@ -473,7 +502,7 @@ func FsGet(c *gin.Context) {
 		Header:   getHeader(meta, reqPath),
 		Provider: provider,
 		WebProxy: storageErr == nil && storage.GetStorage().WebProxy,
-		Related:  toObjsResp(related, parentPath, isEncrypt(parentMeta, parentPath)),
+		Related:  toObjsResp(user, related, parentPath, isEncrypt(parentMeta, parentPath)),
 	})
 }
 
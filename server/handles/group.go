@@ -0,0 +1,207 @@
+package handles
+
+import (
+	"strconv"
+
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+type GroupMemberResp struct {
+	model.GroupMember
+	Username string `json:"username"`
+}
+
+type MyGroupResp struct {
+	model.Group
+	Admin bool `json:"admin"`
+}
+
+type SaveGroupMemberReq struct {
+	GroupID  uint   `json:"group_id" binding:"required"`
+	Username string `json:"username" binding:"required"`
+	Admin    bool   `json:"admin"`
+}
+
+type RemoveGroupMemberReq struct {
+	GroupID uint `json:"group_id" binding:"required"`
+	UserID  uint `json:"user_id" binding:"required"`
+}
+
+func ListGroups(c *gin.Context) {
+	var req model.PageReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	groups, total, err := op.GetGroups(req.Page, req.PerPage)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: groups,
+		Total:   total,
+	})
+}
+
+func GetGroup(c *gin.Context) {
+	id, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	group, err := op.GetGroup(uint(id))
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, group)
+}
+
+func CreateGroup(c *gin.Context) {
+	var req model.Group
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.ID = 0
+	if err := op.CreateGroup(&req); err != nil {
+		common.ErrorResp(c, err, 500, true)
+	} else {
+		common.SuccessResp(c, req)
+	}
+}
+
+func UpdateGroup(c *gin.Context) {
+	var req model.Group
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	group, err := op.GetGroup(req.ID)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	group.Name = req.Name
+	group.Description = req.Description
+	group.HomePath = req.HomePath
+	group.Permission = req.Permission
+	if err := op.UpdateGroup(group); err != nil {
+		common.ErrorResp(c, err, 500, true)
+	} else {
+		common.SuccessResp(c)
+	}
+}
+
+func DeleteGroup(c *gin.Context) {
+	id, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if err := op.DeleteGroup(uint(id)); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+// ListMyGroups lists the groups of the current user
+func ListMyGroups(c *gin.Context) {
+	user := c.MustGet("user").(*model.User)
+	groups, err := op.GetGroupsByUserID(user.ID)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	resp := make([]MyGroupResp, 0, len(groups))
+	for _, g := range groups {
+		resp = append(resp, MyGroupResp{Group: g, Admin: op.CanManageGroup(user, g.ID)})
+	}
+	common.SuccessResp(c, resp)
+}
+
+// ListGroupMembers lists the members of a group, for its members and site admins
+func ListGroupMembers(c *gin.Context) {
+	id, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.MustGet("user").(*model.User)
+	if !user.IsAdmin() && !op.IsGroupMember(user.ID, uint(id)) {
+		common.ErrorResp(c, errs.NotGroupMember, 403)
+		return
+	}
+	members, err := op.GetGroupMembers(uint(id))
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	resp := make([]GroupMemberResp, 0, len(members))
+	for _, m := range members {
+		item := GroupMemberResp{GroupMember: m}
+		if u, err := op.GetUserById(m.UserID); err == nil {
+			item.Username = u.Username
+		}
+		resp = append(resp, item)
+	}
+	common.SuccessResp(c, resp)
+}
+
+// SaveGroupMember adds a member to a group or changes its admin flag, for group admins and site admins
+func SaveGroupMember(c *gin.Context) {
+	var req SaveGroupMemberReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.MustGet("user").(*model.User)
+	if !op.CanManageGroup(user, req.GroupID) {
+		common.ErrorResp(c, errs.NotGroupAdmin, 403)
+		return
+	}
+	if _, err := op.GetGroup(req.GroupID); err != nil {
+		common.ErrorResp(c, err, 404)
+		return
+	}
+	member, err := op.GetUserByName(req.Username)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if member.IsGuest() {
+		common.ErrorStrResp(c, "guest can't join a group", 400)
+		return
+	}
+	if err := op.SaveGroupMember(&model.GroupMember{GroupID: req.GroupID, UserID: member.ID, Admin: req.Admin}); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+// RemoveGroupMember removes a member from a group, group admins and site admins remove anyone, members can leave
+func RemoveGroupMember(c *gin.Context) {
+	var req RemoveGroupMemberReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.MustGet("user").(*model.User)
+	if req.UserID != user.ID && !op.CanManageGroup(user, req.GroupID) {
+		common.ErrorResp(c, errs.NotGroupAdmin, 403)
+		return
+	}
+	if err := op.RemoveGroupMember(req.GroupID, req.UserID); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	common.SuccessResp(c)
+}
@@ -0,0 +1,351 @@
+package handles
+
+import (
+	"fmt"
+	stdpath "path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+type CreateInternalShareReq struct {
+	Path       string `json:"path" binding:"required"`
+	Name       string `json:"name"`
+	TargetType string `json:"target_type" binding:"required"`
+	// the target is given by its id, or by its user, role or group name
+	TargetID   uint   `json:"target_id"`
+	TargetName string `json:"target_name"`
+	Write      bool   `json:"write"`
+	// Permission narrows the bits granted, it defaults to all the bits of a
+	// read or a read-write share the user holds on the path
+	Permission *int32 `json:"permission"`
+}
+
+type InternalShareResp struct {
+	model.InternalShare
+	TargetName string `json:"target_name"`
+}
+
+type InboxItem struct {
+	ID        uint      `json:"id"`
+	Name      string    `json:"name"` // name in the shared with me folder
+	Path      string    `json:"path"` // path to open it
+	OwnerID   uint      `json:"owner_id"`
+	OwnerName string    `json:"owner_name"`
+	IsDir     bool      `json:"is_dir"`
+	Write     bool      `json:"write"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func internalShareTargetID(req *CreateInternalShareReq) (uint, error) {
+	if req.TargetName == "" {
+		return req.TargetID, nil
+	}
+	switch req.TargetType {
+	case model.InternalShareUser:
+		u, err := op.GetUserByName(req.TargetName)
+		if err != nil {
+			return 0, err
+		}
+		return u.ID, nil
+	case model.InternalShareRole:
+		r, err := op.GetRoleByName(req.TargetName)
+		if err != nil {
+			return 0, err
+		}
+		return r.ID, nil
+	}
+	g, err := op.GetGroupByName(req.TargetName)
+	if err != nil {
+		return 0, err
+	}
+	return g.ID, nil
+}
+
+func internalShareTargetName(s *model.InternalShare) string {
+	switch s.TargetType {
+	case model.InternalShareUser:
+		if u, err := op.GetUserById(s.TargetID); err == nil {
+			return u.Username
+		}
+	case model.InternalShareRole:
+		if r, err := op.GetRole(s.TargetID); err == nil {
+			return r.Name
+		}
+	case model.InternalShareGroup:
+		if g, err := op.GetGroup(s.TargetID); err == nil {
+			return g.Name
+		}
+	}
+	return ""
+}
+
+// CreateInternalShare shares a path of the user with a user, a role or a group
+func CreateInternalShare(c *gin.Context) {
+	var req CreateInternalShareReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.MustGet("user").(*model.User)
+	reqPath, err := user.JoinPath(req.Path)
+	if err != nil {
+		common.ErrorResp(c, err, 403)
+		return
+	}
+	// what was shared with the user can't be shared again
+	ownPerm, ok := common.OwnPermissions(user, reqPath)
+	if !ok {
+		common.ErrorStrResp(c, "you have no permission", 403)
+		return
+	}
+	maxPerm := model.InternalShare{Write: req.Write}.MaxPermission()
+	perm := maxPerm & ownPerm
+	if req.Permission != nil {
+		if *req.Permission&^perm != 0 {
+			common.ErrorStrResp(c, "the share can't grant permissions you don't have", 403)
+			return
+		}
+		perm = *req.Permission
+	}
+	if req.Write && !common.HasPermission(perm, common.PermWrite) {
+		common.ErrorStrResp(c, "you have no permission to write to this path", 403)
+		return
+	}
+	obj, err := fs.Get(c, reqPath, &fs.GetArgs{})
+	if err != nil {
+		common.ErrorResp(c, err, 404)
+		return
+	}
+	name := normalizeShareName(obj, req.Name)
+	if name == "" || strings.ContainsAny(name, "/\\") {
+		common.ErrorStrResp(c, "invalid name", 400)
+		return
+	}
+	targetID, err := internalShareTargetID(&req)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if taken, err := sharedWithMeTaken(c, req.TargetType, targetID); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	} else if taken != "" {
+		common.ErrorStrResp(c, fmt.Sprintf("user %s has a folder named %s at its root, it must be renamed first",
+			taken, strings.TrimPrefix(model.SharedWithMe, "/")), 409)
+		return
+	}
+	share := &model.InternalShare{
+		OwnerID:    user.ID,
+		Path:       reqPath,
+		Name:       name,
+		TargetType: req.TargetType,
+		TargetID:   targetID,
+		Write:      req.Write,
+		Permission: perm,
+	}
+	if err := op.CreateInternalShare(share); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	common.SuccessResp(c, InternalShareResp{InternalShare: *share, TargetName: internalShareTargetName(share)})
+}
+
+// ListInternalShares lists the paths the user shared with others
+func ListInternalShares(c *gin.Context) {
+	var req model.PageReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	user := c.MustGet("user").(*model.User)
+	shares, total, err := op.GetInternalSharesByOwner(user.ID, req.Page, req.PerPage)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	resp := make([]InternalShareResp, 0, len(shares))
+	for i := range shares {
+		resp = append(resp, InternalShareResp{InternalShare: shares[i], TargetName: internalShareTargetName(&shares[i])})
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: resp,
+		Total:   total,
+	})
+}
+
+// DeleteInternalShare revokes a share of the user
+func DeleteInternalShare(c *gin.Context) {
+	id, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.MustGet("user").(*model.User)
+	if err := op.DeleteInternalShare(user, uint(id)); err != nil {
+		switch {
+		case errors.Is(err, errs.PermissionDenied):
+			common.ErrorResp(c, err, 403)
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			common.ErrorResp(c, err, 404)
+		default:
+			common.ErrorResp(c, err, 500, true)
+		}
+		return
+	}
+	common.SuccessResp(c)
+}
+
+// InternalShareInbox lists the paths shared with the user
+func InternalShareInbox(c *gin.Context) {
+	user := c.MustGet("user").(*model.User)
+	shares, err := op.GetIncomingShares(user)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	items := make([]InboxItem, 0, len(shares))
+	for name, s := range model.SharedEntries(shares) {
+		item := InboxItem{
+			ID:        s.ID,
+			Name:      name,
+			Path:      stdpath.Join(model.SharedWithMe, name),
+			OwnerID:   s.OwnerID,
+			Write:     s.Write,
+			CreatedAt: s.CreatedAt,
+		}
+		if owner, err := op.GetUserById(s.OwnerID); err == nil {
+			item.OwnerName = owner.Username
+		}
+		if obj, err := fs.Get(c, s.Path, &fs.GetArgs{NoLog: true}); err == nil {
+			item.IsDir = obj.IsDir()
+		}
+		items = append(items, item)
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].CreatedAt.After(items[j].CreatedAt) })
+	common.SuccessResp(c, items)
+}
+
+// listSharedWithMe lists the shared with me folder, the entries whose path
+// is gone are skipped
+func listSharedWithMe(c *gin.Context, user *model.User, req *ListReq) {
+	shares, err := op.GetIncomingShares(user)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	entries := model.SharedEntries(shares)
+	objs := make([]model.Obj, 0, len(entries))
+	for name, s := range entries {
+		obj, err := fs.Get(c, s.Path, &fs.GetArgs{NoLog: true})
+		if err != nil {
+			continue
+		}
+		objs = append(objs, &model.ObjWrapName{Name: name, Obj: obj})
+	}
+	sort.Slice(objs, func(i, j int) bool { return objs[i].GetName() < objs[j].GetName() })
+	total, pageObjs := pagination(objs, &req.PageReq)
+	content := toObjsResp(nil, pageObjs, model.SharedWithMe, false)
+	for i, obj := range pageObjs {
+		if obj.IsDir() {
+			continue
+		}
+		// files are signed by their shared path
+		s := entries[obj.GetName()]
+		meta, _ := op.GetNearestMeta(s.Path)
+		content[i].Sign = common.Sign(user, obj.(*model.ObjWrapName).Obj, stdpath.Dir(s.Path), isEncrypt(meta, s.Path))
+	}
+	common.SuccessResp(c, FsListResp{
+		Content:       content,
+		Total:         int64(total),
+		FilteredTotal: int64(total),
+		Page:          req.Page,
+		PerPage:       req.PerPage,
+		HasMore:       req.PerPage != AllPerPage && req.Page*req.PerPage < total,
+		PagesTotal:    calcPagesTotal(total, req.PerPage),
+		Provider:      "virtual",
+	})
+}
+
+// internalShareTargetUsers returns the users a share with the target reaches
+func internalShareTargetUsers(targetType string, targetID uint) ([]model.User, error) {
+	switch targetType {
+	case model.InternalShareUser:
+		u, err := op.GetUserById(targetID)
+		if err != nil {
+			return nil, err
+		}
+		return []model.User{*u}, nil
+	case model.InternalShareRole:
+		return op.GetUsersByRole(int(targetID))
+	}
+	members, err := op.GetGroupMembers(targetID)
+	if err != nil {
+		return nil, err
+	}
+	users := make([]model.User, 0, len(members))
+	for _, m := range members {
+		if u, err := op.GetUserById(m.UserID); err == nil {
+			users = append(users, *u)
+		}
+	}
+	return users, nil
+}
+
+// sharedWithMeTaken returns the name of a user reached by the target having a
+// real folder where its SharedWithMe folder would be, the virtual one would hide it
+func sharedWithMeTaken(c *gin.Context, targetType string, targetID uint) (string, error) {
+	users, err := internalShareTargetUsers(targetType, targetID)
+	if err != nil {
+		return "", err
+	}
+	for _, u := range users {
+		if u.HasSharedWithMe() {
+			// the folder is already virtual for the user
+			continue
+		}
+		// the folder is under the base path in the root listing and over webdav
+		paths := []string{stdpath.Join(utils.FixAndCleanPath(u.BasePath), model.SharedWithMe)}
+		if p, err := u.JoinPath(model.SharedWithMe); err == nil && p != paths[0] {
+			paths = append(paths, p)
+		}
+		for _, p := range paths {
+			if _, err := fs.Get(c, p, &fs.GetArgs{NoLog: true}); err == nil {
+				return u.Username, nil
+			}
+		}
+	}
+	return "", nil
+}
+
+// sharedWithMeFolder is the virtual folder at the root of the user, nil if
+// nothing is shared with the user
+func sharedWithMeFolder(user *model.User) model.Obj {
+	shares, _ := op.GetIncomingShares(user)
+	if len(shares) == 0 {
+		return nil
+	}
+	return &model.Object{
+		Name:     strings.TrimPrefix(model.SharedWithMe, "/"),
+		Path:     model.SharedWithMe,
+		IsFolder: true,
+		Modified: shares[len(shares)-1].CreatedAt,
+	}
+}
+
+// isUserRoot reports whether the request path is the root of the user
+func isUserRoot(reqPath string) bool {
+	return utils.FixAndCleanPath(reqPath) == "/"
+}
@@ -0,0 +1,91 @@
+package handles
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+func internalShareRouter(user *model.User) *gin.Engine {
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("user", user)
+	})
+	r.POST("/create", CreateInternalShare)
+	r.POST("/delete", DeleteInternalShare)
+	return r
+}
+
+func internalShareRequest(r *gin.Engine, path, body string) int {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return respCode(w)
+}
+
+func TestInternalShareHandlers(t *testing.T) {
+	dir := t.TempDir()
+	for _, d := range []string{"docs", "home/bob/Shared with me", "home/carol"} {
+		if err := os.MkdirAll(filepath.Join(dir, d), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := op.CreateStorage(context.Background(), model.Storage{Driver: "Local", MountPath: "/intshare",
+		Addition: fmt.Sprintf(`{"root_folder_path":%q}`, dir)}); err != nil {
+		t.Fatal(err)
+	}
+	// the base path of a user is the first path of its roles
+	users := map[string]*model.User{}
+	for name, base := range map[string]string{"alice": "/intshare", "bob": "/intshare/home/bob", "carol": "/intshare/home/carol"} {
+		role := &model.Role{Name: "intshare_" + name, PermissionScopes: []model.PermissionEntry{
+			{Path: base, Permission: 1<<common.PermWebdavRead | 1<<common.PermWrite},
+		}}
+		if err := op.CreateRole(role); err != nil {
+			t.Fatal(err)
+		}
+		u := &model.User{Username: "intshare_" + name, Password: "pass", Role: model.Roles{int(role.ID)}}
+		if err := op.CreateUser(u); err != nil {
+			t.Fatal(err)
+		}
+		users[name] = u
+	}
+	owner := internalShareRouter(users["alice"])
+	create := func(target *model.User) int {
+		return internalShareRequest(owner, "/create",
+			fmt.Sprintf(`{"path":"/intshare/docs","target_type":"user","target_id":%d}`, target.ID))
+	}
+
+	// the virtual folder would hide the real one of bob
+	if code := create(users["bob"]); code != 409 {
+		t.Errorf("sharing with a user having a real shared with me folder must be refused, got %d", code)
+	}
+	if code := create(users["carol"]); code != 200 {
+		t.Fatalf("sharing with carol failed, got %d", code)
+	}
+	shares, _, err := op.GetInternalSharesByOwner(users["alice"].ID, 1, 10)
+	if err != nil || len(shares) != 1 {
+		t.Fatalf("expect 1 share, got %d: %v", len(shares), err)
+	}
+	id := shares[0].ID
+
+	if code := internalShareRequest(internalShareRouter(users["bob"]), fmt.Sprintf("/delete?id=%d", id), ""); code != 403 {
+		t.Errorf("deleting the share of another user must be forbidden, got %d", code)
+	}
+	if code := internalShareRequest(owner, fmt.Sprintf("/delete?id=%d", id+1000), ""); code != 404 {
+		t.Errorf("deleting a missing share must be not found, got %d", code)
+	}
+	if code := internalShareRequest(owner, fmt.Sprintf("/delete?id=%d", id), ""); code != 200 {
+		t.Errorf("the owner must delete the share, got %d", code)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/errs"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/op"
 	"github.com/alist-org/alist/v3/server/common"
@@ -16,6 +17,7 @@ import (
 type DelLabelFileBinDingReq struct {
 	FileName string `json:"file_name"`
 	LabelId  string `json:"label_id"`
+	GroupId  uint   `json:"group_id"`
 }
 
 type pageResp[T any] struct {
@@ -96,7 +98,11 @@ func DelLabelByFileName(c *gin.Context) {
 		common.ErrorResp(c, fmt.Errorf("invalid label ID '%s': %v", req.LabelId, err), 500, true)
 		return
 	}
-	if err = db.DelLabelFileBinDingById(uint(labelId), userObj.ID, req.FileName); err != nil {
+	if req.GroupId != 0 && !op.IsGroupMember(userObj.ID, req.GroupId) {
+		common.ErrorResp(c, errs.NotGroupMember, 403)
+		return
+	}
+	if err = db.DelLabelFileBinDingById(uint(labelId), userObj.ID, req.GroupId, req.FileName); err != nil {
 		common.ErrorResp(c, err, 500, true)
 		return
 	}
@@ -158,7 +164,7 @@ func ListLabelFileBinding(c *gin.Context) {
 		}
 	}
 
-	list, total, err := db.ListLabelFileBinDing(userObj.ID, labelIDs, fileName, page, pageSize)
+	list, total, err := db.ListLabelFileBinDing(userObj.ID, op.GetGroupIDsByUserID(userObj.ID), labelIDs, fileName, page, pageSize)
 	if err != nil {
 		common.ErrorResp(c, err, 500, true)
 		return
@@ -10,6 +10,7 @@ import (
 	"github.com/alist-org/alist/v3/internal/db"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/rolesync"
 	"github.com/alist-org/alist/v3/internal/setting"
 	"github.com/alist-org/alist/v3/pkg/utils"
 	"github.com/alist-org/alist/v3/pkg/utils/random"
@@ -44,50 +45,18 @@ func loginLdap(c *gin.Context, req *LoginReq) {
 	}
 
 	// Auth start
-	ldapServer := setting.GetStr(conf.LdapServer)
-	ldapManagerDN := setting.GetStr(conf.LdapManagerDN)
-	ldapManagerPassword := setting.GetStr(conf.LdapManagerPassword)
-	ldapUserSearchBase := setting.GetStr(conf.LdapUserSearchBase)
-	ldapUserSearchFilter := setting.GetStr(conf.LdapUserSearchFilter) // (uid=%s)
-
-	// Connect to LdapServer
-	l, err := dial(ldapServer)
+	l, err := ldapConnect()
 	if err != nil {
-		utils.Log.Errorf("failed to connect to LDAP: %v", err)
 		common.ErrorResp(c, err, 500)
 		return
 	}
-
-	// First bind with a read only user
-	if ldapManagerDN != "" && ldapManagerPassword != "" {
-		err = l.Bind(ldapManagerDN, ldapManagerPassword)
-		if err != nil {
-			utils.Log.Errorf("Failed to bind to LDAP: %v", err)
-			common.ErrorResp(c, err, 500)
-			return
-		}
-	}
-
-	// Search for the given username
-	searchRequest := ldap.NewSearchRequest(
-		ldapUserSearchBase,
-		ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, 0, false,
-		fmt.Sprintf(ldapUserSearchFilter, req.Username),
-		[]string{"dn"},
-		nil,
-	)
-	sr, err := l.Search(searchRequest)
+	defer l.Close()
+	entry, err := ldapSearchUser(l, req.Username)
 	if err != nil {
-		utils.Log.Errorf("LDAP search failed: %v", err)
 		common.ErrorResp(c, err, 500)
 		return
 	}
-	if len(sr.Entries) != 1 {
-		utils.Log.Errorf("User does not exist or too many entries returned")
-		common.ErrorResp(c, err, 500)
-		return
-	}
-	userDN := sr.Entries[0].DN
+	userDN := entry.DN
 
 	// Bind as the user to verify their password
 	err = l.Bind(userDN, req.Password)
@@ -101,14 +70,30 @@ func loginLdap(c *gin.Context, req *LoginReq) {
 	}
 	// Auth finished
 
+	var groups []string
+	if rolesync.Enabled(rolesync.SourceLDAP) {
+		if groups, err = ldapGroups(l, entry); err != nil {
+			utils.Log.Errorf("failed to get LDAP groups of %s: %v", userDN, err)
+			common.ErrorResp(c, err, 500)
+			return
+		}
+	}
 	user, err := op.GetUserByName(req.Username)
 	if err != nil {
-		user, err = ladpRegister(req.Username)
+		user, err = ladpRegister(req.Username, groups)
 		if err != nil {
 			common.ErrorResp(c, err, 400)
 			loginCache.Set(ip, count+1)
 			return
 		}
+	} else {
+		synced, err := rolesync.Sync(rolesync.SourceLDAP, user, groups)
+		if err != nil {
+			utils.Log.Errorf("failed to sync roles of %s: %v", user.Username, err)
+			common.ErrorResp(c, err, 500)
+			return
+		}
+		user = synced
 	}
 
 	// generate token
@@ -121,7 +106,7 @@ func loginLdap(c *gin.Context, req *LoginReq) {
 	loginCache.Del(ip)
 }
 
-func ladpRegister(username string) (*model.User, error) {
+func ladpRegister(username string, groups []string) (*model.User, error) {
 	if username == "" {
 		return nil, errors.New("cannot get username from ldap provider")
 	}
@@ -134,12 +119,98 @@ func ladpRegister(username string) (*model.User, error) {
 		Role:       nil,
 		Disabled:   false,
 	}
+	user, err := rolesync.Sync(rolesync.SourceLDAP, user, groups)
+	if err != nil {
+		return nil, err
+	}
 	if err := db.CreateUser(user); err != nil {
 		return nil, err
 	}
 	return user, nil
 }
 
+// ldapConnect dials the server and binds with the read only manager if it's set
+func ldapConnect() (*ldap.Conn, error) {
+	l, err := dial(setting.GetStr(conf.LdapServer))
+	if err != nil {
+		utils.Log.Errorf("failed to connect to LDAP: %v", err)
+		return nil, err
+	}
+	if err := ldapBindManager(l); err != nil {
+		l.Close()
+		return nil, err
+	}
+	return l, nil
+}
+
+func ldapBindManager(l *ldap.Conn) error {
+	ldapManagerDN := setting.GetStr(conf.LdapManagerDN)
+	ldapManagerPassword := setting.GetStr(conf.LdapManagerPassword)
+	if ldapManagerDN == "" || ldapManagerPassword == "" {
+		return nil
+	}
+	if err := l.Bind(ldapManagerDN, ldapManagerPassword); err != nil {
+		utils.Log.Errorf("Failed to bind to LDAP: %v", err)
+		return err
+	}
+	return nil
+}
+
+// ldapSearchUser finds the entry of the username, it must be unique
+func ldapSearchUser(l *ldap.Conn, username string) (*ldap.Entry, error) {
+	attributes := []string{"dn"}
+	if attr := setting.GetStr(conf.LdapGroupAttribute); attr != "" {
+		attributes = append(attributes, attr)
+	}
+	searchRequest := ldap.NewSearchRequest(
+		setting.GetStr(conf.LdapUserSearchBase),
+		ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, 0, false,
+		fmt.Sprintf(setting.GetStr(conf.LdapUserSearchFilter), username), // (uid=%s)
+		attributes,
+		nil,
+	)
+	sr, err := l.Search(searchRequest)
+	if err != nil {
+		utils.Log.Errorf("LDAP search failed: %v", err)
+		return nil, err
+	}
+	if len(sr.Entries) != 1 {
+		utils.Log.Errorf("User does not exist or too many entries returned")
+		return nil, errors.New("user does not exist or too many entries returned")
+	}
+	return sr.Entries[0], nil
+}
+
+// ldapGroups returns the groups in the group attribute of the entry and, if the group
+// search base is set, the groups found by the group search filter
+func ldapGroups(l *ldap.Conn, entry *ldap.Entry) ([]string, error) {
+	groups := entry.GetAttributeValues(setting.GetStr(conf.LdapGroupAttribute))
+	base := setting.GetStr(conf.LdapGroupSearchBase)
+	if base == "" {
+		return groups, nil
+	}
+	// the connection may be bound as the user, which may not be allowed to search the groups
+	if err := ldapBindManager(l); err != nil {
+		return nil, err
+	}
+	sr, err := l.Search(ldap.NewSearchRequest(
+		base,
+		ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, 0, false,
+		fmt.Sprintf(setting.GetStr(conf.LdapGroupSearchFilter), ldap.EscapeFilter(entry.DN)),
+		[]string{"dn"},
+		nil,
+	))
+	if err != nil {
+		return nil, err
+	}
+	for _, group := range sr.Entries {
+		if !utils.SliceContains(groups, group.DN) {
+			groups = append(groups, group.DN)
+		}
+	}
+	return groups, nil
+}
+
 func dial(ldapServer string) (*ldap.Conn, error) {
 	var tlsEnabled bool = false
 	if strings.HasPrefix(ldapServer, "ldaps://") {
@@ -10,6 +10,7 @@ import (
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/offline_download/tool"
 	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/pipeline"
 	"github.com/alist-org/alist/v3/internal/task"
 	"github.com/alist-org/alist/v3/server/common"
 	"github.com/gin-gonic/gin"
@@ -291,10 +292,11 @@ func OfflineDownloadTools(c *gin.Context) {
 }
 
 type AddOfflineDownloadReq struct {
-	Urls         []string `json:"urls"`
-	Path         string   `json:"path"`
-	Tool         string   `json:"tool"`
-	DeletePolicy string   `json:"delete_policy"`
+	Urls         []string             `json:"urls"`
+	Path         string               `json:"path"`
+	Tool         string               `json:"tool"`
+	DeletePolicy string               `json:"delete_policy"`
+	Pipeline     []model.PipelineStep `json:"pipeline"`
 }
 
 func AddOfflineDownload(c *gin.Context) {
@@ -319,15 +321,20 @@ func AddOfflineDownload(c *gin.Context) {
 		common.ErrorStrResp(c, "permission denied", 403)
 		return
 	}
+	ctx, ok := startPipeline(c, user, reqPath, req.Pipeline)
+	if !ok {
+		return
+	}
 	var tasks []task.TaskExtensionInfo
 	for _, url := range req.Urls {
-		t, err := tool.AddURL(c, &tool.AddURLArgs{
+		t, err := tool.AddURL(ctx, &tool.AddURLArgs{
 			URL:          url,
 			DstDirPath:   reqPath,
 			Tool:         req.Tool,
 			DeletePolicy: tool.DeletePolicy(req.DeletePolicy),
 		})
 		if err != nil {
+			pipeline.Finish(ctx, err)
 			common.ErrorResp(c, err, 500)
 			return
 		}
@@ -335,7 +342,9 @@ func AddOfflineDownload(c *gin.Context) {
 			tasks = append(tasks, t)
 		}
 	}
+	pipeline.Finish(ctx, nil)
 	common.SuccessResp(c, gin.H{
-		"tasks": getTaskInfos(tasks),
+		"tasks":       getTaskInfos(tasks),
+		"pipeline_id": task.PipelineIDFromCtx(ctx),
 	})
 }
@@ -0,0 +1,379 @@
+package handles
+
+import (
+	"html/template"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/idp"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+	"github.com/pquerna/otp/totp"
+	log "github.com/sirupsen/logrus"
+)
+
+// the endpoints of the OpenID Connect provider, other applications log in with the users of this site
+
+var oidcConsentTmpl = template.Must(template.New("consent").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Site}}</title>
+<style>
+body{font-family:sans-serif;background:#f4f5f7;display:flex;justify-content:center;padding-top:10vh;margin:0}
+form{background:#fff;padding:24px 32px;border-radius:8px;box-shadow:0 2px 8px rgba(0,0,0,.1);width:320px}
+input{display:block;width:100%;box-sizing:border-box;margin:6px 0 12px;padding:8px}
+button{padding:8px 16px;margin-right:8px}
+.error{color:#c00}
+</style>
+</head>
+<body>
+<form method="post">
+<h3>{{.Site}}</h3>
+<p><b>{{.Client}}</b> wants to sign you in{{if .Scope}} and read your {{.Scope}}{{end}}, your roles and base path.</p>
+{{if .Error}}<p class="error">{{.Error}}</p>{{end}}
+{{range $k, $v := .Params}}<input type="hidden" name="{{$k}}" value="{{$v}}">{{end}}
+<input type="hidden" name="token" id="token">
+<p id="current" hidden></p>
+<div id="credentials">
+<input name="username" placeholder="Username" autocomplete="username">
+<input name="password" type="password" placeholder="Password" autocomplete="current-password">
+<input name="otp_code" placeholder="2FA code, if enabled" autocomplete="one-time-code">
+</div>
+<button name="action" value="approve">Allow</button>
+<button name="action" value="deny">Deny</button>
+</form>
+<script>
+// the user may be signed in already, the site keeps its token in the local storage
+var token = localStorage.getItem("token");
+if (token) {
+  fetch({{.Base}} + "/api/me", {headers: {Authorization: token}}).then(function (r) { return r.json(); }).then(function (r) {
+    if (r.code !== 200 || r.data.role.indexOf(1) !== -1) return;
+    document.getElementById("token").value = token;
+    document.getElementById("credentials").hidden = true;
+    var current = document.getElementById("current");
+    current.textContent = "Signed in as " + r.data.username;
+    current.hidden = false;
+  });
+}
+</script>
+</body>
+</html>`))
+
+type oidcAuthorizeReq struct {
+	ResponseType        string `form:"response_type"`
+	ClientID            string `form:"client_id"`
+	RedirectURI         string `form:"redirect_uri"`
+	Scope               string `form:"scope"`
+	State               string `form:"state"`
+	Nonce               string `form:"nonce"`
+	CodeChallenge       string `form:"code_challenge"`
+	CodeChallengeMethod string `form:"code_challenge_method"`
+}
+
+func (r *oidcAuthorizeReq) params() map[string]string {
+	return map[string]string{
+		"response_type":         r.ResponseType,
+		"client_id":             r.ClientID,
+		"redirect_uri":          r.RedirectURI,
+		"scope":                 r.Scope,
+		"state":                 r.State,
+		"nonce":                 r.Nonce,
+		"code_challenge":        r.CodeChallenge,
+		"code_challenge_method": r.CodeChallengeMethod,
+	}
+}
+
+func oidcIssuer(c *gin.Context) string {
+	return common.GetApiUrl(c.Request)
+}
+
+func oidcProviderEnabled(c *gin.Context) bool {
+	if setting.GetBool(conf.OIDCProviderEnabled) {
+		return true
+	}
+	c.String(http.StatusNotFound, "oidc provider is disabled")
+	return false
+}
+
+func OIDCDiscovery(c *gin.Context) {
+	if !oidcProviderEnabled(c) {
+		return
+	}
+	issuer := oidcIssuer(c)
+	c.JSON(200, gin.H{
+		"issuer":                                issuer,
+		"authorization_endpoint":                issuer + "/oauth2/authorize",
+		"token_endpoint":                        issuer + "/oauth2/token",
+		"userinfo_endpoint":                     issuer + "/oauth2/userinfo",
+		"jwks_uri":                              issuer + "/oauth2/jwks",
+		"response_types_supported":              []string{"code"},
+		"grant_types_supported":                 []string{"authorization_code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"scopes_supported":                      idp.Scopes,
+		"token_endpoint_auth_methods_supported": []string{"client_secret_basic", "client_secret_post", "none"},
+		"code_challenge_methods_supported":      []string{"S256"},
+		"claims_supported": []string{"sub", "iss", "aud", "exp", "iat", "auth_time", "nonce",
+			"preferred_username", "name", "roles", "base_path"},
+	})
+}
+
+func OIDCJWKS(c *gin.Context) {
+	if !oidcProviderEnabled(c) {
+		return
+	}
+	jwks, err := idp.JWKS()
+	if err != nil {
+		log.Errorf("failed get oidc provider jwks: %+v", err)
+		c.String(500, "failed get jwks")
+		return
+	}
+	c.JSON(200, jwks)
+}
+
+// checkAuthorizeReq validates the authorization request, the errors are only redirected to a registered redirect uri
+func checkAuthorizeReq(c *gin.Context) (*oidcAuthorizeReq, *model.OAuthClient, bool) {
+	if !oidcProviderEnabled(c) {
+		return nil, nil, false
+	}
+	var req oidcAuthorizeReq
+	if err := c.ShouldBind(&req); err != nil {
+		c.String(400, err.Error())
+		return nil, nil, false
+	}
+	client, err := idp.GetClient(req.ClientID)
+	if err != nil {
+		c.String(400, err.Error())
+		return nil, nil, false
+	}
+	if !idp.ValidRedirectURI(client, req.RedirectURI) {
+		c.String(400, idp.ErrInvalidRedirectURI.Error())
+		return nil, nil, false
+	}
+	if req.ResponseType != "code" {
+		oidcRedirectError(c, &req, "unsupported_response_type", "only the authorization code flow is supported")
+		return nil, nil, false
+	}
+	if req.CodeChallenge != "" && req.CodeChallengeMethod != "S256" {
+		oidcRedirectError(c, &req, "invalid_request", "only the S256 code_challenge_method is supported")
+		return nil, nil, false
+	}
+	if client.Public && req.CodeChallenge == "" {
+		oidcRedirectError(c, &req, "invalid_request", "public clients must use PKCE")
+		return nil, nil, false
+	}
+	req.Scope = idp.FilterScope(req.Scope)
+	return &req, client, true
+}
+
+func oidcRedirect(c *gin.Context, req *oidcAuthorizeReq, params url.Values) {
+	u, _ := url.Parse(req.RedirectURI)
+	q := u.Query()
+	for k, v := range params {
+		q[k] = v
+	}
+	if req.State != "" {
+		q.Set("state", req.State)
+	}
+	q.Set("iss", oidcIssuer(c))
+	u.RawQuery = q.Encode()
+	c.Redirect(http.StatusFound, u.String())
+}
+
+func oidcRedirectError(c *gin.Context, req *oidcAuthorizeReq, code, description string) {
+	oidcRedirect(c, req, url.Values{"error": {code}, "error_description": {description}})
+}
+
+func renderConsent(c *gin.Context, req *oidcAuthorizeReq, client *model.OAuthClient, errMsg string) {
+	// the page must not be framed by other sites to trick users into allowing
+	c.Header("X-Frame-Options", "DENY")
+	c.Header("Content-Security-Policy", "frame-ancestors 'none'")
+	c.Header("Cache-Control", "no-store")
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	if errMsg != "" {
+		c.Status(400)
+	} else {
+		c.Status(200)
+	}
+	scope := strings.TrimSpace(strings.Replace(req.Scope, "openid", "", 1))
+	err := oidcConsentTmpl.Execute(c.Writer, gin.H{
+		"Site":   setting.GetStr(conf.SiteTitle),
+		"Client": client.Name,
+		"Scope":  scope,
+		"Params": req.params(),
+		"Error":  errMsg,
+		"Base":   strings.TrimSuffix(conf.URL.Path, "/"),
+	})
+	if err != nil {
+		log.Errorf("failed render consent page: %+v", err)
+	}
+}
+
+// OIDCAuthorize shows the page the user signs in and allows the client on
+func OIDCAuthorize(c *gin.Context) {
+	req, client, ok := checkAuthorizeReq(c)
+	if !ok {
+		return
+	}
+	renderConsent(c, req, client, "")
+}
+
+func OIDCAuthorizeSubmit(c *gin.Context) {
+	req, client, ok := checkAuthorizeReq(c)
+	if !ok {
+		return
+	}
+	if c.PostForm("action") != "approve" {
+		oidcRedirectError(c, req, "access_denied", "the user denied the request")
+		return
+	}
+	user, errMsg := oidcAuthenticate(c)
+	if user == nil {
+		renderConsent(c, req, client, errMsg)
+		return
+	}
+	code, err := idp.CreateCode(&model.OAuthCode{
+		ClientID:      client.ClientID,
+		UserID:        user.ID,
+		RedirectURI:   req.RedirectURI,
+		Scope:         req.Scope,
+		Nonce:         req.Nonce,
+		CodeChallenge: req.CodeChallenge,
+		AuthTime:      time.Now(),
+	})
+	if err != nil {
+		log.Errorf("failed create oauth code: %+v", err)
+		oidcRedirectError(c, req, "server_error", "failed create authorization code")
+		return
+	}
+	oidcRedirect(c, req, url.Values{"code": {code}})
+}
+
+// oidcAuthenticate signs the user in with the token of the site or the credentials, the failures are
+// counted by ip like the login api does
+func oidcAuthenticate(c *gin.Context) (*model.User, string) {
+	if token := c.PostForm("token"); token != "" {
+		if claims, err := common.ParseToken(token); err == nil {
+			user, err := op.GetUserByName(claims.Username)
+			if err == nil && claims.PwdTS == user.PwdTS && !user.Disabled && !user.IsGuest() {
+				return user, ""
+			}
+		}
+		if c.PostForm("username") == "" {
+			return nil, "Your session has expired, sign in please"
+		}
+	}
+	ip := c.ClientIP()
+	count, ok := loginCache.Get(ip)
+	if ok && count >= defaultTimes {
+		loginCache.Expire(ip, defaultDuration)
+		return nil, "Too many unsuccessful sign-in attempts, try again later"
+	}
+	user, err := op.GetUserByName(c.PostForm("username"))
+	if err != nil || user.ValidateRawPassword(c.PostForm("password")) != nil {
+		loginCache.Set(ip, count+1)
+		return nil, invalidLoginCredentialsMsg
+	}
+	if user.OtpSecret != "" && !totp.Validate(c.PostForm("otp_code"), user.OtpSecret) {
+		loginCache.Set(ip, count+1)
+		return nil, "Invalid 2FA code"
+	}
+	if user.Disabled || user.IsGuest() {
+		return nil, "The user is disabled"
+	}
+	loginCache.Del(ip)
+	return user, ""
+}
+
+func oauthError(c *gin.Context, status int, code, description string) {
+	c.Header("Cache-Control", "no-store")
+	c.JSON(status, gin.H{"error": code, "error_description": description})
+}
+
+// OIDCToken exchanges an authorization code for the tokens
+func OIDCToken(c *gin.Context) {
+	if !oidcProviderEnabled(c) {
+		return
+	}
+	if c.PostForm("grant_type") != "authorization_code" {
+		oauthError(c, 400, "unsupported_grant_type", "only the authorization_code grant is supported")
+		return
+	}
+	clientID, secret, basic := c.Request.BasicAuth()
+	if basic {
+		// the credentials are form encoded before they are put in the header
+		clientID, _ = url.QueryUnescape(clientID)
+		secret, _ = url.QueryUnescape(secret)
+	} else {
+		clientID, secret = c.PostForm("client_id"), c.PostForm("client_secret")
+	}
+	client, err := idp.AuthenticateClient(clientID, secret)
+	if err != nil {
+		if basic {
+			c.Header("WWW-Authenticate", `Basic realm="oauth2"`)
+		}
+		oauthError(c, 401, "invalid_client", err.Error())
+		return
+	}
+	code, err := idp.ExchangeCode(c.PostForm("code"), client, c.PostForm("redirect_uri"), c.PostForm("code_verifier"))
+	if err != nil {
+		oauthError(c, 400, "invalid_grant", err.Error())
+		return
+	}
+	user, err := op.GetUserById(code.UserID)
+	if err != nil || user.Disabled {
+		oauthError(c, 400, "invalid_grant", "the user is not found or disabled")
+		return
+	}
+	resp, err := idp.IssueTokens(oidcIssuer(c), code, user)
+	if err != nil {
+		log.Errorf("failed issue oidc tokens: %+v", err)
+		oauthError(c, 500, "server_error", "failed issue tokens")
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	c.JSON(200, resp)
+}
+
+// OIDCUserInfo returns the claims of the user of the access token
+func OIDCUserInfo(c *gin.Context) {
+	if !oidcProviderEnabled(c) {
+		return
+	}
+	invalid := func(description string) {
+		c.Header("WWW-Authenticate", `Bearer error="invalid_token", error_description="`+description+`"`)
+		oauthError(c, 401, "invalid_token", description)
+	}
+	token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if !ok {
+		invalid("bearer token is required")
+		return
+	}
+	claims, err := idp.ParseAccessToken(oidcIssuer(c), token)
+	if err != nil {
+		invalid(err.Error())
+		return
+	}
+	id, _ := strconv.ParseUint(claims.Subject, 10, 64)
+	user, err := op.GetUserById(uint(id))
+	if err != nil || user.Disabled {
+		invalid("the user is not found or disabled")
+		return
+	}
+	info, err := idp.UserClaims(user, claims.Scope)
+	if err != nil {
+		log.Errorf("failed get oidc user claims: %+v", err)
+		oauthError(c, 500, "server_error", "failed get user claims")
+		return
+	}
+	c.JSON(200, info)
+}
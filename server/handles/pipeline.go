@@ -0,0 +1,166 @@
+package handles
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/pipeline"
+	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+var pipelineStepPerms = map[string]uint{
+	model.PipelineDecompress: common.PermDecompress,
+	model.PipelineCopy:       common.PermCopy,
+	model.PipelineMove:       common.PermMove,
+	model.PipelineRename:     common.PermRename,
+	model.PipelineRefresh:    common.PermWrite,
+	model.PipelineWebhook:    common.PermWrite,
+}
+
+// startPipeline creates the pipeline of the request if it has steps, the tasks must be created with the returned ctx.
+// dstDir is where the tasks of the request put their results, steps without a dst_dir are checked against the
+// folder the previous step put its results in.
+func startPipeline(c *gin.Context, user *model.User, dstDir string, steps []model.PipelineStep) (context.Context, bool) {
+	if len(steps) == 0 {
+		return c, true
+	}
+	if err := pipeline.Validate(steps); err != nil {
+		common.ErrorResp(c, err, 400)
+		return nil, false
+	}
+	resolved := make([]model.PipelineStep, 0, len(steps))
+	// inputDir is where the inputs of the step are
+	inputDir := dstDir
+	for _, step := range steps {
+		target := inputDir
+		if step.DstDir != "" {
+			var err error
+			step.DstDir, err = user.JoinPath(step.DstDir)
+			if err != nil {
+				common.ErrorResp(c, err, 403)
+				return nil, false
+			}
+			target = step.DstDir
+		}
+		if !common.CheckPathLimitWithRoles(user, target) {
+			common.ErrorResp(c, errs.PermissionDenied, 403)
+			return nil, false
+		}
+		perm := common.MergeRolePermissions(user, target)
+		if !common.HasPermission(perm, pipelineStepPerms[step.Action]) {
+			common.ErrorResp(c, errs.PermissionDenied, 403)
+			return nil, false
+		}
+		// the inputs are removed by remove_inputs, and by the moves between two storages
+		inputPerm := common.MergeRolePermissions(user, inputDir)
+		if (step.RemoveInputs && !common.HasPermission(inputPerm, common.PermRemove)) ||
+			(step.Action == model.PipelineMove && !common.HasPermission(inputPerm, common.PermMove)) {
+			common.ErrorResp(c, errs.PermissionDenied, 403)
+			return nil, false
+		}
+		if step.Action == model.PipelineWebhook && !user.IsAdmin() && !webhookAllowed(step.URL) {
+			common.ErrorStrResp(c, "the webhook host is not allowed", 403)
+			return nil, false
+		}
+		if step.DstDir != "" {
+			inputDir = step.DstDir
+		}
+		resolved = append(resolved, step)
+	}
+	ctx, _, err := pipeline.Start(c, user, resolved)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return nil, false
+	}
+	return ctx, true
+}
+
+// webhookAllowed reports whether the url is on one of the hosts, or their
+// subdomains, users other than admins may call webhooks of
+func webhookAllowed(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	for _, h := range strings.Split(setting.GetStr(conf.PipelineWebhookHosts), ",") {
+		h = strings.ToLower(strings.TrimSpace(h))
+		if h != "" && (host == h || strings.HasSuffix(host, "."+h)) {
+			return true
+		}
+	}
+	return false
+}
+
+func ListPipelines(c *gin.Context) {
+	var req model.PageReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	isAdmin, uid, ok := getUserInfo(c)
+	if !ok {
+		common.ErrorStrResp(c, "user invalid", 401)
+		return
+	}
+	if isAdmin {
+		uid = 0
+	}
+	pipelines, total, err := db.GetPipelines(uid, req.Page, req.PerPage)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: pipelines,
+		Total:   total,
+	})
+}
+
+func getTargetedPipeline(c *gin.Context) (*model.Pipeline, bool) {
+	isAdmin, uid, ok := getUserInfo(c)
+	if !ok {
+		common.ErrorStrResp(c, "user invalid", 401)
+		return nil, false
+	}
+	id, err := strconv.ParseUint(c.Query("id"), 10, 64)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return nil, false
+	}
+	p, err := db.GetPipelineByID(uint(id))
+	if err != nil || (!isAdmin && p.CreatorID != uid) {
+		common.ErrorStrResp(c, "pipeline not found", 404)
+		return nil, false
+	}
+	return p, true
+}
+
+func GetPipeline(c *gin.Context) {
+	p, ok := getTargetedPipeline(c)
+	if !ok {
+		return
+	}
+	common.SuccessResp(c, p)
+}
+
+func DeletePipeline(c *gin.Context) {
+	p, ok := getTargetedPipeline(c)
+	if !ok {
+		return
+	}
+	if err := db.DeletePipelineByID(p.ID); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c)
+}
@@ -0,0 +1,65 @@
+package handles
+
+import (
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/metrics"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+type ExplainPermissionReq struct {
+	Username string `json:"username" binding:"required"`
+	// Path is relative to the base path of the user like in the fs api
+	Path     string `json:"path"`
+	Protocol string `json:"protocol"`
+	ClientIP string `json:"client_ip"`
+}
+
+type ExplainPermissionResp struct {
+	*common.PermissionExplanation
+	Username string `json:"username"`
+	Disabled bool   `json:"disabled"`
+}
+
+// ExplainPermission shows the effective permission bits of a user on a path and the role and meta rules producing them
+func ExplainPermission(c *gin.Context) {
+	var req ExplainPermissionReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	switch req.Protocol {
+	case "":
+		req.Protocol = metrics.ProtocolHTTP
+	case metrics.ProtocolHTTP, metrics.ProtocolWebDAV, metrics.ProtocolFTP, metrics.ProtocolSFTP,
+		metrics.ProtocolS3, metrics.ProtocolMCP:
+	default:
+		common.ErrorStrResp(c, "unknown protocol "+req.Protocol, 400)
+		return
+	}
+	user, err := op.GetUserByName(req.Username)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	reqPath, err := user.JoinPath(req.Path)
+	if err != nil {
+		common.ErrorResp(c, err, 403)
+		return
+	}
+	meta, err := op.GetNearestMeta(reqPath)
+	if err != nil {
+		if !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+			common.ErrorResp(c, err, 500, true)
+			return
+		}
+	}
+	user = user.WithAccess(req.Protocol, req.ClientIP)
+	common.SuccessResp(c, ExplainPermissionResp{
+		PermissionExplanation: common.ExplainPermissions(user, meta, reqPath),
+		Username:              user.Username,
+		Disabled:              user.Disabled,
+	})
+}
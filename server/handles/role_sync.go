@@ -0,0 +1,80 @@
+package handles
+
+import (
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/rolesync"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+type RoleSyncDryRunReq struct {
+	Source   string `json:"source" binding:"required"`
+	Username string `json:"username" binding:"required"`
+	// Groups are looked up in the directory for ldap if they are not given, the oidc groups are only known at login
+	Groups []string `json:"groups"`
+}
+
+type RoleSyncDryRunResp struct {
+	*rolesync.Result
+	Enabled   bool     `json:"enabled"`
+	Exists    bool     `json:"exists"`
+	RoleNames []string `json:"role_names"`
+}
+
+// DryRunRoleSync shows the roles the user would get from its groups at its next login without changing it
+func DryRunRoleSync(c *gin.Context) {
+	var req RoleSyncDryRunReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if req.Source != rolesync.SourceLDAP && req.Source != rolesync.SourceOIDC {
+		common.ErrorStrResp(c, "source must be ldap or oidc", 400)
+		return
+	}
+	user, err := op.GetUserByName(req.Username)
+	exists := err == nil
+	if !exists {
+		// the user would be created at its first login
+		user = &model.User{Username: req.Username}
+	}
+	groups := req.Groups
+	if groups == nil {
+		if req.Source == rolesync.SourceOIDC {
+			common.ErrorStrResp(c, "groups are required for oidc, they are only known at login", 400)
+			return
+		}
+		l, err := ldapConnect()
+		if err != nil {
+			common.ErrorResp(c, err, 500)
+			return
+		}
+		defer l.Close()
+		entry, err := ldapSearchUser(l, req.Username)
+		if err != nil {
+			common.ErrorResp(c, err, 400)
+			return
+		}
+		if groups, err = ldapGroups(l, entry); err != nil {
+			common.ErrorResp(c, err, 500)
+			return
+		}
+	}
+	res, err := rolesync.Evaluate(req.Source, user, groups)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	resp := RoleSyncDryRunResp{Result: res, Enabled: rolesync.Enabled(req.Source), Exists: exists, RoleNames: []string{}}
+	if user.IsAdmin() || user.IsGuest() {
+		// admins and the guest are never synced
+		resp.Roles, resp.Added, resp.Removed, resp.Changed = user.Role, []string{}, []string{}, false
+	}
+	for _, id := range resp.Roles {
+		if role, err := op.GetRole(uint(id)); err == nil {
+			resp.RoleNames = append(resp.RoleNames, role.Name)
+		}
+	}
+	common.SuccessResp(c, resp)
+}
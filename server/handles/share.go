@@ -14,16 +14,21 @@ import (
 	"github.com/alist-org/alist/v3/internal/db"
 	shareauth "github.com/alist-org/alist/v3/internal/share"
 
+	"github.com/alist-org/alist/v3/internal/errs"
 	"github.com/alist-org/alist/v3/internal/fs"
 	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/pkg/http_range"
 	"github.com/alist-org/alist/v3/pkg/utils"
-	"github.com/alist-org/alist/v3/pkg/utils/random"
 	"github.com/alist-org/alist/v3/server/common"
 	"github.com/gin-gonic/gin"
 )
 
 const shareAccessTokenLifetime = 24 * time.Hour
 
+// shareTopFiles is the number of top files in the stats of a share
+const shareTopFiles = 5
+
 var shareIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,32}$`)
 
 var (
@@ -42,6 +47,16 @@ type CreateShareReq struct {
 	BurnAfterRead *bool  `json:"burn_after_read"`
 	AllowPreview  *bool  `json:"allow_preview"`
 	AllowDownload *bool  `json:"allow_download"`
+	// GroupID makes the share owned by a group of the user
+	GroupID uint `json:"group_id"`
+	// file request options, only for folders
+	AllowUpload       bool   `json:"allow_upload"`
+	UploadOnly        bool   `json:"upload_only"`
+	MaxFileSize       int64  `json:"max_file_size"`
+	AllowedExtensions string `json:"allowed_extensions"`
+	UploadQuota       int64  `json:"upload_quota"`
+	UploadLimit       int64  `json:"upload_limit"`
+	AllowWebDAV       bool   `json:"allow_webdav"`
 }
 
 type UpdateShareReq struct {
@@ -53,6 +68,15 @@ type UpdateShareReq struct {
 	AccessLimit   *int64  `json:"access_limit"`
 	AllowPreview  *bool   `json:"allow_preview"`
 	AllowDownload *bool   `json:"allow_download"`
+	// GroupID hands the share to a group of the user, 0 gives it back to its creator
+	GroupID           *uint   `json:"group_id"`
+	AllowUpload       *bool   `json:"allow_upload"`
+	UploadOnly        *bool   `json:"upload_only"`
+	MaxFileSize       *int64  `json:"max_file_size"`
+	AllowedExtensions *string `json:"allowed_extensions"`
+	UploadQuota       *int64  `json:"upload_quota"`
+	UploadLimit       *int64  `json:"upload_limit"`
+	AllowWebDAV       *bool   `json:"allow_webdav"`
 }
 
 type ShareDeleteReq struct {
@@ -78,27 +102,41 @@ type PublicShareListReq struct {
 }
 
 type ShareResp struct {
-	ID                uint       `json:"id"`
-	ShareID           string     `json:"share_id"`
-	Name              string     `json:"name"`
-	RootPath          string     `json:"root_path"`
-	IsDir             bool       `json:"is_dir"`
-	HasPassword       bool       `json:"has_password"`
-	BurnAfterRead     bool       `json:"burn_after_read"`
-	AccessLimit       int64      `json:"access_limit"`
-	AccessCount       int64      `json:"access_count"`
-	RemainingAccesses int64      `json:"remaining_accesses"`
-	AllowPreview      bool       `json:"allow_preview"`
-	AllowDownload     bool       `json:"allow_download"`
-	Enabled           bool       `json:"enabled"`
-	ViewCount         int64      `json:"view_count"`
-	DownloadCount     int64      `json:"download_count"`
-	LastAccessAt      *time.Time `json:"last_access_at"`
-	ConsumedAt        *time.Time `json:"consumed_at"`
-	ExpiresAt         *time.Time `json:"expires_at"`
-	CreatedAt         time.Time  `json:"created_at"`
-	UpdatedAt         time.Time  `json:"updated_at"`
-	URL               string     `json:"url"`
+	ID                uint              `json:"id"`
+	ShareID           string            `json:"share_id"`
+	CreatorID         uint              `json:"creator_id"`
+	GroupID           uint              `json:"group_id"`
+	Name              string            `json:"name"`
+	RootPath          string            `json:"root_path"`
+	IsDir             bool              `json:"is_dir"`
+	HasPassword       bool              `json:"has_password"`
+	BurnAfterRead     bool              `json:"burn_after_read"`
+	AccessLimit       int64             `json:"access_limit"`
+	AccessCount       int64             `json:"access_count"`
+	RemainingAccesses int64             `json:"remaining_accesses"`
+	AllowPreview      bool              `json:"allow_preview"`
+	AllowDownload     bool              `json:"allow_download"`
+	Enabled           bool              `json:"enabled"`
+	ViewCount         int64             `json:"view_count"`
+	DownloadCount     int64             `json:"download_count"`
+	LastAccessAt      *time.Time        `json:"last_access_at"`
+	ConsumedAt        *time.Time        `json:"consumed_at"`
+	ExpiresAt         *time.Time        `json:"expires_at"`
+	CreatedAt         time.Time         `json:"created_at"`
+	UpdatedAt         time.Time         `json:"updated_at"`
+	URL               string            `json:"url"`
+	AllowUpload       bool              `json:"allow_upload"`
+	UploadOnly        bool              `json:"upload_only"`
+	MaxFileSize       int64             `json:"max_file_size"`
+	AllowedExtensions string            `json:"allowed_extensions"`
+	UploadQuota       int64             `json:"upload_quota"`
+	UploadLimit       int64             `json:"upload_limit"`
+	UploadCount       int64             `json:"upload_count"`
+	UploadedBytes     int64             `json:"uploaded_bytes"`
+	LastUploadAt      *time.Time        `json:"last_upload_at"`
+	Stats             *model.ShareStats `json:"stats,omitempty"`
+	AllowWebDAV       bool              `json:"allow_webdav"`
+	WebDAVURL         string            `json:"webdav_url,omitempty"`
 }
 
 type PublicShareInfoResp struct {
@@ -116,6 +154,13 @@ type PublicShareInfoResp struct {
 	ConsumedAt        *time.Time `json:"consumed_at"`
 	ExpiresAt         *time.Time `json:"expires_at"`
 	CreatedAt         time.Time  `json:"created_at"`
+	AllowUpload       bool       `json:"allow_upload"`
+	UploadOnly        bool       `json:"upload_only"`
+	MaxFileSize       int64      `json:"max_file_size"`
+	AllowedExtensions string     `json:"allowed_extensions"`
+	RemainingUploads  int64      `json:"remaining_uploads"`
+	RemainingQuota    int64      `json:"remaining_quota"`
+	WebDAVURL         string     `json:"webdav_url,omitempty"`
 }
 
 type PublicShareObjResp struct {
@@ -150,11 +195,20 @@ func shareURL(c *gin.Context, shareID string) string {
 	return fmt.Sprintf("%s/s/%s", common.GetApiUrl(c.Request), shareID)
 }
 
+func shareWebDAVURL(c *gin.Context, share *model.Share) string {
+	if !share.AllowWebDAV {
+		return ""
+	}
+	return fmt.Sprintf("%s/dav-share/%s/", common.GetApiUrl(c.Request), share.ShareID)
+}
+
 func toShareResp(c *gin.Context, share *model.Share) ShareResp {
 	accessLimit := share.EffectiveAccessLimit()
 	return ShareResp{
 		ID:                share.ID,
 		ShareID:           share.ShareID,
+		CreatorID:         share.CreatorID,
+		GroupID:           share.GroupID,
 		Name:              share.Name,
 		RootPath:          share.RootPath,
 		IsDir:             share.IsDir,
@@ -174,7 +228,41 @@ func toShareResp(c *gin.Context, share *model.Share) ShareResp {
 		CreatedAt:         share.CreatedAt,
 		UpdatedAt:         share.UpdatedAt,
 		URL:               shareURL(c, share.ShareID),
+		AllowUpload:       share.AllowUpload,
+		UploadOnly:        share.UploadOnly,
+		MaxFileSize:       share.MaxFileSize,
+		AllowedExtensions: share.AllowedExtensions,
+		UploadQuota:       share.UploadQuota,
+		UploadLimit:       share.UploadLimit,
+		UploadCount:       share.UploadCount,
+		UploadedBytes:     share.UploadedBytes,
+		LastUploadAt:      share.LastUploadAt,
+		AllowWebDAV:       share.AllowWebDAV,
+		WebDAVURL:         shareWebDAVURL(c, share),
+	}
+}
+
+// getOwnedShare returns the share if it's created by the user, or owned by a
+// group the user is an admin of
+func getOwnedShare(user *model.User, shareID string) (*model.Share, error) {
+	return db.GetShareByOwnerAndShareID(user.ID, op.GetAdminGroupIDsByUserID(user.ID), shareID)
+}
+
+// checkShareGroup checks that the user can hand a share of rootPath to the
+// group: only its admins manage the shares of a group, and the group must be
+// able to read the path since the share acts as the group
+func checkShareGroup(user *model.User, groupID uint, rootPath string) error {
+	if !op.CanManageGroup(user, groupID) {
+		return errs.NotGroupAdmin
+	}
+	g, err := op.GetGroup(groupID)
+	if err != nil {
+		return err
 	}
+	if !common.CanReadPathByRole(op.GroupUser(g), rootPath) {
+		return errors.New("the group can't read the shared path")
+	}
+	return nil
 }
 
 func normalizeShareName(obj model.Obj, name string) string {
@@ -188,24 +276,6 @@ func normalizeOptionalShareName(name, fallback string) string {
 	return fallback
 }
 
-func generateShareID() (string, error) {
-	for range 10 {
-		shareID := random.String(8)
-		exists, err := db.ShareIDExists(shareID)
-		if err != nil {
-			return "", err
-		}
-		if !exists {
-			return shareID, nil
-		}
-	}
-	return "", fmt.Errorf("failed to generate unique share id")
-}
-
-func sharePasswordHash(password, salt string) string {
-	return model.HashPwd(model.StaticHash(password), salt)
-}
-
 func validateCustomShareID(shareID string) error {
 	if shareID == "" {
 		return nil
@@ -222,7 +292,7 @@ func resolveRequestedShareID(rawShareID, fallback string, excludeID uint) (strin
 		if fallback != "" {
 			return fallback, nil
 		}
-		return generateShareID()
+		return shareauth.GenerateID()
 	}
 	if err := validateCustomShareID(shareID); err != nil {
 		return "", err
@@ -296,7 +366,7 @@ func sharePasswordMatched(share *model.Share, password string) bool {
 	if !share.HasPassword() {
 		return true
 	}
-	hash := sharePasswordHash(password, share.PasswordSalt)
+	hash := shareauth.HashPassword(password, share.PasswordSalt)
 	return subtle.ConstantTimeCompare([]byte(hash), []byte(share.PasswordHash)) == 1
 }
 
@@ -338,6 +408,24 @@ func recordShareAccess(share *model.Share) error {
 	return nil
 }
 
+// logShareAccess records the visit in the access log of the share
+func logShareAccess(c *gin.Context, share *model.Share, action, relPath string, bytes int64) {
+	shareauth.LogAccess(share, action, relPath, c.ClientIP(), c.Request.UserAgent(), bytes)
+}
+
+// servedBytes is the length of the requested ranges of a file of the size
+func servedBytes(c *gin.Context, size int64) int64 {
+	ranges, err := http_range.ParseRange(c.GetHeader("Range"), size)
+	if err != nil || len(ranges) == 0 {
+		return size
+	}
+	var n int64
+	for _, r := range ranges {
+		n += r.Length
+	}
+	return n
+}
+
 func ensureShareAccess(c *gin.Context, share *model.Share, token string) bool {
 	if !share.HasPassword() {
 		return true
@@ -447,6 +535,12 @@ func CreateShare(c *gin.Context) {
 		common.ErrorStrResp(c, "you have no permission", 403)
 		return
 	}
+	if req.GroupID != 0 {
+		if err := checkShareGroup(user, req.GroupID, reqPath); err != nil {
+			common.ErrorResp(c, err, 403)
+			return
+		}
+	}
 	obj, err := fs.Get(c, reqPath, &fs.GetArgs{})
 	if err != nil {
 		common.ErrorResp(c, err, 500)
@@ -479,9 +573,30 @@ func CreateShare(c *gin.Context) {
 		common.ErrorResp(c, err, 400)
 		return
 	}
+	upload := shareUploadOptions{
+		AllowUpload:       req.AllowUpload,
+		UploadOnly:        req.UploadOnly,
+		MaxFileSize:       req.MaxFileSize,
+		AllowedExtensions: req.AllowedExtensions,
+		UploadQuota:       req.UploadQuota,
+		UploadLimit:       req.UploadLimit,
+	}
+	if err := upload.validate(obj.IsDir()); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if req.AllowWebDAV && !obj.IsDir() {
+		common.ErrorStrResp(c, "only folders can be served over webdav", 400)
+		return
+	}
+	if upload.AllowUpload && !common.CanUploadTo(user, reqPath) {
+		common.ErrorStrResp(c, "you have no permission to upload to this folder", 403)
+		return
+	}
 	share := &model.Share{
 		ShareID:       shareID,
 		CreatorID:     user.ID,
+		GroupID:       req.GroupID,
 		Name:          normalizeShareName(obj, req.Name),
 		RootPath:      reqPath,
 		IsDir:         obj.IsDir(),
@@ -489,12 +604,13 @@ func CreateShare(c *gin.Context) {
 		AccessLimit:   accessLimit,
 		AllowPreview:  allowPreview,
 		AllowDownload: allowDownload,
+		AllowWebDAV:   req.AllowWebDAV,
 		Enabled:       true,
 		ExpiresAt:     expiresAt,
 	}
+	upload.apply(share)
 	if req.Password != "" {
-		share.PasswordSalt = random.String(16)
-		share.PasswordHash = sharePasswordHash(req.Password, share.PasswordSalt)
+		shareauth.SetPassword(share, req.Password)
 	}
 	if err := db.CreateShare(share); err != nil {
 		common.ErrorResp(c, err, 500, true)
@@ -510,11 +626,21 @@ func UpdateShare(c *gin.Context) {
 		return
 	}
 	user := c.MustGet("user").(*model.User)
-	share, err := db.GetShareByCreatorAndShareID(user.ID, req.ShareID)
+	share, err := getOwnedShare(user, req.ShareID)
 	if err != nil {
 		common.ErrorResp(c, err, 404)
 		return
 	}
+	if req.GroupID != nil && *req.GroupID != share.GroupID {
+		// taken back from its group, the share goes back to its creator
+		if *req.GroupID != 0 {
+			if err := checkShareGroup(user, *req.GroupID, share.RootPath); err != nil {
+				common.ErrorResp(c, err, 403)
+				return
+			}
+		}
+		share.GroupID = *req.GroupID
+	}
 
 	shareID, err := resolveRequestedShareID(req.NewShareID, share.ShareID, share.ID)
 	if err != nil {
@@ -554,16 +680,53 @@ func UpdateShare(c *gin.Context) {
 		}
 	}
 
+	upload := shareUploadOptionsOf(share)
+	if req.AllowUpload != nil {
+		upload.AllowUpload = *req.AllowUpload
+	}
+	if req.UploadOnly != nil {
+		upload.UploadOnly = *req.UploadOnly
+	}
+	if req.MaxFileSize != nil {
+		upload.MaxFileSize = *req.MaxFileSize
+	}
+	if req.AllowedExtensions != nil {
+		upload.AllowedExtensions = *req.AllowedExtensions
+	}
+	if req.UploadQuota != nil {
+		upload.UploadQuota = *req.UploadQuota
+	}
+	if req.UploadLimit != nil {
+		upload.UploadLimit = *req.UploadLimit
+	}
+	if err := upload.validate(share.IsDir); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	allowWebDAV := share.AllowWebDAV
+	if req.AllowWebDAV != nil {
+		allowWebDAV = *req.AllowWebDAV
+	}
+	if allowWebDAV && !share.IsDir {
+		common.ErrorStrResp(c, "only folders can be served over webdav", 400)
+		return
+	}
+	if upload.AllowUpload && !share.AllowUpload && !common.CanUploadTo(user, share.RootPath) {
+		common.ErrorStrResp(c, "you have no permission to upload to this folder", 403)
+		return
+	}
+
 	share.ShareID = shareID
 	share.Name = normalizeOptionalShareName(req.Name, share.Name)
 	share.BurnAfterRead = burnAfterRead
 	share.AccessLimit = accessLimit
 	share.AllowPreview = allowPreview
 	share.AllowDownload = allowDownload
+	share.AllowWebDAV = allowWebDAV
 	share.ExpiresAt = expiresAt
+	upload.apply(share)
 	if req.Password != "" {
-		share.PasswordSalt = random.String(16)
-		share.PasswordHash = sharePasswordHash(req.Password, share.PasswordSalt)
+		shareauth.SetPassword(share, req.Password)
 	}
 	if share.Enabled && accessLimit > 0 && share.AccessCount >= accessLimit {
 		now := time.Now()
@@ -587,14 +750,25 @@ func ListShares(c *gin.Context) {
 	}
 	req.Validate()
 	user := c.MustGet("user").(*model.User)
-	shares, total, err := db.GetSharesByCreator(user.ID, req.Page, req.PerPage)
+	shares, total, err := db.GetSharesByOwner(user.ID, op.GetAdminGroupIDsByUserID(user.ID), req.Page, req.PerPage)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	ids := make([]uint, 0, len(shares))
+	for i := range shares {
+		ids = append(ids, shares[i].ID)
+	}
+	stats, err := db.GetShareStats(ids, shareTopFiles)
 	if err != nil {
 		common.ErrorResp(c, err, 500, true)
 		return
 	}
 	resp := make([]ShareResp, 0, len(shares))
 	for i := range shares {
-		resp = append(resp, toShareResp(c, &shares[i]))
+		r := toShareResp(c, &shares[i])
+		r.Stats = stats[shares[i].ID]
+		resp = append(resp, r)
 	}
 	common.SuccessResp(c, common.PageResp{
 		Content: resp,
@@ -609,11 +783,12 @@ func DisableShare(c *gin.Context) {
 		return
 	}
 	user := c.MustGet("user").(*model.User)
-	if _, err := db.GetShareByCreatorAndShareID(user.ID, req.ShareID); err != nil {
+	share, err := getOwnedShare(user, req.ShareID)
+	if err != nil {
 		common.ErrorResp(c, err, 404)
 		return
 	}
-	if err := db.DisableShareByShareID(user.ID, req.ShareID); err != nil {
+	if err := db.DisableShareByShareID(share.CreatorID, share.ShareID); err != nil {
 		common.ErrorResp(c, err, 500, true)
 		return
 	}
@@ -627,9 +802,49 @@ func DeleteShare(c *gin.Context) {
 		return
 	}
 	user := c.MustGet("user").(*model.User)
-	if err := db.DeleteShareByShareID(user.ID, req.ShareID); err != nil {
+	share, err := getOwnedShare(user, req.ShareID)
+	if err != nil {
+		common.ErrorResp(c, err, 404)
+		return
+	}
+	if err := db.DeleteShareByShareID(share.CreatorID, share.ShareID); err != nil {
 		common.ErrorResp(c, err, 500, true)
 		return
 	}
 	common.SuccessResp(c)
 }
+
+type ShareAccessLogReq struct {
+	model.PageReq
+	ShareID string `json:"share_id" form:"share_id" binding:"required"`
+	Action  string `json:"action" form:"action"`
+}
+
+// ListShareAccessLog lists the visits of a share of the user, newest first
+func ListShareAccessLog(c *gin.Context) {
+	var req ShareAccessLogReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	user := c.MustGet("user").(*model.User)
+	share, err := getOwnedShare(user, req.ShareID)
+	if err != nil {
+		common.ErrorResp(c, err, 404)
+		return
+	}
+	accesses, total, err := db.GetShareAccesses(share.ID, req.Action, req.Page, req.PerPage)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	// the visitors are told apart by their ip, it's only shown masked
+	for i := range accesses {
+		accesses[i].IP = utils.MaskIP(accesses[i].IP)
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: accesses,
+		Total:   total,
+	})
+}
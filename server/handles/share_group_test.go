@@ -0,0 +1,49 @@
+package handles
+
+import (
+	"testing"
+
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/gin-gonic/gin"
+)
+
+func TestUpdateShareGroupKeepsCreator(t *testing.T) {
+	group := &model.Group{Name: "share_group"}
+	if err := op.CreateGroup(group); err != nil {
+		t.Fatal(err)
+	}
+	members := map[string]*model.User{}
+	for _, name := range []string{"member", "group_admin"} {
+		u := &model.User{Username: "share_group_" + name, Password: "pass", BasePath: "/"}
+		if err := op.CreateUser(u); err != nil {
+			t.Fatal(err)
+		}
+		if err := op.SaveGroupMember(&model.GroupMember{GroupID: group.ID, UserID: u.ID, Admin: name == "group_admin"}); err != nil {
+			t.Fatal(err)
+		}
+		members[name] = u
+	}
+	share := &model.Share{ShareID: "group_owned", CreatorID: members["member"].ID, GroupID: group.ID,
+		Name: "group_owned", RootPath: "/", IsDir: true, Enabled: true}
+	if err := db.CreateShare(share); err != nil {
+		t.Fatal(err)
+	}
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("user", members["group_admin"])
+	})
+	r.POST("/update", UpdateShare)
+	if code := internalShareRequest(r, "/update", `{"share_id":"group_owned","group_id":0}`); code != 200 {
+		t.Fatalf("the group admin must take the share back from the group, got %d", code)
+	}
+	updated, err := db.GetShareByShareID("group_owned")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.GroupID != 0 || updated.CreatorID != members["member"].ID {
+		t.Errorf("the share must go back to its creator, got group %d creator %d", updated.GroupID, updated.CreatorID)
+	}
+}
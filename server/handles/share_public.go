@@ -8,6 +8,7 @@ import (
 	shareauth "github.com/alist-org/alist/v3/internal/share"
 
 	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/server/common"
 	"github.com/gin-gonic/gin"
 )
@@ -33,6 +34,7 @@ func GetPublicShareInfo(c *gin.Context) {
 	}
 	if authed {
 		_ = db.TouchShareView(share.ShareID)
+		logShareAccess(c, share, model.ShareActionView, "/", 0)
 	}
 	common.SuccessResp(c, PublicShareInfoResp{
 		ShareID:           share.ShareID,
@@ -49,6 +51,13 @@ func GetPublicShareInfo(c *gin.Context) {
 		ConsumedAt:        share.ConsumedAt,
 		ExpiresAt:         share.ExpiresAt,
 		CreatedAt:         share.CreatedAt,
+		AllowUpload:       share.AllowUpload,
+		UploadOnly:        share.UploadOnly,
+		MaxFileSize:       share.MaxFileSize,
+		AllowedExtensions: share.AllowedExtensions,
+		RemainingUploads:  share.RemainingUploads(),
+		RemainingQuota:    share.RemainingUploadBytes(),
+		WebDAVURL:         shareWebDAVURL(c, share),
 	})
 }
 
@@ -86,6 +95,7 @@ func AuthPublicShare(c *gin.Context) {
 		token = shareauth.SignAccess(share, ttl)
 	}
 	_ = db.TouchShareView(share.ShareID)
+	logShareAccess(c, share, model.ShareActionView, "/", 0)
 	common.SuccessResp(c, gin.H{"token": token})
 }
 
@@ -101,7 +111,7 @@ func ListPublicShare(c *gin.Context) {
 		common.ErrorResp(c, err, 404)
 		return
 	}
-	if !ensureShareAvailable(c, share) {
+	if !ensureShareAvailable(c, share) || !ensureShareListable(c, share) {
 		return
 	}
 	token := getShareAccessToken(c, req.Token)
@@ -127,6 +137,7 @@ func ListPublicShare(c *gin.Context) {
 		common.ErrorResp(c, err, 500)
 		return
 	}
+	logShareAccess(c, share, model.ShareActionList, relPath, 0)
 	total, pageObjs := pagination(objs, &req.PageReq)
 	content := make([]PublicShareObjResp, 0, len(pageObjs))
 	for _, item := range pageObjs {
@@ -163,7 +174,7 @@ func GetPublicShare(c *gin.Context) {
 		common.ErrorResp(c, err, 404)
 		return
 	}
-	if !ensureShareAvailable(c, share) {
+	if !ensureShareAvailable(c, share) || !ensureShareListable(c, share) {
 		return
 	}
 	token := getShareAccessToken(c, req.Token)
@@ -180,6 +191,7 @@ func GetPublicShare(c *gin.Context) {
 		common.ErrorResp(c, err, 404)
 		return
 	}
+	logShareAccess(c, share, model.ShareActionView, relPath, 0)
 	provider := "unknown"
 	storage, storageErr := fs.GetStorage(targetPath, &fs.GetStoragesArgs{})
 	if storageErr == nil {
@@ -197,7 +209,7 @@ func ShareDown(c *gin.Context) {
 		common.ErrorResp(c, err, 404)
 		return
 	}
-	if !ensureShareAvailable(c, share) {
+	if !ensureShareAvailable(c, share) || !ensureShareListable(c, share) {
 		return
 	}
 	if !share.AllowDownload {
@@ -208,7 +220,7 @@ func ShareDown(c *gin.Context) {
 	if !ensureShareAccess(c, share, token) {
 		return
 	}
-	targetPath, _, err := resolveShareWildcardTarget(share, c.Param("path"))
+	targetPath, relPath, err := resolveShareWildcardTarget(share, c.Param("path"))
 	if err != nil {
 		common.ErrorResp(c, err, 400)
 		return
@@ -228,6 +240,7 @@ func ShareDown(c *gin.Context) {
 			common.ErrorResp(c, err, 500, true)
 			return
 		}
+		logShareAccess(c, share, model.ShareActionDownload, relPath, servedBytes(c, obj.GetSize()))
 	}
 	c.Set("path", targetPath)
 	Down(c)
@@ -239,7 +252,7 @@ func ShareProxy(c *gin.Context) {
 		common.ErrorResp(c, err, 404)
 		return
 	}
-	if !ensureShareAvailable(c, share) {
+	if !ensureShareAvailable(c, share) || !ensureShareListable(c, share) {
 		return
 	}
 	if !share.AllowPreview {
@@ -250,7 +263,7 @@ func ShareProxy(c *gin.Context) {
 	if !ensureShareAccess(c, share, token) {
 		return
 	}
-	targetPath, _, err := resolveShareWildcardTarget(share, c.Param("path"))
+	targetPath, relPath, err := resolveShareWildcardTarget(share, c.Param("path"))
 	if err != nil {
 		common.ErrorResp(c, err, 400)
 		return
@@ -269,6 +282,7 @@ func ShareProxy(c *gin.Context) {
 			common.ErrorResp(c, err, 500, true)
 			return
 		}
+		logShareAccess(c, share, model.ShareActionProxy, relPath, servedBytes(c, obj.GetSize()))
 	}
 	c.Set("path", targetPath)
 	Proxy(c)
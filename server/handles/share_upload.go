@@ -0,0 +1,220 @@
+package handles
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	stdpath "path"
+	"strings"
+	"unicode"
+
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/metrics"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/stream"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+const maxShareUploaderLength = 64
+
+type shareUploadOptions struct {
+	AllowUpload       bool
+	UploadOnly        bool
+	MaxFileSize       int64
+	AllowedExtensions string
+	UploadQuota       int64
+	UploadLimit       int64
+}
+
+func shareUploadOptionsOf(share *model.Share) shareUploadOptions {
+	return shareUploadOptions{
+		AllowUpload:       share.AllowUpload,
+		UploadOnly:        share.UploadOnly,
+		MaxFileSize:       share.MaxFileSize,
+		AllowedExtensions: share.AllowedExtensions,
+		UploadQuota:       share.UploadQuota,
+		UploadLimit:       share.UploadLimit,
+	}
+}
+
+func (o *shareUploadOptions) validate(isDir bool) error {
+	if o.MaxFileSize < 0 || o.UploadQuota < 0 || o.UploadLimit < 0 {
+		return fmt.Errorf("max_file_size, upload_quota and upload_limit must be 0 or greater")
+	}
+	if o.UploadOnly && !o.AllowUpload {
+		return fmt.Errorf("upload_only requires allow_upload")
+	}
+	if o.AllowUpload && !isDir {
+		return fmt.Errorf("only folders can accept uploads")
+	}
+	o.AllowedExtensions = normalizeShareExtensions(o.AllowedExtensions)
+	return nil
+}
+
+func (o shareUploadOptions) apply(share *model.Share) {
+	share.AllowUpload = o.AllowUpload
+	share.UploadOnly = o.UploadOnly
+	share.MaxFileSize = o.MaxFileSize
+	share.AllowedExtensions = o.AllowedExtensions
+	share.UploadQuota = o.UploadQuota
+	share.UploadLimit = o.UploadLimit
+}
+
+// normalizeShareExtensions turns ".PDF, docx" into "pdf,docx"
+func normalizeShareExtensions(raw string) string {
+	seen := make(map[string]struct{})
+	exts := make([]string, 0)
+	for _, ext := range strings.Split(raw, ",") {
+		ext = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(ext), "."))
+		if ext == "" {
+			continue
+		}
+		if _, ok := seen[ext]; ok {
+			continue
+		}
+		seen[ext] = struct{}{}
+		exts = append(exts, ext)
+	}
+	return strings.Join(exts, ",")
+}
+
+// SanitizeShareUploader makes the name given by a visitor usable as a folder name
+func SanitizeShareUploader(raw string) (string, error) {
+	name := strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || unicode.IsControl(r) {
+			return '_'
+		}
+		return r
+	}, strings.TrimSpace(raw))
+	if runes := []rune(name); len(runes) > maxShareUploaderLength {
+		name = strings.TrimSpace(string(runes[:maxShareUploaderLength]))
+	}
+	if name == "" || strings.Trim(name, ".") == "" {
+		return "", fmt.Errorf("uploader name is required")
+	}
+	return name, nil
+}
+
+func validShareUploadName(name string) bool {
+	return name != "" && name != "." && name != ".." && !strings.ContainsAny(name, "/\\")
+}
+
+// ensureShareListable refuses to show the files of an upload only share
+func ensureShareListable(c *gin.Context, share *model.Share) bool {
+	if share.UploadOnly {
+		common.ErrorStrResp(c, "this share only accepts uploads", 403)
+		return false
+	}
+	return true
+}
+
+// UploadPublicShare puts a file sent by a visitor of a file request into the
+// folder of its uploader inside the share, on behalf of the share creator or
+// its group
+func UploadPublicShare(c *gin.Context) {
+	defer c.Request.Body.Close()
+	share, err := db.GetShareByShareID(c.GetHeader("Share-Id"))
+	if err != nil {
+		common.ErrorResp(c, err, 404)
+		return
+	}
+	if !ensureShareAvailable(c, share) {
+		return
+	}
+	if !share.AllowUpload {
+		common.ErrorStrResp(c, "upload is not allowed", 403)
+		return
+	}
+	if !ensureShareAccess(c, share, getShareAccessToken(c, "")) {
+		return
+	}
+	name, err := url.PathUnescape(c.GetHeader("File-Name"))
+	if err != nil || !validShareUploadName(name) {
+		common.ErrorStrResp(c, "invalid file name", 400)
+		return
+	}
+	uploader, err := url.PathUnescape(c.GetHeader("Uploader"))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if uploader, err = SanitizeShareUploader(uploader); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	size := c.Request.ContentLength
+	if size < 0 {
+		common.ErrorStrResp(c, "Content-Length is required", http.StatusLengthRequired)
+		return
+	}
+	if share.MaxFileSize > 0 && size > share.MaxFileSize {
+		common.ErrorStrResp(c, "file is too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+	if !share.ExtensionAllowed(name) {
+		common.ErrorStrResp(c, "file type is not allowed", 400)
+		return
+	}
+	dir, _, err := resolveShareTarget(share, uploader)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	actor, err := op.GetShareActor(share)
+	if err != nil {
+		common.ErrorResp(c, err, 403)
+		return
+	}
+	actor = actor.WithAccess(metrics.ProtocolHTTP, c.ClientIP())
+	if !common.CanUploadTo(actor, dir) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
+	c.Set("user", actor)
+	if res, _ := fs.Get(c, stdpath.Join(dir, name), &fs.GetArgs{NoLog: true}); res != nil {
+		common.ErrorStrResp(c, "file exists", 403)
+		return
+	}
+	ok, err := db.ReserveShareUpload(share.ShareID, size)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	if !ok {
+		common.ErrorStrResp(c, "upload limit of the share is reached", 403)
+		return
+	}
+	mimetype := c.GetHeader("Content-Type")
+	if len(mimetype) == 0 {
+		mimetype = utils.GetMimeType(name)
+	}
+	s := &stream.FileStream{
+		Obj: &model.Object{
+			Name:     name,
+			Size:     size,
+			Modified: getLastModified(c),
+		},
+		Reader:   c.Request.Body,
+		Mimetype: mimetype,
+	}
+	if err = fs.PutDirectly(c, dir, s, true); err != nil {
+		_ = db.ReleaseShareUpload(share.ShareID, size)
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	logShareAccess(c, share, model.ShareActionUpload, stdpath.Join("/", uploader, name), size)
+	if n, _ := io.ReadFull(c.Request.Body, []byte{0}); n == 1 {
+		_, _ = utils.CopyWithBuffer(io.Discard, c.Request.Body)
+	}
+	common.SuccessResp(c, gin.H{
+		"name":     name,
+		"uploader": uploader,
+		"size":     size,
+	})
+}
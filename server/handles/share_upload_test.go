@@ -0,0 +1,172 @@
+package handles
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	_ "github.com/alist-org/alist/v3/drivers/local"
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/db/dbtest"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	dbtest.Init()
+	// the builtin roles take the ids of model.GUEST and model.ADMIN
+	for _, name := range []string{"guest", "admin"} {
+		if err := db.CreateRole(&model.Role{Name: name}); err != nil {
+			panic(err)
+		}
+	}
+	gin.SetMode(gin.TestMode)
+}
+
+func shareTestRouter() *gin.Engine {
+	r := gin.New()
+	r.PUT("/upload", UploadPublicShare)
+	r.POST("/list", ListPublicShare)
+	r.POST("/get", GetPublicShare)
+	r.GET("/sd/:share_id/*path", ShareDown)
+	return r
+}
+
+// newUploadShare mounts a local folder and shares it with visitors uploading into it
+func newUploadShare(t *testing.T, shareID string, configure func(s *model.Share)) (*model.Share, string) {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "existing.txt"), []byte("hello"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	mountPath := "/" + shareID
+	if _, err := op.CreateStorage(context.Background(), model.Storage{Driver: "Local", MountPath: mountPath,
+		Addition: fmt.Sprintf(`{"root_folder_path":%q}`, dir)}); err != nil {
+		t.Fatal(err)
+	}
+	role := &model.Role{Name: shareID, PermissionScopes: []model.PermissionEntry{
+		{Path: mountPath, Permission: 1<<common.PermWrite | 1<<common.PermSeeHides},
+	}}
+	if err := op.CreateRole(role); err != nil {
+		t.Fatal(err)
+	}
+	creator := &model.User{Username: shareID + "_creator", Password: "pass", BasePath: "/", Role: model.Roles{int(role.ID)}}
+	if err := db.CreateUser(creator); err != nil {
+		t.Fatal(err)
+	}
+	share := &model.Share{ShareID: shareID, CreatorID: creator.ID, Name: shareID, RootPath: mountPath, IsDir: true,
+		Enabled: true, AllowDownload: true, AllowPreview: true, AllowUpload: true}
+	configure(share)
+	if err := db.CreateShare(share); err != nil {
+		t.Fatal(err)
+	}
+	return share, dir
+}
+
+func uploadToShare(r *gin.Engine, shareID, name, content string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPut, "/upload", strings.NewReader(content))
+	req.Header.Set("Share-Id", shareID)
+	req.Header.Set("File-Name", name)
+	req.Header.Set("Uploader", "visitor")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+// respCode is the code of an error response, the http status otherwise
+func respCode(w *httptest.ResponseRecorder) int {
+	var resp struct {
+		Code int `json:"code"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || resp.Code == 0 {
+		return w.Code
+	}
+	return resp.Code
+}
+
+func TestUploadPublicShare(t *testing.T) {
+	r := shareTestRouter()
+	share, dir := newUploadShare(t, "upload_limits", func(s *model.Share) {
+		s.MaxFileSize = 10
+		s.AllowedExtensions = "txt,md"
+		s.UploadQuota = 15
+	})
+
+	if w := uploadToShare(r, share.ShareID, "a.txt", "12345678"); respCode(w) != 200 {
+		t.Fatalf("upload failed: %s", w.Body.String())
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, "visitor", "a.txt")); err != nil || string(data) != "12345678" {
+		t.Fatalf("file must be put into the folder of its uploader: %v %q", err, data)
+	}
+	if code := respCode(uploadToShare(r, share.ShareID, "big.txt", "12345678901")); code != http.StatusRequestEntityTooLarge {
+		t.Errorf("file over max_file_size must be rejected, got %d", code)
+	}
+	if code := respCode(uploadToShare(r, share.ShareID, "a.exe", "1")); code != 400 {
+		t.Errorf("blocked extension must be rejected, got %d", code)
+	}
+	if code := respCode(uploadToShare(r, share.ShareID, "a.md", "1")); code != 200 {
+		t.Errorf("allowed extension must be accepted, got %d", code)
+	}
+	// 9 of the 15 bytes are used
+	if code := respCode(uploadToShare(r, share.ShareID, "b.txt", "1234567")); code != 403 {
+		t.Errorf("upload over the quota must be rejected, got %d", code)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "visitor", "b.txt")); !os.IsNotExist(err) {
+		t.Error("rejected file must not be written")
+	}
+	updated, err := db.GetShareByShareID(share.ShareID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.UploadCount != 2 || updated.UploadedBytes != 9 {
+		t.Errorf("only the accepted uploads must be counted, got %d files of %d bytes", updated.UploadCount, updated.UploadedBytes)
+	}
+}
+
+func TestUploadOnlyShare(t *testing.T) {
+	r := shareTestRouter()
+	share, _ := newUploadShare(t, "upload_only", func(s *model.Share) {
+		s.UploadOnly = true
+	})
+	if code := respCode(uploadToShare(r, share.ShareID, "a.txt", "hello")); code != 200 {
+		t.Fatalf("upload only share must accept uploads, got %d", code)
+	}
+	post := func(path, body string) int {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return respCode(w)
+	}
+	if code := post("/list", `{"share_id":"upload_only","path":"/"}`); code != 403 {
+		t.Errorf("list must be refused, got %d", code)
+	}
+	if code := post("/get", `{"share_id":"upload_only","path":"/existing.txt"}`); code != 403 {
+		t.Errorf("get must be refused, got %d", code)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/sd/upload_only/existing.txt", nil))
+	if code := respCode(w); code != 403 {
+		t.Errorf("download must be refused, got %d", code)
+	}
+
+	// the same requests succeed once the share shows its files
+	share.UploadOnly = false
+	if err := db.UpdateShare(share); err != nil {
+		t.Fatal(err)
+	}
+	if code := post("/list", `{"share_id":"upload_only","path":"/"}`); code != 200 {
+		t.Errorf("list must be allowed, got %d", code)
+	}
+	if code := post("/get", `{"share_id":"upload_only","path":"/existing.txt"}`); code != 200 {
+		t.Errorf("get must be allowed, got %d", code)
+	}
+}
@@ -16,6 +16,7 @@ import (
 	"github.com/alist-org/alist/v3/internal/conf"
 	"github.com/alist-org/alist/v3/internal/db"
 	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/rolesync"
 	"github.com/alist-org/alist/v3/internal/setting"
 	"github.com/alist-org/alist/v3/pkg/utils"
 	"github.com/alist-org/alist/v3/pkg/utils/random"
@@ -184,6 +185,27 @@ func parseJWT(p string) ([]byte, error) {
 	return payload, nil
 }
 
+// oidcGroups returns the groups claim of the id token, which may be a list or a single string
+func oidcGroups(payload []byte) []string {
+	var claims map[string]any
+	if err := utils.Json.Unmarshal(payload, &claims); err != nil {
+		return nil
+	}
+	switch v := claims[setting.GetStr(conf.SSOOIDCGroupsKey, "groups")].(type) {
+	case string:
+		return []string{v}
+	case []any:
+		groups := make([]string, 0, len(v))
+		for _, g := range v {
+			if s, ok := g.(string); ok {
+				groups = append(groups, s)
+			}
+		}
+		return groups
+	}
+	return nil
+}
+
 func OIDCLoginCallback(c *gin.Context) {
 	useCompatibility := setting.GetBool(conf.SSOCompatibilityMode)
 	method := c.Query("method")
@@ -260,6 +282,13 @@ func OIDCLoginCallback(c *gin.Context) {
 				return
 			}
 		}
+		synced, err := rolesync.Sync(rolesync.SourceOIDC, user, oidcGroups(payload))
+		if err != nil {
+			utils.Log.Errorf("failed to sync roles of %s: %v", user.Username, err)
+			common.ErrorResp(c, err, 500)
+			return
+		}
+		user = synced
 		token, err := common.GenerateToken(user)
 		if err != nil {
 			common.ErrorResp(c, err, 400)
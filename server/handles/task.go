@@ -26,6 +26,7 @@ type TaskInfo struct {
 	EndTime     *time.Time  `json:"end_time"`
 	TotalBytes  int64       `json:"total_bytes"`
 	Error       string      `json:"error"`
+	PipelineID  uint        `json:"pipeline_id,omitempty"`
 }
 
 func getTaskInfo[T task.TaskExtensionInfo](task T) TaskInfo {
@@ -56,6 +57,7 @@ func getTaskInfo[T task.TaskExtensionInfo](task T) TaskInfo {
 		EndTime:     task.GetEndTime(),
 		TotalBytes:  task.GetTotalBytes(),
 		Error:       errMsg,
+		PipelineID:  task.GetPipelineID(),
 	}
 }
 
@@ -156,8 +158,9 @@ func taskRoute[T task.TaskExtensionInfo](g *gin.RouterGroup, manager task.Manage
 		manager.Cancel(task.GetID())
 		common.SuccessResp(c)
 	}))
-	g.POST("/delete", getTargetedHandler(manager, func(c *gin.Context, task T) {
-		manager.Remove(task.GetID())
+	g.POST("/delete", getTargetedHandler(manager, func(c *gin.Context, t T) {
+		manager.Remove(t.GetID())
+		task.Notify()
 		common.SuccessResp(c)
 	}))
 	g.POST("/retry", getTargetedHandler(manager, func(c *gin.Context, task T) {
@@ -167,8 +170,9 @@ func taskRoute[T task.TaskExtensionInfo](g *gin.RouterGroup, manager task.Manage
 	g.POST("/cancel_some", getBatchHandler(manager, func(task T) {
 		manager.Cancel(task.GetID())
 	}))
-	g.POST("/delete_some", getBatchHandler(manager, func(task T) {
-		manager.Remove(task.GetID())
+	g.POST("/delete_some", getBatchHandler(manager, func(t T) {
+		manager.Remove(t.GetID())
+		task.Notify()
 	}))
 	g.POST("/retry_some", getBatchHandler(manager, func(task T) {
 		manager.Retry(task.GetID())
@@ -184,6 +188,7 @@ func taskRoute[T task.TaskExtensionInfo](g *gin.RouterGroup, manager task.Manage
 			return (isAdmin || uid == task.GetCreator().ID) &&
 				argsContains(task.GetState(), tache.StateCanceled, tache.StateFailed, tache.StateSucceeded)
 		})
+		task.Notify()
 		common.SuccessResp(c)
 	})
 	g.POST("/clear_succeeded", func(c *gin.Context) {
@@ -196,6 +201,7 @@ func taskRoute[T task.TaskExtensionInfo](g *gin.RouterGroup, manager task.Manage
 		manager.RemoveByCondition(func(task T) bool {
 			return (isAdmin || uid == task.GetCreator().ID) && task.GetState() == tache.StateSucceeded
 		})
+		task.Notify()
 		common.SuccessResp(c)
 	})
 	g.POST("/retry_failed", func(c *gin.Context) {
@@ -221,6 +227,18 @@ func SetupTaskRoute(g *gin.RouterGroup) {
 	taskRoute(g.Group("/offline_download"), tool.DownloadTaskManager)
 	taskRoute(g.Group("/offline_download_transfer"), tool.TransferTaskManager)
 	taskRoute(g.Group("/s3_transition"), fs.S3TransitionTaskManager)
+	taskRoute(g.Group("/hash"), fs.HashTaskManager)
+	taskRoute(g.Group("/dedupe"), fs.DedupeTaskManager)
 	taskRoute(g.Group("/decompress"), fs.ArchiveDownloadTaskManager)
 	taskRoute(g.Group("/decompress_upload"), fs.ArchiveContentUploadTaskManager)
+	g.GET("/pipeline/list", ListPipelines)
+	g.POST("/pipeline/info", GetPipeline)
+	g.POST("/pipeline/delete", DeletePipeline)
+	g.POST("/stream_token", TaskStreamToken)
+}
+
+// SetupTaskStreamRoute sets up the event streams of the tasks, g must be authorized by middlewares.StreamAuth
+func SetupTaskStreamRoute(g *gin.RouterGroup) {
+	g.GET("/events", TaskEvents)
+	g.GET("/ws", TaskWebSocket)
 }
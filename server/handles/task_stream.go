@@ -0,0 +1,414 @@
+package handles
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/offline_download/tool"
+	"github.com/alist-org/alist/v3/internal/sign"
+	"github.com/alist-org/alist/v3/internal/task"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	log "github.com/sirupsen/logrus"
+	"github.com/xhofe/tache"
+)
+
+const (
+	// taskSampleInterval is the least time between two samples of the tasks, the changes
+	// notified meanwhile are coalesced into the next sample
+	taskSampleInterval = time.Second
+	// taskStallInterval resamples the running tasks without any change, so the speed of a
+	// stalled task drops
+	taskStallInterval  = 5 * time.Second
+	taskPingInterval   = 30 * time.Second
+	taskEventBuffer    = 256
+	taskStreamTokenTTL = time.Minute
+)
+
+const (
+	TaskEventUpdate = "update"
+	TaskEventRemove = "remove"
+)
+
+type TaskEvent struct {
+	Event string `json:"event"`
+	// Type is the type of the task, the same as the route of its manager under /api/task
+	Type string `json:"type"`
+	TaskInfo
+	// Speed is in bytes per second, ETA in seconds and -1 if unknown
+	Speed float64 `json:"speed"`
+	ETA   int64   `json:"eta"`
+}
+
+type taskEntry struct {
+	info      TaskInfo
+	creatorID uint
+}
+
+type taskSource struct {
+	typ  string
+	list func() []taskEntry
+}
+
+func newTaskSource[T task.TaskExtensionInfo](typ string, manager task.Manager[T]) taskSource {
+	return taskSource{
+		typ: typ,
+		list: func() []taskEntry {
+			return utils.MustSliceConvert(manager.GetAll(), func(t T) taskEntry {
+				var creatorID uint
+				if t.GetCreator() != nil {
+					creatorID = t.GetCreator().ID
+				}
+				return taskEntry{info: getTaskInfo(t), creatorID: creatorID}
+			})
+		},
+	}
+}
+
+// the managers are created at boot, so the sources are resolved on each start of the bus
+func taskSources() []taskSource {
+	return []taskSource{
+		newTaskSource("upload", fs.UploadTaskManager),
+		newTaskSource("copy", fs.CopyTaskManager),
+		newTaskSource("offline_download", tool.DownloadTaskManager),
+		newTaskSource("offline_download_transfer", tool.TransferTaskManager),
+		newTaskSource("s3_transition", fs.S3TransitionTaskManager),
+		newTaskSource("hash", fs.HashTaskManager),
+		newTaskSource("dedupe", fs.DedupeTaskManager),
+		newTaskSource("decompress", fs.ArchiveDownloadTaskManager),
+		newTaskSource("decompress_upload", fs.ArchiveContentUploadTaskManager),
+	}
+}
+
+type taskSubscriber struct {
+	isAdmin bool
+	uid     uint
+	types   []string
+	ch      chan TaskEvent
+}
+
+func (s *taskSubscriber) canSee(typ string, creatorID uint) bool {
+	return (s.isAdmin || s.uid == creatorID) && (len(s.types) == 0 || utils.SliceContains(s.types, typ))
+}
+
+type taskSample struct {
+	event     TaskEvent
+	creatorID uint
+	done      float64
+	at        time.Time
+}
+
+// taskEventBus samples all task managers when a task is changed while there are subscribers
+// and publishes the changes to them
+type taskEventBus struct {
+	mu      sync.Mutex
+	subs    map[*taskSubscriber]struct{}
+	samples map[string]*taskSample
+	stop    chan struct{}
+}
+
+var taskBus = &taskEventBus{
+	subs: make(map[*taskSubscriber]struct{}),
+}
+
+func (b *taskEventBus) subscribe(sub *taskSubscriber) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subs[sub] = struct{}{}
+	if b.stop == nil {
+		b.stop = make(chan struct{})
+		b.samples = make(map[string]*taskSample)
+		go b.run(taskSources(), b.stop)
+		return
+	}
+	// the new subscriber gets the current state of all visible tasks first
+	for _, s := range b.samples {
+		if sub.canSee(s.event.Type, s.creatorID) {
+			b.send(sub, s.event)
+		}
+	}
+}
+
+func (b *taskEventBus) unsubscribe(sub *taskSubscriber) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.remove(sub)
+}
+
+func (b *taskEventBus) remove(sub *taskSubscriber) {
+	if _, ok := b.subs[sub]; !ok {
+		return
+	}
+	delete(b.subs, sub)
+	close(sub.ch)
+	if len(b.subs) == 0 && b.stop != nil {
+		close(b.stop)
+		b.stop = nil
+	}
+}
+
+// send drops the subscriber if it can't keep up, its stream ends and the client has to reconnect
+func (b *taskEventBus) send(sub *taskSubscriber, event TaskEvent) {
+	select {
+	case sub.ch <- event:
+	default:
+		log.Warnf("task event subscriber is too slow, dropped")
+		b.remove(sub)
+	}
+}
+
+func (b *taskEventBus) run(sources []taskSource, stop chan struct{}) {
+	for {
+		running, ok := b.sample(sources, stop)
+		if !ok {
+			return
+		}
+		last := time.Now()
+		var stall <-chan time.Time
+		if running {
+			stall = time.After(taskStallInterval)
+		}
+		select {
+		case <-stop:
+			return
+		case <-stall:
+			continue
+		case <-task.Changed():
+		}
+		if wait := time.Until(last.Add(taskSampleInterval)); wait > 0 {
+			select {
+			case <-stop:
+				return
+			case <-time.After(wait):
+			}
+		}
+	}
+}
+
+// sample publishes the changes since the last sample, it tells if any task is running and false
+// as the second value if the bus has been stopped
+func (b *taskEventBus) sample(sources []taskSource, stop chan struct{}) (bool, bool) {
+	now := time.Now()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.stop != stop {
+		return false, false
+	}
+	running := false
+	seen := make(map[string]struct{}, len(b.samples))
+	for _, source := range sources {
+		for _, entry := range source.list() {
+			key := source.typ + "/" + entry.info.ID
+			seen[key] = struct{}{}
+			done := entry.info.Progress / 100 * float64(entry.info.TotalBytes)
+			prev, ok := b.samples[key]
+			event := TaskEvent{
+				Event:    TaskEventUpdate,
+				Type:     source.typ,
+				TaskInfo: entry.info,
+				ETA:      -1,
+			}
+			if entry.info.State == tache.StateRunning {
+				running = true
+			}
+			if ok && entry.info.State == tache.StateRunning {
+				if elapsed := now.Sub(prev.at).Seconds(); elapsed > 0 && done >= prev.done {
+					// smooth the speed to avoid jumping between chunks
+					event.Speed = 0.5*(done-prev.done)/elapsed + 0.5*prev.event.Speed
+				}
+				if event.Speed > 0 && entry.info.TotalBytes > 0 {
+					event.ETA = int64((float64(entry.info.TotalBytes) - done) / event.Speed)
+				}
+			}
+			b.samples[key] = &taskSample{event: event, creatorID: entry.creatorID, done: done, at: now}
+			if ok && !taskInfoChanged(prev.event, event) {
+				continue
+			}
+			b.publish(event, entry.creatorID)
+		}
+	}
+	for key, s := range b.samples {
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		delete(b.samples, key)
+		s.event.Event = TaskEventRemove
+		s.event.Speed = 0
+		s.event.ETA = -1
+		b.publish(s.event, s.creatorID)
+	}
+	return running, true
+}
+
+func taskInfoChanged(prev, cur TaskEvent) bool {
+	return prev.State != cur.State || prev.Progress != cur.Progress || prev.Status != cur.Status ||
+		prev.Error != cur.Error || prev.TotalBytes != cur.TotalBytes || prev.Speed != cur.Speed
+}
+
+func (b *taskEventBus) publish(event TaskEvent, creatorID uint) {
+	for sub := range b.subs {
+		if sub.canSee(event.Type, creatorID) {
+			b.send(sub, event)
+		}
+	}
+}
+
+func newTaskSubscriber(c *gin.Context) (*taskSubscriber, bool) {
+	isAdmin, uid, ok := getUserInfo(c)
+	if !ok {
+		// if there is no bug, here is unreachable
+		common.ErrorStrResp(c, "user invalid", 401)
+		return nil, false
+	}
+	var types []string
+	if t := c.Query("type"); t != "" {
+		types = strings.Split(t, ",")
+	}
+	return &taskSubscriber{
+		isAdmin: isAdmin,
+		uid:     uid,
+		types:   types,
+		ch:      make(chan TaskEvent, taskEventBuffer),
+	}, true
+}
+
+// TaskEvents streams the changes of the tasks visible to the user as Server-Sent Events
+func TaskEvents(c *gin.Context) {
+	sub, ok := newTaskSubscriber(c)
+	if !ok {
+		return
+	}
+	taskBus.subscribe(sub)
+	defer taskBus.unsubscribe(sub)
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	ping := time.NewTicker(taskPingInterval)
+	defer ping.Stop()
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case event, ok := <-sub.ch:
+			if !ok {
+				return false
+			}
+			c.SSEvent("task", event)
+		case <-ping.C:
+			c.SSEvent("ping", time.Now().Unix())
+		}
+		return true
+	})
+}
+
+// TaskStreamToken returns a short-lived token opening the event streams of the user in the token
+// query, since browsers can't send the Authorization header with EventSource and WebSocket
+func TaskStreamToken(c *gin.Context) {
+	_, uid, ok := getUserInfo(c)
+	if !ok {
+		// if there is no bug, here is unreachable
+		common.ErrorStrResp(c, "user invalid", 401)
+		return
+	}
+	common.SuccessResp(c, gin.H{
+		"token":      sign.SignStream(uid, taskStreamTokenTTL),
+		"expires_in": int64(taskStreamTokenTTL.Seconds()),
+	})
+}
+
+var taskWsUpgrader = websocket.Upgrader{
+	CheckOrigin: checkTaskOrigin,
+}
+
+// checkTaskOrigin accepts the clients sending no Origin, the pages of the site itself and the
+// origins allowed by the CORS config, since the stream token may leak to other pages
+func checkTaskOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	if strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+	if site, err := url.Parse(conf.Conf.SiteURL); err == nil && site.Host != "" && strings.EqualFold(u.Host, site.Host) {
+		return true
+	}
+	for _, allowed := range conf.Conf.Cors.AllowOrigins {
+		if allowed == "*" || strings.EqualFold(strings.TrimSuffix(allowed, "/"), origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// TaskWebSocket streams the changes of the tasks visible to the user as JSON messages over a WebSocket
+func TaskWebSocket(c *gin.Context) {
+	sub, ok := newTaskSubscriber(c)
+	if !ok {
+		return
+	}
+	conn, err := taskWsUpgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		log.Debugf("failed upgrade task websocket: %+v", err)
+		return
+	}
+	defer conn.Close()
+	taskBus.subscribe(sub)
+	defer taskBus.unsubscribe(sub)
+	// the client doesn't send anything, reading only detects the close
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+	ping := time.NewTicker(taskPingInterval)
+	defer ping.Stop()
+	for {
+		select {
+		case <-closed:
+			return
+		case event, ok := <-sub.ch:
+			if !ok {
+				_ = conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "too slow"))
+				return
+			}
+			if err := conn.WriteJSON(event); err != nil {
+				return
+			}
+		case <-ping.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(10*time.Second)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// CountTasks counts the tasks of all managers by type and state for the metrics
+func CountTasks() map[string]map[tache.State]int {
+	counts := make(map[string]map[tache.State]int)
+	for _, source := range taskSources() {
+		states := make(map[tache.State]int)
+		for _, entry := range source.list() {
+			states[entry.info.State]++
+		}
+		counts[source.typ] = states
+	}
+	return counts
+}
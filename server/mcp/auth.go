@@ -8,6 +8,7 @@ import (
 	"strings"
 
 	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/metrics"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/op"
 	"github.com/alist-org/alist/v3/internal/setting"
@@ -33,7 +34,7 @@ func HTTPContextFunc(ctx context.Context, r *http.Request) context.Context {
 		return ctx
 	}
 
-	return context.WithValue(ctx, userKey, user)
+	return context.WithValue(ctx, userKey, user.WithAccess(metrics.ProtocolMCP, r.RemoteAddr))
 }
 
 func authenticateToken(token string) (*model.User, error) {
@@ -151,7 +152,7 @@ func checkManage(user *model.User, reqPath string, permBit uint) error {
 // UserContextFunc returns an HTTPContextFunc that injects a specific user (for STDIO mode).
 func userContextMiddleware(user *model.User) func(ctx context.Context) context.Context {
 	return func(ctx context.Context) context.Context {
-		return context.WithValue(ctx, userKey, user)
+		return context.WithValue(ctx, userKey, user.WithAccess(metrics.ProtocolMCP, ""))
 	}
 }
 
@@ -2,11 +2,14 @@ package mcp
 
 import (
 	"encoding/json"
+	"math"
 	"time"
 
 	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/task"
 	"github.com/alist-org/alist/v3/pkg/utils"
 	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/xhofe/tache"
 )
 
 type objJSON struct {
@@ -33,6 +36,71 @@ func objToJSON(obj model.Obj) objJSON {
 	return j
 }
 
+type objTreeJSON struct {
+	objJSON
+	Children []objTreeJSON `json:"children,omitempty"`
+}
+
+func objTreeToJSON(objs []model.ObjTree) []objTreeJSON {
+	if objs == nil {
+		return nil
+	}
+	ret := make([]objTreeJSON, 0, len(objs))
+	for _, obj := range objs {
+		ret = append(ret, objTreeJSON{
+			objJSON:  objToJSON(obj),
+			Children: objTreeToJSON(obj.GetChildren()),
+		})
+	}
+	return ret
+}
+
+type taskJSON struct {
+	ID         string      `json:"id"`
+	Type       string      `json:"type,omitempty"`
+	Name       string      `json:"name"`
+	Creator    string      `json:"creator"`
+	State      tache.State `json:"state"`
+	Status     string      `json:"status"`
+	Progress   float64     `json:"progress"`
+	StartTime  *time.Time  `json:"start_time"`
+	EndTime    *time.Time  `json:"end_time"`
+	TotalBytes int64       `json:"total_bytes"`
+	Error      string      `json:"error,omitempty"`
+}
+
+func taskToJSON(t task.TaskExtensionInfo) taskJSON {
+	j := taskJSON{
+		ID:         t.GetID(),
+		Name:       t.GetName(),
+		State:      t.GetState(),
+		Status:     t.GetStatus(),
+		Progress:   t.GetProgress(),
+		StartTime:  t.GetStartTime(),
+		EndTime:    t.GetEndTime(),
+		TotalBytes: t.GetTotalBytes(),
+	}
+	// if progress is NaN, set it to 100
+	if math.IsNaN(j.Progress) {
+		j.Progress = 100
+	}
+	if creator := t.GetCreator(); creator != nil {
+		j.Creator = creator.Username
+	}
+	if err := t.GetErr(); err != nil {
+		j.Error = err.Error()
+	}
+	return j
+}
+
+func taskInfosToJSON(tasks []task.TaskExtensionInfo) []taskJSON {
+	ret := make([]taskJSON, 0, len(tasks))
+	for _, t := range tasks {
+		ret = append(ret, taskToJSON(t))
+	}
+	return ret
+}
+
 func hashInfoToMap(hi utils.HashInfo) map[string]string {
 	m := make(map[string]string)
 	for ht, v := range hi.All() {
@@ -0,0 +1,149 @@
+package mcp
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/stream"
+	"github.com/alist-org/alist/v3/pkg/http_range"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/mark3labs/mcp-go/mcp"
+	mcpserver "github.com/mark3labs/mcp-go/server"
+)
+
+// maxResourceSize limits the size of files read as resources, larger files should be downloaded via fs_download_url
+const maxResourceSize = 10 * 1024 * 1024
+
+func registerResources(s *mcpserver.MCPServer) {
+	// alist://file/{path}
+	s.AddResourceTemplate(mcp.NewResourceTemplate("alist://file{+path}", "file",
+		mcp.WithTemplateDescription(fmt.Sprintf("Content of a file, at most %d bytes", maxResourceSize)),
+	), resourceHandlerWithAuth(handleFileResource))
+
+	// alist://readme/{path}
+	s.AddResourceTemplate(mcp.NewResourceTemplate("alist://readme{+path}", "readme",
+		mcp.WithTemplateDescription("Readme of a directory set in its metadata"),
+		mcp.WithTemplateMIMEType("text/markdown"),
+	), resourceHandlerWithAuth(handleReadmeResource))
+
+	// alist://header/{path}
+	s.AddResourceTemplate(mcp.NewResourceTemplate("alist://header{+path}", "header",
+		mcp.WithTemplateDescription("Header of a directory set in its metadata"),
+		mcp.WithTemplateMIMEType("text/markdown"),
+	), resourceHandlerWithAuth(handleHeaderResource))
+}
+
+// resourceHandlerWithAuth wraps a resource handler to require authentication.
+func resourceHandlerWithAuth(fn func(ctx context.Context, user *model.User, path string, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error)) mcpserver.ResourceTemplateHandlerFunc {
+	return func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		user, err := resolveUser(ctx)
+		if err != nil {
+			return nil, err
+		}
+		path, _ := request.Params.Arguments["path"].(string)
+		if path == "" {
+			path = "/"
+		}
+		return fn(ctx, user, path, request)
+	}
+}
+
+func handleFileResource(ctx context.Context, user *model.User, pathStr string, req mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	ctx, reqPath, err := buildFsContext(ctx, user, pathStr)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkAccess(user, reqPath); err != nil {
+		return nil, err
+	}
+
+	link, obj, err := fs.Link(ctx, reqPath, model.LinkArgs{})
+	if err != nil {
+		return nil, err
+	}
+	if obj.IsDir() {
+		return nil, fmt.Errorf("%s is a directory", pathStr)
+	}
+	if obj.GetSize() > maxResourceSize {
+		return nil, fmt.Errorf("file is too large (%d bytes), use fs_download_url instead", obj.GetSize())
+	}
+	ss, err := stream.NewSeekableStream(stream.FileStream{Obj: obj, Ctx: ctx}, link)
+	if err != nil {
+		return nil, err
+	}
+	defer ss.Close()
+	reader, err := ss.RangeRead(http_range.Range{Length: -1})
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(io.LimitReader(reader, maxResourceSize))
+	if err != nil {
+		return nil, err
+	}
+
+	mimeType := ss.GetMimetype()
+	if utils.GetFileType(obj.GetName()) == conf.TEXT || strings.HasPrefix(mimeType, "text/") {
+		return []mcp.ResourceContents{mcp.TextResourceContents{
+			URI:      req.Params.URI,
+			MIMEType: mimeType,
+			Text:     string(data),
+		}}, nil
+	}
+	return []mcp.ResourceContents{mcp.BlobResourceContents{
+		URI:      req.Params.URI,
+		MIMEType: mimeType,
+		Blob:     base64.StdEncoding.EncodeToString(data),
+	}}, nil
+}
+
+// dirMeta returns the nearest meta of the directory after checking the access of user.
+func dirMeta(user *model.User, pathStr string) (*model.Meta, string, error) {
+	reqPath, err := user.JoinPath(pathStr)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := checkAccess(user, reqPath); err != nil {
+		return nil, "", err
+	}
+	meta, _ := op.GetNearestMeta(reqPath)
+	return meta, reqPath, nil
+}
+
+func handleReadmeResource(ctx context.Context, user *model.User, pathStr string, req mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	meta, reqPath, err := dirMeta(user, pathStr)
+	if err != nil {
+		return nil, err
+	}
+	readme := ""
+	if meta != nil && (utils.PathEqual(meta.Path, reqPath) || meta.RSub) {
+		readme = meta.Readme
+	}
+	return []mcp.ResourceContents{mcp.TextResourceContents{
+		URI:      req.Params.URI,
+		MIMEType: "text/markdown",
+		Text:     readme,
+	}}, nil
+}
+
+func handleHeaderResource(ctx context.Context, user *model.User, pathStr string, req mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	meta, reqPath, err := dirMeta(user, pathStr)
+	if err != nil {
+		return nil, err
+	}
+	header := ""
+	if meta != nil && (utils.PathEqual(meta.Path, reqPath) || meta.HeaderSub) {
+		header = meta.Header
+	}
+	return []mcp.ResourceContents{mcp.TextResourceContents{
+		URI:      req.Params.URI,
+		MIMEType: "text/markdown",
+		Text:     header,
+	}}, nil
+}
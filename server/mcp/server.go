@@ -10,17 +10,24 @@ import (
 	mcpserver "github.com/mark3labs/mcp-go/server"
 )
 
-// NewServer creates an MCP server with all alist tools registered.
+// NewServer creates an MCP server with all alist tools and resources registered.
 func NewServer() *mcpserver.MCPServer {
 	s := mcpserver.NewMCPServer(
 		"alist",
 		conf.Version,
 		mcpserver.WithToolCapabilities(false),
+		mcpserver.WithResourceCapabilities(false, false),
 		mcpserver.WithRecovery(),
 	)
 	registerReadTools(s)
 	registerManageTools(s)
 	registerUploadTools(s)
+	registerArchiveTools(s)
+	registerShareTools(s)
+	registerTaskTools(s)
+	registerOfflineDownloadTools(s)
+	registerLabelTools(s)
+	registerResources(s)
 	return s
 }
 
@@ -0,0 +1,184 @@
+package mcp
+
+import (
+	"context"
+
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/task"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/mark3labs/mcp-go/mcp"
+	mcpserver "github.com/mark3labs/mcp-go/server"
+	"github.com/pkg/errors"
+)
+
+func registerArchiveTools(s *mcpserver.MCPServer) {
+	// archive_meta
+	s.AddTool(mcp.NewTool("archive_meta",
+		mcp.WithDescription("Get the comment, encryption state and directory tree of an archive"),
+		mcp.WithString("path", mcp.Required(), mcp.Description("Path to the archive file")),
+		mcp.WithString("archive_pass", mcp.Description("Password of the archive if it is encrypted")),
+		mcp.WithBoolean("refresh", mcp.Description("Force refresh from storage (default: false)")),
+	), toolHandlerWithAuth(handleArchiveMeta))
+
+	// archive_list
+	s.AddTool(mcp.NewTool("archive_list",
+		mcp.WithDescription("List the entries of a directory inside an archive"),
+		mcp.WithString("path", mcp.Required(), mcp.Description("Path to the archive file")),
+		mcp.WithString("inner_path", mcp.Description("Directory inside the archive (default: /)")),
+		mcp.WithString("archive_pass", mcp.Description("Password of the archive if it is encrypted")),
+		mcp.WithBoolean("refresh", mcp.Description("Force refresh from storage (default: false)")),
+	), toolHandlerWithAuth(handleArchiveList))
+
+	// archive_decompress
+	s.AddTool(mcp.NewTool("archive_decompress",
+		mcp.WithDescription("Extract archives into a directory, the extraction runs as a background task"),
+		mcp.WithString("src_dir", mcp.Required(), mcp.Description("Directory containing the archives")),
+		mcp.WithArray("names", mcp.Required(), mcp.Description("Names of the archives to extract")),
+		mcp.WithString("dst_dir", mcp.Required(), mcp.Description("Directory to extract into")),
+		mcp.WithString("inner_path", mcp.Description("Only extract this path inside the archives (default: /)")),
+		mcp.WithString("archive_pass", mcp.Description("Password of the archives if they are encrypted")),
+		mcp.WithBoolean("put_into_new_dir", mcp.Description("Extract each archive into a new directory named after it (default: false)")),
+	), toolHandlerWithAuth(handleArchiveDecompress))
+}
+
+// checkReadArchives checks if user can read the content of archives under the path.
+func checkReadArchives(user *model.User, reqPath string) error {
+	if err := checkAccess(user, reqPath); err != nil {
+		return err
+	}
+	perm := common.MergeRolePermissions(user, reqPath)
+	if !user.IsAdmin() && !common.HasPermission(perm, common.PermReadArchives) {
+		return errors.New("reading archives not permitted")
+	}
+	return nil
+}
+
+func archiveError(err error) (*mcp.CallToolResult, error) {
+	if errors.Is(err, errs.WrongArchivePassword) {
+		return toolError("wrong archive password")
+	}
+	return wrapError(err)
+}
+
+func handleArchiveMeta(ctx context.Context, user *model.User, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	pathStr, err := req.RequireString("path")
+	if err != nil {
+		return toolError("path is required")
+	}
+
+	ctx, reqPath, err := buildFsContext(ctx, user, pathStr)
+	if err != nil {
+		return wrapError(err)
+	}
+	if err := checkReadArchives(user, reqPath); err != nil {
+		return toolError(err.Error())
+	}
+
+	ret, err := fs.ArchiveMeta(ctx, reqPath, model.ArchiveMetaArgs{
+		ArchiveArgs: model.ArchiveArgs{Password: req.GetString("archive_pass", "")},
+		Refresh:     req.GetBool("refresh", false),
+	})
+	if err != nil {
+		return archiveError(err)
+	}
+
+	return jsonResult(map[string]interface{}{
+		"comment":   ret.GetComment(),
+		"encrypted": ret.IsEncrypted(),
+		"content":   objTreeToJSON(ret.GetTree()),
+	})
+}
+
+func handleArchiveList(ctx context.Context, user *model.User, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	pathStr, err := req.RequireString("path")
+	if err != nil {
+		return toolError("path is required")
+	}
+
+	ctx, reqPath, err := buildFsContext(ctx, user, pathStr)
+	if err != nil {
+		return wrapError(err)
+	}
+	if err := checkReadArchives(user, reqPath); err != nil {
+		return toolError(err.Error())
+	}
+
+	objs, err := fs.ArchiveList(ctx, reqPath, model.ArchiveListArgs{
+		ArchiveInnerArgs: model.ArchiveInnerArgs{
+			ArchiveArgs: model.ArchiveArgs{Password: req.GetString("archive_pass", "")},
+			InnerPath:   utils.FixAndCleanPath(req.GetString("inner_path", "/")),
+		},
+		Refresh: req.GetBool("refresh", false),
+	})
+	if err != nil {
+		return archiveError(err)
+	}
+
+	items := make([]objJSON, 0, len(objs))
+	for _, obj := range objs {
+		items = append(items, objToJSON(obj))
+	}
+	return jsonResult(map[string]interface{}{
+		"content": items,
+		"total":   len(items),
+	})
+}
+
+func handleArchiveDecompress(ctx context.Context, user *model.User, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	srcDirStr, err := req.RequireString("src_dir")
+	if err != nil {
+		return toolError("src_dir is required")
+	}
+	dstDirStr, err := req.RequireString("dst_dir")
+	if err != nil {
+		return toolError("dst_dir is required")
+	}
+	names := getStringArray(req, "names")
+	if len(names) == 0 {
+		return toolError("names is required and must not be empty")
+	}
+
+	srcDir, err := user.JoinPath(srcDirStr)
+	if err != nil {
+		return wrapError(err)
+	}
+	dstDir, err := user.JoinPath(dstDirStr)
+	if err != nil {
+		return wrapError(err)
+	}
+	if err := checkManage(user, srcDir, common.PermDecompress); err != nil {
+		return toolError(err.Error())
+	}
+	if err := checkManage(user, dstDir, common.PermWrite); err != nil {
+		return toolError(err.Error())
+	}
+
+	ctx = context.WithValue(ctx, "user", user)
+	args := model.ArchiveDecompressArgs{
+		ArchiveInnerArgs: model.ArchiveInnerArgs{
+			ArchiveArgs: model.ArchiveArgs{Password: req.GetString("archive_pass", "")},
+			InnerPath:   utils.FixAndCleanPath(req.GetString("inner_path", "/")),
+		},
+		PutIntoNewDir: req.GetBool("put_into_new_dir", false),
+	}
+	tasks := make([]task.TaskExtensionInfo, 0, len(names))
+	for _, name := range names {
+		srcPath, err := utils.JoinUnderBase(srcDir, name)
+		if err != nil {
+			return toolErrorf("invalid name %q: %s", name, err.Error())
+		}
+		t, err := fs.ArchiveDecompress(ctx, srcPath, dstDir, args)
+		if err != nil {
+			return archiveError(err)
+		}
+		if t != nil {
+			tasks = append(tasks, t)
+		}
+	}
+	return jsonResult(map[string]interface{}{
+		"tasks": taskInfosToJSON(tasks),
+	})
+}
@@ -0,0 +1,234 @@
+package mcp
+
+import (
+	"context"
+	stdpath "path"
+	"strconv"
+	"strings"
+
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/mark3labs/mcp-go/mcp"
+	mcpserver "github.com/mark3labs/mcp-go/server"
+)
+
+func registerLabelTools(s *mcpserver.MCPServer) {
+	// label_list
+	s.AddTool(mcp.NewTool("label_list",
+		mcp.WithDescription("List all labels"),
+	), toolHandlerWithAuth(handleLabelList))
+
+	// label_create
+	s.AddTool(mcp.NewTool("label_create",
+		mcp.WithDescription("Create a label (admin only)"),
+		mcp.WithString("name", mcp.Required(), mcp.Description("Name of the label")),
+		mcp.WithString("description", mcp.Description("Description of the label")),
+		mcp.WithString("bg_color", mcp.Description("Background color of the label, e.g. #ff0000")),
+	), toolHandlerWithAuth(handleLabelCreate))
+
+	// label_update
+	s.AddTool(mcp.NewTool("label_update",
+		mcp.WithDescription("Update a label (admin only), omitted fields are kept"),
+		mcp.WithNumber("id", mcp.Required(), mcp.Description("ID of the label")),
+		mcp.WithString("name", mcp.Description("New name of the label")),
+		mcp.WithString("description", mcp.Description("New description of the label")),
+		mcp.WithString("bg_color", mcp.Description("New background color of the label")),
+	), toolHandlerWithAuth(handleLabelUpdate))
+
+	// label_delete
+	s.AddTool(mcp.NewTool("label_delete",
+		mcp.WithDescription("Delete a label that is not bound to any file (admin only)"),
+		mcp.WithNumber("id", mcp.Required(), mcp.Description("ID of the label")),
+	), toolHandlerWithAuth(handleLabelDelete))
+
+	// label_set
+	s.AddTool(mcp.NewTool("label_set",
+		mcp.WithDescription("Set the labels of a file, replacing the current ones"),
+		mcp.WithString("path", mcp.Required(), mcp.Description("Path to the file")),
+		mcp.WithArray("label_ids", mcp.Description("IDs of the labels, empty to remove all labels")),
+	), toolHandlerWithAuth(handleLabelSet))
+
+	// label_files
+	s.AddTool(mcp.NewTool("label_files",
+		mcp.WithDescription("List the files labeled by the current user with any of the labels"),
+		mcp.WithArray("label_ids", mcp.Required(), mcp.Description("IDs of the labels")),
+	), toolHandlerWithAuth(handleLabelFiles))
+}
+
+// getUintArray extracts an array of ids from tool request arguments, numbers and numeric strings are accepted.
+func getUintArray(req mcp.CallToolRequest, name string) ([]uint64, bool) {
+	val, ok := req.GetArguments()[name]
+	if !ok || val == nil {
+		return nil, true
+	}
+	arr, ok := val.([]interface{})
+	if !ok {
+		return nil, false
+	}
+	result := make([]uint64, 0, len(arr))
+	for _, v := range arr {
+		switch v := v.(type) {
+		case float64:
+			if v < 0 || v != float64(uint64(v)) {
+				return nil, false
+			}
+			result = append(result, uint64(v))
+		case string:
+			id, err := strconv.ParseUint(strings.TrimSpace(v), 10, 64)
+			if err != nil {
+				return nil, false
+			}
+			result = append(result, id)
+		default:
+			return nil, false
+		}
+	}
+	return result, true
+}
+
+func handleLabelList(ctx context.Context, user *model.User, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	if err := checkAccess(user, user.BasePath); err != nil {
+		return toolError(err.Error())
+	}
+	labels, total, err := db.GetLabels(1, model.MaxInt)
+	if err != nil {
+		return wrapError(err)
+	}
+	return jsonResult(map[string]interface{}{
+		"content": labels,
+		"total":   total,
+	})
+}
+
+func handleLabelCreate(ctx context.Context, user *model.User, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	if !user.IsAdmin() {
+		return toolError("only admins can manage labels")
+	}
+	name, err := req.RequireString("name")
+	if err != nil || strings.TrimSpace(name) == "" {
+		return toolError("name is required")
+	}
+	if db.GetLabelByName(name) {
+		return toolErrorf("label %q already exists", name)
+	}
+	id, err := db.CreateLabel(model.Label{
+		Name:        name,
+		Description: req.GetString("description", ""),
+		BgColor:     req.GetString("bg_color", ""),
+	})
+	if err != nil {
+		return wrapError(err)
+	}
+	return jsonResult(map[string]interface{}{
+		"id": id,
+	})
+}
+
+func handleLabelUpdate(ctx context.Context, user *model.User, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	if !user.IsAdmin() {
+		return toolError("only admins can manage labels")
+	}
+	id, err := req.RequireFloat("id")
+	if err != nil {
+		return toolError("id is required")
+	}
+	label, err := db.GetLabelById(uint(id))
+	if err != nil {
+		return toolError("label not found")
+	}
+	if name := req.GetString("name", ""); name != "" && name != label.Name {
+		if db.GetLabelByName(name) {
+			return toolErrorf("label %q already exists", name)
+		}
+		label.Name = name
+	}
+	label.Description = req.GetString("description", label.Description)
+	label.BgColor = req.GetString("bg_color", label.BgColor)
+	label, err = db.UpdateLabel(label)
+	if err != nil {
+		return wrapError(err)
+	}
+	return jsonResult(label)
+}
+
+func handleLabelDelete(ctx context.Context, user *model.User, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	if !user.IsAdmin() {
+		return toolError("only admins can manage labels")
+	}
+	id, err := req.RequireFloat("id")
+	if err != nil {
+		return toolError("id is required")
+	}
+	if err := op.DeleteLabelById(ctx, uint(id), user.ID); err != nil {
+		return wrapError(err)
+	}
+	return textResult("label deleted")
+}
+
+func handleLabelSet(ctx context.Context, user *model.User, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	pathStr, err := req.RequireString("path")
+	if err != nil {
+		return toolError("path is required")
+	}
+	labelIDs, ok := getUintArray(req, "label_ids")
+	if !ok {
+		return toolError("label_ids must be an array of label IDs")
+	}
+
+	ctx, reqPath, err := buildFsContext(ctx, user, pathStr)
+	if err != nil {
+		return wrapError(err)
+	}
+	if err := checkManage(user, reqPath, common.PermMCPManage); err != nil {
+		return toolError(err.Error())
+	}
+
+	obj, err := fs.Get(ctx, reqPath, &fs.GetArgs{})
+	if err != nil {
+		return wrapError(err)
+	}
+	if obj.IsDir() {
+		return toolError("labels can't be set on directories")
+	}
+	if err := op.CreateLabelFileBinDing(op.CreateLabelFileBinDingReq{
+		Id:          obj.GetID(),
+		Path:        stdpath.Dir(reqPath),
+		Name:        obj.GetName(),
+		Size:        obj.GetSize(),
+		IsDir:       obj.IsDir(),
+		Modified:    obj.ModTime(),
+		Created:     obj.CreateTime(),
+		Type:        utils.GetObjType(obj.GetName(), obj.IsDir()),
+		HashInfoStr: obj.GetHash().String(),
+		LabelIDs:    labelIDs,
+	}, user.ID); err != nil {
+		return wrapError(err)
+	}
+	return textResult("labels set successfully")
+}
+
+func handleLabelFiles(ctx context.Context, user *model.User, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	labelIDs, ok := getUintArray(req, "label_ids")
+	if !ok || len(labelIDs) == 0 {
+		return toolError("label_ids is required and must not be empty")
+	}
+	if err := checkAccess(user, user.BasePath); err != nil {
+		return toolError(err.Error())
+	}
+	ids := make([]string, 0, len(labelIDs))
+	for _, id := range labelIDs {
+		ids = append(ids, strconv.FormatUint(id, 10))
+	}
+	files, err := op.GetFileByLabel(user.ID, strings.Join(ids, ","))
+	if err != nil {
+		return wrapError(err)
+	}
+	return jsonResult(map[string]interface{}{
+		"content": files,
+		"total":   len(files),
+	})
+}
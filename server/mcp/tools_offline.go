@@ -0,0 +1,75 @@
+package mcp
+
+import (
+	"context"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/offline_download/tool"
+	"github.com/alist-org/alist/v3/internal/task"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/mark3labs/mcp-go/mcp"
+	mcpserver "github.com/mark3labs/mcp-go/server"
+)
+
+func registerOfflineDownloadTools(s *mcpserver.MCPServer) {
+	// offline_download_add
+	s.AddTool(mcp.NewTool("offline_download_add",
+		mcp.WithDescription("Download URLs into a directory with an offline download tool, the download runs as a background task"),
+		mcp.WithArray("urls", mcp.Required(), mcp.Description("URLs to download (http(s), magnet, ...)")),
+		mcp.WithString("path", mcp.Required(), mcp.Description("Directory to save the files into")),
+		mcp.WithString("tool", mcp.Required(), mcp.Description("Offline download tool, e.g. SimpleHttp, aria2, qBittorrent, Transmission")),
+		mcp.WithString("delete_policy", mcp.Description("delete_on_upload_succeed, delete_on_upload_failed, delete_never or delete_always (default: delete_on_upload_succeed)")),
+	), toolHandlerWithAuth(handleOfflineDownloadAdd))
+}
+
+func handleOfflineDownloadAdd(ctx context.Context, user *model.User, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	urls := getStringArray(req, "urls")
+	if len(urls) == 0 {
+		return toolError("urls is required and must not be empty")
+	}
+	pathStr, err := req.RequireString("path")
+	if err != nil {
+		return toolError("path is required")
+	}
+	toolName, err := req.RequireString("tool")
+	if err != nil {
+		return toolError("tool is required")
+	}
+	if _, err := tool.Tools.Get(toolName); err != nil {
+		return toolErrorf("unknown tool %q, available: %v", toolName, tool.Tools.Names())
+	}
+	deletePolicy := tool.DeletePolicy(req.GetString("delete_policy", string(tool.DeleteOnUploadSucceed)))
+	switch deletePolicy {
+	case tool.DeleteOnUploadSucceed, tool.DeleteOnUploadFailed, tool.DeleteNever, tool.DeleteAlways:
+	default:
+		return toolErrorf("invalid delete_policy: %s", deletePolicy)
+	}
+
+	reqPath, err := user.JoinPath(pathStr)
+	if err != nil {
+		return wrapError(err)
+	}
+	if err := checkManage(user, reqPath, common.PermAddOfflineDownload); err != nil {
+		return toolError(err.Error())
+	}
+
+	ctx = context.WithValue(ctx, "user", user)
+	tasks := make([]task.TaskExtensionInfo, 0, len(urls))
+	for _, url := range urls {
+		t, err := tool.AddURL(ctx, &tool.AddURLArgs{
+			URL:          url,
+			DstDirPath:   reqPath,
+			Tool:         toolName,
+			DeletePolicy: deletePolicy,
+		})
+		if err != nil {
+			return wrapError(err)
+		}
+		if t != nil {
+			tasks = append(tasks, t)
+		}
+	}
+	return jsonResult(map[string]interface{}{
+		"tasks": taskInfosToJSON(tasks),
+	})
+}
@@ -0,0 +1,192 @@
+package mcp
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	shareauth "github.com/alist-org/alist/v3/internal/share"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/mark3labs/mcp-go/mcp"
+	mcpserver "github.com/mark3labs/mcp-go/server"
+)
+
+func registerShareTools(s *mcpserver.MCPServer) {
+	// share_create
+	s.AddTool(mcp.NewTool("share_create",
+		mcp.WithDescription("Create a public share link for a file or directory"),
+		mcp.WithString("path", mcp.Required(), mcp.Description("Path of the file or directory to share")),
+		mcp.WithString("name", mcp.Description("Display name of the share (default: name of the file)")),
+		mcp.WithString("password", mcp.Description("Password required to open the share")),
+		mcp.WithNumber("expire_hours", mcp.Description("Hours until the share expires, 0 means never (default: 0)")),
+		mcp.WithNumber("access_limit", mcp.Description("Maximum number of accesses, 0 means unlimited (default: 0)")),
+		mcp.WithBoolean("allow_preview", mcp.Description("Allow previewing files (default: true)")),
+		mcp.WithBoolean("allow_download", mcp.Description("Allow downloading files (default: true)")),
+	), toolHandlerWithAuth(handleShareCreate))
+
+	// share_list
+	s.AddTool(mcp.NewTool("share_list",
+		mcp.WithDescription("List the shares created by the current user"),
+		mcp.WithNumber("page", mcp.Description("Page number (default: 1)")),
+		mcp.WithNumber("per_page", mcp.Description("Items per page (default: 30)")),
+	), toolHandlerWithAuth(handleShareList))
+
+	// share_disable
+	s.AddTool(mcp.NewTool("share_disable",
+		mcp.WithDescription("Disable a share created by the current user"),
+		mcp.WithString("share_id", mcp.Required(), mcp.Description("ID of the share")),
+	), toolHandlerWithAuth(handleShareDisable))
+}
+
+type shareJSON struct {
+	ShareID       string     `json:"share_id"`
+	Name          string     `json:"name"`
+	Path          string     `json:"path"`
+	IsDir         bool       `json:"is_dir"`
+	HasPassword   bool       `json:"has_password"`
+	AccessLimit   int64      `json:"access_limit"`
+	AccessCount   int64      `json:"access_count"`
+	AllowPreview  bool       `json:"allow_preview"`
+	AllowDownload bool       `json:"allow_download"`
+	Enabled       bool       `json:"enabled"`
+	ExpiresAt     *time.Time `json:"expires_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+	URL           string     `json:"url"`
+}
+
+func shareToJSON(user *model.User, share *model.Share) shareJSON {
+	// show the path relative to the base path of the user like the other tools do
+	path := strings.TrimPrefix(share.RootPath, strings.TrimSuffix(user.BasePath, "/"))
+	if path == "" {
+		path = "/"
+	}
+	return shareJSON{
+		ShareID:       share.ShareID,
+		Name:          share.Name,
+		Path:          path,
+		IsDir:         share.IsDir,
+		HasPassword:   share.HasPassword(),
+		AccessLimit:   share.EffectiveAccessLimit(),
+		AccessCount:   share.AccessCount,
+		AllowPreview:  share.AllowPreview,
+		AllowDownload: share.AllowDownload,
+		Enabled:       share.Enabled,
+		ExpiresAt:     share.ExpiresAt,
+		CreatedAt:     share.CreatedAt,
+		URL:           common.GetApiUrl(nil) + "/s/" + share.ShareID,
+	}
+}
+
+func handleShareCreate(ctx context.Context, user *model.User, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	if user.IsGuest() {
+		return toolError("guests can't create shares")
+	}
+	pathStr, err := req.RequireString("path")
+	if err != nil {
+		return toolError("path is required")
+	}
+	expireHours := intParam(req, "expire_hours", 0)
+	accessLimit := intParam(req, "access_limit", 0)
+	if expireHours < 0 || accessLimit < 0 {
+		return toolError("expire_hours and access_limit must be 0 or greater")
+	}
+
+	ctx, reqPath, err := buildFsContext(ctx, user, pathStr)
+	if err != nil {
+		return wrapError(err)
+	}
+	if err := checkManage(user, reqPath, common.PermMCPManage); err != nil {
+		return toolError(err.Error())
+	}
+	if !common.CanReadPathByRole(user, reqPath) {
+		return toolError("permission denied")
+	}
+
+	obj, err := fs.Get(ctx, reqPath, &fs.GetArgs{})
+	if err != nil {
+		return wrapError(err)
+	}
+	shareID, err := shareauth.GenerateID()
+	if err != nil {
+		return wrapError(err)
+	}
+	name := strings.TrimSpace(req.GetString("name", ""))
+	if name == "" {
+		name = obj.GetName()
+	}
+	share := &model.Share{
+		ShareID:       shareID,
+		CreatorID:     user.ID,
+		Name:          name,
+		RootPath:      reqPath,
+		IsDir:         obj.IsDir(),
+		BurnAfterRead: accessLimit == 1,
+		AccessLimit:   int64(accessLimit),
+		AllowPreview:  req.GetBool("allow_preview", true),
+		AllowDownload: req.GetBool("allow_download", true),
+		Enabled:       true,
+	}
+	if expireHours > 0 {
+		expiresAt := time.Now().Add(time.Duration(expireHours) * time.Hour)
+		share.ExpiresAt = &expiresAt
+	}
+	if password := req.GetString("password", ""); password != "" {
+		shareauth.SetPassword(share, password)
+	}
+	if err := db.CreateShare(share); err != nil {
+		return wrapError(err)
+	}
+	return jsonResult(shareToJSON(user, share))
+}
+
+func handleShareList(ctx context.Context, user *model.User, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	if user.IsGuest() {
+		return toolError("guests can't list shares")
+	}
+	if err := checkAccess(user, user.BasePath); err != nil {
+		return toolError(err.Error())
+	}
+	pageReq := model.PageReq{
+		Page:    intParam(req, "page", 1),
+		PerPage: intParam(req, "per_page", 30),
+	}
+	pageReq.Validate()
+
+	shares, total, err := db.GetSharesByOwner(user.ID, op.GetAdminGroupIDsByUserID(user.ID), pageReq.Page, pageReq.PerPage)
+	if err != nil {
+		return wrapError(err)
+	}
+	items := make([]shareJSON, 0, len(shares))
+	for i := range shares {
+		items = append(items, shareToJSON(user, &shares[i]))
+	}
+	return jsonResult(map[string]interface{}{
+		"content": items,
+		"total":   total,
+	})
+}
+
+func handleShareDisable(ctx context.Context, user *model.User, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	if user.IsGuest() {
+		return toolError("guests can't manage shares")
+	}
+	shareID, err := req.RequireString("share_id")
+	if err != nil {
+		return toolError("share_id is required")
+	}
+	if err := checkManage(user, user.BasePath, common.PermMCPManage); err != nil {
+		return toolError(err.Error())
+	}
+	share, err := db.GetShareByOwnerAndShareID(user.ID, op.GetAdminGroupIDsByUserID(user.ID), shareID)
+	if err != nil {
+		return toolError("share not found")
+	}
+	if err := db.DisableShareByShareID(share.CreatorID, share.ShareID); err != nil {
+		return wrapError(err)
+	}
+	return textResult("share disabled")
+}
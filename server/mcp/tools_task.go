@@ -0,0 +1,217 @@
+package mcp
+
+import (
+	"context"
+	"strings"
+
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/offline_download/tool"
+	"github.com/alist-org/alist/v3/internal/task"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/mark3labs/mcp-go/mcp"
+	mcpserver "github.com/mark3labs/mcp-go/server"
+	"github.com/xhofe/tache"
+)
+
+// taskManager hides the task type of a task.Manager so that all managers can be handled together
+type taskManager struct {
+	getByID   func(id string) (task.TaskExtensionInfo, bool)
+	getByCond func(cond func(task.TaskExtensionInfo) bool) []task.TaskExtensionInfo
+	cancel    func(id string)
+	retry     func(id string)
+}
+
+func newTaskManager[T task.TaskExtensionInfo](m task.Manager[T]) taskManager {
+	return taskManager{
+		getByID: func(id string) (task.TaskExtensionInfo, bool) {
+			return m.GetByID(id)
+		},
+		getByCond: func(cond func(task.TaskExtensionInfo) bool) []task.TaskExtensionInfo {
+			tasks := m.GetByCondition(func(t T) bool { return cond(t) })
+			return utils.MustSliceConvert(tasks, func(t T) task.TaskExtensionInfo { return t })
+		},
+		cancel: m.Cancel,
+		retry:  m.Retry,
+	}
+}
+
+// taskTypes are the same as the routes of /api/task
+var taskTypes = []string{
+	"upload",
+	"copy",
+	"offline_download",
+	"offline_download_transfer",
+	"s3_transition",
+	"hash",
+	"dedupe",
+	"decompress",
+	"decompress_upload",
+}
+
+// getTaskManager is resolved on each call because the managers are created at boot
+func getTaskManager(typ string) (taskManager, bool) {
+	switch typ {
+	case "upload":
+		return newTaskManager(fs.UploadTaskManager), true
+	case "copy":
+		return newTaskManager(fs.CopyTaskManager), true
+	case "offline_download":
+		return newTaskManager(tool.DownloadTaskManager), true
+	case "offline_download_transfer":
+		return newTaskManager(tool.TransferTaskManager), true
+	case "s3_transition":
+		return newTaskManager(fs.S3TransitionTaskManager), true
+	case "hash":
+		return newTaskManager(fs.HashTaskManager), true
+	case "dedupe":
+		return newTaskManager(fs.DedupeTaskManager), true
+	case "decompress":
+		return newTaskManager(fs.ArchiveDownloadTaskManager), true
+	case "decompress_upload":
+		return newTaskManager(fs.ArchiveContentUploadTaskManager), true
+	}
+	return taskManager{}, false
+}
+
+var (
+	undoneTaskStates = []tache.State{tache.StatePending, tache.StateRunning, tache.StateCanceling,
+		tache.StateErrored, tache.StateFailing, tache.StateWaitingRetry, tache.StateBeforeRetry}
+	doneTaskStates = []tache.State{tache.StateCanceled, tache.StateFailed, tache.StateSucceeded}
+)
+
+func registerTaskTools(s *mcpserver.MCPServer) {
+	typeDesc := "Task type, one of: " + strings.Join(taskTypes, ", ")
+
+	// task_list
+	s.AddTool(mcp.NewTool("task_list",
+		mcp.WithDescription("List background tasks created by the current user (all tasks for admins)"),
+		mcp.WithString("type", mcp.Description(typeDesc+" (default: all types)")),
+		mcp.WithString("state", mcp.Description("undone, done or all (default: all)")),
+	), toolHandlerWithAuth(handleTaskList))
+
+	// task_get
+	s.AddTool(mcp.NewTool("task_get",
+		mcp.WithDescription("Get the progress and state of a task"),
+		mcp.WithString("type", mcp.Required(), mcp.Description(typeDesc)),
+		mcp.WithString("id", mcp.Required(), mcp.Description("Task ID")),
+	), toolHandlerWithAuth(handleTaskGet))
+
+	// task_cancel
+	s.AddTool(mcp.NewTool("task_cancel",
+		mcp.WithDescription("Cancel a running or pending task"),
+		mcp.WithString("type", mcp.Required(), mcp.Description(typeDesc)),
+		mcp.WithString("id", mcp.Required(), mcp.Description("Task ID")),
+	), toolHandlerWithAuth(handleTaskCancel))
+
+	// task_retry
+	s.AddTool(mcp.NewTool("task_retry",
+		mcp.WithDescription("Retry a failed task"),
+		mcp.WithString("type", mcp.Required(), mcp.Description(typeDesc)),
+		mcp.WithString("id", mcp.Required(), mcp.Description("Task ID")),
+	), toolHandlerWithAuth(handleTaskRetry))
+}
+
+// canSeeTask checks if the task is visible to user, only admins can see the tasks of others.
+func canSeeTask(user *model.User, t task.TaskExtensionInfo) bool {
+	if user.IsAdmin() {
+		return true
+	}
+	creator := t.GetCreator()
+	return creator != nil && creator.ID == user.ID
+}
+
+func handleTaskList(ctx context.Context, user *model.User, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	if err := checkAccess(user, user.BasePath); err != nil {
+		return toolError(err.Error())
+	}
+	types := taskTypes
+	if typ := req.GetString("type", ""); typ != "" {
+		if _, ok := getTaskManager(typ); !ok {
+			return toolErrorf("unknown task type: %s", typ)
+		}
+		types = []string{typ}
+	}
+	var states []tache.State
+	switch state := req.GetString("state", "all"); state {
+	case "undone":
+		states = undoneTaskStates
+	case "done":
+		states = doneTaskStates
+	case "all":
+	default:
+		return toolErrorf("unknown state: %s", state)
+	}
+
+	items := make([]taskJSON, 0)
+	for _, typ := range types {
+		m, _ := getTaskManager(typ)
+		tasks := m.getByCond(func(t task.TaskExtensionInfo) bool {
+			return canSeeTask(user, t) && (states == nil || utils.SliceContains(states, t.GetState()))
+		})
+		for _, t := range tasks {
+			j := taskToJSON(t)
+			j.Type = typ
+			items = append(items, j)
+		}
+	}
+	return jsonResult(map[string]interface{}{
+		"content": items,
+		"total":   len(items),
+	})
+}
+
+// getTask finds the task for the request, tasks of other users are reported as not found
+func getTask(user *model.User, req mcp.CallToolRequest) (taskManager, task.TaskExtensionInfo, *mcp.CallToolResult) {
+	if err := checkAccess(user, user.BasePath); err != nil {
+		return taskManager{}, nil, mcp.NewToolResultError(err.Error())
+	}
+	typ, err := req.RequireString("type")
+	if err != nil {
+		return taskManager{}, nil, mcp.NewToolResultError("type is required")
+	}
+	id, err := req.RequireString("id")
+	if err != nil {
+		return taskManager{}, nil, mcp.NewToolResultError("id is required")
+	}
+	m, ok := getTaskManager(typ)
+	if !ok {
+		return taskManager{}, nil, mcp.NewToolResultError("unknown task type: " + typ)
+	}
+	t, ok := m.getByID(id)
+	if !ok || !canSeeTask(user, t) {
+		return taskManager{}, nil, mcp.NewToolResultError("task not found")
+	}
+	return m, t, nil
+}
+
+func handleTaskGet(ctx context.Context, user *model.User, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	_, t, errResult := getTask(user, req)
+	if errResult != nil {
+		return errResult, nil
+	}
+	j := taskToJSON(t)
+	j.Type = req.GetString("type", "")
+	return jsonResult(j)
+}
+
+func handleTaskCancel(ctx context.Context, user *model.User, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	m, t, errResult := getTask(user, req)
+	if errResult != nil {
+		return errResult, nil
+	}
+	m.cancel(t.GetID())
+	return textResult("task canceled")
+}
+
+func handleTaskRetry(ctx context.Context, user *model.User, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	m, t, errResult := getTask(user, req)
+	if errResult != nil {
+		return errResult, nil
+	}
+	if t.GetState() != tache.StateFailed && t.GetState() != tache.StateCanceled {
+		return toolError("only failed or canceled tasks can be retried")
+	}
+	m.retry(t.GetID())
+	return textResult("task retried")
+}
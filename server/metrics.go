@@ -0,0 +1,23 @@
+package server
+
+import (
+	"strings"
+
+	"github.com/alist-org/alist/v3/internal/metrics"
+	"github.com/alist-org/alist/v3/server/handles"
+	"github.com/alist-org/alist/v3/server/middlewares"
+	"github.com/gin-gonic/gin"
+)
+
+func _metrics(g *gin.RouterGroup) {
+	metrics.RegisterTaskCounter(handles.CountTasks)
+	g.GET("/metrics", metricsAuth, middlewares.AuthAdmin, gin.WrapH(metrics.Handler()))
+}
+
+// metricsAuth also accepts the token as a bearer token, which is how prometheus sends it
+func metricsAuth(c *gin.Context) {
+	if token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer "); ok {
+		c.Request.Header.Set("Authorization", token)
+	}
+	middlewares.Auth(c)
+}
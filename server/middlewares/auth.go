@@ -8,9 +8,11 @@ import (
 	"github.com/alist-org/alist/v3/internal/conf"
 	"github.com/alist-org/alist/v3/internal/device"
 	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/metrics"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/op"
 	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/alist-org/alist/v3/internal/sign"
 	"github.com/alist-org/alist/v3/pkg/utils"
 	"github.com/alist-org/alist/v3/server/common"
 	"github.com/gin-gonic/gin"
@@ -102,6 +104,47 @@ func Auth(c *gin.Context) {
 	c.Next()
 }
 
+// StreamAuth is Auth for the event streams, which browsers open without the Authorization
+// header. Without the header it accepts a short-lived token made by sign.SignStream in the query.
+func StreamAuth(c *gin.Context) {
+	token := c.Query("token")
+	if token == "" || c.GetHeader("Authorization") != "" {
+		Auth(c)
+		return
+	}
+	userID, err := sign.VerifyStream(token)
+	if err != nil {
+		common.ErrorResp(c, err, 401)
+		c.Abort()
+		return
+	}
+	user, err := op.GetUserById(userID)
+	if err != nil {
+		common.ErrorResp(c, err, 401)
+		c.Abort()
+		return
+	}
+	if user.Disabled {
+		common.ErrorStrResp(c, "Current user is disabled, replace please", 401)
+		c.Abort()
+		return
+	}
+	if len(user.Role) > 0 {
+		roles, err := op.GetRolesByUserID(user.ID)
+		if err != nil {
+			common.ErrorStrResp(c, fmt.Sprintf("Fail to load roles: %v", err), 500)
+			c.Abort()
+			return
+		}
+		user.RolesDetail = roles
+	}
+	if !HandleSession(c, user) {
+		return
+	}
+	log.Debugf("use stream token: %+v", user)
+	c.Next()
+}
+
 // HandleSession verifies device sessions and stores context values.
 func HandleSession(c *gin.Context, user *model.User) bool {
 	clientID := c.GetHeader("Client-Id")
@@ -121,7 +164,7 @@ func HandleSession(c *gin.Context, user *model.User) bool {
 		return false
 	}
 	c.Set("device_key", key)
-	c.Set("user", user)
+	c.Set("user", user.WithAccess(metrics.ProtocolHTTP, c.ClientIP()))
 	return true
 }
 
@@ -134,7 +177,7 @@ func Authn(c *gin.Context) {
 			c.Abort()
 			return
 		}
-		c.Set("user", admin)
+		c.Set("user", admin.WithAccess(metrics.ProtocolHTTP, c.ClientIP()))
 		log.Debugf("use admin token: %+v", admin)
 		c.Next()
 		return
@@ -146,7 +189,7 @@ func Authn(c *gin.Context) {
 			c.Abort()
 			return
 		}
-		c.Set("user", guest)
+		c.Set("user", guest.WithAccess(metrics.ProtocolHTTP, c.ClientIP()))
 		log.Debugf("use empty token: %+v", guest)
 		c.Next()
 		return
@@ -187,7 +230,7 @@ func Authn(c *gin.Context) {
 		}
 		user.RolesDetail = roles
 	}
-	c.Set("user", user)
+	c.Set("user", user.WithAccess(metrics.ProtocolHTTP, c.ClientIP()))
 	log.Debugf("use login token: %+v", user)
 	c.Next()
 }
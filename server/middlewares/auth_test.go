@@ -0,0 +1,47 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/sign"
+	"github.com/gin-gonic/gin"
+)
+
+func TestStreamAuth(t *testing.T) {
+	user := &model.User{Username: "streamer", Password: "password", Role: model.Roles{}}
+	if err := op.CreateUser(user); err != nil {
+		t.Fatal(err)
+	}
+	r := gin.New()
+	r.GET("/events", StreamAuth, func(c *gin.Context) {
+		c.String(200, c.MustGet("user").(*model.User).Username)
+	})
+	get := func(token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/events?"+url.Values{"token": {token}}.Encode(), nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := get(sign.SignStream(user.ID, time.Minute)); w.Code != 200 || w.Body.String() != "streamer" {
+		t.Fatalf("valid token rejected: %d %s", w.Code, w.Body.String())
+	}
+	if code := respCode(get(sign.SignStream(user.ID, -time.Minute))); code != 401 {
+		t.Errorf("expired token must be rejected, got %d", code)
+	}
+	// the id of the user is signed too
+	token := sign.SignStream(user.ID, time.Minute)
+	if code := respCode(get("1" + token)); code != 401 {
+		t.Errorf("token of another user must be rejected, got %d", code)
+	}
+	// a download sign doesn't open the streams
+	if code := respCode(get(sign.SignUser("/", user.ID))); code != 401 {
+		t.Errorf("user sign must be rejected, got %d", code)
+	}
+}
@@ -1,11 +1,16 @@
 package middlewares
 
 import (
+	"net/http"
 	"net/url"
 	"strings"
+	"time"
 
 	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/metrics"
 	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/alist-org/alist/v3/internal/sign"
 
 	"github.com/alist-org/alist/v3/internal/errs"
 	"github.com/alist-org/alist/v3/internal/model"
@@ -28,6 +33,21 @@ func Down(verifyFunc func(string, string) error) func(c *gin.Context) {
 			}
 		}
 		c.Set("meta", meta)
+		// one-off download links carry their own sign and restrictions
+		if token := c.Query("link"); token != "" {
+			if verifyDownloadLink(c, rawPath, token) {
+				c.Next()
+			}
+			return
+		}
+		// signs made for a user act on behalf of the user, e.g. for storages
+		// with per user keys
+		if s := strings.TrimSuffix(c.Query("sign"), "/"); sign.IsUserSign(s) {
+			if verifyUserSign(c, rawPath, s) {
+				c.Next()
+			}
+			return
+		}
 		// verify sign
 		if needSign(meta, rawPath) {
 			s := c.Query("sign")
@@ -42,6 +62,103 @@ func Down(verifyFunc func(string, string) error) func(c *gin.Context) {
 	}
 }
 
+// downloadSessionWindow is how long the requests of a client for a link
+// count as a single download from the first one, e.g. the ranges of a resumed
+// download. The window is fixed, the requests don't extend it.
+const downloadSessionWindow = 10 * time.Minute
+
+// verifyDownloadLink checks the link against its sign and restrictions, and
+// that its creator can still read the file, then counts the download once
+// per session of a client, whatever the ranges requested.
+func verifyDownloadLink(c *gin.Context, rawPath, token string) bool {
+	if err := sign.VerifyLink(rawPath, token, strings.TrimSuffix(c.Query("sign"), "/")); err != nil {
+		common.ErrorResp(c, err, 401)
+		c.Abort()
+		return false
+	}
+	link, err := db.GetDownloadLinkByToken(token)
+	if err != nil || link.Path != rawPath {
+		common.ErrorStrResp(c, "link is revoked", 401)
+		c.Abort()
+		return false
+	}
+	now := time.Now()
+	if link.IsExpired(now) || !link.CreatedAt.Add(common.DownloadLinkMaxTTL()).After(now) || link.IsExhausted() {
+		common.ErrorStrResp(c, "link is expired", http.StatusGone)
+		c.Abort()
+		return false
+	}
+	if !link.AllowsIP(c.ClientIP()) || !link.AllowsReferer(c.Request.Referer()) {
+		common.ErrorStrResp(c, "you are not allowed to use this link", 403)
+		c.Abort()
+		return false
+	}
+	// the link acts on behalf of its creator, e.g. for storages with per user keys
+	creator, err := op.GetUserById(link.CreatorID)
+	if err != nil || creator.Disabled {
+		common.ErrorStrResp(c, "link is revoked", 401)
+		c.Abort()
+		return false
+	}
+	creator = creator.WithAccess(metrics.ProtocolHTTP, c.ClientIP())
+	meta, _ := c.Get("meta")
+	m, _ := meta.(*model.Meta)
+	password := ""
+	if m != nil && link.MatchesPassword(m.Password) {
+		password = m.Password
+	}
+	if !common.CheckPathLimitWithRoles(creator, rawPath) || !common.CanAccessWithRoles(creator, m, rawPath, password) {
+		common.ErrorStrResp(c, "link is revoked", 401)
+		c.Abort()
+		return false
+	}
+	c.Set("user", creator)
+	// the file is always proxied, a redirect would escape the restrictions
+	c.Set("download_link", true)
+	if c.Request.Method != http.MethodGet {
+		return true
+	}
+	client := utils.HashData(utils.SHA256, []byte(c.ClientIP()+"|"+c.Request.UserAgent()))
+	inSession, err := db.InDownloadSession(token, client, now)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		c.Abort()
+		return false
+	}
+	if inSession {
+		return true
+	}
+	ok, err := db.UseDownloadLink(token, client, now.Add(downloadSessionWindow))
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		c.Abort()
+		return false
+	}
+	if !ok {
+		common.ErrorStrResp(c, "link is expired", http.StatusGone)
+		c.Abort()
+		return false
+	}
+	return true
+}
+
+func verifyUserSign(c *gin.Context, rawPath, s string) bool {
+	userID, err := sign.VerifyUser(rawPath, s)
+	if err != nil {
+		common.ErrorResp(c, err, 401)
+		c.Abort()
+		return false
+	}
+	user, err := op.GetUserById(userID)
+	if err != nil || user.Disabled {
+		common.ErrorStrResp(c, "the user of the sign is not allowed", 401)
+		c.Abort()
+		return false
+	}
+	c.Set("user", user.WithAccess(metrics.ProtocolHTTP, c.ClientIP()))
+	return true
+}
+
 func parsePath(path string) string {
 	path, _ = url.PathUnescape(path)
 	return utils.FixAndCleanPath(path)
@@ -0,0 +1,127 @@
+package middlewares
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/db/dbtest"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/sign"
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	dbtest.Init()
+	gin.SetMode(gin.TestMode)
+}
+
+func downloadLinkRouter() *gin.Engine {
+	r := gin.New()
+	r.GET("/d/*path", Down(sign.Verify), func(c *gin.Context) {
+		user := c.MustGet("user").(*model.User)
+		c.String(200, "%s %v", user.Username, c.GetBool("download_link"))
+	})
+	return r
+}
+
+func newDownloadLink(t *testing.T, creatorID uint, path string, maxDownloads int64, createdAt time.Time) *model.DownloadLink {
+	l := &model.DownloadLink{
+		Token:        fmt.Sprintf("token%d", time.Now().UnixNano()),
+		CreatorID:    creatorID,
+		Path:         path,
+		MaxDownloads: maxDownloads,
+		ExpiresAt:    time.Now().Add(time.Hour),
+		CreatedAt:    createdAt,
+	}
+	if err := db.CreateDownloadLink(l); err != nil {
+		t.Fatal(err)
+	}
+	return l
+}
+
+func getLink(r *gin.Engine, l *model.DownloadLink, ip, rangeHeader string) *httptest.ResponseRecorder {
+	q := url.Values{"link": {l.Token}, "sign": {sign.SignLink(l.Path, l.Token, l.ExpiresAt)}}
+	req := httptest.NewRequest(http.MethodGet, "/d"+l.Path+"?"+q.Encode(), nil)
+	req.RemoteAddr = ip + ":40000"
+	if rangeHeader != "" {
+		req.Header.Set("Range", rangeHeader)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+// respCode is the code of an error response, the http status otherwise
+func respCode(w *httptest.ResponseRecorder) int {
+	var resp struct {
+		Code int `json:"code"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || resp.Code == 0 {
+		return w.Code
+	}
+	return resp.Code
+}
+
+func TestDownloadLink(t *testing.T) {
+	role := &model.Role{Name: "link_files", PermissionScopes: []model.PermissionEntry{{Path: "/files"}}}
+	if err := op.CreateRole(role); err != nil {
+		t.Fatal(err)
+	}
+	creator := &model.User{Username: "link_creator", Password: "pass", BasePath: "/", Role: model.Roles{int(role.ID)}}
+	if err := db.CreateUser(creator); err != nil {
+		t.Fatal(err)
+	}
+	r := downloadLinkRouter()
+
+	once := newDownloadLink(t, creator.ID, "/files/a.bin", 1, time.Now())
+	// a download starting past the first byte is counted too
+	if w := getLink(r, once, "10.0.0.1", "bytes=1-"); w.Code != 200 || w.Body.String() != "link_creator true" {
+		t.Fatalf("expected the first download to pass through the proxy, got %d %s", w.Code, w.Body)
+	}
+	// the other ranges of the same client belong to the same download
+	if w := getLink(r, once, "10.0.0.1", "bytes=0-9,20-29"); w.Code != 200 {
+		t.Errorf("expected the session to go on, got %d %s", w.Code, w.Body)
+	}
+	if w := getLink(r, once, "10.0.0.2", "bytes=5-"); respCode(w) != http.StatusGone {
+		t.Errorf("expected another client to be refused, got %d %s", w.Code, w.Body)
+	}
+	if l, _ := db.GetDownloadLinkByToken(once.Token); l.Downloads != 1 {
+		t.Errorf("expected a single download, got %d", l.Downloads)
+	}
+
+	// the window of a session starts with the download and isn't extended
+	twice := newDownloadLink(t, creator.ID, "/files/a.bin", 2, time.Now())
+	getLink(r, twice, "10.0.0.3", "")
+	var session model.DownloadSession
+	db.GetDb().Where("token = ?", twice.Token).First(&session)
+	getLink(r, twice, "10.0.0.3", "bytes=5-")
+	var after model.DownloadSession
+	db.GetDb().Where("token = ?", twice.Token).First(&after)
+	if !after.ExpiresAt.Equal(session.ExpiresAt) {
+		t.Errorf("expected the session to keep its end, got %v then %v", session.ExpiresAt, after.ExpiresAt)
+	}
+	db.GetDb().Model(&model.DownloadSession{}).Where("token = ?", twice.Token).Update("expires_at", time.Now().Add(-time.Second))
+	getLink(r, twice, "10.0.0.3", "bytes=5-")
+	if l, _ := db.GetDownloadLinkByToken(twice.Token); l.Downloads != 2 {
+		t.Errorf("expected a request past the window to count again, got %d", l.Downloads)
+	}
+
+	// the creator can't read the file anymore
+	unreadable := newDownloadLink(t, creator.ID, "/other/b.bin", 0, time.Now())
+	if w := getLink(r, unreadable, "10.0.0.1", ""); respCode(w) != 401 {
+		t.Errorf("expected a link to a file the creator can't read to be refused, got %d %s", w.Code, w.Body)
+	}
+
+	// links older than the max ttl are expired whatever their expiration
+	old := newDownloadLink(t, creator.ID, "/files/a.bin", 0, time.Now().Add(-31*24*time.Hour))
+	if w := getLink(r, old, "10.0.0.1", ""); respCode(w) != http.StatusGone {
+		t.Errorf("expected a link past the max ttl to be expired, got %d %s", w.Code, w.Body)
+	}
+}
@@ -0,0 +1,17 @@
+package middlewares
+
+import (
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/metrics"
+	"github.com/gin-gonic/gin"
+)
+
+// Metrics records the count and latency of the requests of protocol
+func Metrics(protocol string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+		metrics.ObserveHTTPRequest(protocol, c.Writer.Status(), start)
+	}
+}
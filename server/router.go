@@ -4,6 +4,7 @@ import (
 	"github.com/alist-org/alist/v3/cmd/flags"
 	"github.com/alist-org/alist/v3/internal/conf"
 	"github.com/alist-org/alist/v3/internal/message"
+	"github.com/alist-org/alist/v3/internal/metrics"
 	"github.com/alist-org/alist/v3/internal/sign"
 	"github.com/alist-org/alist/v3/internal/stream"
 	"github.com/alist-org/alist/v3/pkg/utils"
@@ -38,8 +39,11 @@ func Init(e *gin.Engine) {
 	if conf.Conf.MaxConnections > 0 {
 		g.Use(middlewares.MaxAllowed(conf.Conf.MaxConnections))
 	}
-	WebDav(g.Group("/dav"))
-	S3(g.Group("/s3"))
+	WebDav(g.Group("/dav", middlewares.Metrics(metrics.ProtocolWebDAV)))
+	S3(g.Group("/s3", middlewares.Metrics(metrics.ProtocolS3)))
+	WebDavShare(g.Group("/dav-share/:share_id", middlewares.Metrics(metrics.ProtocolWebDAV)))
+	g.Use(middlewares.Metrics(metrics.ProtocolHTTP))
+	_metrics(g)
 
 	downloadLimiter := middlewares.DownloadRateLimiter(stream.ClientDownloadLimit)
 	signCheck := middlewares.Down(sign.Verify)
@@ -57,6 +61,17 @@ func Init(e *gin.Engine) {
 	g.GET("/sp/:share_id/*path", downloadLimiter, handles.ShareProxy)
 	g.HEAD("/sp/:share_id", handles.ShareProxy)
 	g.HEAD("/sp/:share_id/*path", handles.ShareProxy)
+
+	// oidc provider
+	g.GET("/.well-known/openid-configuration", handles.OIDCDiscovery)
+	oauth2 := g.Group("/oauth2")
+	oauth2.GET("/authorize", handles.OIDCAuthorize)
+	oauth2.POST("/authorize", handles.OIDCAuthorizeSubmit)
+	oauth2.POST("/token", handles.OIDCToken)
+	oauth2.GET("/userinfo", handles.OIDCUserInfo)
+	oauth2.POST("/userinfo", handles.OIDCUserInfo)
+	oauth2.GET("/jwks", handles.OIDCJWKS)
+
 	archiveSignCheck := middlewares.Down(sign.VerifyArchive)
 	g.GET("/ad/*path", archiveSignCheck, downloadLimiter, handles.ArchiveDown)
 	g.GET("/ap/*path", archiveSignCheck, downloadLimiter, handles.ArchiveProxy)
@@ -107,6 +122,7 @@ func Init(e *gin.Engine) {
 	public.POST("/share/auth", handles.AuthPublicShare)
 	public.POST("/share/list", handles.ListPublicShare)
 	public.POST("/share/get", handles.GetPublicShare)
+	public.PUT("/share/upload", handles.UploadPublicShare)
 
 	_fs(auth.Group("/fs"))
 	share := auth.Group("/share", middlewares.AuthNotGuest)
@@ -115,7 +131,30 @@ func Init(e *gin.Engine) {
 	share.POST("/disable", handles.DisableShare)
 	share.GET("/list", handles.ListShares)
 	share.POST("/delete", handles.DeleteShare)
+	share.GET("/access_log", handles.ListShareAccessLog)
+
+	internalShare := auth.Group("/internal_share", middlewares.AuthNotGuest)
+	internalShare.POST("/create", handles.CreateInternalShare)
+	internalShare.GET("/list", handles.ListInternalShares)
+	internalShare.POST("/delete", handles.DeleteInternalShare)
+	internalShare.GET("/inbox", handles.InternalShareInbox)
+	cryptKey := auth.Group("/crypt", middlewares.AuthNotGuest)
+	cryptKey.GET("/status", handles.CryptStatus)
+	cryptKey.POST("/setup", handles.SetupCryptKey)
+	cryptKey.POST("/unlock", handles.UnlockCryptKey)
+	cryptKey.POST("/lock", handles.LockCryptKey)
+	cryptKey.POST("/rotate", handles.RotateCryptKey)
+	downloadLink := auth.Group("/download_link", middlewares.AuthNotGuest)
+	downloadLink.POST("/create", handles.CreateDownloadLink)
+	downloadLink.GET("/list", handles.ListDownloadLinks)
+	downloadLink.POST("/revoke", handles.RevokeDownloadLink)
+	group := auth.Group("/group", middlewares.AuthNotGuest)
+	group.GET("/list", handles.ListMyGroups)
+	group.GET("/members", handles.ListGroupMembers)
+	group.POST("/member/save", handles.SaveGroupMember)
+	group.POST("/member/remove", handles.RemoveGroupMember)
 	_task(auth.Group("/task", middlewares.AuthNotGuest))
+	handles.SetupTaskStreamRoute(api.Group("/task", middlewares.StreamAuth, middlewares.AuthNotGuest))
 	_label(auth.Group("/label"))
 	_labelFileBinding(auth.Group("/label_file_binding"))
 	admin(auth.Group("/admin", middlewares.AuthAdmin))
@@ -143,6 +182,7 @@ func admin(g *gin.RouterGroup) {
 	user.POST("/cancel_2fa", handles.Cancel2FAById)
 	user.POST("/delete", handles.DeleteUser)
 	user.POST("/del_cache", handles.DelUserCache)
+	user.POST("/role_sync/dry_run", handles.DryRunRoleSync)
 	user.GET("/sshkey/list", handles.ListPublicKeys)
 	user.POST("/sshkey/delete", handles.DeletePublicKey)
 
@@ -152,6 +192,14 @@ func admin(g *gin.RouterGroup) {
 	role.POST("/create", handles.CreateRole)
 	role.POST("/update", handles.UpdateRole)
 	role.POST("/delete", handles.DeleteRole)
+	role.POST("/explain", handles.ExplainPermission)
+
+	group := g.Group("/group")
+	group.GET("/list", handles.ListGroups)
+	group.GET("/get", handles.GetGroup)
+	group.POST("/create", handles.CreateGroup)
+	group.POST("/update", handles.UpdateGroup)
+	group.POST("/delete", handles.DeleteGroup)
 
 	storage := g.Group("/storage")
 	storage.GET("/list", handles.ListStorages)
@@ -163,6 +211,16 @@ func admin(g *gin.RouterGroup) {
 	storage.POST("/disable", handles.DisableStorage)
 	storage.POST("/load_all", handles.LoadAllStorages)
 
+	hashCatalog := g.Group("/hash_catalog")
+	hashCatalog.POST("/build", handles.BuildHashCatalog)
+	hashCatalog.POST("/clear", handles.ClearHashCatalog)
+
+	crypt := g.Group("/crypt")
+	crypt.GET("/holders", handles.ListCryptMountHolders)
+	crypt.POST("/init", handles.InitCryptMount)
+	crypt.POST("/grant", handles.GrantCryptMount)
+	crypt.POST("/revoke", handles.RevokeCryptMount)
+
 	driver := g.Group("/driver")
 	driver.GET("/list", handles.ListDriverInfo)
 	driver.GET("/names", handles.ListDriverNames)
@@ -186,6 +244,10 @@ func admin(g *gin.RouterGroup) {
 	setting.POST("/stop_frp", handles.StopFRP)
 	setting.GET("/frp_runtime", handles.GetFRPRuntime)
 
+	backup := g.Group("/backup")
+	backup.POST("/export", handles.ExportBackup)
+	backup.POST("/import", handles.ImportBackup)
+
 	// retain /admin/task API to ensure compatibility with legacy automation scripts
 	_task(g.Group("/task"))
 
@@ -216,6 +278,14 @@ func admin(g *gin.RouterGroup) {
 	session.GET("/list", handles.ListSessions)
 	session.POST("/evict", handles.EvictSession)
 
+	oauthClient := g.Group("/oauth_client")
+	oauthClient.GET("/list", handles.ListOAuthClients)
+	oauthClient.GET("/get", handles.GetOAuthClient)
+	oauthClient.POST("/create", handles.CreateOAuthClient)
+	oauthClient.POST("/update", handles.UpdateOAuthClient)
+	oauthClient.POST("/reset_secret", handles.ResetOAuthClientSecret)
+	oauthClient.POST("/delete", handles.DeleteOAuthClient)
+
 }
 
 func _fs(g *gin.RouterGroup) {
@@ -234,6 +304,9 @@ func _fs(g *gin.RouterGroup) {
 	g.POST("/copy", handles.FsCopy)
 	g.POST("/remove", handles.FsRemove)
 	g.POST("/remove_empty_directory", handles.FsRemoveEmptyDirectory)
+	g.POST("/dedupe", handles.FsDedupe)
+	g.GET("/dedupe/report", handles.FsDedupeReport)
+	g.POST("/dedupe/apply", handles.FsDedupeApply)
 	uploadLimiter := middlewares.UploadRateLimiter(stream.ClientUploadLimit)
 	g.PUT("/put", middlewares.FsUp, uploadLimiter, handles.FsStream)
 	g.PUT("/form", middlewares.FsUp, uploadLimiter, handles.FsForm)
@@ -273,5 +346,5 @@ func Cors(r *gin.Engine) {
 
 func InitS3(e *gin.Engine) {
 	Cors(e)
-	S3Server(e.Group("/"))
+	S3Server(e.Group("/", middlewares.Metrics(metrics.ProtocolS3)))
 }
@@ -2,8 +2,10 @@ package server
 
 import (
 	"context"
+	"fmt"
 	"github.com/KirCute/sftpd-alist"
 	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/metrics"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/op"
 	"github.com/alist-org/alist/v3/internal/setting"
@@ -13,6 +15,7 @@ import (
 	"github.com/alist-org/alist/v3/server/sftp"
 	"github.com/pkg/errors"
 	"golang.org/x/crypto/ssh"
+	"io"
 	"net/http"
 	"time"
 )
@@ -56,16 +59,31 @@ func (d *SftpDriver) GetConfig() *sftpd.Config {
 }
 
 func (d *SftpDriver) GetFileSystem(sc *ssh.ServerConn) (sftpd.FileSystem, error) {
+	return d.newDriverAdapter(sc)
+}
+
+func (d *SftpDriver) Exec(sc *ssh.ServerConn, command string, stdin io.Reader, stdout, stderr io.Writer) uint32 {
+	fs, err := d.newDriverAdapter(sc)
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "%v\n", err)
+		return 1
+	}
+	utils.Log.Infof("[SFTP] %s(%s) executes: %s", sc.User(), sc.RemoteAddr().String(), command)
+	return fs.Exec(command, stdin, stdout, stderr)
+}
+
+func (d *SftpDriver) newDriverAdapter(sc *ssh.ServerConn) (*sftp.DriverAdapter, error) {
 	userObj, err := op.GetUserByName(sc.User())
 	if err != nil {
 		return nil, err
 	}
+	userObj = userObj.WithAccess(metrics.ProtocolSFTP, sc.RemoteAddr().String())
 	ctx := context.Background()
 	ctx = context.WithValue(ctx, "user", userObj)
 	ctx = context.WithValue(ctx, "meta_pass", "")
 	ctx = context.WithValue(ctx, "client_ip", sc.RemoteAddr().String())
 	ctx = context.WithValue(ctx, "proxy_header", d.proxyHeader)
-	return &sftp.DriverAdapter{FtpDriver: ftp.NewAferoAdapter(ctx)}, nil
+	return &sftp.DriverAdapter{FtpDriver: ftp.NewAferoAdapter(ctx, metrics.ProtocolSFTP)}, nil
 }
 
 func (d *SftpDriver) Close() {
@@ -79,6 +97,7 @@ func (d *SftpDriver) NoClientAuth(conn ssh.ConnMetadata) (*ssh.Permissions, erro
 	if err != nil {
 		return nil, err
 	}
+	guest = guest.WithAccess(metrics.ProtocolSFTP, conn.RemoteAddr().String())
 	permGuest := common.MergeRolePermissions(guest, guest.BasePath)
 	if guest.Disabled || !common.HasPermission(permGuest, common.PermFTPAccess) {
 		return nil, errors.New("user is not allowed to access via SFTP")
@@ -91,6 +110,7 @@ func (d *SftpDriver) PasswordAuth(conn ssh.ConnMetadata, password []byte) (*ssh.
 	if err != nil {
 		return nil, err
 	}
+	userObj = userObj.WithAccess(metrics.ProtocolSFTP, conn.RemoteAddr().String())
 	perm := common.MergeRolePermissions(userObj, userObj.BasePath)
 	if userObj.Disabled || !common.HasPermission(perm, common.PermFTPAccess) {
 		return nil, errors.New("user is not allowed to access via SFTP")
@@ -107,6 +127,7 @@ func (d *SftpDriver) PublicKeyAuth(conn ssh.ConnMetadata, key ssh.PublicKey) (*s
 	if err != nil {
 		return nil, err
 	}
+	userObj = userObj.WithAccess(metrics.ProtocolSFTP, conn.RemoteAddr().String())
 	perm := common.MergeRolePermissions(userObj, userObj.BasePath)
 	if userObj.Disabled || !common.HasPermission(perm, common.PermFTPAccess) {
 		return nil, errors.New("user is not allowed to access via SFTP")
@@ -142,3 +163,5 @@ func (d *SftpDriver) AuthLogCallback(conn ssh.ConnMetadata, method string, err e
 func (d *SftpDriver) GetBanner(_ ssh.ConnMetadata) string {
 	return setting.GetStr(conf.Announcement)
 }
+
+var _ sftp.Driver = (*SftpDriver)(nil)
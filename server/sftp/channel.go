@@ -0,0 +1,272 @@
+package sftp
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/KirCute/sftpd-alist"
+	"github.com/KirCute/sftpd-alist/binp"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/ssh"
+)
+
+// maxPacketLength is the largest packet sftpd.ServeChannel accepts, the size of its read buffer
+const maxPacketLength = 64 * 1024
+
+var errInvalidHandle = errors.New("invalid handle")
+
+// extendedFS is a sftpd.FileSystem which also handles the extensions served by extChannel
+type extendedFS interface {
+	sftpd.FileSystem
+	// PosixRename replaces new if it exists instead of failing like SSH_FXP_RENAME does
+	PosixRename(old, new string) error
+	StatVFS(name string) (*StatVFS, error)
+	// CheckFile returns the hash of the file computed by the first supported algorithm in algs,
+	// together with the name of the algorithm
+	CheckFile(name string, algs []string) (string, []byte, error)
+}
+
+// extChannel serves the packets sftpd.ServeChannel doesn't support: the version advertising
+// the extensions, symlink creation and the extended requests. Other packets are passed through.
+// Read never returns bytes past the current packet, so ServeChannel has replied to every earlier
+// packet when one is served here, and the replies of both never interleave.
+type extChannel struct {
+	ssh.Channel
+	fs  extendedFS
+	r   *bufio.Reader
+	log sftpd.DebugLogger
+	// pending is the part of the current packet held in memory, remain is the part left in r
+	pending []byte
+	remain  int
+	// opening are the paths of OPEN and OPENDIR requests by id, until their replies are written
+	opening map[uint32]string
+	// handles are the paths of the open handles, used by fstatvfs and check-file-handle
+	handles map[string]string
+	// skip is the rest of the packet being written that isn't inspected
+	skip int
+}
+
+func newExtChannel(c ssh.Channel, fs extendedFS, debugf sftpd.DebugLogger) *extChannel {
+	return &extChannel{
+		Channel: c,
+		fs:      fs,
+		r:       bufio.NewReaderSize(c, maxPacketLength),
+		log:     debugf,
+		opening: make(map[uint32]string),
+		handles: make(map[string]string),
+	}
+}
+
+func (c *extChannel) Read(b []byte) (int, error) {
+	for len(c.pending) == 0 && c.remain == 0 {
+		if err := c.next(); err != nil {
+			return 0, err
+		}
+	}
+	if len(c.pending) > 0 {
+		n := copy(b, c.pending)
+		c.pending = c.pending[n:]
+		return n, nil
+	}
+	if len(b) > c.remain {
+		b = b[:c.remain]
+	}
+	n, err := c.r.Read(b)
+	c.remain -= n
+	return n, err
+}
+
+// next reads the header of the next packet, and the whole packet if it's served here or tracked
+func (c *extChannel) next() error {
+	header := make([]byte, 5)
+	if _, err := io.ReadFull(c.r, header); err != nil {
+		return err
+	}
+	length := int(binary.BigEndian.Uint32(header))
+	op := header[4]
+	if length < 1 {
+		return errors.Errorf("invalid length %d of packet %d", length, op)
+	}
+	switch op {
+	case SSH_FXP_INIT, SSH_FXP_SYMLINK, SSH_FXP_EXTENDED, SSH_FXP_OPEN, SSH_FXP_OPENDIR, SSH_FXP_CLOSE:
+	default:
+		c.pending = header
+		c.remain = length - 1
+		return nil
+	}
+	if length > maxPacketLength {
+		return errors.Errorf("invalid length %d of packet %d", length, op)
+	}
+	body := make([]byte, length-1)
+	if _, err := io.ReadFull(c.r, body); err != nil {
+		return err
+	}
+	var id uint32
+	var name string
+	switch op {
+	case SSH_FXP_OPEN, SSH_FXP_OPENDIR:
+		if binp.NewParser(body).B32(&id).B32String(&name) != nil {
+			c.opening[id] = name
+		}
+	case SSH_FXP_CLOSE:
+		if binp.NewParser(body).B32(&id).B32String(&name) != nil {
+			delete(c.handles, name)
+		}
+	default:
+		return c.serve(op, body)
+	}
+	c.pending = append(header, body...)
+	return nil
+}
+
+func (c *extChannel) serve(op byte, body []byte) error {
+	p := binp.NewParser(body)
+	var id uint32
+	switch op {
+	case SSH_FXP_INIT:
+		var l binp.Len
+		o := binp.Out().LenB32(&l).LenStart(&l).Byte(SSH_FXP_VERSION).B32(3)
+		o.B32String("posix-rename@openssh.com").B32String("1")
+		o.B32String("statvfs@openssh.com").B32String("2")
+		o.B32String("fstatvfs@openssh.com").B32String("2")
+		o.B32String("hardlink@openssh.com").B32String("1")
+		o.B32String("check-file-name").B32String("1")
+		o.B32String("check-file-handle").B32String("1")
+		o.LenDone(&l)
+		return c.reply(o.Out())
+	case SSH_FXP_SYMLINK:
+		var target, link string
+		// OpenSSH sends the target path first, which is reversed from the draft,
+		// and all the common clients follow OpenSSH
+		if err := p.B32(&id).B32String(&target).B32String(&link).End(); err != nil {
+			return err
+		}
+		c.log("Symlink id=%d link=%s target=%s\n", id, link, target)
+		return c.status(id, c.fs.CreateLink(link, target, LINK_SYMBOLIC))
+	}
+	var name string
+	if p = p.B32(&id).B32String(&name); p == nil {
+		return errors.New("invalid extended request")
+	}
+	c.log("Extended id=%d name=%s\n", id, name)
+	switch name {
+	case "posix-rename@openssh.com":
+		var oldName, newName string
+		if err := p.B32String(&oldName).B32String(&newName).End(); err != nil {
+			return err
+		}
+		return c.status(id, c.fs.PosixRename(oldName, newName))
+	case "hardlink@openssh.com":
+		var oldName, newName string
+		if err := p.B32String(&oldName).B32String(&newName).End(); err != nil {
+			return err
+		}
+		return c.status(id, c.fs.CreateLink(newName, oldName, 0))
+	case "statvfs@openssh.com", "fstatvfs@openssh.com":
+		var path string
+		if err := p.B32String(&path).End(); err != nil {
+			return err
+		}
+		if name == "fstatvfs@openssh.com" {
+			var ok bool
+			if path, ok = c.handles[path]; !ok {
+				return c.status(id, errInvalidHandle)
+			}
+		}
+		st, err := c.fs.StatVFS(path)
+		if err != nil {
+			return c.status(id, err)
+		}
+		var l binp.Len
+		o := binp.Out().LenB32(&l).LenStart(&l).Byte(SSH_FXP_EXTENDED_REPLY).B32(id)
+		o.B64(st.Bsize).B64(st.Frsize).B64(st.Blocks).B64(st.Bfree).B64(st.Bavail)
+		o.B64(st.Files).B64(st.Ffree).B64(st.Favail).B64(st.Fsid).B64(st.Flag).B64(st.Namemax)
+		o.LenDone(&l)
+		return c.reply(o.Out())
+	case "check-file-name", "check-file-handle":
+		var path, algs string
+		var offset, length uint64
+		var blockSize uint32
+		if err := p.B32String(&path).B32String(&algs).B64(&offset).B64(&length).B32(&blockSize).End(); err != nil {
+			return err
+		}
+		if name == "check-file-handle" {
+			var ok bool
+			if path, ok = c.handles[path]; !ok {
+				return c.status(id, errInvalidHandle)
+			}
+		}
+		if offset != 0 || length != 0 || blockSize != 0 {
+			// hashes of ranges or blocks are not supported
+			return c.status(id, errs.NotSupport)
+		}
+		alg, hash, err := c.fs.CheckFile(path, strings.Split(algs, ","))
+		if err != nil {
+			return c.status(id, err)
+		}
+		var l binp.Len
+		o := binp.Out().LenB32(&l).LenStart(&l).Byte(SSH_FXP_EXTENDED_REPLY).B32(id)
+		o.B32String("check-file").B32String(alg).Bytes(hash)
+		o.LenDone(&l)
+		return c.reply(o.Out())
+	}
+	return c.status(id, errs.NotSupport)
+}
+
+// status replies with the status code of err, mapped like sftpd does
+func (c *extChannel) status(id uint32, err error) error {
+	code := uint32(SSH_FX_FAILURE)
+	switch {
+	case err == nil:
+		code = SSH_FX_OK
+	case err == io.EOF:
+		code = SSH_FX_EOF
+	case os.IsPermission(err):
+		code = SSH_FX_PERMISSION_DENIED
+	case os.IsNotExist(err):
+		code = SSH_FX_NO_SUCH_FILE
+	case errors.Is(err, errs.NotSupport):
+		code = SSH_FX_OP_UNSUPPORTED
+	}
+	if err != nil {
+		c.log("Sending error: %v\n", err)
+	}
+	return c.reply(binp.Out().B32(1 + 4 + 4 + 4 + 4).Byte(SSH_FXP_STATUS).B32(id).B32(code).B32(0).B32(0).Out())
+}
+
+func (c *extChannel) reply(b []byte) error {
+	_, err := c.Channel.Write(b)
+	return err
+}
+
+// Write remembers the handles sent for OPEN and OPENDIR requests. ServeChannel writes each packet
+// in one piece, except for the data of SSH_FXP_DATA which follows its header.
+func (c *extChannel) Write(b []byte) (int, error) {
+	for rest := b; len(rest) > 0; {
+		if c.skip > 0 {
+			n := min(c.skip, len(rest))
+			c.skip -= n
+			rest = rest[n:]
+			continue
+		}
+		var length, id uint32
+		var op byte
+		p := binp.NewParser(rest).B32(&length).Byte(&op).B32(&id)
+		if p == nil {
+			break
+		}
+		if name, ok := c.opening[id]; ok {
+			delete(c.opening, id)
+			var handle string
+			if op == SSH_FXP_HANDLE && p.B32String(&handle) != nil {
+				c.handles[handle] = name
+			}
+		}
+		c.skip = 4 + int(length)
+	}
+	return c.Channel.Write(b)
+}
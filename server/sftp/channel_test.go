@@ -0,0 +1,205 @@
+package sftp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"testing"
+
+	"github.com/KirCute/sftpd-alist"
+	"github.com/KirCute/sftpd-alist/binp"
+	"golang.org/x/crypto/ssh"
+)
+
+// pipeChannel is the server side of a channel, the client writes to in and reads from out
+type pipeChannel struct {
+	in  *io.PipeReader
+	out *io.PipeWriter
+}
+
+func (c pipeChannel) Read(b []byte) (int, error)  { return c.in.Read(b) }
+func (c pipeChannel) Write(b []byte) (int, error) { return c.out.Write(b) }
+func (c pipeChannel) Close() error                { _ = c.in.Close(); return c.out.Close() }
+func (c pipeChannel) CloseWrite() error           { return c.out.Close() }
+func (c pipeChannel) Stderr() io.ReadWriter       { return nil }
+func (c pipeChannel) SendRequest(string, bool, []byte) (bool, error) {
+	return false, nil
+}
+
+var _ ssh.Channel = pipeChannel{}
+
+type extFS struct {
+	sftpd.EmptyFS
+	links   [][3]any
+	renames [][2]string
+	statted []string
+}
+
+func (fs *extFS) CreateLink(path, target string, flags uint32) error {
+	fs.links = append(fs.links, [3]any{path, target, flags})
+	return nil
+}
+
+func (fs *extFS) PosixRename(old, new string) error {
+	fs.renames = append(fs.renames, [2]string{old, new})
+	return nil
+}
+
+func (fs *extFS) StatVFS(name string) (*StatVFS, error) {
+	fs.statted = append(fs.statted, name)
+	return &StatVFS{Bsize: 4096, Blocks: 100, Bfree: 40, Namemax: 255}, nil
+}
+
+func (fs *extFS) CheckFile(_ string, algs []string) (string, []byte, error) {
+	return algs[0], []byte{0xab, 0xcd}, nil
+}
+
+type sftpClient struct {
+	t   *testing.T
+	w   *io.PipeWriter
+	r   *io.PipeReader
+	ids uint32
+}
+
+func newSftpClient(t *testing.T, fs extendedFS) *sftpClient {
+	clientIn, serverOut := io.Pipe()
+	serverIn, clientOut := io.Pipe()
+	go func() {
+		debugf := func(string, ...interface{}) {}
+		_ = sftpd.ServeChannel(newExtChannel(pipeChannel{in: serverIn, out: serverOut}, fs, debugf), fs, debugf)
+	}()
+	c := &sftpClient{t: t, w: clientOut, r: clientIn}
+	t.Cleanup(func() { _ = clientOut.Close() })
+	return c
+}
+
+// send writes a packet of type op whose body follows the id
+func (c *sftpClient) send(op byte, body func(*binp.Printer)) uint32 {
+	c.ids++
+	var l binp.Len
+	o := binp.Out().LenB32(&l).LenStart(&l).Byte(op).B32(c.ids)
+	body(o)
+	o.LenDone(&l)
+	if _, err := c.w.Write(o.Out()); err != nil {
+		c.t.Fatal(err)
+	}
+	return c.ids
+}
+
+// recv reads a packet and returns its type and the data after the type
+func (c *sftpClient) recv() (byte, []byte) {
+	header := make([]byte, 5)
+	if _, err := io.ReadFull(c.r, header); err != nil {
+		c.t.Fatal(err)
+	}
+	data := make([]byte, binary.BigEndian.Uint32(header)-1)
+	if _, err := io.ReadFull(c.r, data); err != nil {
+		c.t.Fatal(err)
+	}
+	return header[4], data
+}
+
+func (c *sftpClient) status(id uint32) uint32 {
+	op, data := c.recv()
+	var rid, code uint32
+	if op != SSH_FXP_STATUS || binp.NewParser(data).B32(&rid).B32(&code) == nil || rid != id {
+		c.t.Fatalf("expect status of %d, got %d %X", id, op, data)
+	}
+	return code
+}
+
+func TestExtensions(t *testing.T) {
+	fs := &extFS{}
+	c := newSftpClient(t, fs)
+
+	if _, err := c.w.Write(binp.Out().B32(5).Byte(SSH_FXP_INIT).B32(3).Out()); err != nil {
+		t.Fatal(err)
+	}
+	op, data := c.recv()
+	if op != SSH_FXP_VERSION {
+		t.Fatalf("expect version, got %d", op)
+	}
+	for _, ext := range []string{"posix-rename@openssh.com", "statvfs@openssh.com", "fstatvfs@openssh.com", "hardlink@openssh.com", "check-file-name"} {
+		if !bytes.Contains(data, []byte(ext)) {
+			t.Errorf("%s is not advertised", ext)
+		}
+	}
+
+	// OpenSSH sends the target before the link
+	id := c.send(SSH_FXP_SYMLINK, func(o *binp.Printer) { o.B32String("/target").B32String("/link") })
+	if code := c.status(id); code != SSH_FX_OK {
+		t.Fatalf("symlink failed with %d", code)
+	}
+	id = c.send(SSH_FXP_EXTENDED, func(o *binp.Printer) {
+		o.B32String("hardlink@openssh.com").B32String("/old").B32String("/new")
+	})
+	if code := c.status(id); code != SSH_FX_OK {
+		t.Fatalf("hardlink failed with %d", code)
+	}
+	want := [][3]any{{"/link", "/target", uint32(LINK_SYMBOLIC)}, {"/new", "/old", uint32(0)}}
+	if len(fs.links) != 2 || fs.links[0] != want[0] || fs.links[1] != want[1] {
+		t.Errorf("unexpected links %v", fs.links)
+	}
+
+	id = c.send(SSH_FXP_EXTENDED, func(o *binp.Printer) {
+		o.B32String("posix-rename@openssh.com").B32String("/a").B32String("/b")
+	})
+	if code := c.status(id); code != SSH_FX_OK || len(fs.renames) != 1 || fs.renames[0] != [2]string{"/a", "/b"} {
+		t.Errorf("posix rename failed with %d: %v", code, fs.renames)
+	}
+
+	id = c.send(SSH_FXP_EXTENDED, func(o *binp.Printer) { o.B32String("statvfs@openssh.com").B32String("/") })
+	op, data = c.recv()
+	var rid uint32
+	var bsize, frsize, blocks uint64
+	if op != SSH_FXP_EXTENDED_REPLY || binp.NewParser(data).B32(&rid).B64(&bsize).B64(&frsize).B64(&blocks) == nil ||
+		rid != id || bsize != 4096 || blocks != 100 {
+		t.Errorf("unexpected statvfs reply %d %X", op, data)
+	}
+
+	id = c.send(SSH_FXP_EXTENDED, func(o *binp.Printer) {
+		o.B32String("check-file-name").B32String("/file").B32String("md5,sha1").B64(0).B64(0).B32(0)
+	})
+	op, data = c.recv()
+	var name, alg string
+	var hash []byte
+	p := binp.NewParser(data).B32(&rid).B32String(&name).B32String(&alg)
+	if op != SSH_FXP_EXTENDED_REPLY || p == nil || rid != id || name != "check-file" || alg != "md5" {
+		t.Fatalf("unexpected check-file reply %d %X", op, data)
+	}
+	if p.PeekRest(&hash); !bytes.Equal(hash, []byte{0xab, 0xcd}) {
+		t.Errorf("unexpected hash %X", hash)
+	}
+
+	// hashes of blocks are not supported
+	id = c.send(SSH_FXP_EXTENDED, func(o *binp.Printer) {
+		o.B32String("check-file-name").B32String("/file").B32String("md5").B64(0).B64(0).B32(1024)
+	})
+	if code := c.status(id); code != SSH_FX_OP_UNSUPPORTED {
+		t.Errorf("expect unsupported block hashes, got %d", code)
+	}
+	// the handles of sftpd are resolved to the opened paths until they are closed
+	id = c.send(SSH_FXP_OPENDIR, func(o *binp.Printer) { o.B32String("/dir") })
+	op, data = c.recv()
+	var handle string
+	if op != SSH_FXP_HANDLE || binp.NewParser(data).B32(&rid).B32String(&handle) == nil || rid != id {
+		t.Fatalf("unexpected opendir reply %d %X", op, data)
+	}
+	id = c.send(SSH_FXP_EXTENDED, func(o *binp.Printer) { o.B32String("fstatvfs@openssh.com").B32String(handle) })
+	if op, data = c.recv(); op != SSH_FXP_EXTENDED_REPLY || len(fs.statted) != 2 || fs.statted[1] != "/dir" {
+		t.Errorf("unexpected fstatvfs reply %d %X of %v", op, data, fs.statted)
+	}
+	id = c.send(SSH_FXP_CLOSE, func(o *binp.Printer) { o.B32String(handle) })
+	if code := c.status(id); code != SSH_FX_OK {
+		t.Fatalf("close failed with %d", code)
+	}
+	id = c.send(SSH_FXP_EXTENDED, func(o *binp.Printer) { o.B32String("fstatvfs@openssh.com").B32String(handle) })
+	if code := c.status(id); code != SSH_FX_FAILURE {
+		t.Errorf("expect failure of a closed handle, got %d", code)
+	}
+
+	id = c.send(SSH_FXP_EXTENDED, func(o *binp.Printer) { o.B32String("unknown@example.com") })
+	if code := c.status(id); code != SSH_FX_OP_UNSUPPORTED {
+		t.Errorf("expect unsupported extension, got %d", code)
+	}
+}
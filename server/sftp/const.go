@@ -9,3 +9,30 @@ const (
 	SSH_FXF_TRUNC  = 0x00000010
 	SSH_FXF_EXCL   = 0x00000020
 )
+
+// From draft-ietf-secsh-filexfer-02, only the types and the codes handled outside sftpd
+const (
+	SSH_FXP_INIT           = 1
+	SSH_FXP_VERSION        = 2
+	SSH_FXP_OPEN           = 3
+	SSH_FXP_CLOSE          = 4
+	SSH_FXP_OPENDIR        = 11
+	SSH_FXP_SYMLINK        = 20
+	SSH_FXP_STATUS         = 101
+	SSH_FXP_HANDLE         = 102
+	SSH_FXP_EXTENDED       = 200
+	SSH_FXP_EXTENDED_REPLY = 201
+)
+
+const (
+	SSH_FX_OK                = 0
+	SSH_FX_EOF               = 1
+	SSH_FX_NO_SUCH_FILE      = 2
+	SSH_FX_PERMISSION_DENIED = 3
+	SSH_FX_FAILURE           = 4
+	SSH_FX_OP_UNSUPPORTED    = 8
+)
+
+// LINK_SYMBOLIC is set in the flags of CreateLink for SSH_FXP_SYMLINK,
+// and it is clear for hardlink@openssh.com
+const LINK_SYMBOLIC = 0x00000001
@@ -0,0 +1,173 @@
+package sftp
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+const statVFSBlockSize = 4096
+
+// StatVFS is the reply of statvfs@openssh.com, see statvfs(3)
+type StatVFS struct {
+	Bsize   uint64 // file system block size
+	Frsize  uint64 // fundamental fs block size
+	Blocks  uint64 // number of blocks (unit f_frsize)
+	Bfree   uint64 // free blocks in file system
+	Bavail  uint64 // free blocks for non-root
+	Files   uint64 // total file inodes
+	Ffree   uint64 // free file inodes
+	Favail  uint64 // free file inodes for non-root
+	Fsid    uint64 // file system id
+	Flag    uint64 // bit mask of f_flag values
+	Namemax uint64 // maximum filename length
+}
+
+func (s *DriverAdapter) StatVFS(name string) (*StatVFS, error) {
+	details, err := s.FtpDriver.GetStorageDetails(name)
+	if err != nil {
+		return nil, err
+	}
+	free := details.FreeSpace / statVFSBlockSize
+	return &StatVFS{
+		Bsize:   statVFSBlockSize,
+		Frsize:  statVFSBlockSize,
+		Blocks:  details.TotalSpace / statVFSBlockSize,
+		Bfree:   free,
+		Bavail:  free,
+		Namemax: 255,
+	}, nil
+}
+
+func (s *DriverAdapter) CheckFile(name string, algs []string) (string, []byte, error) {
+	for _, alg := range algs {
+		ht := utils.GetHashByName(alg)
+		if ht == nil {
+			continue
+		}
+		h, err := s.FtpDriver.GetHash(name, ht)
+		if err != nil {
+			return "", nil, err
+		}
+		sum, err := hex.DecodeString(h)
+		if err != nil {
+			return "", nil, err
+		}
+		return alg, sum, nil
+	}
+	return "", nil, errs.NotSupport
+}
+
+var hashCommands = map[string]*utils.HashType{
+	"md5sum":    utils.MD5,
+	"sha1sum":   utils.SHA1,
+	"sha256sum": utils.SHA256,
+}
+
+// Exec answers the hash commands that clients like rclone run to verify transfers,
+// the output is in the format of coreutils
+func (s *DriverAdapter) Exec(command string, stdin io.Reader, stdout, stderr io.Writer) uint32 {
+	args, err := splitCommand(command)
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "%v\n", err)
+		return 2
+	}
+	if len(args) == 0 {
+		return 0
+	}
+	ht, ok := hashCommands[args[0]]
+	if !ok {
+		_, _ = fmt.Fprintf(stderr, "%s: command not found\n", args[0])
+		return 127
+	}
+	if len(args) == 1 {
+		h, err := utils.HashReader(ht, stdin)
+		if err != nil {
+			_, _ = fmt.Fprintf(stderr, "%s: -: %v\n", args[0], err)
+			return 1
+		}
+		_, _ = fmt.Fprintf(stdout, "%s  -\n", h)
+		return 0
+	}
+	var status uint32
+	for _, name := range args[1:] {
+		h, err := s.FtpDriver.GetHash(name, ht)
+		if err != nil {
+			_, _ = fmt.Fprintf(stderr, "%s: %s: %v\n", args[0], name, err)
+			status = 1
+			continue
+		}
+		_, _ = fmt.Fprintf(stdout, "%s  %s\n", h, name)
+	}
+	return status
+}
+
+// splitCommand splits command into words like a POSIX shell does,
+// quotes and backslash escapes are supported but expansions are not
+func splitCommand(command string) ([]string, error) {
+	var args []string
+	var word strings.Builder
+	inWord := false
+	for i := 0; i < len(command); i++ {
+		c := command[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n':
+			if inWord {
+				args = append(args, word.String())
+				word.Reset()
+				inWord = false
+			}
+		case c == '\\':
+			inWord = true
+			if i+1 < len(command) {
+				i++
+				if command[i] != '\n' {
+					word.WriteByte(command[i])
+				}
+			}
+		case c == '\'':
+			inWord = true
+			end := strings.IndexByte(command[i+1:], '\'')
+			if end < 0 {
+				return nil, errors.New("unterminated single quote")
+			}
+			word.WriteString(command[i+1 : i+1+end])
+			i += end + 1
+		case c == '"':
+			inWord = true
+			closed := false
+			for i++; i < len(command); i++ {
+				c = command[i]
+				if c == '"' {
+					closed = true
+					break
+				}
+				if c == '\\' && i+1 < len(command) && strings.IndexByte("$`\"\\\n", command[i+1]) >= 0 {
+					i++
+					c = command[i]
+					if c == '\n' {
+						continue
+					}
+				}
+				word.WriteByte(c)
+			}
+			if !closed {
+				return nil, errors.New("unterminated double quote")
+			}
+		default:
+			inWord = true
+			word.WriteByte(c)
+		}
+	}
+	if inWord {
+		args = append(args, word.String())
+	}
+	return args, nil
+}
+
+var _ extendedFS = (*DriverAdapter)(nil)
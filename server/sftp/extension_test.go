@@ -0,0 +1,34 @@
+package sftp
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitCommand(t *testing.T) {
+	testCases := []struct {
+		command string
+		want    []string
+	}{
+		{"md5sum", []string{"md5sum"}},
+		{"md5sum /a/b.txt", []string{"md5sum", "/a/b.txt"}},
+		{`sha1sum /a\ dir/\'b\'.txt`, []string{"sha1sum", "/a dir/'b'.txt"}},
+		{`md5sum '/a dir/$b' "/c \"d\"/\$e"`, []string{"md5sum", "/a dir/$b", `/c "d"/$e`}},
+		{`md5sum  ""  x`, []string{"md5sum", "", "x"}},
+	}
+	for _, tc := range testCases {
+		got, err := splitCommand(tc.command)
+		if err != nil {
+			t.Errorf("splitCommand(%q): %v", tc.command, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("splitCommand(%q) = %q, want %q", tc.command, got, tc.want)
+		}
+	}
+	for _, command := range []string{`md5sum 'a`, `md5sum "a`} {
+		if _, err := splitCommand(command); err == nil {
+			t.Errorf("splitCommand(%q): expected error", command)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package sftp
+
+import (
+	"io"
+	"net"
+	"sync"
+
+	"github.com/KirCute/sftpd-alist"
+	"github.com/KirCute/sftpd-alist/binp"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/ssh"
+)
+
+// Driver is a sftpd.SftpDriver which also answers the exec requests of sessions
+type Driver interface {
+	sftpd.SftpDriver
+	// Exec runs command for the connection with stdin and stdout bound to the channel,
+	// the returned value is sent to the client as the exit status
+	Exec(sc *ssh.ServerConn, command string, stdin io.Reader, stdout, stderr io.Writer) uint32
+}
+
+// Server accepts connections like sftpd.SftpServer, besides the sftp subsystem it answers exec requests
+// and serves the extensions of the file systems implementing them, see extChannel
+type Server struct {
+	driver   Driver
+	mu       sync.Mutex
+	listener net.Listener
+	closed   bool
+}
+
+func NewServer(driver Driver) *Server {
+	return &Server{driver: driver}
+}
+
+func (s *Server) RunServer() error {
+	listener, err := net.Listen("tcp", s.driver.GetConfig().HostPort)
+	if err != nil {
+		s.logError("sftpd server failed:", err)
+		return err
+	}
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return listener.Close()
+	}
+	s.listener = listener
+	s.mu.Unlock()
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			s.logError("sftpd server failed:", err)
+			return err
+		}
+		go func() {
+			defer func() { _ = conn.Close() }()
+			if err := s.serveConn(conn); err != nil {
+				s.logError("sftpd connection error:", err)
+			}
+		}()
+	}
+}
+
+func (s *Server) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	if s.listener != nil {
+		_ = s.listener.Close()
+	}
+	s.driver.Close()
+	return nil
+}
+
+func (s *Server) serveConn(conn net.Conn) error {
+	sc, chans, reqs, err := ssh.NewServerConn(conn, &s.driver.GetConfig().ServerConfig)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = sc.Close() }()
+	go ssh.DiscardRequests(reqs)
+	for newChannel := range chans {
+		if newChannel.ChannelType() != "session" {
+			_ = newChannel.Reject(ssh.UnknownChannelType, "unknown channel type")
+			continue
+		}
+		channel, requests, err := newChannel.Accept()
+		if err != nil {
+			return err
+		}
+		go s.serveSession(sc, channel, requests)
+	}
+	return nil
+}
+
+func (s *Server) serveSession(sc *ssh.ServerConn, channel ssh.Channel, in <-chan *ssh.Request) {
+	for req := range in {
+		var serve func()
+		switch {
+		case sftpd.IsSftpRequest(req):
+			serve = func() {
+				if err := s.serveSftp(sc, channel); err != nil {
+					s.logError("sftpd servechannel failed:", err)
+				}
+			}
+		case req.Type == "exec":
+			var command string
+			if binp.NewParser(req.Payload).B32String(&command).End() != nil {
+				break
+			}
+			serve = func() {
+				status := s.driver.Exec(sc, command, channel, channel, channel.Stderr())
+				_ = channel.CloseWrite()
+				_, _ = channel.SendRequest("exit-status", false, binp.Out().B32(status).Out())
+				_ = channel.Close()
+			}
+		}
+		// the reply goes first, clients may drop the output of a request that isn't accepted yet
+		_ = req.Reply(serve != nil, nil)
+		if serve != nil {
+			go serve()
+		}
+	}
+}
+
+func (s *Server) serveSftp(sc *ssh.ServerConn, channel ssh.Channel) error {
+	fs, err := s.driver.GetFileSystem(sc)
+	if err != nil {
+		return err
+	}
+	debugf := s.driver.GetConfig().DebugLogFunc
+	if debugf == nil {
+		debugf = func(string, ...interface{}) {}
+	}
+	if efs, ok := fs.(extendedFS); ok {
+		channel = newExtChannel(channel, efs, debugf)
+	}
+	return sftpd.ServeChannel(channel, fs, debugf)
+}
+
+func (s *Server) logError(v ...interface{}) {
+	if log := s.driver.GetConfig().ErrorLogFunc; log != nil {
+		log(v...)
+	}
+}
@@ -7,10 +7,13 @@ import (
 	"github.com/alist-org/alist/v3/pkg/utils"
 	"github.com/alist-org/alist/v3/server/ftp"
 	"os"
+	stdpath "path"
 )
 
 type DriverAdapter struct {
 	FtpDriver *ftp.AferoAdapter
+	// uploads are the files being written, they don't exist in the storages until their handles are closed
+	uploads map[string]*ftp.FileUploadProxy
 }
 
 func (s *DriverAdapter) OpenFile(_ string, _ uint32, _ *sftpd.Attr) (sftpd.File, error) {
@@ -31,6 +34,21 @@ func (s *DriverAdapter) Rename(old, new string, _ uint32) error {
 	return s.FtpDriver.Rename(old, new)
 }
 
+// PosixRename replaces new if it already exists
+func (s *DriverAdapter) PosixRename(old, new string) error {
+	if utils.FixAndCleanPath(old) != utils.FixAndCleanPath(new) {
+		if _, err := s.FtpDriver.Stat(old); err != nil {
+			return err
+		}
+		if _, err := s.FtpDriver.Stat(new); err == nil {
+			if err = s.FtpDriver.Remove(new); err != nil {
+				return err
+			}
+		}
+	}
+	return s.FtpDriver.Rename(old, new)
+}
+
 func (s *DriverAdapter) Mkdir(name string, attr *sftpd.Attr) error {
 	return s.FtpDriver.Mkdir(name, attr.Mode)
 }
@@ -47,16 +65,42 @@ func (s *DriverAdapter) Stat(name string, _ bool) (*sftpd.Attr, error) {
 	return fileInfoToSftpAttr(stat), nil
 }
 
-func (s *DriverAdapter) SetStat(_ string, _ *sftpd.Attr) error {
-	return errs.NotSupport
+// SetStat only applies the modification time, permissions and owners can't be represented in storages
+func (s *DriverAdapter) SetStat(name string, attr *sftpd.Attr) error {
+	upload, uploading := s.uploads[utils.FixAndCleanPath(name)]
+	if attr.Flags&sftpd.ATTR_SIZE != 0 && !uploading {
+		stat, err := s.FtpDriver.Stat(name)
+		if err != nil {
+			return err
+		}
+		if uint64(stat.Size()) != attr.Size {
+			return errs.NotSupport
+		}
+	}
+	if attr.Flags&sftpd.ATTR_TIME == 0 {
+		return nil
+	}
+	if uploading {
+		upload.SetModTime(attr.MTime)
+		return nil
+	}
+	return s.FtpDriver.Chtimes(name, attr.ATime, attr.MTime)
 }
 
-func (s *DriverAdapter) ReadLink(_ string) (string, error) {
-	return "", errs.NotSupport
+// ReadLink there are no symlinks in storages, so every object resolves to itself
+func (s *DriverAdapter) ReadLink(name string) (string, error) {
+	if _, err := s.FtpDriver.Stat(name); err != nil {
+		return "", err
+	}
+	return utils.FixAndCleanPath(name), nil
 }
 
-func (s *DriverAdapter) CreateLink(_, _ string, _ uint32) error {
-	return errs.NotSupport
+// CreateLink both symlinks and hardlinks are created as copies of the target, see also ftp.Link
+func (s *DriverAdapter) CreateLink(name, target string, _ uint32) error {
+	if !stdpath.IsAbs(target) {
+		target = stdpath.Join(stdpath.Dir(name), target)
+	}
+	return s.FtpDriver.Link(name, target)
 }
 
 func (s *DriverAdapter) RealPath(path string) (string, error) {
@@ -64,7 +108,19 @@ func (s *DriverAdapter) RealPath(path string) (string, error) {
 }
 
 func (s *DriverAdapter) GetHandle(name string, flags uint32, _ *sftpd.Attr, offset uint64) (sftpd.FileTransfer, error) {
-	return s.FtpDriver.GetHandle(name, sftpFlagToOpenMode(flags), int64(offset))
+	t, err := s.FtpDriver.GetHandle(name, sftpFlagToOpenMode(flags), int64(offset))
+	if err != nil {
+		return nil, err
+	}
+	if upload, ok := t.(*ftp.FileUploadProxy); ok {
+		if s.uploads == nil {
+			s.uploads = make(map[string]*ftp.FileUploadProxy)
+		}
+		key := utils.FixAndCleanPath(name)
+		s.uploads[key] = upload
+		return &uploadHandle{FileUploadProxy: upload, adapter: s, key: key}, nil
+	}
+	return t, nil
 }
 
 func (s *DriverAdapter) ReadDir(name string) ([]sftpd.NamedAttr, error) {
@@ -79,6 +135,17 @@ func (s *DriverAdapter) ReadDir(name string) ([]sftpd.NamedAttr, error) {
 	return ret, nil
 }
 
+type uploadHandle struct {
+	*ftp.FileUploadProxy
+	adapter *DriverAdapter
+	key     string
+}
+
+func (u *uploadHandle) Close() error {
+	delete(u.adapter.uploads, u.key)
+	return u.FileUploadProxy.Close()
+}
+
 // From leffss/sftpd
 func sftpFlagToOpenMode(flags uint32) int {
 	mode := 0
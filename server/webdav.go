@@ -15,6 +15,7 @@ import (
 
 	"github.com/alist-org/alist/v3/internal/conf"
 	"github.com/alist-org/alist/v3/internal/device"
+	"github.com/alist-org/alist/v3/internal/metrics"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/op"
 	"github.com/alist-org/alist/v3/internal/setting"
@@ -30,7 +31,7 @@ var handler *webdav.Handler
 func WebDav(dav *gin.RouterGroup) {
 	handler = &webdav.Handler{
 		Prefix:     path.Join(conf.URL.Path, "/dav"),
-		LockSystem: webdav.NewMemLS(),
+		LockSystem: newLockSystem("dav"),
 		Logger: func(request *http.Request, err error) {
 			// Skip logging for NotFoundError as it's not a program error
 			// but a normal case when a file doesn't exist
@@ -86,7 +87,7 @@ func WebDAVAuth(c *gin.Context) {
 					return
 				}
 				c.Set("device_key", key)
-				c.Set("user", admin)
+				c.Set("user", admin.WithAccess(metrics.ProtocolWebDAV, c.ClientIP()))
 				c.Next()
 				return
 			}
@@ -112,6 +113,7 @@ func WebDAVAuth(c *gin.Context) {
 		c.Abort()
 		return
 	}
+	user = user.WithAccess(metrics.ProtocolWebDAV, c.ClientIP())
 	if roles, err := op.GetRolesByUserID(user.ID); err == nil {
 		user.RolesDetail = roles
 	}
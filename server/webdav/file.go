@@ -106,6 +106,7 @@ func walkFS(ctx context.Context, depth int, name string, info model.Obj, walkFn
 	if err != nil {
 		return walkFn(name, info, err)
 	}
+	preloadDeadProps(ctx, name, objs)
 
 	for _, fileInfo := range objs {
 		filename := path.Join(name, fileInfo.GetName())
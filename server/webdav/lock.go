@@ -125,12 +125,17 @@ type memLS struct {
 	byName  map[string]*memLSNode
 	byToken map[string]*memLSNode
 	gen     uint64
+	// newToken makes the tokens instead of the counter when it's set
+	newToken func() string
 	// byExpiry only contains those nodes whose LockDetails have a finite
 	// Duration and are yet to expire.
 	byExpiry byExpiry
 }
 
 func (m *memLS) nextToken() string {
+	if m.newToken != nil {
+		return m.newToken()
+	}
 	m.gen++
 	return strconv.FormatUint(m.gen, 10)
 }
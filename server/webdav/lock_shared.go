@@ -0,0 +1,116 @@
+package webdav
+
+import (
+	"container/heap"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// StoredLock is a lock kept by a LockStore, Expiry is zero for the locks without a timeout
+type StoredLock struct {
+	Token   string
+	Details LockDetails
+	Expiry  time.Time
+}
+
+// LockStore keeps the locks of a shared LockSystem where all the instances serving
+// the same files see them
+type LockStore interface {
+	// Load returns the stored locks
+	Load() ([]StoredLock, error)
+	// Update replaces the stored locks with the ones f returns from them, it returns the
+	// error of f as is. The updates must be serialized across the instances.
+	Update(f func([]StoredLock) ([]StoredLock, error)) error
+}
+
+// NewSharedLS returns a LockSystem keeping its locks in store, so a lock taken through an
+// instance is honored by the others. The locks are not held while a request runs on another
+// instance: Confirm only checks them, and Refresh and Unlock don't wait for the request.
+func NewSharedLS(store LockStore) LockSystem {
+	return &sharedLS{store: store}
+}
+
+type sharedLS struct {
+	store LockStore
+}
+
+func (s *sharedLS) load(locks []StoredLock) *memLS {
+	m := NewMemLS().(*memLS)
+	m.newToken = func() string {
+		return "urn:uuid:" + uuid.NewString()
+	}
+	for _, l := range locks {
+		m.restore(l)
+	}
+	return m
+}
+
+func (s *sharedLS) Confirm(now time.Time, name0, name1 string, conditions ...Condition) (func(), error) {
+	locks, err := s.store.Load()
+	if err != nil {
+		return nil, err
+	}
+	release, err := s.load(locks).Confirm(now, name0, name1, conditions...)
+	if err != nil {
+		return nil, err
+	}
+	release()
+	return func() {}, nil
+}
+
+func (s *sharedLS) Create(now time.Time, details LockDetails) (token string, err error) {
+	err = s.store.Update(func(locks []StoredLock) ([]StoredLock, error) {
+		m := s.load(locks)
+		if token, err = m.Create(now, details); err != nil {
+			return nil, err
+		}
+		return m.snapshot(), nil
+	})
+	return token, err
+}
+
+func (s *sharedLS) Refresh(now time.Time, token string, duration time.Duration) (details LockDetails, err error) {
+	err = s.store.Update(func(locks []StoredLock) ([]StoredLock, error) {
+		m := s.load(locks)
+		if details, err = m.Refresh(now, token, duration); err != nil {
+			return nil, err
+		}
+		return m.snapshot(), nil
+	})
+	return details, err
+}
+
+func (s *sharedLS) Unlock(now time.Time, token string) error {
+	return s.store.Update(func(locks []StoredLock) ([]StoredLock, error) {
+		m := s.load(locks)
+		if err := m.Unlock(now, token); err != nil {
+			return nil, err
+		}
+		return m.snapshot(), nil
+	})
+}
+
+// restore adds a stored lock as it was created, the expired ones are collected by the next operation
+func (m *memLS) restore(l StoredLock) {
+	n := m.create(l.Details.Root)
+	n.token = l.Token
+	n.details = l.Details
+	m.byToken[n.token] = n
+	if n.details.Duration >= 0 {
+		n.expiry = l.Expiry
+		heap.Push(&m.byExpiry, n)
+	}
+}
+
+func (m *memLS) snapshot() []StoredLock {
+	locks := make([]StoredLock, 0, len(m.byToken))
+	for token, n := range m.byToken {
+		l := StoredLock{Token: token, Details: n.details}
+		if n.details.Duration >= 0 {
+			l.Expiry = n.expiry
+		}
+		locks = append(locks, l)
+	}
+	return locks
+}
@@ -0,0 +1,70 @@
+package webdav
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+type memLockStore struct {
+	mu    sync.Mutex
+	locks []StoredLock
+}
+
+func (s *memLockStore) Load() ([]StoredLock, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]StoredLock(nil), s.locks...), nil
+}
+
+func (s *memLockStore) Update(f func([]StoredLock) ([]StoredLock, error)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	locks, err := f(append([]StoredLock(nil), s.locks...))
+	if err != nil {
+		return err
+	}
+	s.locks = locks
+	return nil
+}
+
+func TestSharedLS(t *testing.T) {
+	store := &memLockStore{}
+	a, b := NewSharedLS(store), NewSharedLS(store)
+	now := time.Now()
+	token, err := a.Create(now, LockDetails{Root: "/dir", Duration: time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.Create(now, LockDetails{Root: "/dir/file", Duration: time.Minute}); err != ErrLocked {
+		t.Fatalf("lock taken on another instance must be honored, got %v", err)
+	}
+	if _, err := b.Confirm(now, "/dir/file", ""); err != ErrConfirmationFailed {
+		t.Fatalf("write without the token must fail, got %v", err)
+	}
+	release, err := b.Confirm(now, "/dir/file", "", Condition{Token: token})
+	if err != nil {
+		t.Fatalf("write with the token must be confirmed: %v", err)
+	}
+	release()
+	if _, err := b.Refresh(now, token, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	later := now.Add(30 * time.Minute)
+	if _, err := a.Create(later, LockDetails{Root: "/dir", Duration: time.Minute}); err != ErrLocked {
+		t.Fatalf("refreshed lock must not expire, got %v", err)
+	}
+	if err := b.Unlock(later, token); err != nil {
+		t.Fatal(err)
+	}
+	other, err := a.Create(later, LockDetails{Root: "/dir", Duration: time.Minute})
+	if err != nil {
+		t.Fatalf("unlocked root must be locked again: %v", err)
+	}
+	if other == token {
+		t.Fatal("tokens must not be reused")
+	}
+	if _, err := b.Create(later.Add(2*time.Minute), LockDetails{Root: "/dir", Duration: time.Minute}); err != nil {
+		t.Fatalf("expired lock must be collected: %v", err)
+	}
+}
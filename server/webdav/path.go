@@ -12,6 +12,9 @@ import (
 // before delegating to the user-aware JoinPath permission checks.
 func ResolvePath(user *model.User, raw string) (string, error) {
 	cleaned := utils.FixAndCleanPath(raw)
+	if model.IsSharedWithMePath(cleaned) && user.HasSharedWithMe() {
+		return user.JoinPath(cleaned)
+	}
 	basePath := utils.FixAndCleanPath(user.BasePath)
 
 	if cleaned != "/" && basePath != "/" && !utils.IsSubPath(basePath, cleaned) {
@@ -13,11 +13,16 @@ import (
 	"mime"
 	"net/http"
 	"path"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/fs"
 	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/pkg/utils"
 	"github.com/alist-org/alist/v3/server/common"
 )
 
@@ -173,26 +178,13 @@ var liveProps = map[xml.Name]struct {
 //
 // Each Propstat has a unique status and each property name will only be part
 // of one Propstat element.
-func props(ctx context.Context, ls LockSystem, fi model.Obj, pnames []xml.Name) ([]Propstat, error) {
-	//f, err := fs.OpenFile(ctx, name, os.O_RDONLY, 0)
-	//if err != nil {
-	//	return nil, err
-	//}
-	//defer f.Close()
-	//fi, err := f.Stat()
-	//if err != nil {
-	//	return nil, err
-	//}
+func props(ctx context.Context, ls LockSystem, name string, fi model.Obj, pnames []xml.Name) ([]Propstat, error) {
 	isDir := fi.IsDir()
 
-	var deadProps map[xml.Name]Property
-	// ??? what is this for?
-	//if dph, ok := f.(DeadPropsHolder); ok {
-	//	deadProps, err = dph.DeadProps()
-	//	if err != nil {
-	//		return nil, err
-	//	}
-	//}
+	deadProps, err := findDeadProps(ctx, name)
+	if err != nil {
+		return nil, err
+	}
 
 	pstatOK := Propstat{Status: http.StatusOK}
 	pstatNotFound := Propstat{Status: http.StatusNotFound}
@@ -222,26 +214,13 @@ func props(ctx context.Context, ls LockSystem, fi model.Obj, pnames []xml.Name)
 }
 
 // Propnames returns the property names defined for resource name.
-func propnames(ctx context.Context, ls LockSystem, fi model.Obj) ([]xml.Name, error) {
-	//f, err := fs.OpenFile(ctx, name, os.O_RDONLY, 0)
-	//if err != nil {
-	//	return nil, err
-	//}
-	//defer f.Close()
-	//fi, err := f.Stat()
-	//if err != nil {
-	//	return nil, err
-	//}
+func propnames(ctx context.Context, ls LockSystem, name string, fi model.Obj) ([]xml.Name, error) {
 	isDir := fi.IsDir()
 
-	var deadProps map[xml.Name]Property
-	// ??? what is this for?
-	//if dph, ok := f.(DeadPropsHolder); ok {
-	//	deadProps, err = dph.DeadProps()
-	//	if err != nil {
-	//		return nil, err
-	//	}
-	//}
+	deadProps, err := findDeadProps(ctx, name)
+	if err != nil {
+		return nil, err
+	}
 
 	pnames := make([]xml.Name, 0, len(liveProps)+len(deadProps))
 	for pn, prop := range liveProps {
@@ -250,6 +229,9 @@ func propnames(ctx context.Context, ls LockSystem, fi model.Obj) ([]xml.Name, er
 		}
 	}
 	for pn := range deadProps {
+		if _, ok := liveProps[pn]; ok {
+			continue
+		}
 		pnames = append(pnames, pn)
 	}
 	return pnames, nil
@@ -263,8 +245,8 @@ func propnames(ctx context.Context, ls LockSystem, fi model.Obj) ([]xml.Name, er
 // returned if they are named in 'include'.
 //
 // See http://www.webdav.org/specs/rfc4918.html#METHOD_PROPFIND
-func allprop(ctx context.Context, ls LockSystem, fi model.Obj, include []xml.Name) ([]Propstat, error) {
-	pnames, err := propnames(ctx, ls, fi)
+func allprop(ctx context.Context, ls LockSystem, name string, fi model.Obj, include []xml.Name) ([]Propstat, error) {
+	pnames, err := propnames(ctx, ls, name, fi)
 	if err != nil {
 		return nil, err
 	}
@@ -278,23 +260,124 @@ func allprop(ctx context.Context, ls LockSystem, fi model.Obj, include []xml.Nam
 			pnames = append(pnames, pn)
 		}
 	}
-	return props(ctx, ls, fi, pnames)
+	return props(ctx, ls, name, fi, pnames)
+}
+
+// deadPropsKey holds the dead properties loaded by a PROPFIND, keyed by path
+type deadPropsKey struct{}
+
+// withDeadProps lets a PROPFIND load the dead properties of the children of
+// each folder it lists by one query, instead of one per resource
+func withDeadProps(ctx context.Context) context.Context {
+	return context.WithValue(ctx, deadPropsKey{}, make(map[string][]model.DavProp))
+}
+
+// preloadDeadProps loads the dead properties of the listed children of parent
+func preloadDeadProps(ctx context.Context, parent string, objs []model.Obj) {
+	loaded, ok := ctx.Value(deadPropsKey{}).(map[string][]model.DavProp)
+	if !ok || len(objs) == 0 {
+		return
+	}
+	children, err := op.GetDavPropsOfChildren(parent)
+	if err != nil {
+		// each resource loads its own properties
+		return
+	}
+	for _, obj := range objs {
+		p := utils.FixAndCleanPath(path.Join(parent, obj.GetName()))
+		loaded[p] = children[p]
+	}
+}
+
+// findDeadProps loads the dead properties stored for resource name.
+func findDeadProps(ctx context.Context, name string) (map[xml.Name]Property, error) {
+	name = utils.FixAndCleanPath(name)
+	loaded, _ := ctx.Value(deadPropsKey{}).(map[string][]model.DavProp)
+	stored, ok := loaded[name]
+	if !ok {
+		var err error
+		if stored, err = op.GetDavProps(name); err != nil {
+			return nil, err
+		}
+		if loaded != nil {
+			loaded[name] = stored
+		}
+	}
+	deadProps := make(map[xml.Name]Property, len(stored))
+	for _, p := range stored {
+		pn := xml.Name{Space: p.Space, Local: p.Local}
+		deadProps[pn] = Property{
+			XMLName:  pn,
+			Lang:     p.Lang,
+			InnerXML: []byte(p.InnerXML),
+		}
+	}
+	return deadProps, nil
+}
+
+var getLastModifiedName = xml.Name{Space: "DAV:", Local: "getlastmodified"}
+
+// modTimeProps are the properties whose values are written through to the
+// modification time of the resource when the storage supports it.
+var modTimeProps = map[xml.Name]bool{
+	getLastModifiedName: true,
+	{Space: "urn:schemas-microsoft-com:", Local: "Win32LastModifiedTime"}: true,
+}
+
+func parseModTime(innerXML []byte) (time.Time, error) {
+	value := strings.TrimSpace(string(innerXML))
+	if t, err := http.ParseTime(value); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+// modTimeUnsupported tells if the storage can't set the modification time
+func modTimeUnsupported(err error) bool {
+	return errors.Is(err, errs.NotImplement) || errors.Is(err, errs.NotSupport)
 }
 
 // Patch patches the properties of resource name. The return values are
 // constrained in the same manner as DeadPropsHolder.Patch.
 func patch(ctx context.Context, ls LockSystem, name string, patches []Proppatch) ([]Propstat, error) {
-	conflict := false
-loop:
+	var modTime *time.Time
+	var forbidden []xml.Name
 	for _, patch := range patches {
 		for _, p := range patch.Props {
+			if modTimeProps[p.XMLName] && !patch.Remove {
+				if t, err := parseModTime(p.InnerXML); err == nil {
+					modTime = &t
+					continue
+				}
+			}
 			if _, ok := liveProps[p.XMLName]; ok {
-				conflict = true
-				break loop
+				forbidden = append(forbidden, p.XMLName)
 			}
 		}
 	}
-	if conflict {
+	if len(forbidden) == 0 && modTime != nil {
+		// setting the modification time writes to the storage, the dead
+		// properties only need the webdav manage permission
+		err := errs.PermissionDenied
+		if user, ok := ctx.Value("user").(*model.User); ok &&
+			common.HasPermission(common.MergeRolePermissions(user, name), common.PermWrite) {
+			err = fs.SetModTime(ctx, name, *modTime)
+		}
+		if modTimeUnsupported(err) || errors.Is(err, errs.PermissionDenied) {
+			// DAV:getlastmodified stays protected if it can't be set,
+			// the Win32 properties are still stored as dead properties.
+			for _, patch := range patches {
+				for _, p := range patch.Props {
+					if p.XMLName == getLastModifiedName {
+						forbidden = append(forbidden, p.XMLName)
+					}
+				}
+			}
+		} else if err != nil {
+			return nil, err
+		}
+	}
+	if len(forbidden) > 0 {
 		pstatForbidden := Propstat{
 			Status:   http.StatusForbidden,
 			XMLError: `<D:cannot-modify-protected-property xmlns:D="DAV:"/>`,
@@ -304,7 +387,7 @@ loop:
 		}
 		for _, patch := range patches {
 			for _, p := range patch.Props {
-				if _, ok := liveProps[p.XMLName]; ok {
+				if slices.Contains(forbidden, p.XMLName) {
 					pstatForbidden.Props = append(pstatForbidden.Props, Property{XMLName: p.XMLName})
 				} else {
 					pstatFailedDep.Props = append(pstatFailedDep.Props, Property{XMLName: p.XMLName})
@@ -314,37 +397,31 @@ loop:
 		return makePropstats(pstatForbidden, pstatFailedDep), nil
 	}
 
-	// ------------------------------------------------------------
-	//f, err := fs.OpenFile(ctx, name, os.O_RDWR, 0)
-	//if err != nil {
-	//	return nil, err
-	//}
-	//defer f.Close()
-	//if dph, ok := f.(DeadPropsHolder); ok {
-	//	ret, err := dph.Patch(patches)
-	//	if err != nil {
-	//		return nil, err
-	//	}
-	//	// http://www.webdav.org/specs/rfc4918.html#ELEMENT_propstat says that
-	//	// "The contents of the prop XML element must only list the names of
-	//	// properties to which the result in the status element applies."
-	//	for _, pstat := range ret {
-	//		for i, p := range pstat.Props {
-	//			pstat.Props[i] = Property{XMLName: p.XMLName}
-	//		}
-	//	}
-	//	return ret, nil
-	//}
-	// ------------------------------------------------------------
-
-	// The file doesn't implement the optional DeadPropsHolder interface, so
-	// all patches are forbidden.
-	pstat := Propstat{Status: http.StatusForbidden}
+	var davPatches []model.DavPropPatch
+	pstat := Propstat{Status: http.StatusOK}
 	for _, patch := range patches {
 		for _, p := range patch.Props {
+			// http://www.webdav.org/specs/rfc4918.html#ELEMENT_propstat says that
+			// "The contents of the prop XML element must only list the names of
+			// properties to which the result in the status element applies."
 			pstat.Props = append(pstat.Props, Property{XMLName: p.XMLName})
+			if _, ok := liveProps[p.XMLName]; ok {
+				continue
+			}
+			davPatches = append(davPatches, model.DavPropPatch{
+				DavProp: model.DavProp{
+					Space:    p.XMLName.Space,
+					Local:    p.XMLName.Local,
+					Lang:     p.Lang,
+					InnerXML: string(p.InnerXML),
+				},
+				Remove: patch.Remove,
+			})
 		}
 	}
+	if err := op.PatchDavProps(name, davPatches); err != nil {
+		return nil, err
+	}
 	return []Propstat{pstat}, nil
 }
 
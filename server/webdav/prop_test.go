@@ -0,0 +1,128 @@
+package webdav
+
+import (
+	"context"
+	"encoding/xml"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/db/dbtest"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/pkg/errors"
+)
+
+func init() {
+	dbtest.Init()
+}
+
+func TestParseModTime(t *testing.T) {
+	want := time.Date(2019, 10, 9, 10, 10, 10, 0, time.UTC)
+	testCases := []string{
+		"Wed, 09 Oct 2019 10:10:10 GMT",
+		" Wed, 09 Oct 2019 10:10:10 GMT\n",
+		"2019-10-09T10:10:10Z",
+	}
+	for _, tc := range testCases {
+		got, err := parseModTime([]byte(tc))
+		if err != nil {
+			t.Errorf("parseModTime(%q): %v", tc, err)
+			continue
+		}
+		if !got.Equal(want) {
+			t.Errorf("parseModTime(%q) = %v, want %v", tc, got, want)
+		}
+	}
+	if _, err := parseModTime([]byte("yesterday")); err == nil {
+		t.Errorf("parseModTime(%q): expected error", "yesterday")
+	}
+}
+
+func TestModTimeUnsupported(t *testing.T) {
+	for _, err := range []error{errs.NotImplement, errs.NotSupport, errors.WithMessage(errs.NotSupport, "failed set mod time")} {
+		if !modTimeUnsupported(err) {
+			t.Errorf("%v must leave getlastmodified protected", err)
+		}
+	}
+	if modTimeUnsupported(nil) || modTimeUnsupported(errs.ObjectNotFound) {
+		t.Error("other errors must fail the patch")
+	}
+}
+
+func TestPatchOrder(t *testing.T) {
+	a := xml.Name{Space: "urn:test", Local: "a"}
+	b := xml.Name{Space: "urn:test", Local: "b"}
+	prop := func(name xml.Name, value string) Property {
+		return Property{XMLName: name, InnerXML: []byte(value)}
+	}
+	// the instructions are applied in the order of the document
+	patches := []Proppatch{
+		{Props: []Property{prop(a, "1"), prop(b, "1")}},
+		{Remove: true, Props: []Property{prop(a, "")}},
+		{Props: []Property{prop(b, "2")}},
+		{Remove: true, Props: []Property{prop(b, "")}},
+		{Props: []Property{prop(b, "3")}},
+	}
+	if _, err := patch(context.Background(), nil, "/dav/order", patches); err != nil {
+		t.Fatal(err)
+	}
+	props, err := op.GetDavProps("/dav/order")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(props) != 1 || props[0].Local != "b" || props[0].InnerXML != "3" {
+		t.Fatalf("unexpected props %+v", props)
+	}
+}
+
+func TestPatchModTimeNeedsWrite(t *testing.T) {
+	ctx := context.WithValue(context.Background(), "user", &model.User{Username: "dav_reader"})
+	patches := []Proppatch{{Props: []Property{
+		{XMLName: getLastModifiedName, InnerXML: []byte("Wed, 09 Oct 2019 10:10:10 GMT")},
+	}}}
+	pstats, err := patch(ctx, nil, "/dav/readonly", patches)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pstats) != 1 || pstats[0].Status != http.StatusForbidden {
+		t.Errorf("expected getlastmodified to be protected without write permission, got %+v", pstats)
+	}
+}
+
+func TestDeadPropsOfChildren(t *testing.T) {
+	set := func(path, value string) {
+		err := op.PatchDavProps(path, []model.DavPropPatch{{DavProp: model.DavProp{Space: "urn:test", Local: "p", InnerXML: value}}})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	set("/dav/list/a", "a")
+	set("/dav/list/b", "b")
+	set("/dav/list/sub/c", "c")
+	set("/dav/list_x/d", "d")
+
+	children, err := op.GetDavPropsOfChildren("/dav/list")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(children) != 2 || len(children["/dav/list/a"]) != 1 || len(children["/dav/list/b"]) != 1 {
+		t.Fatalf("expected the properties of the direct children only, got %+v", children)
+	}
+
+	ctx := withDeadProps(context.Background())
+	preloadDeadProps(ctx, "/dav/list", []model.Obj{&model.Object{Name: "a"}, &model.Object{Name: "e"}})
+	// the preloaded properties are served without querying again
+	set("/dav/list/a", "changed")
+	props, err := findDeadProps(ctx, "/dav/list/a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := props[xml.Name{Space: "urn:test", Local: "p"}]; string(p.InnerXML) != "a" {
+		t.Errorf("expected the preloaded property, got %q", p.InnerXML)
+	}
+	if props, err := findDeadProps(ctx, "/dav/list/e"); err != nil || len(props) != 0 {
+		t.Errorf("expected no property, got %+v %v", props, err)
+	}
+}
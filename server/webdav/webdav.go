@@ -33,6 +33,9 @@ type Handler struct {
 	// Logger is an optional error logger. If non-nil, it will be called
 	// for all HTTP requests.
 	Logger func(*http.Request, error)
+	// Confined serves the base path of the user as the root as is, without the
+	// paths of the user's roles. It's used for the WebDAV of shares.
+	Confined bool
 }
 
 func (h *Handler) stripPrefix(p string) (string, int, error) {
@@ -618,6 +621,7 @@ func (h *Handler) handlePropfind(w http.ResponseWriter, r *http.Request) (status
 	ctx := r.Context()
 	userAgent := r.Header.Get("User-Agent")
 	ctx = context.WithValue(ctx, "userAgent", userAgent)
+	ctx = withDeadProps(ctx)
 	user := ctx.Value("user").(*model.User)
 	reqPath, err = ResolvePath(user, reqPath)
 	if err != nil {
@@ -644,7 +648,7 @@ func (h *Handler) handlePropfind(w http.ResponseWriter, r *http.Request) (status
 
 	mw := multistatusWriter{w: w}
 
-	if utils.PathEqual(reqPath, user.BasePath) {
+	if !h.Confined && utils.PathEqual(reqPath, user.BasePath) {
 		hasRootPerm := false
 		for _, role := range user.RolesDetail {
 			for _, entry := range role.PermissionScopes {
@@ -694,7 +698,7 @@ func (h *Handler) handlePropfind(w http.ResponseWriter, r *http.Request) (status
 			for _, item := range infos {
 				var pstats []Propstat
 				if pf.Propname != nil {
-					pnames, err := propnames(ctx, h.LockSystem, item.info)
+					pnames, err := propnames(ctx, h.LockSystem, item.path, item.info)
 					if err != nil {
 						return http.StatusInternalServerError, err
 					}
@@ -704,12 +708,12 @@ func (h *Handler) handlePropfind(w http.ResponseWriter, r *http.Request) (status
 					}
 					pstats = append(pstats, pstat)
 				} else if pf.Allprop != nil {
-					pstats, err = allprop(ctx, h.LockSystem, item.info, pf.Prop)
+					pstats, err = allprop(ctx, h.LockSystem, item.path, item.info, pf.Prop)
 					if err != nil {
 						return http.StatusInternalServerError, err
 					}
 				} else {
-					pstats, err = props(ctx, h.LockSystem, item.info, pf.Prop)
+					pstats, err = props(ctx, h.LockSystem, item.path, item.info, pf.Prop)
 					if err != nil {
 						return http.StatusInternalServerError, err
 					}
@@ -742,7 +746,7 @@ func (h *Handler) handlePropfind(w http.ResponseWriter, r *http.Request) (status
 		}
 		var pstats []Propstat
 		if pf.Propname != nil {
-			pnames, err := propnames(ctx, h.LockSystem, info)
+			pnames, err := propnames(ctx, h.LockSystem, reqPath, info)
 			if err != nil {
 				return err
 			}
@@ -752,9 +756,9 @@ func (h *Handler) handlePropfind(w http.ResponseWriter, r *http.Request) (status
 			}
 			pstats = append(pstats, pstat)
 		} else if pf.Allprop != nil {
-			pstats, err = allprop(ctx, h.LockSystem, info, pf.Prop)
+			pstats, err = allprop(ctx, h.LockSystem, reqPath, info, pf.Prop)
 		} else {
-			pstats, err = props(ctx, h.LockSystem, info, pf.Prop)
+			pstats, err = props(ctx, h.LockSystem, reqPath, info, pf.Prop)
 		}
 		if err != nil {
 			return err
@@ -0,0 +1,95 @@
+package server
+
+import (
+	"sync"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/cluster"
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/server/webdav"
+	"github.com/pkg/errors"
+)
+
+// davLockWait is how long an update of the locks waits for the other instances
+const davLockWait = 10 * time.Second
+
+// newLockSystem returns the lock system of namespace, the locks are kept in the database
+// when the instance runs in a cluster so they hold on every instance
+func newLockSystem(namespace string) webdav.LockSystem {
+	if !cluster.Enabled() {
+		return webdav.NewMemLS()
+	}
+	return webdav.NewSharedLS(davLockStore{namespace: namespace})
+}
+
+// shareLockSystems holds the lock system of each share, their paths are relative to the shares
+var shareLockSystems sync.Map
+
+func shareLockSystem(shareID string) webdav.LockSystem {
+	if ls, ok := shareLockSystems.Load(shareID); ok {
+		return ls.(webdav.LockSystem)
+	}
+	ls, _ := shareLockSystems.LoadOrStore(shareID, newLockSystem("share:"+shareID))
+	return ls.(webdav.LockSystem)
+}
+
+var davLockMu sync.Mutex
+
+type davLockStore struct {
+	namespace string
+}
+
+func (s davLockStore) Load() ([]webdav.StoredLock, error) {
+	locks, err := db.GetDavLocks(s.namespace)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]webdav.StoredLock, len(locks))
+	for i, l := range locks {
+		res[i] = webdav.StoredLock{
+			Token: l.Token,
+			Details: webdav.LockDetails{
+				Root:      l.Root,
+				Duration:  l.Duration,
+				OwnerXML:  l.OwnerXML,
+				ZeroDepth: l.ZeroDepth,
+			},
+			Expiry: l.ExpiresAt,
+		}
+	}
+	return res, nil
+}
+
+// Update holds the lease of the namespace so the instances don't overwrite each other's locks
+func (s davLockStore) Update(f func([]webdav.StoredLock) ([]webdav.StoredLock, error)) error {
+	davLockMu.Lock()
+	defer davLockMu.Unlock()
+	lease := "dav_lock:" + s.namespace
+	for deadline := time.Now().Add(davLockWait); !cluster.TryAcquire(lease); {
+		if time.Now().After(deadline) {
+			return errors.Errorf("timed out waiting for the webdav locks of %s", s.namespace)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	defer cluster.Release(lease)
+	locks, err := s.Load()
+	if err != nil {
+		return err
+	}
+	if locks, err = f(locks); err != nil {
+		return err
+	}
+	res := make([]model.DavLock, len(locks))
+	for i, l := range locks {
+		res[i] = model.DavLock{
+			Token:     l.Token,
+			Root:      l.Details.Root,
+			Duration:  l.Details.Duration,
+			OwnerXML:  l.Details.OwnerXML,
+			ZeroDepth: l.Details.ZeroDepth,
+			ExpiresAt: l.Expiry,
+		}
+	}
+	return db.ReplaceDavLocks(s.namespace, res)
+}
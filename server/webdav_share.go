@@ -0,0 +1,244 @@
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/metrics"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	shareauth "github.com/alist-org/alist/v3/internal/share"
+	"github.com/alist-org/alist/v3/internal/stream"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/alist-org/alist/v3/server/handles"
+	"github.com/alist-org/alist/v3/server/middlewares"
+	"github.com/alist-org/alist/v3/server/webdav"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+// WebDavShare serves the folder of a share over WebDAV, read-only or writable
+// for the shares accepting uploads
+func WebDavShare(dav *gin.RouterGroup) {
+	dav.Use(WebDAVShareAuth)
+	uploadLimiter := middlewares.UploadRateLimiter(stream.ClientUploadLimit)
+	downloadLimiter := middlewares.DownloadRateLimiter(stream.ClientDownloadLimit)
+	dav.Any("/*path", uploadLimiter, downloadLimiter, ServeWebDAVShare)
+	dav.Any("", uploadLimiter, downloadLimiter, ServeWebDAVShare)
+	for _, method := range []string{"PROPFIND", "MKCOL", "LOCK", "UNLOCK", "PROPPATCH", "COPY", "MOVE"} {
+		dav.Handle(method, "/*path", ServeWebDAVShare)
+	}
+	dav.Handle("PROPFIND", "", ServeWebDAVShare)
+}
+
+func ServeWebDAVShare(c *gin.Context) {
+	share := c.MustGet("share").(*model.Share)
+	h := &webdav.Handler{
+		Prefix:     path.Join(conf.URL.Path, "/dav-share", share.ShareID),
+		LockSystem: shareLockSystem(share.ShareID),
+		Confined:   true,
+		Logger: func(request *http.Request, err error) {
+			if errs.IsNotFoundError(err) {
+				log.Debugf("%s %s %v", request.Method, request.URL.Path, err)
+				return
+			}
+			log.Errorf("%s %s %+v", request.Method, request.URL.Path, err)
+		},
+	}
+	ctx := context.WithValue(c.Request.Context(), "user", c.MustGet("user"))
+	h.ServeHTTP(c.Writer, c.Request.WithContext(ctx))
+}
+
+func shareDavUnauthorized(c *gin.Context) {
+	c.Writer.Header()["WWW-Authenticate"] = []string{`Basic realm="alist share"`}
+	c.Status(http.StatusUnauthorized)
+	c.Abort()
+}
+
+// WebDAVShareAuth checks the share password given as the basic auth password,
+// the rules of the share, and acts as the share creator, or its group, confined
+// to the shared folder. Uploads go to the folder of the uploader named by the
+// basic auth user name, like the uploads of the web page.
+func WebDAVShareAuth(c *gin.Context) {
+	share, err := db.GetShareByShareID(c.Param("share_id"))
+	if err != nil || !share.AllowWebDAV || !share.Enabled {
+		c.Status(http.StatusNotFound)
+		c.Abort()
+		return
+	}
+	if share.IsConsumed() || share.IsExpired(time.Now()) {
+		c.Status(http.StatusGone)
+		c.Abort()
+		return
+	}
+	if share.HasPassword() {
+		// guesses of the password count as unsuccessful sign-ins
+		ip := c.ClientIP()
+		if handles.LoginLocked(ip) {
+			c.Status(http.StatusTooManyRequests)
+			c.Abort()
+			return
+		}
+		_, password, ok := c.Request.BasicAuth()
+		hash := shareauth.HashPassword(password, share.PasswordSalt)
+		if !ok || subtle.ConstantTimeCompare([]byte(hash), []byte(share.PasswordHash)) != 1 {
+			if ok {
+				handles.LoginFailed(ip)
+			}
+			shareDavUnauthorized(c)
+			return
+		}
+		handles.LoginSucceeded(ip)
+	}
+	actor, err := op.GetShareActor(share)
+	if err != nil {
+		c.Status(http.StatusForbidden)
+		c.Abort()
+		return
+	}
+	user := actor.WithAccess(metrics.ProtocolWebDAV, c.ClientIP())
+	user.BasePath = share.RootPath
+	reqPath, _ := url.PathUnescape(c.Param("path"))
+	reqPath, err = webdav.ResolvePath(user, reqPath)
+	if err != nil || !utils.IsSubPath(share.RootPath, reqPath) || !common.CanReadPathByRole(actor, share.RootPath) {
+		c.Status(http.StatusForbidden)
+		c.Abort()
+		return
+	}
+	relPath := utils.FixAndCleanPath(strings.TrimPrefix(reqPath, share.RootPath))
+	c.Set("share", share)
+	c.Set("user", user)
+	switch c.Request.Method {
+	case http.MethodOptions:
+		c.Next()
+	case "PROPFIND":
+		// clients of upload only shares may only check the share and the
+		// files of their uploader
+		if share.UploadOnly && (c.GetHeader("Depth") != "0" ||
+			(relPath != "/" && !inShareUploaderDir(relPath, davShareUploader(c), true))) {
+			c.Status(http.StatusForbidden)
+			c.Abort()
+			return
+		}
+		if c.GetHeader("Depth") != "0" {
+			shareauth.LogAccess(share, model.ShareActionList, relPath, c.ClientIP(), c.Request.UserAgent(), 0)
+		}
+		c.Next()
+	case http.MethodGet, http.MethodHead, http.MethodPost:
+		if share.UploadOnly || !share.AllowDownload {
+			c.Status(http.StatusForbidden)
+			c.Abort()
+			return
+		}
+		if c.Request.Method == http.MethodHead {
+			c.Next()
+			return
+		}
+		obj, err := fs.Get(c, reqPath, &fs.GetArgs{NoLog: true})
+		if err == nil && !obj.IsDir() {
+			_ = db.TouchShareDownload(share.ShareID)
+			if _, err := db.RecordShareAccess(share.ShareID); err != nil {
+				c.Status(http.StatusInternalServerError)
+				c.Abort()
+				return
+			}
+			shareauth.LogAccess(share, model.ShareActionDownload, relPath, c.ClientIP(), c.Request.UserAgent(), obj.GetSize())
+		}
+		c.Next()
+	case "LOCK", "UNLOCK", "MKCOL", http.MethodPut:
+		if !share.AllowUpload {
+			c.Status(http.StatusForbidden)
+			c.Abort()
+			return
+		}
+		uploader := davShareUploader(c)
+		if uploader == "" {
+			shareDavUnauthorized(c)
+			return
+		}
+		// only the uploader folder itself can be made, files go inside it
+		if !inShareUploaderDir(relPath, uploader, c.Request.Method != http.MethodPut) ||
+			!common.CanUploadTo(user, path.Dir(reqPath)) {
+			c.Status(http.StatusForbidden)
+			c.Abort()
+			return
+		}
+		if c.Request.Method == http.MethodPut {
+			davSharePut(c, share, reqPath, relPath)
+			return
+		}
+		c.Next()
+	default:
+		// shares never delete, move or change the shared files
+		c.Status(http.StatusForbidden)
+		c.Abort()
+	}
+}
+
+// davShareUploader is the folder of the uploader in the share, named after
+// the basic auth user name, empty if the client gave no name
+func davShareUploader(c *gin.Context) string {
+	username, _, _ := c.Request.BasicAuth()
+	uploader, err := handles.SanitizeShareUploader(username)
+	if err != nil {
+		return ""
+	}
+	return "/" + uploader
+}
+
+// inShareUploaderDir reports whether relPath is inside the uploader folder,
+// or the folder itself when self is set
+func inShareUploaderDir(relPath, uploaderDir string, self bool) bool {
+	if uploaderDir == "" {
+		return false
+	}
+	return (self && relPath == uploaderDir) || strings.HasPrefix(relPath, uploaderDir+"/")
+}
+
+// davSharePut applies the limits of the file request to an upload, existing
+// files are never overwritten
+func davSharePut(c *gin.Context, share *model.Share, reqPath, relPath string) {
+	size := c.Request.ContentLength
+	if size < 0 {
+		c.Status(http.StatusLengthRequired)
+		c.Abort()
+		return
+	}
+	if share.MaxFileSize > 0 && size > share.MaxFileSize {
+		c.Status(http.StatusRequestEntityTooLarge)
+		c.Abort()
+		return
+	}
+	if !share.ExtensionAllowed(path.Base(reqPath)) {
+		c.Status(http.StatusUnsupportedMediaType)
+		c.Abort()
+		return
+	}
+	if res, _ := fs.Get(c, reqPath, &fs.GetArgs{NoLog: true}); res != nil {
+		c.Status(http.StatusForbidden)
+		c.Abort()
+		return
+	}
+	ok, err := db.ReserveShareUpload(share.ShareID, size)
+	if err != nil || !ok {
+		c.Status(http.StatusInsufficientStorage)
+		c.Abort()
+		return
+	}
+	c.Next()
+	if c.Writer.Status() >= http.StatusMultipleChoices {
+		_ = db.ReleaseShareUpload(share.ShareID, size)
+		return
+	}
+	shareauth.LogAccess(share, model.ShareActionUpload, relPath, c.ClientIP(), c.Request.UserAgent(), size)
+}
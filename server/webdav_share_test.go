@@ -0,0 +1,140 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	_ "github.com/alist-org/alist/v3/drivers/local"
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/db/dbtest"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	shareauth "github.com/alist-org/alist/v3/internal/share"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/alist-org/alist/v3/server/handles"
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	dbtest.Init()
+	conf.URL = &url.URL{}
+	gin.SetMode(gin.TestMode)
+}
+
+func davShareRequest(r *gin.Engine, method, path, username, depth, body string) int {
+	req := httptest.NewRequest(method, "/dav-share/upload"+path, strings.NewReader(body))
+	if username != "" {
+		req.SetBasicAuth(username, "")
+	}
+	if depth != "" {
+		req.Header.Set("Depth", depth)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w.Code
+}
+
+func TestWebDAVShareUpload(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := op.CreateStorage(context.Background(), model.Storage{Driver: "Local", MountPath: "/drop",
+		Addition: fmt.Sprintf(`{"root_folder_path":%q}`, dir)}); err != nil {
+		t.Fatalf("create storage: %+v", err)
+	}
+	role := &model.Role{Name: "drop_writer", PermissionScopes: []model.PermissionEntry{{Path: "/drop", Permission: 1 << common.PermWrite}}}
+	if err := op.CreateRole(role); err != nil {
+		t.Fatal(err)
+	}
+	creator := &model.User{Username: "drop_owner", Password: "pass", BasePath: "/", Role: model.Roles{int(role.ID)}}
+	if err := db.CreateUser(creator); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.CreateShare(&model.Share{ShareID: "upload", CreatorID: creator.ID, Name: "drop", RootPath: "/drop",
+		IsDir: true, Enabled: true, AllowWebDAV: true, AllowUpload: true, UploadOnly: true}); err != nil {
+		t.Fatal(err)
+	}
+	r := gin.New()
+	WebDavShare(r.Group("/dav-share/:share_id"))
+
+	// upload only shares can be checked but not listed
+	if code := davShareRequest(r, "PROPFIND", "/", "", "0", ""); code != http.StatusMultiStatus {
+		t.Errorf("expected the root to be checked, got %d", code)
+	}
+	if code := davShareRequest(r, "PROPFIND", "/", "", "1", ""); code != http.StatusForbidden {
+		t.Errorf("expected the root not to be listed, got %d", code)
+	}
+
+	// writes need the name of the uploader and stay in its folder
+	if code := davShareRequest(r, http.MethodPut, "/a.txt", "", "", "hello"); code != http.StatusUnauthorized {
+		t.Errorf("expected an anonymous upload to be asked for a name, got %d", code)
+	}
+	if code := davShareRequest(r, http.MethodPut, "/a.txt", "bob", "", "hello"); code != http.StatusForbidden {
+		t.Errorf("expected an upload out of the uploader folder to be refused, got %d", code)
+	}
+	if code := davShareRequest(r, "MKCOL", "/alice", "bob", "", ""); code != http.StatusForbidden {
+		t.Errorf("expected the folder of another uploader to be refused, got %d", code)
+	}
+	if code := davShareRequest(r, "MKCOL", "/bob", "bob", "", ""); code != http.StatusCreated {
+		t.Errorf("expected the uploader folder to be made, got %d", code)
+	}
+	if code := davShareRequest(r, http.MethodPut, "/bob/a.txt", "bob", "", "hello"); code != http.StatusCreated {
+		t.Errorf("expected the upload into the uploader folder, got %d", code)
+	}
+	if b, err := os.ReadFile(filepath.Join(dir, "bob", "a.txt")); err != nil || string(b) != "hello" {
+		t.Errorf("expected the file in the uploader folder, got %q %v", b, err)
+	}
+	if code := davShareRequest(r, "PROPFIND", "/bob/a.txt", "bob", "0", ""); code != http.StatusMultiStatus {
+		t.Errorf("expected the uploader to check its file, got %d", code)
+	}
+	if code := davShareRequest(r, "PROPFIND", "/bob/a.txt", "alice", "0", ""); code != http.StatusForbidden {
+		t.Errorf("expected another uploader not to see the file, got %d", code)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "a.txt")); !os.IsNotExist(err) {
+		t.Errorf("expected nothing at the root of the share, got %v", err)
+	}
+}
+
+func TestWebDAVSharePasswordLockout(t *testing.T) {
+	creator := &model.User{Username: "locked_owner", Password: "pass", BasePath: "/"}
+	if err := db.CreateUser(creator); err != nil {
+		t.Fatal(err)
+	}
+	share := &model.Share{ShareID: "locked", CreatorID: creator.ID, Name: "locked", RootPath: "/",
+		IsDir: true, Enabled: true, AllowWebDAV: true}
+	shareauth.SetPassword(share, "secret")
+	if err := db.CreateShare(share); err != nil {
+		t.Fatal(err)
+	}
+	r := gin.New()
+	WebDavShare(r.Group("/dav-share/:share_id"))
+	propfind := func(password string) int {
+		req := httptest.NewRequest("PROPFIND", "/dav-share/locked/", nil)
+		req.RemoteAddr = "192.0.2.1:1234"
+		req.SetBasicAuth("visitor", password)
+		req.Header.Set("Depth", "0")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	for i := 0; i < 5; i++ {
+		if code := propfind("guess"); code != http.StatusUnauthorized {
+			t.Fatalf("expected a wrong password to be refused, got %d", code)
+		}
+	}
+	// the ip is locked out, even with the right password
+	if code := propfind("secret"); code != http.StatusTooManyRequests {
+		t.Errorf("expected the ip to be locked out, got %d", code)
+	}
+	handles.LoginSucceeded("192.0.2.1")
+	if code := propfind("secret"); code == http.StatusUnauthorized || code == http.StatusTooManyRequests {
+		t.Errorf("expected the right password to be accepted, got %d", code)
+	}
+}